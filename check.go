@@ -0,0 +1,122 @@
+package pggen
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/atomicleads/pggen/internal/codegen/golang"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// StaleError is the error returned by Check when the generated code differs
+// from the files in the output directory.
+type StaleError struct {
+	Files []StaleFile
+}
+
+// StaleFile is a generated file that differs from the file on disk.
+type StaleFile struct {
+	Path string // path of the generated file, like "author/query.sql.go"
+	// Unified diff from the file on disk to the generated code. The file on
+	// disk is empty if it doesn't exist.
+	Diff string
+}
+
+func (e *StaleError) Error() string {
+	paths := make([]string, len(e.Files))
+	for i, file := range e.Files {
+		paths[i] = file.Path
+	}
+	return fmt.Sprintf("generated code is out of date in %d files, run pggen gen to update: %s",
+		len(e.Files), strings.Join(paths, ", "))
+}
+
+// Check generates code like Generate but compares the code to the existing
+// files in the output directory instead of writing the files. Returns a
+// *StaleError if any file differs.
+func Check(opts GenerateOptions) error {
+	return check(opts.postgresOptions(), []GenerateOptions{opts})
+}
+
+// CheckProject is like Check for each package in opts.
+func CheckProject(opts ProjectOptions) error {
+	return check(opts.postgresOptions(), opts.Packages)
+}
+
+func check(pgOpts postgresOptions, pkgs []GenerateOptions) error {
+	staleErr := &StaleError{}
+	err := generate(pgOpts, pkgs, func(files []golang.GeneratedFile) error {
+		for _, file := range files {
+			diff, err := diffGeneratedFile(file)
+			if err != nil {
+				return err
+			}
+			if diff != "" {
+				staleErr.Files = append(staleErr.Files, StaleFile{Path: displayPath(file.Path), Diff: diff})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(staleErr.Files) > 0 {
+		return staleErr
+	}
+	return nil
+}
+
+// diffGeneratedFile returns the unified diff from the file on disk to the
+// generated file, or an empty string if the files are the same.
+func diffGeneratedFile(file golang.GeneratedFile) (string, error) {
+	existing, err := os.ReadFile(file.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("read generated file to check: %w", err)
+	}
+	if bytes.Equal(existing, file.Contents) {
+		return "", nil
+	}
+	path := displayPath(file.Path)
+	fromFile, toFile := path, path
+	if !filepath.IsAbs(path) {
+		fromFile, toFile = "a/"+path, "b/"+path
+	}
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(existing),
+		B:        splitLines(file.Contents),
+		FromFile: fromFile,
+		ToFile:   toFile,
+		Context:  3,
+	})
+	if err != nil {
+		return "", fmt.Errorf("diff generated file %s: %w", path, err)
+	}
+	return diff, nil
+}
+
+// splitLines splits bs into lines that keep the trailing newline. Unlike
+// difflib.SplitLines, doesn't add an empty line at the end.
+func splitLines(bs []byte) []string {
+	lines := strings.SplitAfter(string(bs), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// displayPath returns path relative to the working directory if path is
+// underneath the working directory.
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return rel
+}
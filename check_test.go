@@ -0,0 +1,102 @@
+package pggen
+
+import (
+	"errors"
+	"github.com/atomicleads/pggen/internal/codegen/golang"
+	"github.com/atomicleads/pggen/internal/pgtest"
+	"github.com/atomicleads/pggen/internal/texts"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	conn, cleanupFunc := pgtest.NewPostgresSchemaString(t, "")
+	defer cleanupFunc()
+	tmpDir := t.TempDir()
+	queryFile := filepath.Join(tmpDir, "query.sql")
+	writeQuery := func(query string) {
+		if err := os.WriteFile(queryFile, []byte(query), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	opts := GenerateOptions{
+		ConnString: conn.Config().ConnString(),
+		QueryFiles: []string{queryFile},
+		OutputDir:  tmpDir,
+		GoPackage:  "check_test",
+		Language:   LangGo,
+	}
+
+	writeQuery(texts.Dedent(`
+		-- name: Foo :one
+		SELECT 1 AS one;
+	`))
+	if err := Generate(opts); err != nil {
+		t.Fatal(err)
+	}
+	if err := Check(opts); err != nil {
+		t.Fatalf("check after generate: %s", err)
+	}
+
+	writeQuery(texts.Dedent(`
+		-- name: Foo :one
+		SELECT 'one' AS one;
+	`))
+	err := Check(opts)
+	staleErr := &StaleError{}
+	if !errors.As(err, &staleErr) {
+		t.Fatalf("check after editing query: want *StaleError; got %v", err)
+	}
+	if assert.Len(t, staleErr.Files, 1) {
+		assert.Equal(t, filepath.Join(tmpDir, "query.sql.go"), staleErr.Files[0].Path)
+		assert.Contains(t, staleErr.Files[0].Diff, "+const fooSQL = `SELECT 'one' AS one;`")
+	}
+}
+
+func TestDiffGeneratedFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "query.sql.go")
+	if err := os.WriteFile(path, []byte("package foo\n\nvar x = 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		file     golang.GeneratedFile
+		wantDiff string
+	}{
+		{
+			name: "same",
+			file: golang.GeneratedFile{Path: path, Contents: []byte("package foo\n\nvar x = 1\n")},
+		},
+		{
+			name: "changed",
+			file: golang.GeneratedFile{Path: path, Contents: []byte("package foo\n\nvar x = 2\n")},
+			wantDiff: "--- " + path + "\n" +
+				"+++ " + path + "\n" +
+				"@@ -1,3 +1,3 @@\n" +
+				" package foo\n" +
+				" \n" +
+				"-var x = 1\n" +
+				"+var x = 2\n",
+		},
+		{
+			name: "missing",
+			file: golang.GeneratedFile{Path: filepath.Join(tmpDir, "missing.sql.go"), Contents: []byte("package foo\n")},
+			wantDiff: "--- " + filepath.Join(tmpDir, "missing.sql.go") + "\n" +
+				"+++ " + filepath.Join(tmpDir, "missing.sql.go") + "\n" +
+				"@@ -0,0 +1 @@\n" +
+				"+package foo\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := diffGeneratedFile(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.wantDiff, got)
+		})
+	}
+}
@@ -2,18 +2,22 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log/slog"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"sort"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/atomicleads/pggen"
+	"github.com/atomicleads/pggen/internal/config"
 	"github.com/atomicleads/pggen/internal/flags"
+	"github.com/atomicleads/pggen/internal/paths"
 	"github.com/atomicleads/pggen/internal/texts"
-	"github.com/bmatcuk/doublestar"
 	"github.com/peterbourgon/ff/v3/ffcli"
 )
 
@@ -40,6 +44,16 @@ EXAMPLES
 
   # Use custom acronym when converting from camel_case_api to camelCaseAPI.
   pggen gen go --schema-glob schema.sql --query-glob query.sql --acronym api
+
+  # Generate every package declared in pggen.yaml in the current directory
+  # using a single postgres database.
+  pggen gen
+
+  # Fail if the generated code is out of date, like in CI.
+  pggen gen --check
+
+  # Regenerate code whenever a query or schema file changes.
+  pggen gen go --schema-glob schema.sql --query-glob 'author/*.sql' --watch
 `
 
 func run() error {
@@ -83,19 +97,51 @@ func newGenCmd() *ffcli.Command {
 	postgresConn := fset.String("postgres-connection", "",
 		`optional connection string to a postgres database, like: `+
 			`"user=postgres host=localhost dbname=pggen"`)
+	postgresIsolation := fset.String("postgres-isolation", string(pggen.IsolationNone),
+		"where to load the schema files with --postgres-connection: 'none' for the "+
+			"database itself, 'database' for a throwaway database, or 'schema' for a "+
+			"throwaway schema set as the search_path")
+	postgresBackend := fset.String("postgres-backend", string(pggen.PostgresDocker),
+		"how to start postgres without --postgres-connection: 'docker' for a Docker "+
+			"container, or 'local' for a throwaway cluster from the local Postgres binaries")
+	postgresBinDir := fset.String("postgres-bin-dir", "",
+		"directory of the initdb, pg_ctl, postgres, and psql binaries for "+
+			"--postgres-backend local, like /usr/lib/postgresql/16/bin; defaults to PATH")
 	queryGlobs := flags.Strings(fset, "query-glob", nil,
 		"generate code for all SQL files that match glob, like 'queries/**/*.sql'")
 	schemaGlobs := flags.Strings(fset, "schema-glob", nil,
 		"create schema in Postgres from all sql, sql.gz, or shell "+
 			"scripts (*.sh) that match a glob, like 'migrations/*.sql'")
+	migrationsDir := fset.String("migrations-dir", "",
+		"create schema in Postgres from the up migrations in a migration tool "+
+			"directory, in version order, before the --schema-glob files")
+	migrationsFormat := fset.String("migrations-format", "",
+		"migration tool of --migrations-dir: 'goose', 'golang-migrate', 'dbmate', "+
+			"'sql-migrate', or 'atlas'")
 	acronyms := flags.Strings(fset, "acronym", nil,
 		"lowercase acronym that should convert to all caps like 'api', "+
 			"or custom mapping like 'apis=APIs'")
 	goTypes := flags.Strings(fset, "go-type", nil,
 		"custom type mapping from Postgres to fully qualified Go type, "+
 			"like 'device_type=github.com/atomicleads/pggen.DeviceType'")
+	protoPkgs := flags.Strings(fset, "proto-package", nil,
+		"Go package generated by protoc-gen-go for a protobuf package, used by "+
+			"the proto-type query pragma, like 'erp.api=github.com/acme/erp/api'")
+	notNullFuncs := flags.Strings(fset, "not-null-func", nil,
+		"user function that never returns null, like 'slugify' or 'my_schema.slugify'; "+
+			"a call is non-null if the function isn't strict or no argument is null")
 	inlineParamCount := fset.Int("inline-param-count", 2,
 		"number of params (inclusive) to inline when calling querier methods; 0 always generates a struct")
+	instrumentation := fset.String("instrumentation", string(pggen.InstrumentationNone),
+		"how the generated querier reports queries: 'none', 'hooks' for a QueryHooks "+
+			"interface, or 'otel' for QueryHooks with an OpenTelemetry implementation")
+	pgxVersion := fset.Int("pgx-version", int(pggen.PgxV4),
+		"major version of pgx used by the generated code: 4 or 5")
+	databaseSQL := fset.Bool("database-sql", false,
+		"generate a querier backed by database/sql instead of pgx; requires --pgx-version 4")
+	checkOnly := fset.Bool("check", false, checkUsage)
+	watch := fset.Bool("watch", false,
+		"keep postgres running and regenerate code when a query or schema file changes")
 	goSubCmd := &ffcli.Command{
 		Name:       "go",
 		ShortUsage: "pggen gen go --query-glob glob [--schema-glob <glob>]... [flags]",
@@ -103,18 +149,19 @@ func newGenCmd() *ffcli.Command {
 		FlagSet:    fset,
 		LongHelp: flagHelp + "\n" + texts.Dedent(`
 			pggen uses the provided --postgres-connection to query the database. If not 
-			present, pggen creates a Docker container to query the database.
+			present, pggen creates a Docker container to query the database, or with
+			--postgres-backend local, a throwaway cluster from the local Postgres binaries.
 		`),
 		Exec: func(ctx context.Context, args []string) error {
 			// Preconditions.
 			if len(*queryGlobs) == 0 {
 				return fmt.Errorf("pggen gen go: at least one file in --query-glob must match")
 			}
-			queries, err := expandSortGlobs(*queryGlobs)
+			queries, err := paths.ExpandGlobs(*queryGlobs)
 			if err != nil {
 				return err
 			}
-			schemas, err := expandSortGlobs(*schemaGlobs)
+			schemas, err := paths.ExpandGlobs(*schemaGlobs)
 			if err != nil {
 				return err
 			}
@@ -133,19 +180,19 @@ func newGenCmd() *ffcli.Command {
 
 			outDir, _ = filepath.Abs(outDir)
 
-			// Parse two acronym formats: "--acronym api" and "--acronym oids=OIDs"
-			acros := make(map[string]string)
-			for _, acro := range *acronyms {
-				ss := strings.SplitN(acro, "=", 2)
-				word := ss[0]
-				if word != strings.ToLower(word) {
-					return fmt.Errorf("acronym %q should be lower case", word)
-				}
-				replacement := strings.ToUpper(word)
-				if len(ss) > 1 {
-					replacement = ss[1]
-				}
-				acros[word] = replacement
+			acros, err := parseAcronyms(*acronyms)
+			if err != nil {
+				return err
+			}
+			lang, err := parseLang(*instrumentation, *pgxVersion, *databaseSQL)
+			if err != nil {
+				return err
+			}
+			if err := validatePostgres(*postgresConn, *postgresIsolation, *postgresBackend, *postgresBinDir); err != nil {
+				return err
+			}
+			if err := validateMigrations(*migrationsDir, *migrationsFormat); err != nil {
+				return err
 			}
 
 			typeOverrides := make(map[string]string, len(*goTypes))
@@ -157,19 +204,53 @@ func newGenCmd() *ffcli.Command {
 				typeOverrides[ss[0]] = ss[1]
 			}
 
+			protoPackages := make(map[string]string, len(*protoPkgs))
+			for _, pkgAssoc := range *protoPkgs {
+				if strings.Count(pkgAssoc, "=") != 1 {
+					return fmt.Errorf("--proto-package must have format <protoPkg>=<goPkg>; got %s", pkgAssoc)
+				}
+				ss := strings.SplitN(pkgAssoc, "=", 2)
+				protoPackages[ss[0]] = ss[1]
+			}
+
 			// Codegen.
-			err = pggen.Generate(pggen.GenerateOptions{
-				Language:         pggen.LangGo,
-				ConnString:       *postgresConn,
-				SchemaFiles:      schemas,
-				QueryFiles:       queries,
-				OutputDir:        outDir,
-				Acronyms:         acros,
-				TypeOverrides:    typeOverrides,
-				LogLevel:         slog.LevelInfo,
-				InlineParamCount: *inlineParamCount,
-			})
-			if err != nil {
+			opts := pggen.GenerateOptions{
+				Language:          lang,
+				ConnString:        *postgresConn,
+				PostgresIsolation: pggen.PostgresIsolation(*postgresIsolation),
+				SchemaFiles:       schemas,
+				MigrationsDir:     *migrationsDir,
+				MigrationsFormat:  pggen.MigrationsFormat(*migrationsFormat),
+				PostgresBackend:   pggen.PostgresBackend(*postgresBackend),
+				PostgresBinDir:    *postgresBinDir,
+				QueryFiles:        queries,
+				OutputDir:         outDir,
+				Acronyms:          acros,
+				TypeOverrides:     typeOverrides,
+				ProtoPackages:     protoPackages,
+				LogLevel:          slog.LevelInfo,
+				InlineParamCount:  *inlineParamCount,
+				Instrumentation:   pggen.Instrumentation(*instrumentation),
+				PgxVersion:        pggen.PgxVersion(*pgxVersion),
+				NotNullFuncs:      *notNullFuncs,
+			}
+			if *checkOnly {
+				if *watch {
+					return fmt.Errorf("--check and --watch can't be used together")
+				}
+				return reportCheck(pggen.Check(opts))
+			}
+			if *watch {
+				ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				return pggen.Watch(ctx, pggen.WatchOptions{
+					Options:     opts,
+					QueryGlobs:  *queryGlobs,
+					SchemaGlobs: *schemaGlobs,
+					OnGenerate:  printWatchEvent,
+				})
+			}
+			if err := pggen.Generate(opts); err != nil {
 				return err
 			}
 
@@ -181,54 +262,258 @@ func newGenCmd() *ffcli.Command {
 			return nil
 		},
 	}
+	genFset := flag.NewFlagSet("gen", flag.ExitOnError)
+	configPath := genFset.String("config", "",
+		"project file that declares the packages to generate; defaults to "+
+			strings.Join(config.FileNames, ", ")+" in the current directory")
+	checkProject := genFset.Bool("check", false, checkUsage)
 	cmd := &ffcli.Command{
-		Name:        "gen",
-		ShortUsage:  "pggen gen (go|<lang>) [options...]",
-		ShortHelp:   "generates code in specific language for Postgres query files",
-		FlagSet:     nil,
+		Name:       "gen",
+		ShortUsage: "pggen gen [--config pggen.yaml] | pggen gen (go|<lang>) [options...]",
+		ShortHelp:  "generates code in specific language for Postgres query files",
+		FlagSet:    genFset,
+		LongHelp: texts.Dedent(`
+			Without a subcommand, pggen gen generates every package declared in a
+			project file, like pggen.yaml, using a single Postgres database.
+		`),
 		Subcommands: []*ffcli.Command{goSubCmd},
 	}
 	cmd.Exec = func(ctx context.Context, args []string) error {
-		fmt.Println(ffcli.DefaultUsageFunc(cmd))
-		os.Exit(1)
-		return nil
+		path := *configPath
+		if path == "" {
+			p, err := config.Find(".")
+			if err != nil {
+				return err
+			}
+			path = p
+		}
+		if path == "" {
+			fmt.Println(ffcli.DefaultUsageFunc(cmd))
+			os.Exit(1)
+		}
+		return genProject(path, *checkProject)
 	}
 	return cmd
 }
 
-// expandSortGlobs gets the absolute paths for all files matching globs. Order
-// files lexicographically within each glob but not across all globs. The order
-// of a glob relative to other globs is important for schemas where a schema
-// might depend on a previous schema.
-func expandSortGlobs(globs []string) ([]string, error) {
-	files := make([]string, 0, len(globs)*4)
-	for _, glob := range globs {
-		var matches []string
-		if !strings.ContainsAny(glob, "*?[{") {
-			// A regular file, not a glob. Check if it exists.
-			if _, err := os.Stat(glob); os.IsNotExist(err) {
-				return nil, fmt.Errorf("file does not exist: %w", err)
-			}
-			matches = append(matches, glob)
-		} else {
-			ms, err := doublestar.Glob(glob)
-			if err != nil {
-				// Ignore err, it's not helpful.
-				return nil, fmt.Errorf("bad glob pattern: %s", glob)
-			}
-			sort.Strings(ms)
-			matches = ms
+const checkUsage = "check that the generated code is up to date instead of writing it; " +
+	"prints a diff and fails if any generated file differs"
+
+// printWatchEvent prints a line for each regeneration in watch mode.
+func printWatchEvent(event pggen.WatchEvent) {
+	now := time.Now().Format(time.TimeOnly)
+	if event.Err != nil {
+		fmt.Printf("%s ERROR: %s\n", now, event.Err.Error())
+		return
+	}
+	reloaded := ""
+	if event.SchemaReloaded {
+		reloaded = "reloaded schema, "
+	}
+	fmt.Printf("%s %sparsed %d query files, wrote %d files, removed %d files\n",
+		now, reloaded, len(event.QueryFiles), len(event.Written), len(event.Removed))
+}
+
+// reportCheck prints the diff of each stale file if err is a
+// *pggen.StaleError.
+func reportCheck(err error) error {
+	staleErr := &pggen.StaleError{}
+	if errors.As(err, &staleErr) {
+		for _, file := range staleErr.Files {
+			fmt.Print(file.Diff)
 		}
-		files = append(files, matches...)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Println("generated code is up to date")
+	return nil
+}
+
+// genProject generates every package declared in the project file at path.
+// If checkOnly is true, checks that the generated code is up to date instead.
+func genProject(path string, checkOnly bool) error {
+	cfg, err := config.Load(path)
+	if err != nil {
+		return err
+	}
+	opts, err := newProjectOptions(cfg)
+	if err != nil {
+		return fmt.Errorf("project file %s: %w", cfg.Path, err)
 	}
-	for i, schema := range files {
-		abs, err := filepath.Abs(schema)
+	if checkOnly {
+		return reportCheck(pggen.CheckProject(opts))
+	}
+	if err := pggen.GenerateProject(opts); err != nil {
+		return err
+	}
+	queryCount := 0
+	for _, pkg := range opts.Packages {
+		queryCount += len(pkg.QueryFiles)
+	}
+	fmt.Printf("generated %d query files in %d packages\n", queryCount, len(opts.Packages))
+	return nil
+}
+
+// newProjectOptions converts a project file into the options for
+// pggen.GenerateProject.
+func newProjectOptions(cfg config.Config) (pggen.ProjectOptions, error) {
+	schemas, err := paths.ExpandGlobs(cfg.SchemaGlobs)
+	if err != nil {
+		return pggen.ProjectOptions{}, err
+	}
+	instrumentation := cfg.Instrumentation
+	if instrumentation == "" {
+		instrumentation = string(pggen.InstrumentationNone)
+	}
+	pgxVersion := cfg.PgxVersion
+	if pgxVersion == 0 {
+		pgxVersion = int(pggen.PgxV4)
+	}
+	lang, err := parseLang(instrumentation, pgxVersion, cfg.DatabaseSQL)
+	if err != nil {
+		return pggen.ProjectOptions{}, err
+	}
+	if err := validatePostgres(cfg.PostgresConnection, cfg.PostgresIsolation, cfg.PostgresBackend, cfg.PostgresBinDir); err != nil {
+		return pggen.ProjectOptions{}, err
+	}
+	if err := validateMigrations(cfg.MigrationsDir, cfg.MigrationsFormat); err != nil {
+		return pggen.ProjectOptions{}, err
+	}
+	inlineParamCount := 2
+	if cfg.InlineParamCount != nil {
+		inlineParamCount = *cfg.InlineParamCount
+	}
+
+	opts := pggen.ProjectOptions{
+		ConnString:        cfg.PostgresConnection,
+		PostgresIsolation: pggen.PostgresIsolation(cfg.PostgresIsolation),
+		SchemaFiles:       schemas,
+		MigrationsDir:     cfg.MigrationsDir,
+		MigrationsFormat:  pggen.MigrationsFormat(cfg.MigrationsFormat),
+		PostgresBackend:   pggen.PostgresBackend(cfg.PostgresBackend),
+		PostgresBinDir:    cfg.PostgresBinDir,
+		NotNullFuncs:      cfg.NotNullFuncs,
+		Packages:          make([]pggen.GenerateOptions, len(cfg.Packages)),
+	}
+	for i, pkg := range cfg.Packages {
+		queries, err := paths.ExpandGlobs(pkg.QueryGlobs)
 		if err != nil {
-			return nil, fmt.Errorf("absolute path for %s: %w", schema, err)
+			return pggen.ProjectOptions{}, fmt.Errorf("package %s: %w", pkg.OutputDir, err)
+		}
+		if len(queries) == 0 {
+			return pggen.ProjectOptions{}, fmt.Errorf("package %s: at least one file in query-globs must match", pkg.OutputDir)
+		}
+		acros, err := parseAcronyms(cfg.PackageAcronyms(pkg))
+		if err != nil {
+			return pggen.ProjectOptions{}, fmt.Errorf("package %s: %w", pkg.OutputDir, err)
+		}
+		opts.Packages[i] = pggen.GenerateOptions{
+			Language:         lang,
+			QueryFiles:       queries,
+			GoPackage:        pkg.GoPackage,
+			OutputDir:        pkg.OutputDir,
+			Acronyms:         acros,
+			TypeOverrides:    cfg.PackageGoTypes(pkg),
+			ProtoPackages:    cfg.ProtoPackages,
+			LogLevel:         slog.LevelInfo,
+			InlineParamCount: inlineParamCount,
+			Instrumentation:  pggen.Instrumentation(instrumentation),
+			PgxVersion:       pggen.PgxVersion(pgxVersion),
+		}
+	}
+	return opts, nil
+}
+
+// parseAcronyms parses two acronym formats: "api" and "oids=OIDs".
+func parseAcronyms(acronyms []string) (map[string]string, error) {
+	acros := make(map[string]string, len(acronyms))
+	for _, acro := range acronyms {
+		ss := strings.SplitN(acro, "=", 2)
+		word := ss[0]
+		if word != strings.ToLower(word) {
+			return nil, fmt.Errorf("acronym %q should be lower case", word)
+		}
+		replacement := strings.ToUpper(word)
+		if len(ss) > 1 {
+			replacement = ss[1]
 		}
-		files[i] = abs
+		acros[word] = replacement
+	}
+	return acros, nil
+}
+
+// parseLang validates the instrumentation and pgx version and returns the
+// language to generate.
+func parseLang(instrumentation string, pgxVersion int, databaseSQL bool) (pggen.Lang, error) {
+	switch pggen.Instrumentation(instrumentation) {
+	case pggen.InstrumentationNone, pggen.InstrumentationHooks, pggen.InstrumentationOTel:
+		break // okay
+	default:
+		return "", fmt.Errorf("--instrumentation must be one of none, hooks, or otel; got %s", instrumentation)
+	}
+
+	switch pggen.PgxVersion(pgxVersion) {
+	case pggen.PgxV4, pggen.PgxV5:
+		break // okay
+	default:
+		return "", fmt.Errorf("--pgx-version must be 4 or 5; got %d", pgxVersion)
+	}
+	if !databaseSQL {
+		return pggen.LangGo, nil
+	}
+	if pggen.PgxVersion(pgxVersion) != pggen.PgxV4 {
+		return "", fmt.Errorf("--database-sql requires --pgx-version 4; got %d", pgxVersion)
+	}
+	return pggen.LangGoDatabaseSQL, nil
+}
+
+// validatePostgres validates the options to connect to or start Postgres.
+// The isolation only applies to a connection string and the bin dir only
+// applies to the local backend.
+func validatePostgres(connString, isolation, backend, binDir string) error {
+	switch pggen.PostgresIsolation(isolation) {
+	case "", pggen.IsolationNone:
+		break // okay
+	case pggen.IsolationDatabase, pggen.IsolationSchema:
+		if connString == "" {
+			return fmt.Errorf("--postgres-isolation %s requires --postgres-connection", isolation)
+		}
+	default:
+		return fmt.Errorf("--postgres-isolation must be one of none, database, or schema; got %s", isolation)
+	}
+	switch pggen.PostgresBackend(backend) {
+	case "", pggen.PostgresDocker, pggen.PostgresLocal:
+		break // okay
+	default:
+		return fmt.Errorf("--postgres-backend must be docker or local; got %s", backend)
+	}
+	if binDir != "" && pggen.PostgresBackend(backend) != pggen.PostgresLocal {
+		return fmt.Errorf("--postgres-bin-dir requires --postgres-backend local")
+	}
+	return nil
+}
+
+// validateMigrations validates that the migrations format is set and known
+// when the migrations dir is set.
+func validateMigrations(dir, format string) error {
+	if dir == "" {
+		if format != "" {
+			return fmt.Errorf("--migrations-format requires --migrations-dir")
+		}
+		return nil
+	}
+	switch pggen.MigrationsFormat(format) {
+	case pggen.MigrationsGoose, pggen.MigrationsGolangMigrate, pggen.MigrationsDbmate,
+		pggen.MigrationsSQLMigrate, pggen.MigrationsAtlas:
+		return nil
+	case "":
+		return fmt.Errorf("--migrations-dir requires --migrations-format")
+	default:
+		return fmt.Errorf("--migrations-format must be one of goose, golang-migrate, dbmate, "+
+			"sql-migrate, or atlas; got %s", format)
 	}
-	return files, nil
 }
 
 func main() {
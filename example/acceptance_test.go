@@ -241,6 +241,22 @@ func TestExamples(t *testing.T) {
 				"--query-glob", "example/domain/query.sql",
 			},
 		},
+		{
+			name: "example/query_hooks",
+			args: []string{
+				"--schema-glob", "example/query_hooks/schema.sql",
+				"--query-glob", "example/query_hooks/query.sql",
+				"--instrumentation", "otel",
+			},
+		},
+		{
+			name: "example/pgx5",
+			args: []string{
+				"--schema-glob", "example/pgx5/schema.sql",
+				"--query-glob", "example/pgx5/query.sql",
+				"--pgx-version", "5",
+			},
+		},
 	}
 	if *update {
 		// update only disables the assertions. Running the tests causes pggen
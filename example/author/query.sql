@@ -2,6 +2,10 @@
 -- name: FindAuthorByID :one
 SELECT * FROM author WHERE author_id = pggen.arg('AuthorID');
 
+-- FindOptionalAuthorByID finds one author by ID or returns nil if none match.
+-- name: FindOptionalAuthorByID :opt
+SELECT * FROM author WHERE author_id = pggen.arg('AuthorID');
+
 -- FindAuthors finds authors by first name.
 -- name: FindAuthors :many
 SELECT * FROM author WHERE first_name = pggen.arg('FirstName');
@@ -48,3 +52,16 @@ SELECT string_agg(first_name, ',') AS names FROM author WHERE author_id = pggen.
 
 -- name: ArrayAggFirstName :one
 SELECT array_agg(first_name) AS names FROM author WHERE author_id = pggen.arg('author_id');
+
+-- DeleteAuthorsByLastName deletes authors by last name and returns the number
+-- of deleted authors.
+-- name: DeleteAuthorsByLastName :execrows
+DELETE FROM author WHERE last_name = pggen.arg('LastName');
+
+-- UpdateAuthorSuffix sets the suffix of exactly one author.
+-- name: UpdateAuthorSuffix :execrows expect-rows=1
+UPDATE author SET suffix = pggen.arg('Suffix') WHERE author_id = pggen.arg('AuthorID');
+
+-- DeleteAuthorByID deletes exactly one author by ID.
+-- name: DeleteAuthorByID :exec expect-rows=1
+DELETE FROM author WHERE author_id = pggen.arg('AuthorID');
@@ -4,6 +4,7 @@ package author
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,38 +12,142 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	// FindAuthorById finds one (or zero) authors by ID.
 	FindAuthorByID(ctx context.Context, authorID int32) (FindAuthorByIDRow, error)
+	// QueueFindAuthorByID enqueues a FindAuthorByID query into batch to be executed
+	// later by the batch.
+	QueueFindAuthorByID(batch genericBatch, authorID int32)
+	// FindAuthorByIDScan scans the result of an executed QueueFindAuthorByID query.
+	FindAuthorByIDScan(results pgx.BatchResults) (FindAuthorByIDRow, error)
+
+	// FindOptionalAuthorByID finds one author by ID or returns nil if none match.
+	FindOptionalAuthorByID(ctx context.Context, authorID int32) (*FindOptionalAuthorByIDRow, error)
+	// QueueFindOptionalAuthorByID enqueues a FindOptionalAuthorByID query into batch to be executed
+	// later by the batch.
+	QueueFindOptionalAuthorByID(batch genericBatch, authorID int32)
+	// FindOptionalAuthorByIDScan scans the result of an executed QueueFindOptionalAuthorByID query.
+	FindOptionalAuthorByIDScan(results pgx.BatchResults) (*FindOptionalAuthorByIDRow, error)
 
 	// FindAuthors finds authors by first name.
 	FindAuthors(ctx context.Context, firstName string) ([]FindAuthorsRow, error)
+	// FindAuthorsEach runs FindAuthors and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindAuthorsEach(ctx context.Context, firstName string, fn func(row FindAuthorsRow) error) error
+	// QueueFindAuthors enqueues a FindAuthors query into batch to be executed
+	// later by the batch.
+	QueueFindAuthors(batch genericBatch, firstName string)
+	// FindAuthorsScan scans the result of an executed QueueFindAuthors query.
+	FindAuthorsScan(results pgx.BatchResults) ([]FindAuthorsRow, error)
 
 	// FindAuthorNames finds one (or zero) authors by ID.
 	FindAuthorNames(ctx context.Context, authorID int32) ([]FindAuthorNamesRow, error)
+	// FindAuthorNamesEach runs FindAuthorNames and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindAuthorNamesEach(ctx context.Context, authorID int32, fn func(row FindAuthorNamesRow) error) error
+	// QueueFindAuthorNames enqueues a FindAuthorNames query into batch to be executed
+	// later by the batch.
+	QueueFindAuthorNames(batch genericBatch, authorID int32)
+	// FindAuthorNamesScan scans the result of an executed QueueFindAuthorNames query.
+	FindAuthorNamesScan(results pgx.BatchResults) ([]FindAuthorNamesRow, error)
 
 	// FindFirstNames finds one (or zero) authors by ID.
-	FindFirstNames(ctx context.Context, authorID int32) ([]*string, error)
+	FindFirstNames(ctx context.Context, authorID int32) ([]string, error)
+	// FindFirstNamesEach runs FindFirstNames and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindFirstNamesEach(ctx context.Context, authorID int32, fn func(row string) error) error
+	// QueueFindFirstNames enqueues a FindFirstNames query into batch to be executed
+	// later by the batch.
+	QueueFindFirstNames(batch genericBatch, authorID int32)
+	// FindFirstNamesScan scans the result of an executed QueueFindFirstNames query.
+	FindFirstNamesScan(results pgx.BatchResults) ([]string, error)
 
 	// DeleteAuthors deletes authors with a first name of "joe".
 	DeleteAuthors(ctx context.Context) (pgconn.CommandTag, error)
+	// QueueDeleteAuthors enqueues a DeleteAuthors query into batch to be executed
+	// later by the batch.
+	QueueDeleteAuthors(batch genericBatch)
+	// DeleteAuthorsScan scans the result of an executed QueueDeleteAuthors query.
+	DeleteAuthorsScan(results pgx.BatchResults) (pgconn.CommandTag, error)
 
 	// DeleteAuthorsByFirstName deletes authors by first name.
 	DeleteAuthorsByFirstName(ctx context.Context, firstName string) (pgconn.CommandTag, error)
+	// QueueDeleteAuthorsByFirstName enqueues a DeleteAuthorsByFirstName query into batch to be executed
+	// later by the batch.
+	QueueDeleteAuthorsByFirstName(batch genericBatch, firstName string)
+	// DeleteAuthorsByFirstNameScan scans the result of an executed QueueDeleteAuthorsByFirstName query.
+	DeleteAuthorsByFirstNameScan(results pgx.BatchResults) (pgconn.CommandTag, error)
 
 	// DeleteAuthorsByFullName deletes authors by the full name.
 	DeleteAuthorsByFullName(ctx context.Context, params DeleteAuthorsByFullNameParams) (pgconn.CommandTag, error)
+	// QueueDeleteAuthorsByFullName enqueues a DeleteAuthorsByFullName query into batch to be executed
+	// later by the batch.
+	QueueDeleteAuthorsByFullName(batch genericBatch, params DeleteAuthorsByFullNameParams)
+	// DeleteAuthorsByFullNameScan scans the result of an executed QueueDeleteAuthorsByFullName query.
+	DeleteAuthorsByFullNameScan(results pgx.BatchResults) (pgconn.CommandTag, error)
 
 	// InsertAuthor inserts an author by name and returns the ID.
 	InsertAuthor(ctx context.Context, firstName string, lastName string) (int32, error)
+	// QueueInsertAuthor enqueues a InsertAuthor query into batch to be executed
+	// later by the batch.
+	QueueInsertAuthor(batch genericBatch, firstName string, lastName string)
+	// InsertAuthorScan scans the result of an executed QueueInsertAuthor query.
+	InsertAuthorScan(results pgx.BatchResults) (int32, error)
 
 	// InsertAuthorSuffix inserts an author by name and suffix and returns the
 	// entire row.
 	InsertAuthorSuffix(ctx context.Context, params InsertAuthorSuffixParams) (InsertAuthorSuffixRow, error)
+	// QueueInsertAuthorSuffix enqueues a InsertAuthorSuffix query into batch to be executed
+	// later by the batch.
+	QueueInsertAuthorSuffix(batch genericBatch, params InsertAuthorSuffixParams)
+	// InsertAuthorSuffixScan scans the result of an executed QueueInsertAuthorSuffix query.
+	InsertAuthorSuffixScan(results pgx.BatchResults) (InsertAuthorSuffixRow, error)
 
 	StringAggFirstName(ctx context.Context, authorID int32) (*string, error)
+	// QueueStringAggFirstName enqueues a StringAggFirstName query into batch to be executed
+	// later by the batch.
+	QueueStringAggFirstName(batch genericBatch, authorID int32)
+	// StringAggFirstNameScan scans the result of an executed QueueStringAggFirstName query.
+	StringAggFirstNameScan(results pgx.BatchResults) (*string, error)
 
 	ArrayAggFirstName(ctx context.Context, authorID int32) ([]string, error)
+	// QueueArrayAggFirstName enqueues a ArrayAggFirstName query into batch to be executed
+	// later by the batch.
+	QueueArrayAggFirstName(batch genericBatch, authorID int32)
+	// ArrayAggFirstNameScan scans the result of an executed QueueArrayAggFirstName query.
+	ArrayAggFirstNameScan(results pgx.BatchResults) ([]string, error)
+
+	// DeleteAuthorsByLastName deletes authors by last name and returns the number
+	// of deleted authors.
+	DeleteAuthorsByLastName(ctx context.Context, lastName string) (int64, error)
+	// QueueDeleteAuthorsByLastName enqueues a DeleteAuthorsByLastName query into batch to be executed
+	// later by the batch.
+	QueueDeleteAuthorsByLastName(batch genericBatch, lastName string)
+	// DeleteAuthorsByLastNameScan scans the result of an executed QueueDeleteAuthorsByLastName query.
+	DeleteAuthorsByLastNameScan(results pgx.BatchResults) (int64, error)
+
+	// UpdateAuthorSuffix sets the suffix of exactly one author.
+	UpdateAuthorSuffix(ctx context.Context, suffix string, authorID int32) (int64, error)
+	// QueueUpdateAuthorSuffix enqueues a UpdateAuthorSuffix query into batch to be executed
+	// later by the batch.
+	QueueUpdateAuthorSuffix(batch genericBatch, suffix string, authorID int32)
+	// UpdateAuthorSuffixScan scans the result of an executed QueueUpdateAuthorSuffix query.
+	UpdateAuthorSuffixScan(results pgx.BatchResults) (int64, error)
+
+	// DeleteAuthorByID deletes exactly one author by ID.
+	DeleteAuthorByID(ctx context.Context, authorID int32) (pgconn.CommandTag, error)
+	// QueueDeleteAuthorByID enqueues a DeleteAuthorByID query into batch to be executed
+	// later by the batch.
+	QueueDeleteAuthorByID(batch genericBatch, authorID int32)
+	// DeleteAuthorByIDScan scans the result of an executed QueueDeleteAuthorByID query.
+	DeleteAuthorByIDScan(results pgx.BatchResults) (pgconn.CommandTag, error)
 }
 
 type DBQuerier struct {
@@ -71,6 +176,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -78,8 +191,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // typeResolver looks up the pgtype.ValueTranscoder by Postgres type name.
@@ -111,6 +334,19 @@ func (tr *typeResolver) setValue(vt pgtype.ValueTranscoder, val interface{}) pgt
 	return vt
 }
 
+// UnexpectedRowsError is returned by a query with the expect-rows pragma when
+// the query affects a different number of rows than expected. The query's
+// changes are not rolled back; run the query in a transaction to undo them.
+type UnexpectedRowsError struct {
+	Query    string // name of the query, like "DeleteAuthor"
+	Expected int64  // rows the query must affect, from the expect-rows pragma
+	Actual   int64  // rows the query affected
+}
+
+func (e *UnexpectedRowsError) Error() string {
+	return fmt.Sprintf("query %s affected %d rows; expected %d", e.Query, e.Actual, e.Expected)
+}
+
 const findAuthorByIDSQL = `SELECT * FROM author WHERE author_id = $1;`
 
 type FindAuthorByIDRow struct {
@@ -126,11 +362,67 @@ func (q *DBQuerier) FindAuthorByID(ctx context.Context, authorID int32) (FindAut
 	row := q.conn.QueryRow(ctx, findAuthorByIDSQL, authorID)
 	var item FindAuthorByIDRow
 	if err := row.Scan(&item.AuthorID, &item.FirstName, &item.LastName, &item.Suffix); err != nil {
-		return item, fmt.Errorf("query FindAuthorByID: %w", err)
+		return item, fmt.Errorf("query FindAuthorByID: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueFindAuthorByID implements Querier.QueueFindAuthorByID.
+func (q *DBQuerier) QueueFindAuthorByID(batch genericBatch, authorID int32) {
+	batch.Queue(findAuthorByIDSQL, authorID)
+}
+
+// FindAuthorByIDScan implements Querier.FindAuthorByIDScan.
+func (q *DBQuerier) FindAuthorByIDScan(results pgx.BatchResults) (FindAuthorByIDRow, error) {
+	row := results.QueryRow()
+	var item FindAuthorByIDRow
+	if err := row.Scan(&item.AuthorID, &item.FirstName, &item.LastName, &item.Suffix); err != nil {
+		return item, fmt.Errorf("scan FindAuthorByIDScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
 
+const findOptionalAuthorByIDSQL = `SELECT * FROM author WHERE author_id = $1;`
+
+type FindOptionalAuthorByIDRow struct {
+	AuthorID  int32   `json:"author_id"`
+	FirstName string  `json:"first_name"`
+	LastName  string  `json:"last_name"`
+	Suffix    *string `json:"suffix"`
+}
+
+// FindOptionalAuthorByID implements Querier.FindOptionalAuthorByID.
+func (q *DBQuerier) FindOptionalAuthorByID(ctx context.Context, authorID int32) (*FindOptionalAuthorByIDRow, error) {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindOptionalAuthorByID")
+	row := q.conn.QueryRow(ctx, findOptionalAuthorByIDSQL, authorID)
+	var item FindOptionalAuthorByIDRow
+	if err := row.Scan(&item.AuthorID, &item.FirstName, &item.LastName, &item.Suffix); err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("query FindOptionalAuthorByID: %w", err)
+	}
+	return &item, nil
+}
+
+// QueueFindOptionalAuthorByID implements Querier.QueueFindOptionalAuthorByID.
+func (q *DBQuerier) QueueFindOptionalAuthorByID(batch genericBatch, authorID int32) {
+	batch.Queue(findOptionalAuthorByIDSQL, authorID)
+}
+
+// FindOptionalAuthorByIDScan implements Querier.FindOptionalAuthorByIDScan.
+func (q *DBQuerier) FindOptionalAuthorByIDScan(results pgx.BatchResults) (*FindOptionalAuthorByIDRow, error) {
+	row := results.QueryRow()
+	var item FindOptionalAuthorByIDRow
+	if err := row.Scan(&item.AuthorID, &item.FirstName, &item.LastName, &item.Suffix); err != nil {
+		if isNoRows(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan FindOptionalAuthorByIDScan row: %w", err)
+	}
+	return &item, nil
+}
+
 const findAuthorsSQL = `SELECT * FROM author WHERE first_name = $1;`
 
 type FindAuthorsRow struct {
@@ -162,11 +454,61 @@ func (q *DBQuerier) FindAuthors(ctx context.Context, firstName string) ([]FindAu
 	return items, err
 }
 
+// FindAuthorsEach implements Querier.FindAuthorsEach.
+func (q *DBQuerier) FindAuthorsEach(ctx context.Context, firstName string, fn func(row FindAuthorsRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindAuthors")
+	rows, err := q.conn.Query(ctx, findAuthorsSQL, firstName)
+	if err != nil {
+		return fmt.Errorf("query FindAuthorsEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item FindAuthorsRow
+		if err := rows.Scan(&item.AuthorID, &item.FirstName, &item.LastName, &item.Suffix); err != nil {
+			return fmt.Errorf("scan FindAuthorsEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindAuthorsEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindAuthors implements Querier.QueueFindAuthors.
+func (q *DBQuerier) QueueFindAuthors(batch genericBatch, firstName string) {
+	batch.Queue(findAuthorsSQL, firstName)
+}
+
+// FindAuthorsScan implements Querier.FindAuthorsScan.
+func (q *DBQuerier) FindAuthorsScan(results pgx.BatchResults) ([]FindAuthorsRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindAuthorsScan: %w", err)
+	}
+	defer rows.Close()
+	items := []FindAuthorsRow{}
+	for rows.Next() {
+		var item FindAuthorsRow
+		if err := rows.Scan(&item.AuthorID, &item.FirstName, &item.LastName, &item.Suffix); err != nil {
+			return nil, fmt.Errorf("scan FindAuthorsScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindAuthorsScan rows: %w", err)
+	}
+	return items, err
+}
+
 const findAuthorNamesSQL = `SELECT first_name, last_name FROM author ORDER BY author_id = $1;`
 
 type FindAuthorNamesRow struct {
-	FirstName *string `json:"first_name"`
-	LastName  *string `json:"last_name"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
 }
 
 // FindAuthorNames implements Querier.FindAuthorNames.
@@ -191,23 +533,73 @@ func (q *DBQuerier) FindAuthorNames(ctx context.Context, authorID int32) ([]Find
 	return items, err
 }
 
+// FindAuthorNamesEach implements Querier.FindAuthorNamesEach.
+func (q *DBQuerier) FindAuthorNamesEach(ctx context.Context, authorID int32, fn func(row FindAuthorNamesRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindAuthorNames")
+	rows, err := q.conn.Query(ctx, findAuthorNamesSQL, authorID)
+	if err != nil {
+		return fmt.Errorf("query FindAuthorNamesEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item FindAuthorNamesRow
+		if err := rows.Scan(&item.FirstName, &item.LastName); err != nil {
+			return fmt.Errorf("scan FindAuthorNamesEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindAuthorNamesEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindAuthorNames implements Querier.QueueFindAuthorNames.
+func (q *DBQuerier) QueueFindAuthorNames(batch genericBatch, authorID int32) {
+	batch.Queue(findAuthorNamesSQL, authorID)
+}
+
+// FindAuthorNamesScan implements Querier.FindAuthorNamesScan.
+func (q *DBQuerier) FindAuthorNamesScan(results pgx.BatchResults) ([]FindAuthorNamesRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindAuthorNamesScan: %w", err)
+	}
+	defer rows.Close()
+	items := []FindAuthorNamesRow{}
+	for rows.Next() {
+		var item FindAuthorNamesRow
+		if err := rows.Scan(&item.FirstName, &item.LastName); err != nil {
+			return nil, fmt.Errorf("scan FindAuthorNamesScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindAuthorNamesScan rows: %w", err)
+	}
+	return items, err
+}
+
 const findFirstNamesSQL = `SELECT first_name FROM author ORDER BY author_id = $1;`
 
 // FindFirstNames implements Querier.FindFirstNames.
-func (q *DBQuerier) FindFirstNames(ctx context.Context, authorID int32) ([]*string, error) {
+func (q *DBQuerier) FindFirstNames(ctx context.Context, authorID int32) ([]string, error) {
 	ctx = context.WithValue(ctx, "pggen_query_name", "FindFirstNames")
 	rows, err := q.conn.Query(ctx, findFirstNamesSQL, authorID)
 	if err != nil {
 		return nil, fmt.Errorf("query FindFirstNames: %w", err)
 	}
 	defer rows.Close()
-	items := []*string{}
+	items := []string{}
 	for rows.Next() {
 		var item string
 		if err := rows.Scan(&item); err != nil {
 			return nil, fmt.Errorf("scan FindFirstNames row: %w", err)
 		}
-		items = append(items, &item)
+		items = append(items, item)
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("close FindFirstNames rows: %w", err)
@@ -215,6 +607,56 @@ func (q *DBQuerier) FindFirstNames(ctx context.Context, authorID int32) ([]*stri
 	return items, err
 }
 
+// FindFirstNamesEach implements Querier.FindFirstNamesEach.
+func (q *DBQuerier) FindFirstNamesEach(ctx context.Context, authorID int32, fn func(row string) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindFirstNames")
+	rows, err := q.conn.Query(ctx, findFirstNamesSQL, authorID)
+	if err != nil {
+		return fmt.Errorf("query FindFirstNamesEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item string
+		if err := rows.Scan(&item); err != nil {
+			return fmt.Errorf("scan FindFirstNamesEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindFirstNamesEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindFirstNames implements Querier.QueueFindFirstNames.
+func (q *DBQuerier) QueueFindFirstNames(batch genericBatch, authorID int32) {
+	batch.Queue(findFirstNamesSQL, authorID)
+}
+
+// FindFirstNamesScan implements Querier.FindFirstNamesScan.
+func (q *DBQuerier) FindFirstNamesScan(results pgx.BatchResults) ([]string, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindFirstNamesScan: %w", err)
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var item string
+		if err := rows.Scan(&item); err != nil {
+			return nil, fmt.Errorf("scan FindFirstNamesScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindFirstNamesScan rows: %w", err)
+	}
+	return items, err
+}
+
 const deleteAuthorsSQL = `DELETE FROM author WHERE first_name = 'joe';`
 
 // DeleteAuthors implements Querier.DeleteAuthors.
@@ -227,6 +669,20 @@ func (q *DBQuerier) DeleteAuthors(ctx context.Context) (pgconn.CommandTag, error
 	return cmdTag, err
 }
 
+// QueueDeleteAuthors implements Querier.QueueDeleteAuthors.
+func (q *DBQuerier) QueueDeleteAuthors(batch genericBatch) {
+	batch.Queue(deleteAuthorsSQL)
+}
+
+// DeleteAuthorsScan implements Querier.DeleteAuthorsScan.
+func (q *DBQuerier) DeleteAuthorsScan(results pgx.BatchResults) (pgconn.CommandTag, error) {
+	cmdTag, err := results.Exec()
+	if err != nil {
+		return cmdTag, fmt.Errorf("exec DeleteAuthorsScan: %w", err)
+	}
+	return cmdTag, err
+}
+
 const deleteAuthorsByFirstNameSQL = `DELETE FROM author WHERE first_name = $1;`
 
 // DeleteAuthorsByFirstName implements Querier.DeleteAuthorsByFirstName.
@@ -239,6 +695,20 @@ func (q *DBQuerier) DeleteAuthorsByFirstName(ctx context.Context, firstName stri
 	return cmdTag, err
 }
 
+// QueueDeleteAuthorsByFirstName implements Querier.QueueDeleteAuthorsByFirstName.
+func (q *DBQuerier) QueueDeleteAuthorsByFirstName(batch genericBatch, firstName string) {
+	batch.Queue(deleteAuthorsByFirstNameSQL, firstName)
+}
+
+// DeleteAuthorsByFirstNameScan implements Querier.DeleteAuthorsByFirstNameScan.
+func (q *DBQuerier) DeleteAuthorsByFirstNameScan(results pgx.BatchResults) (pgconn.CommandTag, error) {
+	cmdTag, err := results.Exec()
+	if err != nil {
+		return cmdTag, fmt.Errorf("exec DeleteAuthorsByFirstNameScan: %w", err)
+	}
+	return cmdTag, err
+}
+
 const deleteAuthorsByFullNameSQL = `DELETE
 FROM author
 WHERE first_name = $1
@@ -261,6 +731,20 @@ func (q *DBQuerier) DeleteAuthorsByFullName(ctx context.Context, params DeleteAu
 	return cmdTag, err
 }
 
+// QueueDeleteAuthorsByFullName implements Querier.QueueDeleteAuthorsByFullName.
+func (q *DBQuerier) QueueDeleteAuthorsByFullName(batch genericBatch, params DeleteAuthorsByFullNameParams) {
+	batch.Queue(deleteAuthorsByFullNameSQL, params.FirstName, params.LastName, params.Suffix)
+}
+
+// DeleteAuthorsByFullNameScan implements Querier.DeleteAuthorsByFullNameScan.
+func (q *DBQuerier) DeleteAuthorsByFullNameScan(results pgx.BatchResults) (pgconn.CommandTag, error) {
+	cmdTag, err := results.Exec()
+	if err != nil {
+		return cmdTag, fmt.Errorf("exec DeleteAuthorsByFullNameScan: %w", err)
+	}
+	return cmdTag, err
+}
+
 const insertAuthorSQL = `INSERT INTO author (first_name, last_name)
 VALUES ($1, $2)
 RETURNING author_id;`
@@ -271,7 +755,22 @@ func (q *DBQuerier) InsertAuthor(ctx context.Context, firstName string, lastName
 	row := q.conn.QueryRow(ctx, insertAuthorSQL, firstName, lastName)
 	var item int32
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query InsertAuthor: %w", err)
+		return item, fmt.Errorf("query InsertAuthor: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueInsertAuthor implements Querier.QueueInsertAuthor.
+func (q *DBQuerier) QueueInsertAuthor(batch genericBatch, firstName string, lastName string) {
+	batch.Queue(insertAuthorSQL, firstName, lastName)
+}
+
+// InsertAuthorScan implements Querier.InsertAuthorScan.
+func (q *DBQuerier) InsertAuthorScan(results pgx.BatchResults) (int32, error) {
+	row := results.QueryRow()
+	var item int32
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan InsertAuthorScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -299,7 +798,22 @@ func (q *DBQuerier) InsertAuthorSuffix(ctx context.Context, params InsertAuthorS
 	row := q.conn.QueryRow(ctx, insertAuthorSuffixSQL, params.FirstName, params.LastName, params.Suffix)
 	var item InsertAuthorSuffixRow
 	if err := row.Scan(&item.AuthorID, &item.FirstName, &item.LastName, &item.Suffix); err != nil {
-		return item, fmt.Errorf("query InsertAuthorSuffix: %w", err)
+		return item, fmt.Errorf("query InsertAuthorSuffix: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueInsertAuthorSuffix implements Querier.QueueInsertAuthorSuffix.
+func (q *DBQuerier) QueueInsertAuthorSuffix(batch genericBatch, params InsertAuthorSuffixParams) {
+	batch.Queue(insertAuthorSuffixSQL, params.FirstName, params.LastName, params.Suffix)
+}
+
+// InsertAuthorSuffixScan implements Querier.InsertAuthorSuffixScan.
+func (q *DBQuerier) InsertAuthorSuffixScan(results pgx.BatchResults) (InsertAuthorSuffixRow, error) {
+	row := results.QueryRow()
+	var item InsertAuthorSuffixRow
+	if err := row.Scan(&item.AuthorID, &item.FirstName, &item.LastName, &item.Suffix); err != nil {
+		return item, fmt.Errorf("scan InsertAuthorSuffixScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -312,7 +826,22 @@ func (q *DBQuerier) StringAggFirstName(ctx context.Context, authorID int32) (*st
 	row := q.conn.QueryRow(ctx, stringAggFirstNameSQL, authorID)
 	var item *string
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query StringAggFirstName: %w", err)
+		return item, fmt.Errorf("query StringAggFirstName: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueStringAggFirstName implements Querier.QueueStringAggFirstName.
+func (q *DBQuerier) QueueStringAggFirstName(batch genericBatch, authorID int32) {
+	batch.Queue(stringAggFirstNameSQL, authorID)
+}
+
+// StringAggFirstNameScan implements Querier.StringAggFirstNameScan.
+func (q *DBQuerier) StringAggFirstNameScan(results pgx.BatchResults) (*string, error) {
+	row := results.QueryRow()
+	var item *string
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan StringAggFirstNameScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -325,11 +854,120 @@ func (q *DBQuerier) ArrayAggFirstName(ctx context.Context, authorID int32) ([]st
 	row := q.conn.QueryRow(ctx, arrayAggFirstNameSQL, authorID)
 	item := []string{}
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query ArrayAggFirstName: %w", err)
+		return item, fmt.Errorf("query ArrayAggFirstName: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
 
+// QueueArrayAggFirstName implements Querier.QueueArrayAggFirstName.
+func (q *DBQuerier) QueueArrayAggFirstName(batch genericBatch, authorID int32) {
+	batch.Queue(arrayAggFirstNameSQL, authorID)
+}
+
+// ArrayAggFirstNameScan implements Querier.ArrayAggFirstNameScan.
+func (q *DBQuerier) ArrayAggFirstNameScan(results pgx.BatchResults) ([]string, error) {
+	row := results.QueryRow()
+	item := []string{}
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan ArrayAggFirstNameScan row: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+const deleteAuthorsByLastNameSQL = `DELETE FROM author WHERE last_name = $1;`
+
+// DeleteAuthorsByLastName implements Querier.DeleteAuthorsByLastName.
+func (q *DBQuerier) DeleteAuthorsByLastName(ctx context.Context, lastName string) (int64, error) {
+	ctx = context.WithValue(ctx, "pggen_query_name", "DeleteAuthorsByLastName")
+	cmdTag, err := q.conn.Exec(ctx, deleteAuthorsByLastNameSQL, lastName)
+	if err != nil {
+		return 0, fmt.Errorf("exec query DeleteAuthorsByLastName: %w", err)
+	}
+	n := cmdTag.RowsAffected()
+	return n, nil
+}
+
+// QueueDeleteAuthorsByLastName implements Querier.QueueDeleteAuthorsByLastName.
+func (q *DBQuerier) QueueDeleteAuthorsByLastName(batch genericBatch, lastName string) {
+	batch.Queue(deleteAuthorsByLastNameSQL, lastName)
+}
+
+// DeleteAuthorsByLastNameScan implements Querier.DeleteAuthorsByLastNameScan.
+func (q *DBQuerier) DeleteAuthorsByLastNameScan(results pgx.BatchResults) (int64, error) {
+	cmdTag, err := results.Exec()
+	if err != nil {
+		return 0, fmt.Errorf("exec DeleteAuthorsByLastNameScan: %w", err)
+	}
+	n := cmdTag.RowsAffected()
+	return n, nil
+}
+
+const updateAuthorSuffixSQL = `UPDATE author SET suffix = $1 WHERE author_id = $2;`
+
+// UpdateAuthorSuffix implements Querier.UpdateAuthorSuffix.
+func (q *DBQuerier) UpdateAuthorSuffix(ctx context.Context, suffix string, authorID int32) (int64, error) {
+	ctx = context.WithValue(ctx, "pggen_query_name", "UpdateAuthorSuffix")
+	cmdTag, err := q.conn.Exec(ctx, updateAuthorSuffixSQL, suffix, authorID)
+	if err != nil {
+		return 0, fmt.Errorf("exec query UpdateAuthorSuffix: %w", err)
+	}
+	n := cmdTag.RowsAffected()
+	if n != 1 {
+		return n, &UnexpectedRowsError{Query: "UpdateAuthorSuffix", Expected: 1, Actual: n}
+	}
+	return n, nil
+}
+
+// QueueUpdateAuthorSuffix implements Querier.QueueUpdateAuthorSuffix.
+func (q *DBQuerier) QueueUpdateAuthorSuffix(batch genericBatch, suffix string, authorID int32) {
+	batch.Queue(updateAuthorSuffixSQL, suffix, authorID)
+}
+
+// UpdateAuthorSuffixScan implements Querier.UpdateAuthorSuffixScan.
+func (q *DBQuerier) UpdateAuthorSuffixScan(results pgx.BatchResults) (int64, error) {
+	cmdTag, err := results.Exec()
+	if err != nil {
+		return 0, fmt.Errorf("exec UpdateAuthorSuffixScan: %w", err)
+	}
+	n := cmdTag.RowsAffected()
+	if n != 1 {
+		return n, &UnexpectedRowsError{Query: "UpdateAuthorSuffix", Expected: 1, Actual: n}
+	}
+	return n, nil
+}
+
+const deleteAuthorByIDSQL = `DELETE FROM author WHERE author_id = $1;`
+
+// DeleteAuthorByID implements Querier.DeleteAuthorByID.
+func (q *DBQuerier) DeleteAuthorByID(ctx context.Context, authorID int32) (pgconn.CommandTag, error) {
+	ctx = context.WithValue(ctx, "pggen_query_name", "DeleteAuthorByID")
+	cmdTag, err := q.conn.Exec(ctx, deleteAuthorByIDSQL, authorID)
+	if err != nil {
+		return cmdTag, fmt.Errorf("exec query DeleteAuthorByID: %w", err)
+	}
+	if n := cmdTag.RowsAffected(); n != 1 {
+		return cmdTag, &UnexpectedRowsError{Query: "DeleteAuthorByID", Expected: 1, Actual: n}
+	}
+	return cmdTag, err
+}
+
+// QueueDeleteAuthorByID implements Querier.QueueDeleteAuthorByID.
+func (q *DBQuerier) QueueDeleteAuthorByID(batch genericBatch, authorID int32) {
+	batch.Queue(deleteAuthorByIDSQL, authorID)
+}
+
+// DeleteAuthorByIDScan implements Querier.DeleteAuthorByIDScan.
+func (q *DBQuerier) DeleteAuthorByIDScan(results pgx.BatchResults) (pgconn.CommandTag, error) {
+	cmdTag, err := results.Exec()
+	if err != nil {
+		return cmdTag, fmt.Errorf("exec DeleteAuthorByIDScan: %w", err)
+	}
+	if n := cmdTag.RowsAffected(); n != 1 {
+		return cmdTag, &UnexpectedRowsError{Query: "DeleteAuthorByID", Expected: 1, Actual: n}
+	}
+	return cmdTag, err
+}
+
 // textPreferrer wraps a pgtype.ValueTranscoder and sets the preferred encoding
 // format to text instead binary (the default). pggen uses the text format
 // when the OID is unknownOID because the binary format requires the OID.
@@ -3,7 +3,8 @@ package author
 import (
 	"context"
 	"errors"
-	"github.com/atomicleads/pggen/internal/ptrs"
+	"fmt"
+	"github.com/jackc/pgconn"
 	"github.com/stretchr/testify/require"
 	"testing"
 
@@ -38,6 +39,51 @@ func TestNewQuerier_FindAuthorByID(t *testing.T) {
 		if !errors.Is(err, pgx.ErrNoRows) {
 			t.Fatalf("expected no rows error to wrap pgx.ErrNoRows; got %s", err)
 		}
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("FindAuthorByIDScan - none-exists", func(t *testing.T) {
+		batch := &pgx.Batch{}
+		q.QueueFindAuthorByID(batch, 888)
+		results := conn.SendBatch(context.Background(), batch)
+		defer func() { require.NoError(t, results.Close()) }()
+		_, err := q.FindAuthorByIDScan(results)
+		assert.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+}
+
+func TestNewQuerier_FindOptionalAuthorByID(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	q := NewQuerier(conn)
+	adamsID := insertAuthor(t, q, "john", "adams")
+
+	t.Run("FindOptionalAuthorByID", func(t *testing.T) {
+		author, err := q.FindOptionalAuthorByID(context.Background(), adamsID)
+		require.NoError(t, err)
+		assert.Equal(t, &FindOptionalAuthorByIDRow{
+			AuthorID:  adamsID,
+			FirstName: "john",
+			LastName:  "adams",
+			Suffix:    nil,
+		}, author)
+	})
+
+	t.Run("FindOptionalAuthorByID - none-exists", func(t *testing.T) {
+		author, err := q.FindOptionalAuthorByID(context.Background(), 888)
+		require.NoError(t, err)
+		assert.Nil(t, author)
+	})
+
+	t.Run("FindOptionalAuthorByIDScan - none-exists", func(t *testing.T) {
+		batch := &pgx.Batch{}
+		q.QueueFindOptionalAuthorByID(batch, 888)
+		results := conn.SendBatch(context.Background(), batch)
+		defer func() { require.NoError(t, results.Close()) }()
+		author, err := q.FindOptionalAuthorByIDScan(results)
+		require.NoError(t, err)
+		assert.Nil(t, author)
 	})
 }
 
@@ -80,6 +126,54 @@ func TestNewQuerier_FindAuthors(t *testing.T) {
 	})
 }
 
+func TestNewQuerier_FindAuthorsEach(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	q := NewQuerier(conn)
+	washingtonID := insertAuthor(t, q, "george", "washington")
+	carverID := insertAuthor(t, q, "george", "carver")
+
+	t.Run("collects rows", func(t *testing.T) {
+		var authors []FindAuthorsRow
+		err := q.FindAuthorsEach(context.Background(), "george", func(row FindAuthorsRow) error {
+			authors = append(authors, row)
+			return nil
+		})
+		require.NoError(t, err)
+		want := []FindAuthorsRow{
+			{AuthorID: washingtonID, FirstName: "george", LastName: "washington", Suffix: nil},
+			{AuthorID: carverID, FirstName: "george", LastName: "carver", Suffix: nil},
+		}
+		assert.Equal(t, want, authors)
+	})
+
+	t.Run("0 rows", func(t *testing.T) {
+		calls := 0
+		err := q.FindAuthorsEach(context.Background(), "joe", func(FindAuthorsRow) error {
+			calls++
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("stops at first error", func(t *testing.T) {
+		stopErr := errors.New("stop")
+		var authorIDs []int32
+		err := q.FindAuthorsEach(context.Background(), "george", func(row FindAuthorsRow) error {
+			authorIDs = append(authorIDs, row.AuthorID)
+			return stopErr
+		})
+		assert.Same(t, stopErr, err, "error from fn should be returned unwrapped")
+		assert.Equal(t, []int32{washingtonID}, authorIDs)
+
+		// The connection is usable after the remaining rows are discarded.
+		authors, err := q.FindAuthors(context.Background(), "george")
+		require.NoError(t, err)
+		assert.Len(t, authors, 2)
+	})
+}
+
 func TestNewQuerier_FindFirstNames(t *testing.T) {
 	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
 	defer cleanup()
@@ -91,7 +185,7 @@ func TestNewQuerier_FindFirstNames(t *testing.T) {
 	t.Run("FindAuthorByID", func(t *testing.T) {
 		firstNames, err := q.FindFirstNames(context.Background(), adamsID)
 		require.NoError(t, err)
-		assert.Equal(t, []*string{ptrs.String("george"), ptrs.String("john")}, firstNames)
+		assert.Equal(t, []string{"george", "john"}, firstNames)
 	})
 }
 
@@ -174,6 +268,84 @@ func TestNewQuerier_DeleteAuthorsByFullName(t *testing.T) {
 	})
 }
 
+func TestNewQuerier_DeleteAuthorsByLastName(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	q := NewQuerier(conn)
+	insertAuthor(t, q, "john", "adams")
+	insertAuthor(t, q, "john quincy", "adams")
+	insertAuthor(t, q, "george", "washington")
+
+	t.Run("DeleteAuthorsByLastName - 2 rows", func(t *testing.T) {
+		n, err := q.DeleteAuthorsByLastName(context.Background(), "adams")
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+	})
+
+	t.Run("DeleteAuthorsByLastName - 0 rows", func(t *testing.T) {
+		n, err := q.DeleteAuthorsByLastName(context.Background(), "adams")
+		require.NoError(t, err)
+		assert.Equal(t, int64(0), n)
+	})
+}
+
+func TestNewQuerier_UpdateAuthorSuffix(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	q := NewQuerier(conn)
+	adamsID := insertAuthor(t, q, "john", "adams")
+
+	t.Run("UpdateAuthorSuffix - 1 row", func(t *testing.T) {
+		n, err := q.UpdateAuthorSuffix(context.Background(), "Jr.", adamsID)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+		author, err := q.FindAuthorByID(context.Background(), adamsID)
+		require.NoError(t, err)
+		assert.Equal(t, "Jr.", *author.Suffix)
+	})
+
+	t.Run("UpdateAuthorSuffix - 0 rows", func(t *testing.T) {
+		n, err := q.UpdateAuthorSuffix(context.Background(), "Jr.", adamsID+1)
+		var rowsErr *UnexpectedRowsError
+		require.ErrorAs(t, err, &rowsErr)
+		assert.Equal(t, &UnexpectedRowsError{Query: "UpdateAuthorSuffix", Expected: 1, Actual: 0}, rowsErr)
+		assert.Equal(t, int64(0), n)
+	})
+
+	t.Run("UpdateAuthorSuffixScan - 0 rows", func(t *testing.T) {
+		batch := &pgx.Batch{}
+		q.QueueUpdateAuthorSuffix(batch, "Sr.", adamsID+1)
+		results := conn.SendBatch(context.Background(), batch)
+		defer func() { require.NoError(t, results.Close()) }()
+		_, err := q.UpdateAuthorSuffixScan(results)
+		var rowsErr *UnexpectedRowsError
+		require.ErrorAs(t, err, &rowsErr)
+		assert.Equal(t, int64(0), rowsErr.Actual)
+	})
+}
+
+func TestNewQuerier_DeleteAuthorByID(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	q := NewQuerier(conn)
+	adamsID := insertAuthor(t, q, "john", "adams")
+
+	t.Run("DeleteAuthorByID - 1 row", func(t *testing.T) {
+		cmdTag, err := q.DeleteAuthorByID(context.Background(), adamsID)
+		require.NoError(t, err)
+		assert.Truef(t, cmdTag.Delete(), "expected delete tag; got %s", cmdTag.String())
+		assert.Equal(t, int64(1), cmdTag.RowsAffected())
+	})
+
+	t.Run("DeleteAuthorByID - 0 rows", func(t *testing.T) {
+		cmdTag, err := q.DeleteAuthorByID(context.Background(), adamsID)
+		var rowsErr *UnexpectedRowsError
+		require.ErrorAs(t, err, &rowsErr)
+		assert.Equal(t, &UnexpectedRowsError{Query: "DeleteAuthorByID", Expected: 1, Actual: 0}, rowsErr)
+		assert.Equal(t, int64(0), cmdTag.RowsAffected())
+	})
+}
+
 func TestNewQuerier_StringAggFirstName(t *testing.T) {
 	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
 	defer cleanup()
@@ -224,6 +396,162 @@ func TestNewQuerier_ArrayAggFirstName(t *testing.T) {
 	})
 }
 
+func TestNewQuerier_Batch(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	q := NewQuerier(conn)
+	adamsID := insertAuthor(t, q, "john", "adams")
+	washingtonID := insertAuthor(t, q, "george", "washington")
+
+	batch := &pgx.Batch{}
+	q.QueueInsertAuthor(batch, "george", "carver")
+	q.QueueFindAuthorByID(batch, adamsID)
+	q.QueueFindAuthors(batch, "george")
+	q.QueueDeleteAuthorsByFirstName(batch, "george")
+	results := conn.SendBatch(context.Background(), batch)
+	defer results.Close()
+
+	carverID, err := q.InsertAuthorScan(results)
+	require.NoError(t, err)
+
+	adams, err := q.FindAuthorByIDScan(results)
+	require.NoError(t, err)
+	assert.Equal(t, FindAuthorByIDRow{AuthorID: adamsID, FirstName: "john", LastName: "adams"}, adams)
+
+	georges, err := q.FindAuthorsScan(results)
+	require.NoError(t, err)
+	want := []FindAuthorsRow{
+		{AuthorID: washingtonID, FirstName: "george", LastName: "washington"},
+		{AuthorID: carverID, FirstName: "george", LastName: "carver"},
+	}
+	assert.Equal(t, want, georges)
+
+	tag, err := q.DeleteAuthorsByFirstNameScan(results)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), tag.RowsAffected())
+}
+
+func TestNewQuerier_BeginFunc(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	q := NewQuerier(conn)
+	ctx := context.Background()
+	errBoom := errors.New("boom")
+
+	t.Run("commit", func(t *testing.T) {
+		err := q.BeginFunc(ctx, func(q *DBQuerier) error {
+			_, err := q.InsertAuthor(ctx, "john", "adams")
+			return err
+		})
+		require.NoError(t, err)
+		authors, err := q.FindAuthors(ctx, "john")
+		require.NoError(t, err)
+		assert.Len(t, authors, 1)
+	})
+
+	t.Run("rollback on error", func(t *testing.T) {
+		err := q.BeginFunc(ctx, func(q *DBQuerier) error {
+			if _, err := q.InsertAuthor(ctx, "george", "washington"); err != nil {
+				return err
+			}
+			return errBoom
+		})
+		require.ErrorIs(t, err, errBoom)
+		authors, err := q.FindAuthors(ctx, "george")
+		require.NoError(t, err)
+		assert.Empty(t, authors, "insert should be rolled back")
+	})
+
+	t.Run("rollback on panic", func(t *testing.T) {
+		assert.Panics(t, func() {
+			_ = q.BeginFunc(ctx, func(q *DBQuerier) error {
+				if _, err := q.InsertAuthor(ctx, "abe", "lincoln"); err != nil {
+					return err
+				}
+				panic("boom")
+			})
+		})
+		authors, err := q.FindAuthors(ctx, "abe")
+		require.NoError(t, err)
+		assert.Empty(t, authors, "insert should be rolled back")
+	})
+
+	t.Run("nested rollback keeps outer changes", func(t *testing.T) {
+		err := q.BeginFunc(ctx, func(q *DBQuerier) error {
+			if _, err := q.InsertAuthor(ctx, "james", "madison"); err != nil {
+				return err
+			}
+			err := q.BeginFunc(ctx, func(q *DBQuerier) error {
+				if _, err := q.InsertAuthor(ctx, "james", "monroe"); err != nil {
+					return err
+				}
+				return errBoom
+			})
+			if !errors.Is(err, errBoom) {
+				return fmt.Errorf("expected nested error; got %w", err)
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		authors, err := q.FindAuthors(ctx, "james")
+		require.NoError(t, err)
+		require.Len(t, authors, 1)
+		assert.Equal(t, "madison", authors[0].LastName)
+	})
+
+	t.Run("retry serialization failure", func(t *testing.T) {
+		calls := 0
+		serializationErr := &pgconn.PgError{Code: "40001"}
+		opts := TxOptions{TxOptions: pgx.TxOptions{IsoLevel: pgx.Serializable}, MaxRetries: 2}
+		err := q.BeginTxFunc(ctx, opts, func(q *DBQuerier) error {
+			calls++
+			return serializationErr
+		})
+		require.ErrorIs(t, err, serializationErr)
+		assert.Equal(t, 3, calls, "should run fn once plus MaxRetries times")
+	})
+
+	t.Run("retry stops after success", func(t *testing.T) {
+		calls := 0
+		err := q.BeginTxFunc(ctx, TxOptions{MaxRetries: 5}, func(q *DBQuerier) error {
+			calls++
+			if calls < 2 {
+				return &pgconn.PgError{Code: "40001"}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, calls)
+	})
+
+	t.Run("no retry for other errors", func(t *testing.T) {
+		calls := 0
+		err := q.BeginTxFunc(ctx, TxOptions{MaxRetries: 5}, func(q *DBQuerier) error {
+			calls++
+			return errBoom
+		})
+		require.ErrorIs(t, err, errBoom)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestNewQuerier_WithTx(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	ctx := context.Background()
+
+	tx, err := conn.Begin(ctx)
+	require.NoError(t, err)
+	q, err := NewQuerier(conn).WithTx(tx)
+	require.NoError(t, err)
+	insertAuthor(t, q, "john", "adams")
+	require.NoError(t, tx.Rollback(ctx))
+
+	authors, err := NewQuerier(conn).FindAuthors(ctx, "john")
+	require.NoError(t, err)
+	assert.Empty(t, authors, "insert in rolled back transaction should not persist")
+}
+
 func insertAuthor(t *testing.T, q *DBQuerier, first, last string) int32 {
 	t.Helper()
 	authorID, err := q.InsertAuthor(context.Background(), first, last)
@@ -4,6 +4,7 @@ package complex_params
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,16 +12,45 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	ParamArrayInt(ctx context.Context, ints []int) ([]int, error)
+	// QueueParamArrayInt enqueues a ParamArrayInt query into batch to be executed
+	// later by the batch.
+	QueueParamArrayInt(batch genericBatch, ints []int)
+	// ParamArrayIntScan scans the result of an executed QueueParamArrayInt query.
+	ParamArrayIntScan(results pgx.BatchResults) ([]int, error)
 
 	ParamNested1(ctx context.Context, dimensions Dimensions) (Dimensions, error)
+	// QueueParamNested1 enqueues a ParamNested1 query into batch to be executed
+	// later by the batch.
+	QueueParamNested1(batch genericBatch, dimensions Dimensions)
+	// ParamNested1Scan scans the result of an executed QueueParamNested1 query.
+	ParamNested1Scan(results pgx.BatchResults) (Dimensions, error)
 
 	ParamNested2(ctx context.Context, image ProductImageType) (ProductImageType, error)
+	// QueueParamNested2 enqueues a ParamNested2 query into batch to be executed
+	// later by the batch.
+	QueueParamNested2(batch genericBatch, image ProductImageType)
+	// ParamNested2Scan scans the result of an executed QueueParamNested2 query.
+	ParamNested2Scan(results pgx.BatchResults) (ProductImageType, error)
 
 	ParamNested2Array(ctx context.Context, images []ProductImageType) ([]ProductImageType, error)
+	// QueueParamNested2Array enqueues a ParamNested2Array query into batch to be executed
+	// later by the batch.
+	QueueParamNested2Array(batch genericBatch, images []ProductImageType)
+	// ParamNested2ArrayScan scans the result of an executed QueueParamNested2Array query.
+	ParamNested2ArrayScan(results pgx.BatchResults) ([]ProductImageType, error)
 
 	ParamNested3(ctx context.Context, imageSet ProductImageSetType) (ProductImageSetType, error)
+	// QueueParamNested3 enqueues a ParamNested3 query into batch to be executed
+	// later by the batch.
+	QueueParamNested3(batch genericBatch, imageSet ProductImageSetType)
+	// ParamNested3Scan scans the result of an executed QueueParamNested3 query.
+	ParamNested3Scan(results pgx.BatchResults) (ProductImageSetType, error)
 }
 
 type DBQuerier struct {
@@ -49,6 +79,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -56,8 +94,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // Dimensions represents the Postgres composite type "dimensions".
@@ -270,7 +418,22 @@ func (q *DBQuerier) ParamArrayInt(ctx context.Context, ints []int) ([]int, error
 	row := q.conn.QueryRow(ctx, paramArrayIntSQL, ints)
 	item := []int{}
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query ParamArrayInt: %w", err)
+		return item, fmt.Errorf("query ParamArrayInt: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueParamArrayInt implements Querier.QueueParamArrayInt.
+func (q *DBQuerier) QueueParamArrayInt(batch genericBatch, ints []int) {
+	batch.Queue(paramArrayIntSQL, ints)
+}
+
+// ParamArrayIntScan implements Querier.ParamArrayIntScan.
+func (q *DBQuerier) ParamArrayIntScan(results pgx.BatchResults) ([]int, error) {
+	row := results.QueryRow()
+	item := []int{}
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan ParamArrayIntScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -284,7 +447,26 @@ func (q *DBQuerier) ParamNested1(ctx context.Context, dimensions Dimensions) (Di
 	var item Dimensions
 	dimensionsRow := q.types.newDimensions()
 	if err := row.Scan(dimensionsRow); err != nil {
-		return item, fmt.Errorf("query ParamNested1: %w", err)
+		return item, fmt.Errorf("query ParamNested1: %w", wrapNoRows(err))
+	}
+	if err := dimensionsRow.AssignTo(&item); err != nil {
+		return item, fmt.Errorf("assign ParamNested1 row: %w", err)
+	}
+	return item, nil
+}
+
+// QueueParamNested1 implements Querier.QueueParamNested1.
+func (q *DBQuerier) QueueParamNested1(batch genericBatch, dimensions Dimensions) {
+	batch.Queue(paramNested1SQL, q.types.newDimensionsInit(dimensions))
+}
+
+// ParamNested1Scan implements Querier.ParamNested1Scan.
+func (q *DBQuerier) ParamNested1Scan(results pgx.BatchResults) (Dimensions, error) {
+	row := results.QueryRow()
+	var item Dimensions
+	dimensionsRow := q.types.newDimensions()
+	if err := row.Scan(dimensionsRow); err != nil {
+		return item, fmt.Errorf("scan ParamNested1Scan row: %w", wrapNoRows(err))
 	}
 	if err := dimensionsRow.AssignTo(&item); err != nil {
 		return item, fmt.Errorf("assign ParamNested1 row: %w", err)
@@ -301,7 +483,26 @@ func (q *DBQuerier) ParamNested2(ctx context.Context, image ProductImageType) (P
 	var item ProductImageType
 	productImageTypeRow := q.types.newProductImageType()
 	if err := row.Scan(productImageTypeRow); err != nil {
-		return item, fmt.Errorf("query ParamNested2: %w", err)
+		return item, fmt.Errorf("query ParamNested2: %w", wrapNoRows(err))
+	}
+	if err := productImageTypeRow.AssignTo(&item); err != nil {
+		return item, fmt.Errorf("assign ParamNested2 row: %w", err)
+	}
+	return item, nil
+}
+
+// QueueParamNested2 implements Querier.QueueParamNested2.
+func (q *DBQuerier) QueueParamNested2(batch genericBatch, image ProductImageType) {
+	batch.Queue(paramNested2SQL, q.types.newProductImageTypeInit(image))
+}
+
+// ParamNested2Scan implements Querier.ParamNested2Scan.
+func (q *DBQuerier) ParamNested2Scan(results pgx.BatchResults) (ProductImageType, error) {
+	row := results.QueryRow()
+	var item ProductImageType
+	productImageTypeRow := q.types.newProductImageType()
+	if err := row.Scan(productImageTypeRow); err != nil {
+		return item, fmt.Errorf("scan ParamNested2Scan row: %w", wrapNoRows(err))
 	}
 	if err := productImageTypeRow.AssignTo(&item); err != nil {
 		return item, fmt.Errorf("assign ParamNested2 row: %w", err)
@@ -318,7 +519,26 @@ func (q *DBQuerier) ParamNested2Array(ctx context.Context, images []ProductImage
 	item := []ProductImageType{}
 	productImageTypeArray := q.types.newProductImageTypeArray()
 	if err := row.Scan(productImageTypeArray); err != nil {
-		return item, fmt.Errorf("query ParamNested2Array: %w", err)
+		return item, fmt.Errorf("query ParamNested2Array: %w", wrapNoRows(err))
+	}
+	if err := productImageTypeArray.AssignTo(&item); err != nil {
+		return item, fmt.Errorf("assign ParamNested2Array row: %w", err)
+	}
+	return item, nil
+}
+
+// QueueParamNested2Array implements Querier.QueueParamNested2Array.
+func (q *DBQuerier) QueueParamNested2Array(batch genericBatch, images []ProductImageType) {
+	batch.Queue(paramNested2ArraySQL, q.types.newProductImageTypeArrayInit(images))
+}
+
+// ParamNested2ArrayScan implements Querier.ParamNested2ArrayScan.
+func (q *DBQuerier) ParamNested2ArrayScan(results pgx.BatchResults) ([]ProductImageType, error) {
+	row := results.QueryRow()
+	item := []ProductImageType{}
+	productImageTypeArray := q.types.newProductImageTypeArray()
+	if err := row.Scan(productImageTypeArray); err != nil {
+		return item, fmt.Errorf("scan ParamNested2ArrayScan row: %w", wrapNoRows(err))
 	}
 	if err := productImageTypeArray.AssignTo(&item); err != nil {
 		return item, fmt.Errorf("assign ParamNested2Array row: %w", err)
@@ -335,7 +555,26 @@ func (q *DBQuerier) ParamNested3(ctx context.Context, imageSet ProductImageSetTy
 	var item ProductImageSetType
 	productImageSetTypeRow := q.types.newProductImageSetType()
 	if err := row.Scan(productImageSetTypeRow); err != nil {
-		return item, fmt.Errorf("query ParamNested3: %w", err)
+		return item, fmt.Errorf("query ParamNested3: %w", wrapNoRows(err))
+	}
+	if err := productImageSetTypeRow.AssignTo(&item); err != nil {
+		return item, fmt.Errorf("assign ParamNested3 row: %w", err)
+	}
+	return item, nil
+}
+
+// QueueParamNested3 implements Querier.QueueParamNested3.
+func (q *DBQuerier) QueueParamNested3(batch genericBatch, imageSet ProductImageSetType) {
+	batch.Queue(paramNested3SQL, q.types.newProductImageSetTypeInit(imageSet))
+}
+
+// ParamNested3Scan implements Querier.ParamNested3Scan.
+func (q *DBQuerier) ParamNested3Scan(results pgx.BatchResults) (ProductImageSetType, error) {
+	row := results.QueryRow()
+	var item ProductImageSetType
+	productImageSetTypeRow := q.types.newProductImageSetType()
+	if err := row.Scan(productImageSetTypeRow); err != nil {
+		return item, fmt.Errorf("scan ParamNested3Scan row: %w", wrapNoRows(err))
 	}
 	if err := productImageSetTypeRow.AssignTo(&item); err != nil {
 		return item, fmt.Errorf("assign ParamNested3 row: %w", err)
@@ -4,6 +4,7 @@ package composite
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,16 +12,53 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	SearchScreenshots(ctx context.Context, params SearchScreenshotsParams) ([]SearchScreenshotsRow, error)
+	// SearchScreenshotsEach runs SearchScreenshots and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	SearchScreenshotsEach(ctx context.Context, params SearchScreenshotsParams, fn func(row SearchScreenshotsRow) error) error
+	// QueueSearchScreenshots enqueues a SearchScreenshots query into batch to be executed
+	// later by the batch.
+	QueueSearchScreenshots(batch genericBatch, params SearchScreenshotsParams)
+	// SearchScreenshotsScan scans the result of an executed QueueSearchScreenshots query.
+	SearchScreenshotsScan(results pgx.BatchResults) ([]SearchScreenshotsRow, error)
 
 	SearchScreenshotsOneCol(ctx context.Context, params SearchScreenshotsOneColParams) ([][]Blocks, error)
+	// SearchScreenshotsOneColEach runs SearchScreenshotsOneCol and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	SearchScreenshotsOneColEach(ctx context.Context, params SearchScreenshotsOneColParams, fn func(row []Blocks) error) error
+	// QueueSearchScreenshotsOneCol enqueues a SearchScreenshotsOneCol query into batch to be executed
+	// later by the batch.
+	QueueSearchScreenshotsOneCol(batch genericBatch, params SearchScreenshotsOneColParams)
+	// SearchScreenshotsOneColScan scans the result of an executed QueueSearchScreenshotsOneCol query.
+	SearchScreenshotsOneColScan(results pgx.BatchResults) ([][]Blocks, error)
 
 	InsertScreenshotBlocks(ctx context.Context, screenshotID int, body string) (InsertScreenshotBlocksRow, error)
+	// QueueInsertScreenshotBlocks enqueues a InsertScreenshotBlocks query into batch to be executed
+	// later by the batch.
+	QueueInsertScreenshotBlocks(batch genericBatch, screenshotID int, body string)
+	// InsertScreenshotBlocksScan scans the result of an executed QueueInsertScreenshotBlocks query.
+	InsertScreenshotBlocksScan(results pgx.BatchResults) (InsertScreenshotBlocksRow, error)
 
 	ArraysInput(ctx context.Context, arrays Arrays) (Arrays, error)
+	// QueueArraysInput enqueues a ArraysInput query into batch to be executed
+	// later by the batch.
+	QueueArraysInput(batch genericBatch, arrays Arrays)
+	// ArraysInputScan scans the result of an executed QueueArraysInput query.
+	ArraysInputScan(results pgx.BatchResults) (Arrays, error)
 
 	UserEmails(ctx context.Context) (UserEmail, error)
+	// QueueUserEmails enqueues a UserEmails query into batch to be executed
+	// later by the batch.
+	QueueUserEmails(batch genericBatch)
+	// UserEmailsScan scans the result of an executed QueueUserEmails query.
+	UserEmailsScan(results pgx.BatchResults) (UserEmail, error)
 }
 
 type DBQuerier struct {
@@ -49,6 +87,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -56,8 +102,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // Arrays represents the Postgres composite type "arrays".
@@ -274,6 +430,64 @@ func (q *DBQuerier) SearchScreenshots(ctx context.Context, params SearchScreensh
 	return items, err
 }
 
+// SearchScreenshotsEach implements Querier.SearchScreenshotsEach.
+func (q *DBQuerier) SearchScreenshotsEach(ctx context.Context, params SearchScreenshotsParams, fn func(row SearchScreenshotsRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "SearchScreenshots")
+	rows, err := q.conn.Query(ctx, searchScreenshotsSQL, params.Body, params.Limit, params.Offset)
+	if err != nil {
+		return fmt.Errorf("query SearchScreenshotsEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	blocksArray := q.types.newBlocksArray()
+	for rows.Next() {
+		var item SearchScreenshotsRow
+		if err := rows.Scan(&item.ID, blocksArray); err != nil {
+			return fmt.Errorf("scan SearchScreenshotsEach row: %w", err)
+		}
+		if err := blocksArray.AssignTo(&item.Blocks); err != nil {
+			return fmt.Errorf("assign SearchScreenshots row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close SearchScreenshotsEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueSearchScreenshots implements Querier.QueueSearchScreenshots.
+func (q *DBQuerier) QueueSearchScreenshots(batch genericBatch, params SearchScreenshotsParams) {
+	batch.Queue(searchScreenshotsSQL, params.Body, params.Limit, params.Offset)
+}
+
+// SearchScreenshotsScan implements Querier.SearchScreenshotsScan.
+func (q *DBQuerier) SearchScreenshotsScan(results pgx.BatchResults) ([]SearchScreenshotsRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query SearchScreenshotsScan: %w", err)
+	}
+	defer rows.Close()
+	items := []SearchScreenshotsRow{}
+	blocksArray := q.types.newBlocksArray()
+	for rows.Next() {
+		var item SearchScreenshotsRow
+		if err := rows.Scan(&item.ID, blocksArray); err != nil {
+			return nil, fmt.Errorf("scan SearchScreenshotsScan row: %w", err)
+		}
+		if err := blocksArray.AssignTo(&item.Blocks); err != nil {
+			return nil, fmt.Errorf("assign SearchScreenshots row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close SearchScreenshotsScan rows: %w", err)
+	}
+	return items, err
+}
+
 const searchScreenshotsOneColSQL = `SELECT
   array_agg(bl) AS blocks
 FROM screenshots ss
@@ -315,6 +529,64 @@ func (q *DBQuerier) SearchScreenshotsOneCol(ctx context.Context, params SearchSc
 	return items, err
 }
 
+// SearchScreenshotsOneColEach implements Querier.SearchScreenshotsOneColEach.
+func (q *DBQuerier) SearchScreenshotsOneColEach(ctx context.Context, params SearchScreenshotsOneColParams, fn func(row []Blocks) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "SearchScreenshotsOneCol")
+	rows, err := q.conn.Query(ctx, searchScreenshotsOneColSQL, params.Body, params.Limit, params.Offset)
+	if err != nil {
+		return fmt.Errorf("query SearchScreenshotsOneColEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	blocksArray := q.types.newBlocksArray()
+	for rows.Next() {
+		var item []Blocks
+		if err := rows.Scan(blocksArray); err != nil {
+			return fmt.Errorf("scan SearchScreenshotsOneColEach row: %w", err)
+		}
+		if err := blocksArray.AssignTo(&item); err != nil {
+			return fmt.Errorf("assign SearchScreenshotsOneCol row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close SearchScreenshotsOneColEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueSearchScreenshotsOneCol implements Querier.QueueSearchScreenshotsOneCol.
+func (q *DBQuerier) QueueSearchScreenshotsOneCol(batch genericBatch, params SearchScreenshotsOneColParams) {
+	batch.Queue(searchScreenshotsOneColSQL, params.Body, params.Limit, params.Offset)
+}
+
+// SearchScreenshotsOneColScan implements Querier.SearchScreenshotsOneColScan.
+func (q *DBQuerier) SearchScreenshotsOneColScan(results pgx.BatchResults) ([][]Blocks, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query SearchScreenshotsOneColScan: %w", err)
+	}
+	defer rows.Close()
+	items := [][]Blocks{}
+	blocksArray := q.types.newBlocksArray()
+	for rows.Next() {
+		var item []Blocks
+		if err := rows.Scan(blocksArray); err != nil {
+			return nil, fmt.Errorf("scan SearchScreenshotsOneColScan row: %w", err)
+		}
+		if err := blocksArray.AssignTo(&item); err != nil {
+			return nil, fmt.Errorf("assign SearchScreenshotsOneCol row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close SearchScreenshotsOneColScan rows: %w", err)
+	}
+	return items, err
+}
+
 const insertScreenshotBlocksSQL = `WITH screens AS (
   INSERT INTO screenshots (id) VALUES ($1)
     ON CONFLICT DO NOTHING
@@ -336,7 +608,22 @@ func (q *DBQuerier) InsertScreenshotBlocks(ctx context.Context, screenshotID int
 	row := q.conn.QueryRow(ctx, insertScreenshotBlocksSQL, screenshotID, body)
 	var item InsertScreenshotBlocksRow
 	if err := row.Scan(&item.ID, &item.ScreenshotID, &item.Body); err != nil {
-		return item, fmt.Errorf("query InsertScreenshotBlocks: %w", err)
+		return item, fmt.Errorf("query InsertScreenshotBlocks: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueInsertScreenshotBlocks implements Querier.QueueInsertScreenshotBlocks.
+func (q *DBQuerier) QueueInsertScreenshotBlocks(batch genericBatch, screenshotID int, body string) {
+	batch.Queue(insertScreenshotBlocksSQL, screenshotID, body)
+}
+
+// InsertScreenshotBlocksScan implements Querier.InsertScreenshotBlocksScan.
+func (q *DBQuerier) InsertScreenshotBlocksScan(results pgx.BatchResults) (InsertScreenshotBlocksRow, error) {
+	row := results.QueryRow()
+	var item InsertScreenshotBlocksRow
+	if err := row.Scan(&item.ID, &item.ScreenshotID, &item.Body); err != nil {
+		return item, fmt.Errorf("scan InsertScreenshotBlocksScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -350,7 +637,26 @@ func (q *DBQuerier) ArraysInput(ctx context.Context, arrays Arrays) (Arrays, err
 	var item Arrays
 	arraysRow := q.types.newArrays()
 	if err := row.Scan(arraysRow); err != nil {
-		return item, fmt.Errorf("query ArraysInput: %w", err)
+		return item, fmt.Errorf("query ArraysInput: %w", wrapNoRows(err))
+	}
+	if err := arraysRow.AssignTo(&item); err != nil {
+		return item, fmt.Errorf("assign ArraysInput row: %w", err)
+	}
+	return item, nil
+}
+
+// QueueArraysInput implements Querier.QueueArraysInput.
+func (q *DBQuerier) QueueArraysInput(batch genericBatch, arrays Arrays) {
+	batch.Queue(arraysInputSQL, q.types.newArraysInit(arrays))
+}
+
+// ArraysInputScan implements Querier.ArraysInputScan.
+func (q *DBQuerier) ArraysInputScan(results pgx.BatchResults) (Arrays, error) {
+	row := results.QueryRow()
+	var item Arrays
+	arraysRow := q.types.newArrays()
+	if err := row.Scan(arraysRow); err != nil {
+		return item, fmt.Errorf("scan ArraysInputScan row: %w", wrapNoRows(err))
 	}
 	if err := arraysRow.AssignTo(&item); err != nil {
 		return item, fmt.Errorf("assign ArraysInput row: %w", err)
@@ -367,7 +673,26 @@ func (q *DBQuerier) UserEmails(ctx context.Context) (UserEmail, error) {
 	var item UserEmail
 	rowRow := q.types.newUserEmail()
 	if err := row.Scan(rowRow); err != nil {
-		return item, fmt.Errorf("query UserEmails: %w", err)
+		return item, fmt.Errorf("query UserEmails: %w", wrapNoRows(err))
+	}
+	if err := rowRow.AssignTo(&item); err != nil {
+		return item, fmt.Errorf("assign UserEmails row: %w", err)
+	}
+	return item, nil
+}
+
+// QueueUserEmails implements Querier.QueueUserEmails.
+func (q *DBQuerier) QueueUserEmails(batch genericBatch) {
+	batch.Queue(userEmailsSQL)
+}
+
+// UserEmailsScan implements Querier.UserEmailsScan.
+func (q *DBQuerier) UserEmailsScan(results pgx.BatchResults) (UserEmail, error) {
+	row := results.QueryRow()
+	var item UserEmail
+	rowRow := q.types.newUserEmail()
+	if err := row.Scan(rowRow); err != nil {
+		return item, fmt.Errorf("scan UserEmailsScan row: %w", wrapNoRows(err))
 	}
 	if err := rowRow.AssignTo(&item); err != nil {
 		return item, fmt.Errorf("assign UserEmails row: %w", err)
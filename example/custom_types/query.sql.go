@@ -4,20 +4,44 @@ package custom_types
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/atomicleads/pggen/example/custom_types/mytype"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
-	"github.com/atomicleads/pggen/example/custom_types/mytype"
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	CustomTypes(ctx context.Context) (CustomTypesRow, error)
+	// QueueCustomTypes enqueues a CustomTypes query into batch to be executed
+	// later by the batch.
+	QueueCustomTypes(batch genericBatch)
+	// CustomTypesScan scans the result of an executed QueueCustomTypes query.
+	CustomTypesScan(results pgx.BatchResults) (CustomTypesRow, error)
 
 	CustomMyInt(ctx context.Context) (int, error)
+	// QueueCustomMyInt enqueues a CustomMyInt query into batch to be executed
+	// later by the batch.
+	QueueCustomMyInt(batch genericBatch)
+	// CustomMyIntScan scans the result of an executed QueueCustomMyInt query.
+	CustomMyIntScan(results pgx.BatchResults) (int, error)
 
 	IntArray(ctx context.Context) ([][]int32, error)
+	// IntArrayEach runs IntArray and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	IntArrayEach(ctx context.Context, fn func(row []int32) error) error
+	// QueueIntArray enqueues a IntArray query into batch to be executed
+	// later by the batch.
+	QueueIntArray(batch genericBatch)
+	// IntArrayScan scans the result of an executed QueueIntArray query.
+	IntArrayScan(results pgx.BatchResults) ([][]int32, error)
 }
 
 type DBQuerier struct {
@@ -46,6 +70,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -53,8 +85,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // typeResolver looks up the pgtype.ValueTranscoder by Postgres type name.
@@ -99,7 +241,22 @@ func (q *DBQuerier) CustomTypes(ctx context.Context) (CustomTypesRow, error) {
 	row := q.conn.QueryRow(ctx, customTypesSQL)
 	var item CustomTypesRow
 	if err := row.Scan(&item.Column, &item.Int8); err != nil {
-		return item, fmt.Errorf("query CustomTypes: %w", err)
+		return item, fmt.Errorf("query CustomTypes: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueCustomTypes implements Querier.QueueCustomTypes.
+func (q *DBQuerier) QueueCustomTypes(batch genericBatch) {
+	batch.Queue(customTypesSQL)
+}
+
+// CustomTypesScan implements Querier.CustomTypesScan.
+func (q *DBQuerier) CustomTypesScan(results pgx.BatchResults) (CustomTypesRow, error) {
+	row := results.QueryRow()
+	var item CustomTypesRow
+	if err := row.Scan(&item.Column, &item.Int8); err != nil {
+		return item, fmt.Errorf("scan CustomTypesScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -112,7 +269,22 @@ func (q *DBQuerier) CustomMyInt(ctx context.Context) (int, error) {
 	row := q.conn.QueryRow(ctx, customMyIntSQL)
 	var item int
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query CustomMyInt: %w", err)
+		return item, fmt.Errorf("query CustomMyInt: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueCustomMyInt implements Querier.QueueCustomMyInt.
+func (q *DBQuerier) QueueCustomMyInt(batch genericBatch) {
+	batch.Queue(customMyIntSQL)
+}
+
+// CustomMyIntScan implements Querier.CustomMyIntScan.
+func (q *DBQuerier) CustomMyIntScan(results pgx.BatchResults) (int, error) {
+	row := results.QueryRow()
+	var item int
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan CustomMyIntScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -141,6 +313,56 @@ func (q *DBQuerier) IntArray(ctx context.Context) ([][]int32, error) {
 	return items, err
 }
 
+// IntArrayEach implements Querier.IntArrayEach.
+func (q *DBQuerier) IntArrayEach(ctx context.Context, fn func(row []int32) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "IntArray")
+	rows, err := q.conn.Query(ctx, intArraySQL)
+	if err != nil {
+		return fmt.Errorf("query IntArrayEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item []int32
+		if err := rows.Scan(&item); err != nil {
+			return fmt.Errorf("scan IntArrayEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close IntArrayEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueIntArray implements Querier.QueueIntArray.
+func (q *DBQuerier) QueueIntArray(batch genericBatch) {
+	batch.Queue(intArraySQL)
+}
+
+// IntArrayScan implements Querier.IntArrayScan.
+func (q *DBQuerier) IntArrayScan(results pgx.BatchResults) ([][]int32, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query IntArrayScan: %w", err)
+	}
+	defer rows.Close()
+	items := [][]int32{}
+	for rows.Next() {
+		var item []int32
+		if err := rows.Scan(&item); err != nil {
+			return nil, fmt.Errorf("scan IntArrayScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close IntArrayScan rows: %w", err)
+	}
+	return items, err
+}
+
 // textPreferrer wraps a pgtype.ValueTranscoder and sets the preferred encoding
 // format to text instead binary (the default). pggen uses the text format
 // when the OID is unknownOID because the binary format requires the OID.
@@ -4,6 +4,7 @@ package device
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,20 +12,71 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	FindDevicesByUser(ctx context.Context, id int) ([]FindDevicesByUserRow, error)
+	// FindDevicesByUserEach runs FindDevicesByUser and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindDevicesByUserEach(ctx context.Context, id int, fn func(row FindDevicesByUserRow) error) error
+	// QueueFindDevicesByUser enqueues a FindDevicesByUser query into batch to be executed
+	// later by the batch.
+	QueueFindDevicesByUser(batch genericBatch, id int)
+	// FindDevicesByUserScan scans the result of an executed QueueFindDevicesByUser query.
+	FindDevicesByUserScan(results pgx.BatchResults) ([]FindDevicesByUserRow, error)
 
 	CompositeUser(ctx context.Context) ([]CompositeUserRow, error)
+	// CompositeUserEach runs CompositeUser and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	CompositeUserEach(ctx context.Context, fn func(row CompositeUserRow) error) error
+	// QueueCompositeUser enqueues a CompositeUser query into batch to be executed
+	// later by the batch.
+	QueueCompositeUser(batch genericBatch)
+	// CompositeUserScan scans the result of an executed QueueCompositeUser query.
+	CompositeUserScan(results pgx.BatchResults) ([]CompositeUserRow, error)
 
 	CompositeUserOne(ctx context.Context) (User, error)
+	// QueueCompositeUserOne enqueues a CompositeUserOne query into batch to be executed
+	// later by the batch.
+	QueueCompositeUserOne(batch genericBatch)
+	// CompositeUserOneScan scans the result of an executed QueueCompositeUserOne query.
+	CompositeUserOneScan(results pgx.BatchResults) (User, error)
 
 	CompositeUserOneTwoCols(ctx context.Context) (CompositeUserOneTwoColsRow, error)
+	// QueueCompositeUserOneTwoCols enqueues a CompositeUserOneTwoCols query into batch to be executed
+	// later by the batch.
+	QueueCompositeUserOneTwoCols(batch genericBatch)
+	// CompositeUserOneTwoColsScan scans the result of an executed QueueCompositeUserOneTwoCols query.
+	CompositeUserOneTwoColsScan(results pgx.BatchResults) (CompositeUserOneTwoColsRow, error)
 
 	CompositeUserMany(ctx context.Context) ([]User, error)
+	// CompositeUserManyEach runs CompositeUserMany and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	CompositeUserManyEach(ctx context.Context, fn func(row User) error) error
+	// QueueCompositeUserMany enqueues a CompositeUserMany query into batch to be executed
+	// later by the batch.
+	QueueCompositeUserMany(batch genericBatch)
+	// CompositeUserManyScan scans the result of an executed QueueCompositeUserMany query.
+	CompositeUserManyScan(results pgx.BatchResults) ([]User, error)
 
 	InsertUser(ctx context.Context, userID int, name string) (pgconn.CommandTag, error)
+	// QueueInsertUser enqueues a InsertUser query into batch to be executed
+	// later by the batch.
+	QueueInsertUser(batch genericBatch, userID int, name string)
+	// InsertUserScan scans the result of an executed QueueInsertUser query.
+	InsertUserScan(results pgx.BatchResults) (pgconn.CommandTag, error)
 
 	InsertDevice(ctx context.Context, mac pgtype.Macaddr, owner int) (pgconn.CommandTag, error)
+	// QueueInsertDevice enqueues a InsertDevice query into batch to be executed
+	// later by the batch.
+	QueueInsertDevice(batch genericBatch, mac pgtype.Macaddr, owner int)
+	// InsertDeviceScan scans the result of an executed QueueInsertDevice query.
+	InsertDeviceScan(results pgx.BatchResults) (pgconn.CommandTag, error)
 }
 
 type DBQuerier struct {
@@ -53,6 +105,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -60,8 +120,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // User represents the Postgres composite type "user".
@@ -209,6 +379,56 @@ func (q *DBQuerier) FindDevicesByUser(ctx context.Context, id int) ([]FindDevice
 	return items, err
 }
 
+// FindDevicesByUserEach implements Querier.FindDevicesByUserEach.
+func (q *DBQuerier) FindDevicesByUserEach(ctx context.Context, id int, fn func(row FindDevicesByUserRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindDevicesByUser")
+	rows, err := q.conn.Query(ctx, findDevicesByUserSQL, id)
+	if err != nil {
+		return fmt.Errorf("query FindDevicesByUserEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item FindDevicesByUserRow
+		if err := rows.Scan(&item.ID, &item.Name, &item.MacAddrs); err != nil {
+			return fmt.Errorf("scan FindDevicesByUserEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindDevicesByUserEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindDevicesByUser implements Querier.QueueFindDevicesByUser.
+func (q *DBQuerier) QueueFindDevicesByUser(batch genericBatch, id int) {
+	batch.Queue(findDevicesByUserSQL, id)
+}
+
+// FindDevicesByUserScan implements Querier.FindDevicesByUserScan.
+func (q *DBQuerier) FindDevicesByUserScan(results pgx.BatchResults) ([]FindDevicesByUserRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindDevicesByUserScan: %w", err)
+	}
+	defer rows.Close()
+	items := []FindDevicesByUserRow{}
+	for rows.Next() {
+		var item FindDevicesByUserRow
+		if err := rows.Scan(&item.ID, &item.Name, &item.MacAddrs); err != nil {
+			return nil, fmt.Errorf("scan FindDevicesByUserScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindDevicesByUserScan rows: %w", err)
+	}
+	return items, err
+}
+
 const compositeUserSQL = `SELECT
   d.mac,
   d.type,
@@ -248,6 +468,64 @@ func (q *DBQuerier) CompositeUser(ctx context.Context) ([]CompositeUserRow, erro
 	return items, err
 }
 
+// CompositeUserEach implements Querier.CompositeUserEach.
+func (q *DBQuerier) CompositeUserEach(ctx context.Context, fn func(row CompositeUserRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "CompositeUser")
+	rows, err := q.conn.Query(ctx, compositeUserSQL)
+	if err != nil {
+		return fmt.Errorf("query CompositeUserEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	userRow := q.types.newUser()
+	for rows.Next() {
+		var item CompositeUserRow
+		if err := rows.Scan(&item.Mac, &item.Type, userRow); err != nil {
+			return fmt.Errorf("scan CompositeUserEach row: %w", err)
+		}
+		if err := userRow.AssignTo(&item.User); err != nil {
+			return fmt.Errorf("assign CompositeUser row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close CompositeUserEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueCompositeUser implements Querier.QueueCompositeUser.
+func (q *DBQuerier) QueueCompositeUser(batch genericBatch) {
+	batch.Queue(compositeUserSQL)
+}
+
+// CompositeUserScan implements Querier.CompositeUserScan.
+func (q *DBQuerier) CompositeUserScan(results pgx.BatchResults) ([]CompositeUserRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query CompositeUserScan: %w", err)
+	}
+	defer rows.Close()
+	items := []CompositeUserRow{}
+	userRow := q.types.newUser()
+	for rows.Next() {
+		var item CompositeUserRow
+		if err := rows.Scan(&item.Mac, &item.Type, userRow); err != nil {
+			return nil, fmt.Errorf("scan CompositeUserScan row: %w", err)
+		}
+		if err := userRow.AssignTo(&item.User); err != nil {
+			return nil, fmt.Errorf("assign CompositeUser row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close CompositeUserScan rows: %w", err)
+	}
+	return items, err
+}
+
 const compositeUserOneSQL = `SELECT ROW (15, 'qux')::"user" AS "user";`
 
 // CompositeUserOne implements Querier.CompositeUserOne.
@@ -257,7 +535,26 @@ func (q *DBQuerier) CompositeUserOne(ctx context.Context) (User, error) {
 	var item User
 	userRow := q.types.newUser()
 	if err := row.Scan(userRow); err != nil {
-		return item, fmt.Errorf("query CompositeUserOne: %w", err)
+		return item, fmt.Errorf("query CompositeUserOne: %w", wrapNoRows(err))
+	}
+	if err := userRow.AssignTo(&item); err != nil {
+		return item, fmt.Errorf("assign CompositeUserOne row: %w", err)
+	}
+	return item, nil
+}
+
+// QueueCompositeUserOne implements Querier.QueueCompositeUserOne.
+func (q *DBQuerier) QueueCompositeUserOne(batch genericBatch) {
+	batch.Queue(compositeUserOneSQL)
+}
+
+// CompositeUserOneScan implements Querier.CompositeUserOneScan.
+func (q *DBQuerier) CompositeUserOneScan(results pgx.BatchResults) (User, error) {
+	row := results.QueryRow()
+	var item User
+	userRow := q.types.newUser()
+	if err := row.Scan(userRow); err != nil {
+		return item, fmt.Errorf("scan CompositeUserOneScan row: %w", wrapNoRows(err))
 	}
 	if err := userRow.AssignTo(&item); err != nil {
 		return item, fmt.Errorf("assign CompositeUserOne row: %w", err)
@@ -279,7 +576,26 @@ func (q *DBQuerier) CompositeUserOneTwoCols(ctx context.Context) (CompositeUserO
 	var item CompositeUserOneTwoColsRow
 	userRow := q.types.newUser()
 	if err := row.Scan(&item.Num, userRow); err != nil {
-		return item, fmt.Errorf("query CompositeUserOneTwoCols: %w", err)
+		return item, fmt.Errorf("query CompositeUserOneTwoCols: %w", wrapNoRows(err))
+	}
+	if err := userRow.AssignTo(&item.User); err != nil {
+		return item, fmt.Errorf("assign CompositeUserOneTwoCols row: %w", err)
+	}
+	return item, nil
+}
+
+// QueueCompositeUserOneTwoCols implements Querier.QueueCompositeUserOneTwoCols.
+func (q *DBQuerier) QueueCompositeUserOneTwoCols(batch genericBatch) {
+	batch.Queue(compositeUserOneTwoColsSQL)
+}
+
+// CompositeUserOneTwoColsScan implements Querier.CompositeUserOneTwoColsScan.
+func (q *DBQuerier) CompositeUserOneTwoColsScan(results pgx.BatchResults) (CompositeUserOneTwoColsRow, error) {
+	row := results.QueryRow()
+	var item CompositeUserOneTwoColsRow
+	userRow := q.types.newUser()
+	if err := row.Scan(&item.Num, userRow); err != nil {
+		return item, fmt.Errorf("scan CompositeUserOneTwoColsScan row: %w", wrapNoRows(err))
 	}
 	if err := userRow.AssignTo(&item.User); err != nil {
 		return item, fmt.Errorf("assign CompositeUserOneTwoCols row: %w", err)
@@ -315,6 +631,64 @@ func (q *DBQuerier) CompositeUserMany(ctx context.Context) ([]User, error) {
 	return items, err
 }
 
+// CompositeUserManyEach implements Querier.CompositeUserManyEach.
+func (q *DBQuerier) CompositeUserManyEach(ctx context.Context, fn func(row User) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "CompositeUserMany")
+	rows, err := q.conn.Query(ctx, compositeUserManySQL)
+	if err != nil {
+		return fmt.Errorf("query CompositeUserManyEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	userRow := q.types.newUser()
+	for rows.Next() {
+		var item User
+		if err := rows.Scan(userRow); err != nil {
+			return fmt.Errorf("scan CompositeUserManyEach row: %w", err)
+		}
+		if err := userRow.AssignTo(&item); err != nil {
+			return fmt.Errorf("assign CompositeUserMany row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close CompositeUserManyEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueCompositeUserMany implements Querier.QueueCompositeUserMany.
+func (q *DBQuerier) QueueCompositeUserMany(batch genericBatch) {
+	batch.Queue(compositeUserManySQL)
+}
+
+// CompositeUserManyScan implements Querier.CompositeUserManyScan.
+func (q *DBQuerier) CompositeUserManyScan(results pgx.BatchResults) ([]User, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query CompositeUserManyScan: %w", err)
+	}
+	defer rows.Close()
+	items := []User{}
+	userRow := q.types.newUser()
+	for rows.Next() {
+		var item User
+		if err := rows.Scan(userRow); err != nil {
+			return nil, fmt.Errorf("scan CompositeUserManyScan row: %w", err)
+		}
+		if err := userRow.AssignTo(&item); err != nil {
+			return nil, fmt.Errorf("assign CompositeUserMany row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close CompositeUserManyScan rows: %w", err)
+	}
+	return items, err
+}
+
 const insertUserSQL = `INSERT INTO "user" (id, name)
 VALUES ($1, $2);`
 
@@ -328,6 +702,20 @@ func (q *DBQuerier) InsertUser(ctx context.Context, userID int, name string) (pg
 	return cmdTag, err
 }
 
+// QueueInsertUser implements Querier.QueueInsertUser.
+func (q *DBQuerier) QueueInsertUser(batch genericBatch, userID int, name string) {
+	batch.Queue(insertUserSQL, userID, name)
+}
+
+// InsertUserScan implements Querier.InsertUserScan.
+func (q *DBQuerier) InsertUserScan(results pgx.BatchResults) (pgconn.CommandTag, error) {
+	cmdTag, err := results.Exec()
+	if err != nil {
+		return cmdTag, fmt.Errorf("exec InsertUserScan: %w", err)
+	}
+	return cmdTag, err
+}
+
 const insertDeviceSQL = `INSERT INTO device (mac, owner)
 VALUES ($1, $2);`
 
@@ -341,6 +729,20 @@ func (q *DBQuerier) InsertDevice(ctx context.Context, mac pgtype.Macaddr, owner
 	return cmdTag, err
 }
 
+// QueueInsertDevice implements Querier.QueueInsertDevice.
+func (q *DBQuerier) QueueInsertDevice(batch genericBatch, mac pgtype.Macaddr, owner int) {
+	batch.Queue(insertDeviceSQL, mac, owner)
+}
+
+// InsertDeviceScan implements Querier.InsertDeviceScan.
+func (q *DBQuerier) InsertDeviceScan(results pgx.BatchResults) (pgconn.CommandTag, error) {
+	cmdTag, err := results.Exec()
+	if err != nil {
+		return cmdTag, fmt.Errorf("exec InsertDeviceScan: %w", err)
+	}
+	return cmdTag, err
+}
+
 // textPreferrer wraps a pgtype.ValueTranscoder and sets the preferred encoding
 // format to text instead binary (the default). pggen uses the text format
 // when the OID is unknownOID because the binary format requires the OID.
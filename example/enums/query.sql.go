@@ -4,6 +4,7 @@ package enums
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,22 +12,68 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	FindAllDevices(ctx context.Context) ([]FindAllDevicesRow, error)
+	// FindAllDevicesEach runs FindAllDevices and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindAllDevicesEach(ctx context.Context, fn func(row FindAllDevicesRow) error) error
+	// QueueFindAllDevices enqueues a FindAllDevices query into batch to be executed
+	// later by the batch.
+	QueueFindAllDevices(batch genericBatch)
+	// FindAllDevicesScan scans the result of an executed QueueFindAllDevices query.
+	FindAllDevicesScan(results pgx.BatchResults) ([]FindAllDevicesRow, error)
 
 	InsertDevice(ctx context.Context, mac pgtype.Macaddr, typePg DeviceType) (pgconn.CommandTag, error)
+	// QueueInsertDevice enqueues a InsertDevice query into batch to be executed
+	// later by the batch.
+	QueueInsertDevice(batch genericBatch, mac pgtype.Macaddr, typePg DeviceType)
+	// InsertDeviceScan scans the result of an executed QueueInsertDevice query.
+	InsertDeviceScan(results pgx.BatchResults) (pgconn.CommandTag, error)
 
 	// Select an array of all device_type enum values.
 	FindOneDeviceArray(ctx context.Context) ([]DeviceType, error)
+	// QueueFindOneDeviceArray enqueues a FindOneDeviceArray query into batch to be executed
+	// later by the batch.
+	QueueFindOneDeviceArray(batch genericBatch)
+	// FindOneDeviceArrayScan scans the result of an executed QueueFindOneDeviceArray query.
+	FindOneDeviceArrayScan(results pgx.BatchResults) ([]DeviceType, error)
 
 	// Select many rows of device_type enum values.
 	FindManyDeviceArray(ctx context.Context) ([][]DeviceType, error)
+	// FindManyDeviceArrayEach runs FindManyDeviceArray and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindManyDeviceArrayEach(ctx context.Context, fn func(row []DeviceType) error) error
+	// QueueFindManyDeviceArray enqueues a FindManyDeviceArray query into batch to be executed
+	// later by the batch.
+	QueueFindManyDeviceArray(batch genericBatch)
+	// FindManyDeviceArrayScan scans the result of an executed QueueFindManyDeviceArray query.
+	FindManyDeviceArrayScan(results pgx.BatchResults) ([][]DeviceType, error)
 
 	// Select many rows of device_type enum values with multiple output columns.
 	FindManyDeviceArrayWithNum(ctx context.Context) ([]FindManyDeviceArrayWithNumRow, error)
+	// FindManyDeviceArrayWithNumEach runs FindManyDeviceArrayWithNum and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindManyDeviceArrayWithNumEach(ctx context.Context, fn func(row FindManyDeviceArrayWithNumRow) error) error
+	// QueueFindManyDeviceArrayWithNum enqueues a FindManyDeviceArrayWithNum query into batch to be executed
+	// later by the batch.
+	QueueFindManyDeviceArrayWithNum(batch genericBatch)
+	// FindManyDeviceArrayWithNumScan scans the result of an executed QueueFindManyDeviceArrayWithNum query.
+	FindManyDeviceArrayWithNumScan(results pgx.BatchResults) ([]FindManyDeviceArrayWithNumRow, error)
 
 	// Regression test for https://github.com/atomicleads/pggen/issues/23.
 	EnumInsideComposite(ctx context.Context) (Device, error)
+	// QueueEnumInsideComposite enqueues a EnumInsideComposite query into batch to be executed
+	// later by the batch.
+	QueueEnumInsideComposite(batch genericBatch)
+	// EnumInsideCompositeScan scans the result of an executed QueueEnumInsideComposite query.
+	EnumInsideCompositeScan(results pgx.BatchResults) (Device, error)
 }
 
 type DBQuerier struct {
@@ -55,6 +102,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -62,8 +117,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // Device represents the Postgres composite type "device".
@@ -228,6 +393,56 @@ func (q *DBQuerier) FindAllDevices(ctx context.Context) ([]FindAllDevicesRow, er
 	return items, err
 }
 
+// FindAllDevicesEach implements Querier.FindAllDevicesEach.
+func (q *DBQuerier) FindAllDevicesEach(ctx context.Context, fn func(row FindAllDevicesRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindAllDevices")
+	rows, err := q.conn.Query(ctx, findAllDevicesSQL)
+	if err != nil {
+		return fmt.Errorf("query FindAllDevicesEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item FindAllDevicesRow
+		if err := rows.Scan(&item.Mac, &item.Type); err != nil {
+			return fmt.Errorf("scan FindAllDevicesEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindAllDevicesEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindAllDevices implements Querier.QueueFindAllDevices.
+func (q *DBQuerier) QueueFindAllDevices(batch genericBatch) {
+	batch.Queue(findAllDevicesSQL)
+}
+
+// FindAllDevicesScan implements Querier.FindAllDevicesScan.
+func (q *DBQuerier) FindAllDevicesScan(results pgx.BatchResults) ([]FindAllDevicesRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindAllDevicesScan: %w", err)
+	}
+	defer rows.Close()
+	items := []FindAllDevicesRow{}
+	for rows.Next() {
+		var item FindAllDevicesRow
+		if err := rows.Scan(&item.Mac, &item.Type); err != nil {
+			return nil, fmt.Errorf("scan FindAllDevicesScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindAllDevicesScan rows: %w", err)
+	}
+	return items, err
+}
+
 const insertDeviceSQL = `INSERT INTO device (mac, type)
 VALUES ($1, $2);`
 
@@ -241,6 +456,20 @@ func (q *DBQuerier) InsertDevice(ctx context.Context, mac pgtype.Macaddr, typePg
 	return cmdTag, err
 }
 
+// QueueInsertDevice implements Querier.QueueInsertDevice.
+func (q *DBQuerier) QueueInsertDevice(batch genericBatch, mac pgtype.Macaddr, typePg DeviceType) {
+	batch.Queue(insertDeviceSQL, mac, typePg)
+}
+
+// InsertDeviceScan implements Querier.InsertDeviceScan.
+func (q *DBQuerier) InsertDeviceScan(results pgx.BatchResults) (pgconn.CommandTag, error) {
+	cmdTag, err := results.Exec()
+	if err != nil {
+		return cmdTag, fmt.Errorf("exec InsertDeviceScan: %w", err)
+	}
+	return cmdTag, err
+}
+
 const findOneDeviceArraySQL = `SELECT enum_range(NULL::device_type) AS device_types;`
 
 // FindOneDeviceArray implements Querier.FindOneDeviceArray.
@@ -250,7 +479,26 @@ func (q *DBQuerier) FindOneDeviceArray(ctx context.Context) ([]DeviceType, error
 	item := []DeviceType{}
 	deviceTypesArray := q.types.newDeviceTypeArray()
 	if err := row.Scan(deviceTypesArray); err != nil {
-		return item, fmt.Errorf("query FindOneDeviceArray: %w", err)
+		return item, fmt.Errorf("query FindOneDeviceArray: %w", wrapNoRows(err))
+	}
+	if err := deviceTypesArray.AssignTo(&item); err != nil {
+		return item, fmt.Errorf("assign FindOneDeviceArray row: %w", err)
+	}
+	return item, nil
+}
+
+// QueueFindOneDeviceArray implements Querier.QueueFindOneDeviceArray.
+func (q *DBQuerier) QueueFindOneDeviceArray(batch genericBatch) {
+	batch.Queue(findOneDeviceArraySQL)
+}
+
+// FindOneDeviceArrayScan implements Querier.FindOneDeviceArrayScan.
+func (q *DBQuerier) FindOneDeviceArrayScan(results pgx.BatchResults) ([]DeviceType, error) {
+	row := results.QueryRow()
+	item := []DeviceType{}
+	deviceTypesArray := q.types.newDeviceTypeArray()
+	if err := row.Scan(deviceTypesArray); err != nil {
+		return item, fmt.Errorf("scan FindOneDeviceArrayScan row: %w", wrapNoRows(err))
 	}
 	if err := deviceTypesArray.AssignTo(&item); err != nil {
 		return item, fmt.Errorf("assign FindOneDeviceArray row: %w", err)
@@ -288,12 +536,70 @@ func (q *DBQuerier) FindManyDeviceArray(ctx context.Context) ([][]DeviceType, er
 	return items, err
 }
 
+// FindManyDeviceArrayEach implements Querier.FindManyDeviceArrayEach.
+func (q *DBQuerier) FindManyDeviceArrayEach(ctx context.Context, fn func(row []DeviceType) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindManyDeviceArray")
+	rows, err := q.conn.Query(ctx, findManyDeviceArraySQL)
+	if err != nil {
+		return fmt.Errorf("query FindManyDeviceArrayEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	deviceTypesArray := q.types.newDeviceTypeArray()
+	for rows.Next() {
+		var item []DeviceType
+		if err := rows.Scan(deviceTypesArray); err != nil {
+			return fmt.Errorf("scan FindManyDeviceArrayEach row: %w", err)
+		}
+		if err := deviceTypesArray.AssignTo(&item); err != nil {
+			return fmt.Errorf("assign FindManyDeviceArray row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindManyDeviceArrayEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindManyDeviceArray implements Querier.QueueFindManyDeviceArray.
+func (q *DBQuerier) QueueFindManyDeviceArray(batch genericBatch) {
+	batch.Queue(findManyDeviceArraySQL)
+}
+
+// FindManyDeviceArrayScan implements Querier.FindManyDeviceArrayScan.
+func (q *DBQuerier) FindManyDeviceArrayScan(results pgx.BatchResults) ([][]DeviceType, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindManyDeviceArrayScan: %w", err)
+	}
+	defer rows.Close()
+	items := [][]DeviceType{}
+	deviceTypesArray := q.types.newDeviceTypeArray()
+	for rows.Next() {
+		var item []DeviceType
+		if err := rows.Scan(deviceTypesArray); err != nil {
+			return nil, fmt.Errorf("scan FindManyDeviceArrayScan row: %w", err)
+		}
+		if err := deviceTypesArray.AssignTo(&item); err != nil {
+			return nil, fmt.Errorf("assign FindManyDeviceArray row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindManyDeviceArrayScan rows: %w", err)
+	}
+	return items, err
+}
+
 const findManyDeviceArrayWithNumSQL = `SELECT 1 AS num, enum_range('ipad'::device_type, 'iot'::device_type) AS device_types
 UNION ALL
 SELECT 2 as num, enum_range(NULL::device_type) AS device_types;`
 
 type FindManyDeviceArrayWithNumRow struct {
-	Num         *int32       `json:"num"`
+	Num         int32        `json:"num"`
 	DeviceTypes []DeviceType `json:"device_types"`
 }
 
@@ -323,6 +629,64 @@ func (q *DBQuerier) FindManyDeviceArrayWithNum(ctx context.Context) ([]FindManyD
 	return items, err
 }
 
+// FindManyDeviceArrayWithNumEach implements Querier.FindManyDeviceArrayWithNumEach.
+func (q *DBQuerier) FindManyDeviceArrayWithNumEach(ctx context.Context, fn func(row FindManyDeviceArrayWithNumRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindManyDeviceArrayWithNum")
+	rows, err := q.conn.Query(ctx, findManyDeviceArrayWithNumSQL)
+	if err != nil {
+		return fmt.Errorf("query FindManyDeviceArrayWithNumEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	deviceTypesArray := q.types.newDeviceTypeArray()
+	for rows.Next() {
+		var item FindManyDeviceArrayWithNumRow
+		if err := rows.Scan(&item.Num, deviceTypesArray); err != nil {
+			return fmt.Errorf("scan FindManyDeviceArrayWithNumEach row: %w", err)
+		}
+		if err := deviceTypesArray.AssignTo(&item.DeviceTypes); err != nil {
+			return fmt.Errorf("assign FindManyDeviceArrayWithNum row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindManyDeviceArrayWithNumEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindManyDeviceArrayWithNum implements Querier.QueueFindManyDeviceArrayWithNum.
+func (q *DBQuerier) QueueFindManyDeviceArrayWithNum(batch genericBatch) {
+	batch.Queue(findManyDeviceArrayWithNumSQL)
+}
+
+// FindManyDeviceArrayWithNumScan implements Querier.FindManyDeviceArrayWithNumScan.
+func (q *DBQuerier) FindManyDeviceArrayWithNumScan(results pgx.BatchResults) ([]FindManyDeviceArrayWithNumRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindManyDeviceArrayWithNumScan: %w", err)
+	}
+	defer rows.Close()
+	items := []FindManyDeviceArrayWithNumRow{}
+	deviceTypesArray := q.types.newDeviceTypeArray()
+	for rows.Next() {
+		var item FindManyDeviceArrayWithNumRow
+		if err := rows.Scan(&item.Num, deviceTypesArray); err != nil {
+			return nil, fmt.Errorf("scan FindManyDeviceArrayWithNumScan row: %w", err)
+		}
+		if err := deviceTypesArray.AssignTo(&item.DeviceTypes); err != nil {
+			return nil, fmt.Errorf("assign FindManyDeviceArrayWithNum row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindManyDeviceArrayWithNumScan rows: %w", err)
+	}
+	return items, err
+}
+
 const enumInsideCompositeSQL = `SELECT ROW('08:00:2b:01:02:03'::macaddr, 'phone'::device_type) ::device;`
 
 // EnumInsideComposite implements Querier.EnumInsideComposite.
@@ -332,7 +696,26 @@ func (q *DBQuerier) EnumInsideComposite(ctx context.Context) (Device, error) {
 	var item Device
 	rowRow := q.types.newDevice()
 	if err := row.Scan(rowRow); err != nil {
-		return item, fmt.Errorf("query EnumInsideComposite: %w", err)
+		return item, fmt.Errorf("query EnumInsideComposite: %w", wrapNoRows(err))
+	}
+	if err := rowRow.AssignTo(&item); err != nil {
+		return item, fmt.Errorf("assign EnumInsideComposite row: %w", err)
+	}
+	return item, nil
+}
+
+// QueueEnumInsideComposite implements Querier.QueueEnumInsideComposite.
+func (q *DBQuerier) QueueEnumInsideComposite(batch genericBatch) {
+	batch.Queue(enumInsideCompositeSQL)
+}
+
+// EnumInsideCompositeScan implements Querier.EnumInsideCompositeScan.
+func (q *DBQuerier) EnumInsideCompositeScan(results pgx.BatchResults) (Device, error) {
+	row := results.QueryRow()
+	var item Device
+	rowRow := q.types.newDevice()
+	if err := row.Scan(rowRow); err != nil {
+		return item, fmt.Errorf("scan EnumInsideCompositeScan row: %w", wrapNoRows(err))
 	}
 	if err := rowRow.AssignTo(&item); err != nil {
 		return item, fmt.Errorf("assign EnumInsideComposite row: %w", err)
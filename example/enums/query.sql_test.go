@@ -80,14 +80,12 @@ func TestNewQuerier_FindManyDeviceArrayWithNum(t *testing.T) {
 	defer cleanup()
 
 	q := NewQuerier(conn)
-	one, two := int32(1), int32(2)
-
 	t.Run("FindManyDeviceArrayWithNum", func(t *testing.T) {
 		devices, err := q.FindManyDeviceArrayWithNum(ctx)
 		require.NoError(t, err)
 		assert.Equal(t, []FindManyDeviceArrayWithNumRow{
-			{Num: &one, DeviceTypes: allDeviceTypes[3:]},
-			{Num: &two, DeviceTypes: allDeviceTypes},
+			{Num: 1, DeviceTypes: allDeviceTypes[3:]},
+			{Num: 2, DeviceTypes: allDeviceTypes},
 		}, devices)
 	})
 }
@@ -4,6 +4,7 @@ package order
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,20 +12,75 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	CreateTenant(ctx context.Context, key string, name string) (CreateTenantRow, error)
+	// QueueCreateTenant enqueues a CreateTenant query into batch to be executed
+	// later by the batch.
+	QueueCreateTenant(batch genericBatch, key string, name string)
+	// CreateTenantScan scans the result of an executed QueueCreateTenant query.
+	CreateTenantScan(results pgx.BatchResults) (CreateTenantRow, error)
 
 	FindOrdersByCustomer(ctx context.Context, customerID int32) ([]FindOrdersByCustomerRow, error)
+	// FindOrdersByCustomerEach runs FindOrdersByCustomer and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindOrdersByCustomerEach(ctx context.Context, customerID int32, fn func(row FindOrdersByCustomerRow) error) error
+	// QueueFindOrdersByCustomer enqueues a FindOrdersByCustomer query into batch to be executed
+	// later by the batch.
+	QueueFindOrdersByCustomer(batch genericBatch, customerID int32)
+	// FindOrdersByCustomerScan scans the result of an executed QueueFindOrdersByCustomer query.
+	FindOrdersByCustomerScan(results pgx.BatchResults) ([]FindOrdersByCustomerRow, error)
 
 	FindProductsInOrder(ctx context.Context, orderID int32) ([]FindProductsInOrderRow, error)
+	// FindProductsInOrderEach runs FindProductsInOrder and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindProductsInOrderEach(ctx context.Context, orderID int32, fn func(row FindProductsInOrderRow) error) error
+	// QueueFindProductsInOrder enqueues a FindProductsInOrder query into batch to be executed
+	// later by the batch.
+	QueueFindProductsInOrder(batch genericBatch, orderID int32)
+	// FindProductsInOrderScan scans the result of an executed QueueFindProductsInOrder query.
+	FindProductsInOrderScan(results pgx.BatchResults) ([]FindProductsInOrderRow, error)
 
 	InsertCustomer(ctx context.Context, params InsertCustomerParams) (InsertCustomerRow, error)
+	// QueueInsertCustomer enqueues a InsertCustomer query into batch to be executed
+	// later by the batch.
+	QueueInsertCustomer(batch genericBatch, params InsertCustomerParams)
+	// InsertCustomerScan scans the result of an executed QueueInsertCustomer query.
+	InsertCustomerScan(results pgx.BatchResults) (InsertCustomerRow, error)
 
 	InsertOrder(ctx context.Context, params InsertOrderParams) (InsertOrderRow, error)
+	// QueueInsertOrder enqueues a InsertOrder query into batch to be executed
+	// later by the batch.
+	QueueInsertOrder(batch genericBatch, params InsertOrderParams)
+	// InsertOrderScan scans the result of an executed QueueInsertOrder query.
+	InsertOrderScan(results pgx.BatchResults) (InsertOrderRow, error)
 
 	FindOrdersByPrice(ctx context.Context, minTotal pgtype.Numeric) ([]FindOrdersByPriceRow, error)
+	// FindOrdersByPriceEach runs FindOrdersByPrice and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindOrdersByPriceEach(ctx context.Context, minTotal pgtype.Numeric, fn func(row FindOrdersByPriceRow) error) error
+	// QueueFindOrdersByPrice enqueues a FindOrdersByPrice query into batch to be executed
+	// later by the batch.
+	QueueFindOrdersByPrice(batch genericBatch, minTotal pgtype.Numeric)
+	// FindOrdersByPriceScan scans the result of an executed QueueFindOrdersByPrice query.
+	FindOrdersByPriceScan(results pgx.BatchResults) ([]FindOrdersByPriceRow, error)
 
 	FindOrdersMRR(ctx context.Context) ([]FindOrdersMRRRow, error)
+	// FindOrdersMRREach runs FindOrdersMRR and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindOrdersMRREach(ctx context.Context, fn func(row FindOrdersMRRRow) error) error
+	// QueueFindOrdersMRR enqueues a FindOrdersMRR query into batch to be executed
+	// later by the batch.
+	QueueFindOrdersMRR(batch genericBatch)
+	// FindOrdersMRRScan scans the result of an executed QueueFindOrdersMRR query.
+	FindOrdersMRRScan(results pgx.BatchResults) ([]FindOrdersMRRRow, error)
 }
 
 type DBQuerier struct {
@@ -53,6 +109,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -60,8 +124,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // typeResolver looks up the pgtype.ValueTranscoder by Postgres type name.
@@ -109,7 +283,22 @@ func (q *DBQuerier) CreateTenant(ctx context.Context, key string, name string) (
 	row := q.conn.QueryRow(ctx, createTenantSQL, key, name)
 	var item CreateTenantRow
 	if err := row.Scan(&item.TenantID, &item.Rname, &item.Name); err != nil {
-		return item, fmt.Errorf("query CreateTenant: %w", err)
+		return item, fmt.Errorf("query CreateTenant: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueCreateTenant implements Querier.QueueCreateTenant.
+func (q *DBQuerier) QueueCreateTenant(batch genericBatch, key string, name string) {
+	batch.Queue(createTenantSQL, key, name)
+}
+
+// CreateTenantScan implements Querier.CreateTenantScan.
+func (q *DBQuerier) CreateTenantScan(results pgx.BatchResults) (CreateTenantRow, error) {
+	row := results.QueryRow()
+	var item CreateTenantRow
+	if err := row.Scan(&item.TenantID, &item.Rname, &item.Name); err != nil {
+		return item, fmt.Errorf("scan CreateTenantScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -147,6 +336,56 @@ func (q *DBQuerier) FindOrdersByCustomer(ctx context.Context, customerID int32)
 	return items, err
 }
 
+// FindOrdersByCustomerEach implements Querier.FindOrdersByCustomerEach.
+func (q *DBQuerier) FindOrdersByCustomerEach(ctx context.Context, customerID int32, fn func(row FindOrdersByCustomerRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindOrdersByCustomer")
+	rows, err := q.conn.Query(ctx, findOrdersByCustomerSQL, customerID)
+	if err != nil {
+		return fmt.Errorf("query FindOrdersByCustomerEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item FindOrdersByCustomerRow
+		if err := rows.Scan(&item.OrderID, &item.OrderDate, &item.OrderTotal, &item.CustomerID); err != nil {
+			return fmt.Errorf("scan FindOrdersByCustomerEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindOrdersByCustomerEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindOrdersByCustomer implements Querier.QueueFindOrdersByCustomer.
+func (q *DBQuerier) QueueFindOrdersByCustomer(batch genericBatch, customerID int32) {
+	batch.Queue(findOrdersByCustomerSQL, customerID)
+}
+
+// FindOrdersByCustomerScan implements Querier.FindOrdersByCustomerScan.
+func (q *DBQuerier) FindOrdersByCustomerScan(results pgx.BatchResults) ([]FindOrdersByCustomerRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindOrdersByCustomerScan: %w", err)
+	}
+	defer rows.Close()
+	items := []FindOrdersByCustomerRow{}
+	for rows.Next() {
+		var item FindOrdersByCustomerRow
+		if err := rows.Scan(&item.OrderID, &item.OrderDate, &item.OrderTotal, &item.CustomerID); err != nil {
+			return nil, fmt.Errorf("scan FindOrdersByCustomerScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindOrdersByCustomerScan rows: %w", err)
+	}
+	return items, err
+}
+
 const findProductsInOrderSQL = `SELECT o.order_id, p.product_id, p.name
 FROM orders o
   INNER JOIN order_product op USING (order_id)
@@ -154,9 +393,9 @@ FROM orders o
 WHERE o.order_id = $1;`
 
 type FindProductsInOrderRow struct {
-	OrderID   *int32  `json:"order_id"`
-	ProductID *int32  `json:"product_id"`
-	Name      *string `json:"name"`
+	OrderID   int32  `json:"order_id"`
+	ProductID int32  `json:"product_id"`
+	Name      string `json:"name"`
 }
 
 // FindProductsInOrder implements Querier.FindProductsInOrder.
@@ -181,6 +420,56 @@ func (q *DBQuerier) FindProductsInOrder(ctx context.Context, orderID int32) ([]F
 	return items, err
 }
 
+// FindProductsInOrderEach implements Querier.FindProductsInOrderEach.
+func (q *DBQuerier) FindProductsInOrderEach(ctx context.Context, orderID int32, fn func(row FindProductsInOrderRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindProductsInOrder")
+	rows, err := q.conn.Query(ctx, findProductsInOrderSQL, orderID)
+	if err != nil {
+		return fmt.Errorf("query FindProductsInOrderEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item FindProductsInOrderRow
+		if err := rows.Scan(&item.OrderID, &item.ProductID, &item.Name); err != nil {
+			return fmt.Errorf("scan FindProductsInOrderEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindProductsInOrderEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindProductsInOrder implements Querier.QueueFindProductsInOrder.
+func (q *DBQuerier) QueueFindProductsInOrder(batch genericBatch, orderID int32) {
+	batch.Queue(findProductsInOrderSQL, orderID)
+}
+
+// FindProductsInOrderScan implements Querier.FindProductsInOrderScan.
+func (q *DBQuerier) FindProductsInOrderScan(results pgx.BatchResults) ([]FindProductsInOrderRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindProductsInOrderScan: %w", err)
+	}
+	defer rows.Close()
+	items := []FindProductsInOrderRow{}
+	for rows.Next() {
+		var item FindProductsInOrderRow
+		if err := rows.Scan(&item.OrderID, &item.ProductID, &item.Name); err != nil {
+			return nil, fmt.Errorf("scan FindProductsInOrderScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindProductsInOrderScan rows: %w", err)
+	}
+	return items, err
+}
+
 const insertCustomerSQL = `INSERT INTO customer (first_name, last_name, email)
 VALUES ($1, $2, $3)
 RETURNING *;`
@@ -204,7 +493,22 @@ func (q *DBQuerier) InsertCustomer(ctx context.Context, params InsertCustomerPar
 	row := q.conn.QueryRow(ctx, insertCustomerSQL, params.FirstName, params.LastName, params.Email)
 	var item InsertCustomerRow
 	if err := row.Scan(&item.CustomerID, &item.FirstName, &item.LastName, &item.Email); err != nil {
-		return item, fmt.Errorf("query InsertCustomer: %w", err)
+		return item, fmt.Errorf("query InsertCustomer: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueInsertCustomer implements Querier.QueueInsertCustomer.
+func (q *DBQuerier) QueueInsertCustomer(batch genericBatch, params InsertCustomerParams) {
+	batch.Queue(insertCustomerSQL, params.FirstName, params.LastName, params.Email)
+}
+
+// InsertCustomerScan implements Querier.InsertCustomerScan.
+func (q *DBQuerier) InsertCustomerScan(results pgx.BatchResults) (InsertCustomerRow, error) {
+	row := results.QueryRow()
+	var item InsertCustomerRow
+	if err := row.Scan(&item.CustomerID, &item.FirstName, &item.LastName, &item.Email); err != nil {
+		return item, fmt.Errorf("scan InsertCustomerScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -232,7 +536,22 @@ func (q *DBQuerier) InsertOrder(ctx context.Context, params InsertOrderParams) (
 	row := q.conn.QueryRow(ctx, insertOrderSQL, params.OrderDate, params.OrderTotal, params.CustID)
 	var item InsertOrderRow
 	if err := row.Scan(&item.OrderID, &item.OrderDate, &item.OrderTotal, &item.CustomerID); err != nil {
-		return item, fmt.Errorf("query InsertOrder: %w", err)
+		return item, fmt.Errorf("query InsertOrder: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueInsertOrder implements Querier.QueueInsertOrder.
+func (q *DBQuerier) QueueInsertOrder(batch genericBatch, params InsertOrderParams) {
+	batch.Queue(insertOrderSQL, params.OrderDate, params.OrderTotal, params.CustID)
+}
+
+// InsertOrderScan implements Querier.InsertOrderScan.
+func (q *DBQuerier) InsertOrderScan(results pgx.BatchResults) (InsertOrderRow, error) {
+	row := results.QueryRow()
+	var item InsertOrderRow
+	if err := row.Scan(&item.OrderID, &item.OrderDate, &item.OrderTotal, &item.CustomerID); err != nil {
+		return item, fmt.Errorf("scan InsertOrderScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -6,6 +6,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
 )
 
 const findOrdersByPriceSQL = `SELECT * FROM orders WHERE order_total > $1;`
@@ -39,6 +40,56 @@ func (q *DBQuerier) FindOrdersByPrice(ctx context.Context, minTotal pgtype.Numer
 	return items, err
 }
 
+// FindOrdersByPriceEach implements Querier.FindOrdersByPriceEach.
+func (q *DBQuerier) FindOrdersByPriceEach(ctx context.Context, minTotal pgtype.Numeric, fn func(row FindOrdersByPriceRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindOrdersByPrice")
+	rows, err := q.conn.Query(ctx, findOrdersByPriceSQL, minTotal)
+	if err != nil {
+		return fmt.Errorf("query FindOrdersByPriceEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item FindOrdersByPriceRow
+		if err := rows.Scan(&item.OrderID, &item.OrderDate, &item.OrderTotal, &item.CustomerID); err != nil {
+			return fmt.Errorf("scan FindOrdersByPriceEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindOrdersByPriceEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindOrdersByPrice implements Querier.QueueFindOrdersByPrice.
+func (q *DBQuerier) QueueFindOrdersByPrice(batch genericBatch, minTotal pgtype.Numeric) {
+	batch.Queue(findOrdersByPriceSQL, minTotal)
+}
+
+// FindOrdersByPriceScan implements Querier.FindOrdersByPriceScan.
+func (q *DBQuerier) FindOrdersByPriceScan(results pgx.BatchResults) ([]FindOrdersByPriceRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindOrdersByPriceScan: %w", err)
+	}
+	defer rows.Close()
+	items := []FindOrdersByPriceRow{}
+	for rows.Next() {
+		var item FindOrdersByPriceRow
+		if err := rows.Scan(&item.OrderID, &item.OrderDate, &item.OrderTotal, &item.CustomerID); err != nil {
+			return nil, fmt.Errorf("scan FindOrdersByPriceScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindOrdersByPriceScan rows: %w", err)
+	}
+	return items, err
+}
+
 const findOrdersMRRSQL = `SELECT date_trunc('month', order_date) AS month, sum(order_total) AS order_mrr
 FROM orders
 GROUP BY date_trunc('month', order_date);`
@@ -69,3 +120,53 @@ func (q *DBQuerier) FindOrdersMRR(ctx context.Context) ([]FindOrdersMRRRow, erro
 	}
 	return items, err
 }
+
+// FindOrdersMRREach implements Querier.FindOrdersMRREach.
+func (q *DBQuerier) FindOrdersMRREach(ctx context.Context, fn func(row FindOrdersMRRRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindOrdersMRR")
+	rows, err := q.conn.Query(ctx, findOrdersMRRSQL)
+	if err != nil {
+		return fmt.Errorf("query FindOrdersMRREach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item FindOrdersMRRRow
+		if err := rows.Scan(&item.Month, &item.OrderMRR); err != nil {
+			return fmt.Errorf("scan FindOrdersMRREach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindOrdersMRREach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindOrdersMRR implements Querier.QueueFindOrdersMRR.
+func (q *DBQuerier) QueueFindOrdersMRR(batch genericBatch) {
+	batch.Queue(findOrdersMRRSQL)
+}
+
+// FindOrdersMRRScan implements Querier.FindOrdersMRRScan.
+func (q *DBQuerier) FindOrdersMRRScan(results pgx.BatchResults) ([]FindOrdersMRRRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindOrdersMRRScan: %w", err)
+	}
+	defer rows.Close()
+	items := []FindOrdersMRRRow{}
+	for rows.Next() {
+		var item FindOrdersMRRRow
+		if err := rows.Scan(&item.Month, &item.OrderMRR); err != nil {
+			return nil, fmt.Errorf("scan FindOrdersMRRScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindOrdersMRRScan rows: %w", err)
+	}
+	return items, err
+}
@@ -4,6 +4,7 @@ package function
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,8 +12,21 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	OutParams(ctx context.Context) ([]OutParamsRow, error)
+	// OutParamsEach runs OutParams and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	OutParamsEach(ctx context.Context, fn func(row OutParamsRow) error) error
+	// QueueOutParams enqueues a OutParams query into batch to be executed
+	// later by the batch.
+	QueueOutParams(batch genericBatch)
+	// OutParamsScan scans the result of an executed QueueOutParams query.
+	OutParamsScan(results pgx.BatchResults) ([]OutParamsRow, error)
 }
 
 type DBQuerier struct {
@@ -41,6 +55,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -48,8 +70,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // ListItem represents the Postgres composite type "list_item".
@@ -207,6 +339,72 @@ func (q *DBQuerier) OutParams(ctx context.Context) ([]OutParamsRow, error) {
 	return items, err
 }
 
+// OutParamsEach implements Querier.OutParamsEach.
+func (q *DBQuerier) OutParamsEach(ctx context.Context, fn func(row OutParamsRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "OutParams")
+	rows, err := q.conn.Query(ctx, outParamsSQL)
+	if err != nil {
+		return fmt.Errorf("query OutParamsEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	itemsArray := q.types.newListItemArray()
+	statsRow := q.types.newListStats()
+	for rows.Next() {
+		var item OutParamsRow
+		if err := rows.Scan(itemsArray, statsRow); err != nil {
+			return fmt.Errorf("scan OutParamsEach row: %w", err)
+		}
+		if err := itemsArray.AssignTo(&item.Items); err != nil {
+			return fmt.Errorf("assign OutParams row: %w", err)
+		}
+		if err := statsRow.AssignTo(&item.Stats); err != nil {
+			return fmt.Errorf("assign OutParams row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close OutParamsEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueOutParams implements Querier.QueueOutParams.
+func (q *DBQuerier) QueueOutParams(batch genericBatch) {
+	batch.Queue(outParamsSQL)
+}
+
+// OutParamsScan implements Querier.OutParamsScan.
+func (q *DBQuerier) OutParamsScan(results pgx.BatchResults) ([]OutParamsRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query OutParamsScan: %w", err)
+	}
+	defer rows.Close()
+	items := []OutParamsRow{}
+	itemsArray := q.types.newListItemArray()
+	statsRow := q.types.newListStats()
+	for rows.Next() {
+		var item OutParamsRow
+		if err := rows.Scan(itemsArray, statsRow); err != nil {
+			return nil, fmt.Errorf("scan OutParamsScan row: %w", err)
+		}
+		if err := itemsArray.AssignTo(&item.Items); err != nil {
+			return nil, fmt.Errorf("assign OutParams row: %w", err)
+		}
+		if err := statsRow.AssignTo(&item.Stats); err != nil {
+			return nil, fmt.Errorf("assign OutParams row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close OutParamsScan rows: %w", err)
+	}
+	return items, err
+}
+
 // textPreferrer wraps a pgtype.ValueTranscoder and sets the preferred encoding
 // format to text instead binary (the default). pggen uses the text format
 // when the OID is unknownOID because the binary format requires the OID.
@@ -4,6 +4,7 @@ package go_pointer_types
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,18 +12,64 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	GenSeries1(ctx context.Context) (*int, error)
+	// QueueGenSeries1 enqueues a GenSeries1 query into batch to be executed
+	// later by the batch.
+	QueueGenSeries1(batch genericBatch)
+	// GenSeries1Scan scans the result of an executed QueueGenSeries1 query.
+	GenSeries1Scan(results pgx.BatchResults) (*int, error)
 
 	GenSeries(ctx context.Context) ([]*int, error)
+	// GenSeriesEach runs GenSeries and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	GenSeriesEach(ctx context.Context, fn func(row *int) error) error
+	// QueueGenSeries enqueues a GenSeries query into batch to be executed
+	// later by the batch.
+	QueueGenSeries(batch genericBatch)
+	// GenSeriesScan scans the result of an executed QueueGenSeries query.
+	GenSeriesScan(results pgx.BatchResults) ([]*int, error)
 
 	GenSeriesArr1(ctx context.Context) ([]int, error)
+	// QueueGenSeriesArr1 enqueues a GenSeriesArr1 query into batch to be executed
+	// later by the batch.
+	QueueGenSeriesArr1(batch genericBatch)
+	// GenSeriesArr1Scan scans the result of an executed QueueGenSeriesArr1 query.
+	GenSeriesArr1Scan(results pgx.BatchResults) ([]int, error)
 
 	GenSeriesArr(ctx context.Context) ([][]int, error)
+	// GenSeriesArrEach runs GenSeriesArr and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	GenSeriesArrEach(ctx context.Context, fn func(row []int) error) error
+	// QueueGenSeriesArr enqueues a GenSeriesArr query into batch to be executed
+	// later by the batch.
+	QueueGenSeriesArr(batch genericBatch)
+	// GenSeriesArrScan scans the result of an executed QueueGenSeriesArr query.
+	GenSeriesArrScan(results pgx.BatchResults) ([][]int, error)
 
 	GenSeriesStr1(ctx context.Context) (*string, error)
+	// QueueGenSeriesStr1 enqueues a GenSeriesStr1 query into batch to be executed
+	// later by the batch.
+	QueueGenSeriesStr1(batch genericBatch)
+	// GenSeriesStr1Scan scans the result of an executed QueueGenSeriesStr1 query.
+	GenSeriesStr1Scan(results pgx.BatchResults) (*string, error)
 
 	GenSeriesStr(ctx context.Context) ([]*string, error)
+	// GenSeriesStrEach runs GenSeriesStr and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	GenSeriesStrEach(ctx context.Context, fn func(row *string) error) error
+	// QueueGenSeriesStr enqueues a GenSeriesStr query into batch to be executed
+	// later by the batch.
+	QueueGenSeriesStr(batch genericBatch)
+	// GenSeriesStrScan scans the result of an executed QueueGenSeriesStr query.
+	GenSeriesStrScan(results pgx.BatchResults) ([]*string, error)
 }
 
 type DBQuerier struct {
@@ -51,6 +98,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -58,8 +113,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // typeResolver looks up the pgtype.ValueTranscoder by Postgres type name.
@@ -101,7 +266,22 @@ func (q *DBQuerier) GenSeries1(ctx context.Context) (*int, error) {
 	row := q.conn.QueryRow(ctx, genSeries1SQL)
 	var item *int
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query GenSeries1: %w", err)
+		return item, fmt.Errorf("query GenSeries1: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueGenSeries1 implements Querier.QueueGenSeries1.
+func (q *DBQuerier) QueueGenSeries1(batch genericBatch) {
+	batch.Queue(genSeries1SQL)
+}
+
+// GenSeries1Scan implements Querier.GenSeries1Scan.
+func (q *DBQuerier) GenSeries1Scan(results pgx.BatchResults) (*int, error) {
+	row := results.QueryRow()
+	var item *int
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan GenSeries1Scan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -131,6 +311,56 @@ func (q *DBQuerier) GenSeries(ctx context.Context) ([]*int, error) {
 	return items, err
 }
 
+// GenSeriesEach implements Querier.GenSeriesEach.
+func (q *DBQuerier) GenSeriesEach(ctx context.Context, fn func(row *int) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "GenSeries")
+	rows, err := q.conn.Query(ctx, genSeriesSQL)
+	if err != nil {
+		return fmt.Errorf("query GenSeriesEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item int
+		if err := rows.Scan(&item); err != nil {
+			return fmt.Errorf("scan GenSeriesEach row: %w", err)
+		}
+		if err := fn(&item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close GenSeriesEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueGenSeries implements Querier.QueueGenSeries.
+func (q *DBQuerier) QueueGenSeries(batch genericBatch) {
+	batch.Queue(genSeriesSQL)
+}
+
+// GenSeriesScan implements Querier.GenSeriesScan.
+func (q *DBQuerier) GenSeriesScan(results pgx.BatchResults) ([]*int, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query GenSeriesScan: %w", err)
+	}
+	defer rows.Close()
+	items := []*int{}
+	for rows.Next() {
+		var item int
+		if err := rows.Scan(&item); err != nil {
+			return nil, fmt.Errorf("scan GenSeriesScan row: %w", err)
+		}
+		items = append(items, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close GenSeriesScan rows: %w", err)
+	}
+	return items, err
+}
+
 const genSeriesArr1SQL = `SELECT array_agg(n)
 FROM generate_series(0, 2) n;`
 
@@ -140,7 +370,22 @@ func (q *DBQuerier) GenSeriesArr1(ctx context.Context) ([]int, error) {
 	row := q.conn.QueryRow(ctx, genSeriesArr1SQL)
 	item := []int{}
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query GenSeriesArr1: %w", err)
+		return item, fmt.Errorf("query GenSeriesArr1: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueGenSeriesArr1 implements Querier.QueueGenSeriesArr1.
+func (q *DBQuerier) QueueGenSeriesArr1(batch genericBatch) {
+	batch.Queue(genSeriesArr1SQL)
+}
+
+// GenSeriesArr1Scan implements Querier.GenSeriesArr1Scan.
+func (q *DBQuerier) GenSeriesArr1Scan(results pgx.BatchResults) ([]int, error) {
+	row := results.QueryRow()
+	item := []int{}
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan GenSeriesArr1Scan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -170,6 +415,56 @@ func (q *DBQuerier) GenSeriesArr(ctx context.Context) ([][]int, error) {
 	return items, err
 }
 
+// GenSeriesArrEach implements Querier.GenSeriesArrEach.
+func (q *DBQuerier) GenSeriesArrEach(ctx context.Context, fn func(row []int) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "GenSeriesArr")
+	rows, err := q.conn.Query(ctx, genSeriesArrSQL)
+	if err != nil {
+		return fmt.Errorf("query GenSeriesArrEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item []int
+		if err := rows.Scan(&item); err != nil {
+			return fmt.Errorf("scan GenSeriesArrEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close GenSeriesArrEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueGenSeriesArr implements Querier.QueueGenSeriesArr.
+func (q *DBQuerier) QueueGenSeriesArr(batch genericBatch) {
+	batch.Queue(genSeriesArrSQL)
+}
+
+// GenSeriesArrScan implements Querier.GenSeriesArrScan.
+func (q *DBQuerier) GenSeriesArrScan(results pgx.BatchResults) ([][]int, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query GenSeriesArrScan: %w", err)
+	}
+	defer rows.Close()
+	items := [][]int{}
+	for rows.Next() {
+		var item []int
+		if err := rows.Scan(&item); err != nil {
+			return nil, fmt.Errorf("scan GenSeriesArrScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close GenSeriesArrScan rows: %w", err)
+	}
+	return items, err
+}
+
 const genSeriesStr1SQL = `SELECT n::text
 FROM generate_series(0, 2) n
 LIMIT 1;`
@@ -180,7 +475,22 @@ func (q *DBQuerier) GenSeriesStr1(ctx context.Context) (*string, error) {
 	row := q.conn.QueryRow(ctx, genSeriesStr1SQL)
 	var item *string
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query GenSeriesStr1: %w", err)
+		return item, fmt.Errorf("query GenSeriesStr1: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueGenSeriesStr1 implements Querier.QueueGenSeriesStr1.
+func (q *DBQuerier) QueueGenSeriesStr1(batch genericBatch) {
+	batch.Queue(genSeriesStr1SQL)
+}
+
+// GenSeriesStr1Scan implements Querier.GenSeriesStr1Scan.
+func (q *DBQuerier) GenSeriesStr1Scan(results pgx.BatchResults) (*string, error) {
+	row := results.QueryRow()
+	var item *string
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan GenSeriesStr1Scan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -210,6 +520,56 @@ func (q *DBQuerier) GenSeriesStr(ctx context.Context) ([]*string, error) {
 	return items, err
 }
 
+// GenSeriesStrEach implements Querier.GenSeriesStrEach.
+func (q *DBQuerier) GenSeriesStrEach(ctx context.Context, fn func(row *string) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "GenSeriesStr")
+	rows, err := q.conn.Query(ctx, genSeriesStrSQL)
+	if err != nil {
+		return fmt.Errorf("query GenSeriesStrEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item string
+		if err := rows.Scan(&item); err != nil {
+			return fmt.Errorf("scan GenSeriesStrEach row: %w", err)
+		}
+		if err := fn(&item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close GenSeriesStrEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueGenSeriesStr implements Querier.QueueGenSeriesStr.
+func (q *DBQuerier) QueueGenSeriesStr(batch genericBatch) {
+	batch.Queue(genSeriesStrSQL)
+}
+
+// GenSeriesStrScan implements Querier.GenSeriesStrScan.
+func (q *DBQuerier) GenSeriesStrScan(results pgx.BatchResults) ([]*string, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query GenSeriesStrScan: %w", err)
+	}
+	defer rows.Close()
+	items := []*string{}
+	for rows.Next() {
+		var item string
+		if err := rows.Scan(&item); err != nil {
+			return nil, fmt.Errorf("scan GenSeriesStrScan row: %w", err)
+		}
+		items = append(items, &item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close GenSeriesStrScan rows: %w", err)
+	}
+	return items, err
+}
+
 // textPreferrer wraps a pgtype.ValueTranscoder and sets the preferred encoding
 // format to text instead binary (the default). pggen uses the text format
 // when the OID is unknownOID because the binary format requires the OID.
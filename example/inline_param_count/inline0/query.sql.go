@@ -4,6 +4,7 @@ package inline0
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,18 +12,42 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	// CountAuthors returns the number of authors (zero params).
-	CountAuthors(ctx context.Context) (*int, error)
+	CountAuthors(ctx context.Context) (int, error)
+	// QueueCountAuthors enqueues a CountAuthors query into batch to be executed
+	// later by the batch.
+	QueueCountAuthors(batch genericBatch)
+	// CountAuthorsScan scans the result of an executed QueueCountAuthors query.
+	CountAuthorsScan(results pgx.BatchResults) (int, error)
 
 	// FindAuthorById finds one (or zero) authors by ID (one param).
 	FindAuthorByID(ctx context.Context, params FindAuthorByIDParams) (FindAuthorByIDRow, error)
+	// QueueFindAuthorByID enqueues a FindAuthorByID query into batch to be executed
+	// later by the batch.
+	QueueFindAuthorByID(batch genericBatch, params FindAuthorByIDParams)
+	// FindAuthorByIDScan scans the result of an executed QueueFindAuthorByID query.
+	FindAuthorByIDScan(results pgx.BatchResults) (FindAuthorByIDRow, error)
 
 	// InsertAuthor inserts an author by name and returns the ID (two params).
 	InsertAuthor(ctx context.Context, params InsertAuthorParams) (int32, error)
+	// QueueInsertAuthor enqueues a InsertAuthor query into batch to be executed
+	// later by the batch.
+	QueueInsertAuthor(batch genericBatch, params InsertAuthorParams)
+	// InsertAuthorScan scans the result of an executed QueueInsertAuthor query.
+	InsertAuthorScan(results pgx.BatchResults) (int32, error)
 
 	// DeleteAuthorsByFullName deletes authors by the full name (three params).
 	DeleteAuthorsByFullName(ctx context.Context, params DeleteAuthorsByFullNameParams) (pgconn.CommandTag, error)
+	// QueueDeleteAuthorsByFullName enqueues a DeleteAuthorsByFullName query into batch to be executed
+	// later by the batch.
+	QueueDeleteAuthorsByFullName(batch genericBatch, params DeleteAuthorsByFullNameParams)
+	// DeleteAuthorsByFullNameScan scans the result of an executed QueueDeleteAuthorsByFullName query.
+	DeleteAuthorsByFullNameScan(results pgx.BatchResults) (pgconn.CommandTag, error)
 }
 
 type DBQuerier struct {
@@ -51,6 +76,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -58,8 +91,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // typeResolver looks up the pgtype.ValueTranscoder by Postgres type name.
@@ -94,12 +237,27 @@ func (tr *typeResolver) setValue(vt pgtype.ValueTranscoder, val interface{}) pgt
 const countAuthorsSQL = `SELECT count(*) FROM author;`
 
 // CountAuthors implements Querier.CountAuthors.
-func (q *DBQuerier) CountAuthors(ctx context.Context) (*int, error) {
+func (q *DBQuerier) CountAuthors(ctx context.Context) (int, error) {
 	ctx = context.WithValue(ctx, "pggen_query_name", "CountAuthors")
 	row := q.conn.QueryRow(ctx, countAuthorsSQL)
-	var item *int
+	var item int
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("query CountAuthors: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueCountAuthors implements Querier.QueueCountAuthors.
+func (q *DBQuerier) QueueCountAuthors(batch genericBatch) {
+	batch.Queue(countAuthorsSQL)
+}
+
+// CountAuthorsScan implements Querier.CountAuthorsScan.
+func (q *DBQuerier) CountAuthorsScan(results pgx.BatchResults) (int, error) {
+	row := results.QueryRow()
+	var item int
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query CountAuthors: %w", err)
+		return item, fmt.Errorf("scan CountAuthorsScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -123,7 +281,22 @@ func (q *DBQuerier) FindAuthorByID(ctx context.Context, params FindAuthorByIDPar
 	row := q.conn.QueryRow(ctx, findAuthorByIDSQL, params.AuthorID)
 	var item FindAuthorByIDRow
 	if err := row.Scan(&item.AuthorID, &item.FirstName, &item.LastName, &item.Suffix); err != nil {
-		return item, fmt.Errorf("query FindAuthorByID: %w", err)
+		return item, fmt.Errorf("query FindAuthorByID: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueFindAuthorByID implements Querier.QueueFindAuthorByID.
+func (q *DBQuerier) QueueFindAuthorByID(batch genericBatch, params FindAuthorByIDParams) {
+	batch.Queue(findAuthorByIDSQL, params.AuthorID)
+}
+
+// FindAuthorByIDScan implements Querier.FindAuthorByIDScan.
+func (q *DBQuerier) FindAuthorByIDScan(results pgx.BatchResults) (FindAuthorByIDRow, error) {
+	row := results.QueryRow()
+	var item FindAuthorByIDRow
+	if err := row.Scan(&item.AuthorID, &item.FirstName, &item.LastName, &item.Suffix); err != nil {
+		return item, fmt.Errorf("scan FindAuthorByIDScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -143,7 +316,22 @@ func (q *DBQuerier) InsertAuthor(ctx context.Context, params InsertAuthorParams)
 	row := q.conn.QueryRow(ctx, insertAuthorSQL, params.FirstName, params.LastName)
 	var item int32
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query InsertAuthor: %w", err)
+		return item, fmt.Errorf("query InsertAuthor: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueInsertAuthor implements Querier.QueueInsertAuthor.
+func (q *DBQuerier) QueueInsertAuthor(batch genericBatch, params InsertAuthorParams) {
+	batch.Queue(insertAuthorSQL, params.FirstName, params.LastName)
+}
+
+// InsertAuthorScan implements Querier.InsertAuthorScan.
+func (q *DBQuerier) InsertAuthorScan(results pgx.BatchResults) (int32, error) {
+	row := results.QueryRow()
+	var item int32
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan InsertAuthorScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -170,6 +358,20 @@ func (q *DBQuerier) DeleteAuthorsByFullName(ctx context.Context, params DeleteAu
 	return cmdTag, err
 }
 
+// QueueDeleteAuthorsByFullName implements Querier.QueueDeleteAuthorsByFullName.
+func (q *DBQuerier) QueueDeleteAuthorsByFullName(batch genericBatch, params DeleteAuthorsByFullNameParams) {
+	batch.Queue(deleteAuthorsByFullNameSQL, params.FirstName, params.LastName, params.Suffix)
+}
+
+// DeleteAuthorsByFullNameScan implements Querier.DeleteAuthorsByFullNameScan.
+func (q *DBQuerier) DeleteAuthorsByFullNameScan(results pgx.BatchResults) (pgconn.CommandTag, error) {
+	cmdTag, err := results.Exec()
+	if err != nil {
+		return cmdTag, fmt.Errorf("exec DeleteAuthorsByFullNameScan: %w", err)
+	}
+	return cmdTag, err
+}
+
 // textPreferrer wraps a pgtype.ValueTranscoder and sets the preferred encoding
 // format to text instead binary (the default). pggen uses the text format
 // when the OID is unknownOID because the binary format requires the OID.
@@ -4,6 +4,7 @@ package ltree
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,14 +12,42 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	FindTopScienceChildren(ctx context.Context) ([]pgtype.Text, error)
+	// FindTopScienceChildrenEach runs FindTopScienceChildren and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindTopScienceChildrenEach(ctx context.Context, fn func(row pgtype.Text) error) error
+	// QueueFindTopScienceChildren enqueues a FindTopScienceChildren query into batch to be executed
+	// later by the batch.
+	QueueFindTopScienceChildren(batch genericBatch)
+	// FindTopScienceChildrenScan scans the result of an executed QueueFindTopScienceChildren query.
+	FindTopScienceChildrenScan(results pgx.BatchResults) ([]pgtype.Text, error)
 
 	FindTopScienceChildrenAgg(ctx context.Context) (pgtype.TextArray, error)
+	// QueueFindTopScienceChildrenAgg enqueues a FindTopScienceChildrenAgg query into batch to be executed
+	// later by the batch.
+	QueueFindTopScienceChildrenAgg(batch genericBatch)
+	// FindTopScienceChildrenAggScan scans the result of an executed QueueFindTopScienceChildrenAgg query.
+	FindTopScienceChildrenAggScan(results pgx.BatchResults) (pgtype.TextArray, error)
 
 	InsertSampleData(ctx context.Context) (pgconn.CommandTag, error)
+	// QueueInsertSampleData enqueues a InsertSampleData query into batch to be executed
+	// later by the batch.
+	QueueInsertSampleData(batch genericBatch)
+	// InsertSampleDataScan scans the result of an executed QueueInsertSampleData query.
+	InsertSampleDataScan(results pgx.BatchResults) (pgconn.CommandTag, error)
 
 	FindLtreeInput(ctx context.Context, inLtree pgtype.Text, inLtreeArray []string) (FindLtreeInputRow, error)
+	// QueueFindLtreeInput enqueues a FindLtreeInput query into batch to be executed
+	// later by the batch.
+	QueueFindLtreeInput(batch genericBatch, inLtree pgtype.Text, inLtreeArray []string)
+	// FindLtreeInputScan scans the result of an executed QueueFindLtreeInput query.
+	FindLtreeInputScan(results pgx.BatchResults) (FindLtreeInputRow, error)
 }
 
 type DBQuerier struct {
@@ -47,6 +76,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -54,8 +91,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // typeResolver looks up the pgtype.ValueTranscoder by Postgres type name.
@@ -113,6 +260,56 @@ func (q *DBQuerier) FindTopScienceChildren(ctx context.Context) ([]pgtype.Text,
 	return items, err
 }
 
+// FindTopScienceChildrenEach implements Querier.FindTopScienceChildrenEach.
+func (q *DBQuerier) FindTopScienceChildrenEach(ctx context.Context, fn func(row pgtype.Text) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindTopScienceChildren")
+	rows, err := q.conn.Query(ctx, findTopScienceChildrenSQL)
+	if err != nil {
+		return fmt.Errorf("query FindTopScienceChildrenEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item pgtype.Text
+		if err := rows.Scan(&item); err != nil {
+			return fmt.Errorf("scan FindTopScienceChildrenEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindTopScienceChildrenEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindTopScienceChildren implements Querier.QueueFindTopScienceChildren.
+func (q *DBQuerier) QueueFindTopScienceChildren(batch genericBatch) {
+	batch.Queue(findTopScienceChildrenSQL)
+}
+
+// FindTopScienceChildrenScan implements Querier.FindTopScienceChildrenScan.
+func (q *DBQuerier) FindTopScienceChildrenScan(results pgx.BatchResults) ([]pgtype.Text, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindTopScienceChildrenScan: %w", err)
+	}
+	defer rows.Close()
+	items := []pgtype.Text{}
+	for rows.Next() {
+		var item pgtype.Text
+		if err := rows.Scan(&item); err != nil {
+			return nil, fmt.Errorf("scan FindTopScienceChildrenScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindTopScienceChildrenScan rows: %w", err)
+	}
+	return items, err
+}
+
 const findTopScienceChildrenAggSQL = `SELECT array_agg(path)
 FROM test
 WHERE path <@ 'Top.Science';`
@@ -123,7 +320,22 @@ func (q *DBQuerier) FindTopScienceChildrenAgg(ctx context.Context) (pgtype.TextA
 	row := q.conn.QueryRow(ctx, findTopScienceChildrenAggSQL)
 	var item pgtype.TextArray
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query FindTopScienceChildrenAgg: %w", err)
+		return item, fmt.Errorf("query FindTopScienceChildrenAgg: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueFindTopScienceChildrenAgg implements Querier.QueueFindTopScienceChildrenAgg.
+func (q *DBQuerier) QueueFindTopScienceChildrenAgg(batch genericBatch) {
+	batch.Queue(findTopScienceChildrenAggSQL)
+}
+
+// FindTopScienceChildrenAggScan implements Querier.FindTopScienceChildrenAggScan.
+func (q *DBQuerier) FindTopScienceChildrenAggScan(results pgx.BatchResults) (pgtype.TextArray, error) {
+	row := results.QueryRow()
+	var item pgtype.TextArray
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan FindTopScienceChildrenAggScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -153,6 +365,20 @@ func (q *DBQuerier) InsertSampleData(ctx context.Context) (pgconn.CommandTag, er
 	return cmdTag, err
 }
 
+// QueueInsertSampleData implements Querier.QueueInsertSampleData.
+func (q *DBQuerier) QueueInsertSampleData(batch genericBatch) {
+	batch.Queue(insertSampleDataSQL)
+}
+
+// InsertSampleDataScan implements Querier.InsertSampleDataScan.
+func (q *DBQuerier) InsertSampleDataScan(results pgx.BatchResults) (pgconn.CommandTag, error) {
+	cmdTag, err := results.Exec()
+	if err != nil {
+		return cmdTag, fmt.Errorf("exec InsertSampleDataScan: %w", err)
+	}
+	return cmdTag, err
+}
+
 const findLtreeInputSQL = `SELECT
   $1::ltree                   AS ltree,
   -- This won't work, but I'm not quite sure why.
@@ -175,7 +401,22 @@ func (q *DBQuerier) FindLtreeInput(ctx context.Context, inLtree pgtype.Text, inL
 	row := q.conn.QueryRow(ctx, findLtreeInputSQL, inLtree, inLtreeArray)
 	var item FindLtreeInputRow
 	if err := row.Scan(&item.Ltree, &item.TextArr); err != nil {
-		return item, fmt.Errorf("query FindLtreeInput: %w", err)
+		return item, fmt.Errorf("query FindLtreeInput: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueFindLtreeInput implements Querier.QueueFindLtreeInput.
+func (q *DBQuerier) QueueFindLtreeInput(batch genericBatch, inLtree pgtype.Text, inLtreeArray []string) {
+	batch.Queue(findLtreeInputSQL, inLtree, inLtreeArray)
+}
+
+// FindLtreeInputScan implements Querier.FindLtreeInputScan.
+func (q *DBQuerier) FindLtreeInputScan(results pgx.BatchResults) (FindLtreeInputRow, error) {
+	row := results.QueryRow()
+	var item FindLtreeInputRow
+	if err := row.Scan(&item.Ltree, &item.TextArr); err != nil {
+		return item, fmt.Errorf("scan FindLtreeInputScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
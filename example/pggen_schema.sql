@@ -6,3 +6,8 @@ CREATE SCHEMA pggen;
 -- placeholder for a prepared query: $1, $2, etc.
 CREATE FUNCTION pggen.arg(param text) RETURNS any AS
 '';
+
+-- pggen.narg defines a named parameter like pggen.arg that's nullable, so the
+-- Go parameter has a nullable type.
+CREATE FUNCTION pggen.narg(param text) RETURNS any AS
+'';
@@ -0,0 +1,46 @@
+package pgx5
+
+import (
+	"github.com/atomicleads/pggen"
+	"github.com/atomicleads/pggen/internal/pgtest"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerate_Go_Example_Pgx5(t *testing.T) {
+	conn, cleanupFunc := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanupFunc()
+
+	tmpDir := t.TempDir()
+	err := pggen.Generate(
+		pggen.GenerateOptions{
+			ConnString:       conn.Config().ConnString(),
+			QueryFiles:       []string{"query.sql"},
+			OutputDir:        tmpDir,
+			GoPackage:        "pgx5",
+			Language:         pggen.LangGo,
+			InlineParamCount: 2,
+			PgxVersion:       pggen.PgxV5,
+		})
+	if err != nil {
+		t.Fatalf("Generate() example/pgx5: %s", err)
+	}
+
+	wantQueryFile := "query.sql.go"
+	gotQueryFile := filepath.Join(tmpDir, "query.sql.go")
+	assert.FileExists(t, gotQueryFile,
+		"Generate() should emit query.sql.go")
+	wantQueries, err := os.ReadFile(wantQueryFile)
+	if err != nil {
+		t.Fatalf("read wanted query.go.sql: %s", err)
+	}
+	gotQueries, err := os.ReadFile(gotQueryFile)
+	if err != nil {
+		t.Fatalf("read generated query.go.sql: %s", err)
+	}
+	assert.Equalf(t, string(wantQueries), string(gotQueries),
+		"Got file %s; does not match contents of %s",
+		gotQueryFile, wantQueryFile)
+}
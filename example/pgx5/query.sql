@@ -0,0 +1,14 @@
+-- name: InsertDevice :one
+INSERT INTO device (type, dims)
+VALUES (pggen.arg('type'), pggen.arg('dims'))
+RETURNING device_id;
+
+-- name: FindDeviceByID :one
+SELECT device_id, type, dims FROM device WHERE device_id = pggen.arg('device_id');
+
+-- name: FindDevicesByType :many
+SELECT device_id, type, dims FROM device WHERE type = pggen.arg('type') ORDER BY device_id;
+
+-- Select an array of all device_type enum values.
+-- name: FindDeviceTypes :one
+SELECT enum_range(NULL::device_type) AS device_types;
@@ -0,0 +1,94 @@
+package pgx5
+
+import (
+	"context"
+	"github.com/atomicleads/pggen/internal/pgtest"
+	"github.com/atomicleads/pggen/internal/ptrs"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+// connectPgx5 opens a pgx v5 connection to the schema of the pgx v4 test
+// connection and registers the generated types.
+func connectPgx5(t *testing.T, connString string) *pgx.Conn {
+	t.Helper()
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, connString)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		if err := conn.Close(context.Background()); err != nil {
+			t.Errorf("close pgx v5 conn: %s", err)
+		}
+	})
+	require.NoError(t, RegisterTypes(ctx, conn))
+	return conn
+}
+
+func TestNewQuerier_RegisterTypes(t *testing.T) {
+	pgxConn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	conn := connectPgx5(t, pgxConn.Config().ConnString())
+	q := NewQuerier(conn)
+	ctx := context.Background()
+
+	phoneDims := Dimensions{Width: ptrs.Int32(6), Height: ptrs.Int32(12)}
+	phoneID, err := q.InsertDevice(ctx, DeviceTypePhone, phoneDims)
+	require.NoError(t, err)
+	laptopDims := Dimensions{Width: ptrs.Int32(30), Height: nil}
+	laptopID, err := q.InsertDevice(ctx, DeviceTypeLaptop, laptopDims)
+	require.NoError(t, err)
+
+	t.Run("FindDeviceByID", func(t *testing.T) {
+		got, err := q.FindDeviceByID(ctx, phoneID)
+		require.NoError(t, err)
+		assert.Equal(t, FindDeviceByIDRow{DeviceID: phoneID, Type: DeviceTypePhone, Dims: phoneDims}, got)
+	})
+
+	t.Run("FindDeviceByID - none-exists", func(t *testing.T) {
+		_, err := q.FindDeviceByID(ctx, 888)
+		require.ErrorIs(t, err, ErrNotFound)
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+
+	t.Run("FindDevicesByType", func(t *testing.T) {
+		got, err := q.FindDevicesByType(ctx, DeviceTypeLaptop)
+		require.NoError(t, err)
+		assert.Equal(t, []FindDevicesByTypeRow{{DeviceID: laptopID, Type: DeviceTypeLaptop, Dims: laptopDims}}, got)
+	})
+
+	t.Run("FindDeviceTypes", func(t *testing.T) {
+		got, err := q.FindDeviceTypes(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, []DeviceType{DeviceTypePhone, DeviceTypeLaptop, DeviceTypeDesktop}, got)
+	})
+
+	t.Run("batch", func(t *testing.T) {
+		batch := &pgx.Batch{}
+		q.QueueFindDeviceByID(batch, laptopID)
+		q.QueueFindDeviceTypes(batch)
+		results := conn.SendBatch(ctx, batch)
+		defer func() { require.NoError(t, results.Close()) }()
+
+		device, err := q.FindDeviceByIDScan(results)
+		require.NoError(t, err)
+		assert.Equal(t, FindDeviceByIDRow{DeviceID: laptopID, Type: DeviceTypeLaptop, Dims: laptopDims}, device)
+		types, err := q.FindDeviceTypesScan(results)
+		require.NoError(t, err)
+		assert.Equal(t, []DeviceType{DeviceTypePhone, DeviceTypeLaptop, DeviceTypeDesktop}, types)
+	})
+}
+
+func TestNewQuerier_UnregisteredTypes(t *testing.T) {
+	pgxConn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	ctx := context.Background()
+	conn, err := pgx.Connect(ctx, pgxConn.Config().ConnString())
+	require.NoError(t, err)
+	defer func() { require.NoError(t, conn.Close(ctx)) }()
+
+	// Without RegisterTypes, pgx v5 doesn't know how to encode the composite.
+	_, err = NewQuerier(conn).InsertDevice(ctx, DeviceTypePhone, Dimensions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,16 @@
+CREATE TYPE device_type AS ENUM (
+  'phone',
+  'laptop',
+  'desktop'
+  );
+
+CREATE TYPE dimensions AS (
+  width  int4,
+  height int4
+);
+
+CREATE TABLE device (
+  device_id serial PRIMARY KEY,
+  type      device_type NOT NULL,
+  dims      dimensions
+);
@@ -0,0 +1,17 @@
+-- FindAuthorByID finds one author by ID.
+-- name: FindAuthorByID :one
+SELECT * FROM author WHERE author_id = pggen.arg('author_id');
+
+-- FindAuthors finds authors by first name.
+-- name: FindAuthors :many
+SELECT * FROM author WHERE first_name = pggen.arg('first_name');
+
+-- InsertAuthor inserts an author by name and returns the ID.
+-- name: InsertAuthor :one
+INSERT INTO author (first_name, last_name)
+VALUES (pggen.arg('first_name'), pggen.arg('last_name'))
+RETURNING author_id;
+
+-- DeleteAuthors deletes authors by first name.
+-- name: DeleteAuthors :exec
+DELETE FROM author WHERE first_name = pggen.arg('first_name');
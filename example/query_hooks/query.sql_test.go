@@ -0,0 +1,153 @@
+package query_hooks
+
+import (
+	"context"
+	"errors"
+	"github.com/atomicleads/pggen/internal/pgtest"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+	"sync"
+	"testing"
+)
+
+type hooksCtxKey struct{}
+
+// recordingHooks records the events passed to AfterQuery.
+type recordingHooks struct {
+	mu      sync.Mutex
+	befores []QueryEvent
+	afters  []QueryEvent
+}
+
+func (h *recordingHooks) BeforeQuery(ctx context.Context, event QueryEvent) context.Context {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.befores = append(h.befores, event)
+	return context.WithValue(ctx, hooksCtxKey{}, event.Name)
+}
+
+func (h *recordingHooks) AfterQuery(ctx context.Context, event QueryEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if name, _ := ctx.Value(hooksCtxKey{}).(string); name != event.Name {
+		event.Err = errors.New("AfterQuery context not derived from BeforeQuery context")
+	}
+	h.afters = append(h.afters, event)
+}
+
+// lastEvent returns the event passed to the last AfterQuery call.
+func (h *recordingHooks) lastEvent(t *testing.T) QueryEvent {
+	t.Helper()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	require.NotEmpty(t, h.afters, "AfterQuery never called")
+	require.Equal(t, len(h.befores), len(h.afters), "BeforeQuery and AfterQuery calls")
+	return h.afters[len(h.afters)-1]
+}
+
+func TestNewQuerier_Hooks(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	ctx := context.Background()
+	hooks := &recordingHooks{}
+	q := NewQuerier(conn, hooks)
+
+	adamsID, err := q.InsertAuthor(ctx, "john", "adams")
+	require.NoError(t, err)
+	_, err = q.InsertAuthor(ctx, "john", "quincy")
+	require.NoError(t, err)
+
+	t.Run("FindAuthorByID", func(t *testing.T) {
+		_, err := q.FindAuthorByID(ctx, adamsID)
+		require.NoError(t, err)
+		event := hooks.lastEvent(t)
+		assert.Equal(t, "FindAuthorByID", event.Name)
+		assert.Equal(t, ":one", event.ResultKind)
+		assert.Equal(t, int64(1), event.RowCount)
+		assert.NoError(t, event.Err)
+		assert.False(t, event.Start.IsZero(), "event start")
+	})
+
+	t.Run("FindAuthorByID - none-exists", func(t *testing.T) {
+		_, err := q.FindAuthorByID(ctx, 888)
+		require.ErrorIs(t, err, ErrNotFound)
+		event := hooks.lastEvent(t)
+		assert.Equal(t, int64(0), event.RowCount)
+		assert.ErrorIs(t, event.Err, ErrNotFound)
+	})
+
+	t.Run("FindAuthors", func(t *testing.T) {
+		authors, err := q.FindAuthors(ctx, "john")
+		require.NoError(t, err)
+		require.Len(t, authors, 2)
+		event := hooks.lastEvent(t)
+		assert.Equal(t, "FindAuthors", event.Name)
+		assert.Equal(t, ":many", event.ResultKind)
+		assert.Equal(t, int64(2), event.RowCount)
+	})
+
+	t.Run("DeleteAuthors", func(t *testing.T) {
+		_, err := q.DeleteAuthors(ctx, "john")
+		require.NoError(t, err)
+		event := hooks.lastEvent(t)
+		assert.Equal(t, "DeleteAuthors", event.Name)
+		assert.Equal(t, ":exec", event.ResultKind)
+		assert.Equal(t, int64(2), event.RowCount)
+	})
+}
+
+func TestNewQuerier_NilHooks(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	q := NewQuerier(conn, nil)
+
+	authorID, err := q.InsertAuthor(context.Background(), "john", "adams")
+	require.NoError(t, err)
+	author, err := q.FindAuthorByID(context.Background(), authorID)
+	require.NoError(t, err)
+	assert.Equal(t, FindAuthorByIDRow{AuthorID: authorID, FirstName: "john", LastName: "adams"}, author)
+}
+
+func TestNewQuerier_OTelQueryHooks(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	q := NewQuerier(conn, NewOTelQueryHooks(noop.NewTracerProvider().Tracer("pggen")))
+
+	authorID, err := q.InsertAuthor(context.Background(), "john", "adams")
+	require.NoError(t, err)
+	_, err = q.FindAuthorByID(context.Background(), authorID+1)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestNewQuerier_OTelQueryHooks_Batch(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchema(t, []string{"schema.sql"})
+	defer cleanup()
+	recorder := tracetest.NewSpanRecorder()
+	tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer("pggen")
+	q := NewQuerier(conn, NewOTelQueryHooks(tracer))
+
+	ctx, parent := tracer.Start(context.Background(), "batch")
+	batch := &pgx.Batch{}
+	q.QueueInsertAuthor(batch, "john", "adams")
+	q.QueueFindAuthors(batch, "john")
+	results := conn.SendBatch(ctx, batch)
+	_, err := q.InsertAuthorScan(ctx, results)
+	require.NoError(t, err)
+	authors, err := q.FindAuthorsScan(ctx, results)
+	require.NoError(t, err)
+	require.Len(t, authors, 1)
+	require.NoError(t, results.Close())
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "sql:InsertAuthor", spans[0].Name())
+	assert.Equal(t, "sql:FindAuthors", spans[1].Name())
+	for _, span := range spans[:2] {
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID(), "parent of span %s", span.Name())
+	}
+}
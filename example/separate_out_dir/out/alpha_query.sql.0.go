@@ -4,6 +4,7 @@ package out
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,14 +12,38 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	AlphaNested(ctx context.Context) (string, error)
+	// QueueAlphaNested enqueues a AlphaNested query into batch to be executed
+	// later by the batch.
+	QueueAlphaNested(batch genericBatch)
+	// AlphaNestedScan scans the result of an executed QueueAlphaNested query.
+	AlphaNestedScan(results pgx.BatchResults) (string, error)
 
 	AlphaCompositeArray(ctx context.Context) ([]Alpha, error)
+	// QueueAlphaCompositeArray enqueues a AlphaCompositeArray query into batch to be executed
+	// later by the batch.
+	QueueAlphaCompositeArray(batch genericBatch)
+	// AlphaCompositeArrayScan scans the result of an executed QueueAlphaCompositeArray query.
+	AlphaCompositeArrayScan(results pgx.BatchResults) ([]Alpha, error)
 
 	Alpha(ctx context.Context) (string, error)
+	// QueueAlpha enqueues a Alpha query into batch to be executed
+	// later by the batch.
+	QueueAlpha(batch genericBatch)
+	// AlphaScan scans the result of an executed QueueAlpha query.
+	AlphaScan(results pgx.BatchResults) (string, error)
 
 	Bravo(ctx context.Context) (string, error)
+	// QueueBravo enqueues a Bravo query into batch to be executed
+	// later by the batch.
+	QueueBravo(batch genericBatch)
+	// BravoScan scans the result of an executed QueueBravo query.
+	BravoScan(results pgx.BatchResults) (string, error)
 }
 
 type DBQuerier struct {
@@ -47,6 +72,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -54,8 +87,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // Alpha represents the Postgres composite type "alpha".
@@ -166,7 +309,22 @@ func (q *DBQuerier) AlphaNested(ctx context.Context) (string, error) {
 	row := q.conn.QueryRow(ctx, alphaNestedSQL)
 	var item string
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query AlphaNested: %w", err)
+		return item, fmt.Errorf("query AlphaNested: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueAlphaNested implements Querier.QueueAlphaNested.
+func (q *DBQuerier) QueueAlphaNested(batch genericBatch) {
+	batch.Queue(alphaNestedSQL)
+}
+
+// AlphaNestedScan implements Querier.AlphaNestedScan.
+func (q *DBQuerier) AlphaNestedScan(results pgx.BatchResults) (string, error) {
+	row := results.QueryRow()
+	var item string
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan AlphaNestedScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -180,7 +338,26 @@ func (q *DBQuerier) AlphaCompositeArray(ctx context.Context) ([]Alpha, error) {
 	item := []Alpha{}
 	arrayArray := q.types.newAlphaArray()
 	if err := row.Scan(arrayArray); err != nil {
-		return item, fmt.Errorf("query AlphaCompositeArray: %w", err)
+		return item, fmt.Errorf("query AlphaCompositeArray: %w", wrapNoRows(err))
+	}
+	if err := arrayArray.AssignTo(&item); err != nil {
+		return item, fmt.Errorf("assign AlphaCompositeArray row: %w", err)
+	}
+	return item, nil
+}
+
+// QueueAlphaCompositeArray implements Querier.QueueAlphaCompositeArray.
+func (q *DBQuerier) QueueAlphaCompositeArray(batch genericBatch) {
+	batch.Queue(alphaCompositeArraySQL)
+}
+
+// AlphaCompositeArrayScan implements Querier.AlphaCompositeArrayScan.
+func (q *DBQuerier) AlphaCompositeArrayScan(results pgx.BatchResults) ([]Alpha, error) {
+	row := results.QueryRow()
+	item := []Alpha{}
+	arrayArray := q.types.newAlphaArray()
+	if err := row.Scan(arrayArray); err != nil {
+		return item, fmt.Errorf("scan AlphaCompositeArrayScan row: %w", wrapNoRows(err))
 	}
 	if err := arrayArray.AssignTo(&item); err != nil {
 		return item, fmt.Errorf("assign AlphaCompositeArray row: %w", err)
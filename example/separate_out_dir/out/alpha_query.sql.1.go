@@ -5,6 +5,7 @@ package out
 import (
 	"context"
 	"fmt"
+	"github.com/jackc/pgx/v4"
 )
 
 const alphaSQL = `SELECT 'alpha' as output;`
@@ -15,7 +16,22 @@ func (q *DBQuerier) Alpha(ctx context.Context) (string, error) {
 	row := q.conn.QueryRow(ctx, alphaSQL)
 	var item string
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query Alpha: %w", err)
+		return item, fmt.Errorf("query Alpha: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueAlpha implements Querier.QueueAlpha.
+func (q *DBQuerier) QueueAlpha(batch genericBatch) {
+	batch.Queue(alphaSQL)
+}
+
+// AlphaScan implements Querier.AlphaScan.
+func (q *DBQuerier) AlphaScan(results pgx.BatchResults) (string, error) {
+	row := results.QueryRow()
+	var item string
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan AlphaScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
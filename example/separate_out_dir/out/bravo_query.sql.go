@@ -5,6 +5,7 @@ package out
 import (
 	"context"
 	"fmt"
+	"github.com/jackc/pgx/v4"
 )
 
 const bravoSQL = `SELECT 'bravo' as output;`
@@ -15,7 +16,22 @@ func (q *DBQuerier) Bravo(ctx context.Context) (string, error) {
 	row := q.conn.QueryRow(ctx, bravoSQL)
 	var item string
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query Bravo: %w", err)
+		return item, fmt.Errorf("query Bravo: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueBravo implements Querier.QueueBravo.
+func (q *DBQuerier) QueueBravo(batch genericBatch) {
+	batch.Queue(bravoSQL)
+}
+
+// BravoScan implements Querier.BravoScan.
+func (q *DBQuerier) BravoScan(results pgx.BatchResults) (string, error) {
+	row := results.QueryRow()
+	var item string
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan BravoScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
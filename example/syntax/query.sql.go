@@ -4,6 +4,7 @@ package syntax
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,32 +12,81 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	// Query to test escaping in generated Go.
 	Backtick(ctx context.Context) (string, error)
+	// QueueBacktick enqueues a Backtick query into batch to be executed
+	// later by the batch.
+	QueueBacktick(batch genericBatch)
+	// BacktickScan scans the result of an executed QueueBacktick query.
+	BacktickScan(results pgx.BatchResults) (string, error)
 
 	// Query to test escaping in generated Go.
 	BacktickQuoteBacktick(ctx context.Context) (string, error)
+	// QueueBacktickQuoteBacktick enqueues a BacktickQuoteBacktick query into batch to be executed
+	// later by the batch.
+	QueueBacktickQuoteBacktick(batch genericBatch)
+	// BacktickQuoteBacktickScan scans the result of an executed QueueBacktickQuoteBacktick query.
+	BacktickQuoteBacktickScan(results pgx.BatchResults) (string, error)
 
 	// Query to test escaping in generated Go.
 	BacktickNewline(ctx context.Context) (string, error)
+	// QueueBacktickNewline enqueues a BacktickNewline query into batch to be executed
+	// later by the batch.
+	QueueBacktickNewline(batch genericBatch)
+	// BacktickNewlineScan scans the result of an executed QueueBacktickNewline query.
+	BacktickNewlineScan(results pgx.BatchResults) (string, error)
 
 	// Query to test escaping in generated Go.
 	BacktickDoubleQuote(ctx context.Context) (string, error)
+	// QueueBacktickDoubleQuote enqueues a BacktickDoubleQuote query into batch to be executed
+	// later by the batch.
+	QueueBacktickDoubleQuote(batch genericBatch)
+	// BacktickDoubleQuoteScan scans the result of an executed QueueBacktickDoubleQuote query.
+	BacktickDoubleQuoteScan(results pgx.BatchResults) (string, error)
 
 	// Query to test escaping in generated Go.
 	BacktickBackslashN(ctx context.Context) (string, error)
+	// QueueBacktickBackslashN enqueues a BacktickBackslashN query into batch to be executed
+	// later by the batch.
+	QueueBacktickBackslashN(batch genericBatch)
+	// BacktickBackslashNScan scans the result of an executed QueueBacktickBackslashN query.
+	BacktickBackslashNScan(results pgx.BatchResults) (string, error)
 
 	// Illegal names.
 	IllegalNameSymbols(ctx context.Context, helloWorld string) (IllegalNameSymbolsRow, error)
+	// QueueIllegalNameSymbols enqueues a IllegalNameSymbols query into batch to be executed
+	// later by the batch.
+	QueueIllegalNameSymbols(batch genericBatch, helloWorld string)
+	// IllegalNameSymbolsScan scans the result of an executed QueueIllegalNameSymbols query.
+	IllegalNameSymbolsScan(results pgx.BatchResults) (IllegalNameSymbolsRow, error)
 
 	// Space after pggen.arg
 	SpaceAfter(ctx context.Context, space string) (string, error)
+	// QueueSpaceAfter enqueues a SpaceAfter query into batch to be executed
+	// later by the batch.
+	QueueSpaceAfter(batch genericBatch, space string)
+	// SpaceAfterScan scans the result of an executed QueueSpaceAfter query.
+	SpaceAfterScan(results pgx.BatchResults) (string, error)
 
 	// Enum named 123.
 	BadEnumName(ctx context.Context) (UnnamedEnum123, error)
+	// QueueBadEnumName enqueues a BadEnumName query into batch to be executed
+	// later by the batch.
+	QueueBadEnumName(batch genericBatch)
+	// BadEnumNameScan scans the result of an executed QueueBadEnumName query.
+	BadEnumNameScan(results pgx.BatchResults) (UnnamedEnum123, error)
 
 	GoKeyword(ctx context.Context, go_ string) (string, error)
+	// QueueGoKeyword enqueues a GoKeyword query into batch to be executed
+	// later by the batch.
+	QueueGoKeyword(batch genericBatch, go_ string)
+	// GoKeywordScan scans the result of an executed QueueGoKeyword query.
+	GoKeywordScan(results pgx.BatchResults) (string, error)
 }
 
 type DBQuerier struct {
@@ -65,6 +115,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -72,8 +130,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // UnnamedEnum123 represents the Postgres enum "123".
@@ -125,7 +293,22 @@ func (q *DBQuerier) Backtick(ctx context.Context) (string, error) {
 	row := q.conn.QueryRow(ctx, backtickSQL)
 	var item string
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query Backtick: %w", err)
+		return item, fmt.Errorf("query Backtick: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueBacktick implements Querier.QueueBacktick.
+func (q *DBQuerier) QueueBacktick(batch genericBatch) {
+	batch.Queue(backtickSQL)
+}
+
+// BacktickScan implements Querier.BacktickScan.
+func (q *DBQuerier) BacktickScan(results pgx.BatchResults) (string, error) {
+	row := results.QueryRow()
+	var item string
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan BacktickScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -138,7 +321,22 @@ func (q *DBQuerier) BacktickQuoteBacktick(ctx context.Context) (string, error) {
 	row := q.conn.QueryRow(ctx, backtickQuoteBacktickSQL)
 	var item string
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query BacktickQuoteBacktick: %w", err)
+		return item, fmt.Errorf("query BacktickQuoteBacktick: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueBacktickQuoteBacktick implements Querier.QueueBacktickQuoteBacktick.
+func (q *DBQuerier) QueueBacktickQuoteBacktick(batch genericBatch) {
+	batch.Queue(backtickQuoteBacktickSQL)
+}
+
+// BacktickQuoteBacktickScan implements Querier.BacktickQuoteBacktickScan.
+func (q *DBQuerier) BacktickQuoteBacktickScan(results pgx.BatchResults) (string, error) {
+	row := results.QueryRow()
+	var item string
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan BacktickQuoteBacktickScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -151,7 +349,22 @@ func (q *DBQuerier) BacktickNewline(ctx context.Context) (string, error) {
 	row := q.conn.QueryRow(ctx, backtickNewlineSQL)
 	var item string
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query BacktickNewline: %w", err)
+		return item, fmt.Errorf("query BacktickNewline: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueBacktickNewline implements Querier.QueueBacktickNewline.
+func (q *DBQuerier) QueueBacktickNewline(batch genericBatch) {
+	batch.Queue(backtickNewlineSQL)
+}
+
+// BacktickNewlineScan implements Querier.BacktickNewlineScan.
+func (q *DBQuerier) BacktickNewlineScan(results pgx.BatchResults) (string, error) {
+	row := results.QueryRow()
+	var item string
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan BacktickNewlineScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -164,7 +377,22 @@ func (q *DBQuerier) BacktickDoubleQuote(ctx context.Context) (string, error) {
 	row := q.conn.QueryRow(ctx, backtickDoubleQuoteSQL)
 	var item string
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query BacktickDoubleQuote: %w", err)
+		return item, fmt.Errorf("query BacktickDoubleQuote: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueBacktickDoubleQuote implements Querier.QueueBacktickDoubleQuote.
+func (q *DBQuerier) QueueBacktickDoubleQuote(batch genericBatch) {
+	batch.Queue(backtickDoubleQuoteSQL)
+}
+
+// BacktickDoubleQuoteScan implements Querier.BacktickDoubleQuoteScan.
+func (q *DBQuerier) BacktickDoubleQuoteScan(results pgx.BatchResults) (string, error) {
+	row := results.QueryRow()
+	var item string
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan BacktickDoubleQuoteScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -177,7 +405,22 @@ func (q *DBQuerier) BacktickBackslashN(ctx context.Context) (string, error) {
 	row := q.conn.QueryRow(ctx, backtickBackslashNSQL)
 	var item string
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query BacktickBackslashN: %w", err)
+		return item, fmt.Errorf("query BacktickBackslashN: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueBacktickBackslashN implements Querier.QueueBacktickBackslashN.
+func (q *DBQuerier) QueueBacktickBackslashN(batch genericBatch) {
+	batch.Queue(backtickBackslashNSQL)
+}
+
+// BacktickBackslashNScan implements Querier.BacktickBackslashNScan.
+func (q *DBQuerier) BacktickBackslashNScan(results pgx.BatchResults) (string, error) {
+	row := results.QueryRow()
+	var item string
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan BacktickBackslashNScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -195,7 +438,22 @@ func (q *DBQuerier) IllegalNameSymbols(ctx context.Context, helloWorld string) (
 	row := q.conn.QueryRow(ctx, illegalNameSymbolsSQL, helloWorld)
 	var item IllegalNameSymbolsRow
 	if err := row.Scan(&item.UnnamedColumn0, &item.FooBar); err != nil {
-		return item, fmt.Errorf("query IllegalNameSymbols: %w", err)
+		return item, fmt.Errorf("query IllegalNameSymbols: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueIllegalNameSymbols implements Querier.QueueIllegalNameSymbols.
+func (q *DBQuerier) QueueIllegalNameSymbols(batch genericBatch, helloWorld string) {
+	batch.Queue(illegalNameSymbolsSQL, helloWorld)
+}
+
+// IllegalNameSymbolsScan implements Querier.IllegalNameSymbolsScan.
+func (q *DBQuerier) IllegalNameSymbolsScan(results pgx.BatchResults) (IllegalNameSymbolsRow, error) {
+	row := results.QueryRow()
+	var item IllegalNameSymbolsRow
+	if err := row.Scan(&item.UnnamedColumn0, &item.FooBar); err != nil {
+		return item, fmt.Errorf("scan IllegalNameSymbolsScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -208,7 +466,22 @@ func (q *DBQuerier) SpaceAfter(ctx context.Context, space string) (string, error
 	row := q.conn.QueryRow(ctx, spaceAfterSQL, space)
 	var item string
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query SpaceAfter: %w", err)
+		return item, fmt.Errorf("query SpaceAfter: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueSpaceAfter implements Querier.QueueSpaceAfter.
+func (q *DBQuerier) QueueSpaceAfter(batch genericBatch, space string) {
+	batch.Queue(spaceAfterSQL, space)
+}
+
+// SpaceAfterScan implements Querier.SpaceAfterScan.
+func (q *DBQuerier) SpaceAfterScan(results pgx.BatchResults) (string, error) {
+	row := results.QueryRow()
+	var item string
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan SpaceAfterScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -221,7 +494,22 @@ func (q *DBQuerier) BadEnumName(ctx context.Context) (UnnamedEnum123, error) {
 	row := q.conn.QueryRow(ctx, badEnumNameSQL)
 	var item UnnamedEnum123
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query BadEnumName: %w", err)
+		return item, fmt.Errorf("query BadEnumName: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueBadEnumName implements Querier.QueueBadEnumName.
+func (q *DBQuerier) QueueBadEnumName(batch genericBatch) {
+	batch.Queue(badEnumNameSQL)
+}
+
+// BadEnumNameScan implements Querier.BadEnumNameScan.
+func (q *DBQuerier) BadEnumNameScan(results pgx.BatchResults) (UnnamedEnum123, error) {
+	row := results.QueryRow()
+	var item UnnamedEnum123
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan BadEnumNameScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -234,7 +522,22 @@ func (q *DBQuerier) GoKeyword(ctx context.Context, go_ string) (string, error) {
 	row := q.conn.QueryRow(ctx, goKeywordSQL, go_)
 	var item string
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query GoKeyword: %w", err)
+		return item, fmt.Errorf("query GoKeyword: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueGoKeyword implements Querier.QueueGoKeyword.
+func (q *DBQuerier) QueueGoKeyword(batch genericBatch, go_ string) {
+	batch.Queue(goKeywordSQL, go_)
+}
+
+// GoKeywordScan implements Querier.GoKeywordScan.
+func (q *DBQuerier) GoKeywordScan(results pgx.BatchResults) (string, error) {
+	row := results.QueryRow()
+	var item string
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan GoKeywordScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -4,6 +4,7 @@ package void
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,16 +12,49 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	VoidOnly(ctx context.Context) (pgconn.CommandTag, error)
+	// QueueVoidOnly enqueues a VoidOnly query into batch to be executed
+	// later by the batch.
+	QueueVoidOnly(batch genericBatch)
+	// VoidOnlyScan scans the result of an executed QueueVoidOnly query.
+	VoidOnlyScan(results pgx.BatchResults) (pgconn.CommandTag, error)
 
 	VoidOnlyTwoParams(ctx context.Context, id int32) (pgconn.CommandTag, error)
+	// QueueVoidOnlyTwoParams enqueues a VoidOnlyTwoParams query into batch to be executed
+	// later by the batch.
+	QueueVoidOnlyTwoParams(batch genericBatch, id int32)
+	// VoidOnlyTwoParamsScan scans the result of an executed QueueVoidOnlyTwoParams query.
+	VoidOnlyTwoParamsScan(results pgx.BatchResults) (pgconn.CommandTag, error)
 
 	VoidTwo(ctx context.Context) (string, error)
+	// QueueVoidTwo enqueues a VoidTwo query into batch to be executed
+	// later by the batch.
+	QueueVoidTwo(batch genericBatch)
+	// VoidTwoScan scans the result of an executed QueueVoidTwo query.
+	VoidTwoScan(results pgx.BatchResults) (string, error)
 
 	VoidThree(ctx context.Context) (VoidThreeRow, error)
+	// QueueVoidThree enqueues a VoidThree query into batch to be executed
+	// later by the batch.
+	QueueVoidThree(batch genericBatch)
+	// VoidThreeScan scans the result of an executed QueueVoidThree query.
+	VoidThreeScan(results pgx.BatchResults) (VoidThreeRow, error)
 
 	VoidThree2(ctx context.Context) ([]string, error)
+	// VoidThree2Each runs VoidThree2 and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	VoidThree2Each(ctx context.Context, fn func(row string) error) error
+	// QueueVoidThree2 enqueues a VoidThree2 query into batch to be executed
+	// later by the batch.
+	QueueVoidThree2(batch genericBatch)
+	// VoidThree2Scan scans the result of an executed QueueVoidThree2 query.
+	VoidThree2Scan(results pgx.BatchResults) ([]string, error)
 }
 
 type DBQuerier struct {
@@ -49,6 +83,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -56,8 +98,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // typeResolver looks up the pgtype.ValueTranscoder by Postgres type name.
@@ -101,6 +253,20 @@ func (q *DBQuerier) VoidOnly(ctx context.Context) (pgconn.CommandTag, error) {
 	return cmdTag, err
 }
 
+// QueueVoidOnly implements Querier.QueueVoidOnly.
+func (q *DBQuerier) QueueVoidOnly(batch genericBatch) {
+	batch.Queue(voidOnlySQL)
+}
+
+// VoidOnlyScan implements Querier.VoidOnlyScan.
+func (q *DBQuerier) VoidOnlyScan(results pgx.BatchResults) (pgconn.CommandTag, error) {
+	cmdTag, err := results.Exec()
+	if err != nil {
+		return cmdTag, fmt.Errorf("exec VoidOnlyScan: %w", err)
+	}
+	return cmdTag, err
+}
+
 const voidOnlyTwoParamsSQL = `SELECT void_fn_two_params($1, 'text');`
 
 // VoidOnlyTwoParams implements Querier.VoidOnlyTwoParams.
@@ -113,6 +279,20 @@ func (q *DBQuerier) VoidOnlyTwoParams(ctx context.Context, id int32) (pgconn.Com
 	return cmdTag, err
 }
 
+// QueueVoidOnlyTwoParams implements Querier.QueueVoidOnlyTwoParams.
+func (q *DBQuerier) QueueVoidOnlyTwoParams(batch genericBatch, id int32) {
+	batch.Queue(voidOnlyTwoParamsSQL, id)
+}
+
+// VoidOnlyTwoParamsScan implements Querier.VoidOnlyTwoParamsScan.
+func (q *DBQuerier) VoidOnlyTwoParamsScan(results pgx.BatchResults) (pgconn.CommandTag, error) {
+	cmdTag, err := results.Exec()
+	if err != nil {
+		return cmdTag, fmt.Errorf("exec VoidOnlyTwoParamsScan: %w", err)
+	}
+	return cmdTag, err
+}
+
 const voidTwoSQL = `SELECT void_fn(), 'foo' as name;`
 
 // VoidTwo implements Querier.VoidTwo.
@@ -121,7 +301,22 @@ func (q *DBQuerier) VoidTwo(ctx context.Context) (string, error) {
 	row := q.conn.QueryRow(ctx, voidTwoSQL)
 	var item string
 	if err := row.Scan(nil, &item); err != nil {
-		return item, fmt.Errorf("query VoidTwo: %w", err)
+		return item, fmt.Errorf("query VoidTwo: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueVoidTwo implements Querier.QueueVoidTwo.
+func (q *DBQuerier) QueueVoidTwo(batch genericBatch) {
+	batch.Queue(voidTwoSQL)
+}
+
+// VoidTwoScan implements Querier.VoidTwoScan.
+func (q *DBQuerier) VoidTwoScan(results pgx.BatchResults) (string, error) {
+	row := results.QueryRow()
+	var item string
+	if err := row.Scan(nil, &item); err != nil {
+		return item, fmt.Errorf("scan VoidTwoScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -139,7 +334,22 @@ func (q *DBQuerier) VoidThree(ctx context.Context) (VoidThreeRow, error) {
 	row := q.conn.QueryRow(ctx, voidThreeSQL)
 	var item VoidThreeRow
 	if err := row.Scan(nil, &item.Foo, &item.Bar); err != nil {
-		return item, fmt.Errorf("query VoidThree: %w", err)
+		return item, fmt.Errorf("query VoidThree: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueVoidThree implements Querier.QueueVoidThree.
+func (q *DBQuerier) QueueVoidThree(batch genericBatch) {
+	batch.Queue(voidThreeSQL)
+}
+
+// VoidThreeScan implements Querier.VoidThreeScan.
+func (q *DBQuerier) VoidThreeScan(results pgx.BatchResults) (VoidThreeRow, error) {
+	row := results.QueryRow()
+	var item VoidThreeRow
+	if err := row.Scan(nil, &item.Foo, &item.Bar); err != nil {
+		return item, fmt.Errorf("scan VoidThreeScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -168,6 +378,56 @@ func (q *DBQuerier) VoidThree2(ctx context.Context) ([]string, error) {
 	return items, err
 }
 
+// VoidThree2Each implements Querier.VoidThree2Each.
+func (q *DBQuerier) VoidThree2Each(ctx context.Context, fn func(row string) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "VoidThree2")
+	rows, err := q.conn.Query(ctx, voidThree2SQL)
+	if err != nil {
+		return fmt.Errorf("query VoidThree2Each: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item string
+		if err := rows.Scan(&item, nil, nil); err != nil {
+			return fmt.Errorf("scan VoidThree2Each row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close VoidThree2Each rows: %w", err)
+	}
+	return nil
+}
+
+// QueueVoidThree2 implements Querier.QueueVoidThree2.
+func (q *DBQuerier) QueueVoidThree2(batch genericBatch) {
+	batch.Queue(voidThree2SQL)
+}
+
+// VoidThree2Scan implements Querier.VoidThree2Scan.
+func (q *DBQuerier) VoidThree2Scan(results pgx.BatchResults) ([]string, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query VoidThree2Scan: %w", err)
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var item string
+		if err := rows.Scan(&item, nil, nil); err != nil {
+			return nil, fmt.Errorf("scan VoidThree2Scan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close VoidThree2Scan rows: %w", err)
+	}
+	return items, err
+}
+
 // textPreferrer wraps a pgtype.ValueTranscoder and sets the preferred encoding
 // format to text instead binary (the default). pggen uses the text format
 // when the OID is unknownOID because the binary format requires the OID.
@@ -11,10 +11,10 @@ import (
 
 func TestGenerate_Golang_Error(t *testing.T) {
 	tests := []struct {
-		name       string
-		schema     string
-		queries    string
-		wantErrMsg string
+		name        string
+		schema      string
+		queries     string
+		wantErrMsgs []string
 	}{
 		{
 			name:   "duplicate query name",
@@ -25,7 +25,7 @@ func TestGenerate_Golang_Error(t *testing.T) {
 			-- name: Foo :many
 			SELECT 1;
 			`),
-			wantErrMsg: `duplicate query name Foo`,
+			wantErrMsgs: []string{`query.sql:3:1: duplicate query name Foo; first declared on line 1`},
 		},
 		{
 			name:   "type error",
@@ -34,7 +34,44 @@ func TestGenerate_Golang_Error(t *testing.T) {
 			-- name: Foo :one
 			SELECT encode(123, 'foo'::text);
 			`),
-			wantErrMsg: `function encode(integer, text) does not exist`,
+			wantErrMsgs: []string{`function encode(integer, text) does not exist`},
+		},
+		{
+			name:   "error position",
+			schema: "",
+			queries: texts.Dedent(`
+			-- name: Foo :one
+			SELECT pggen.arg('bar')::int AS bar,
+			  unknown_col;
+			`),
+			wantErrMsgs: []string{
+				"query.sql:3:3: query Foo: fetch field descriptions: column \"unknown_col\" does not exist\n" +
+					"3 |   unknown_col;\n" +
+					"  |   ^\n",
+			},
+		},
+		{
+			name:   "all errors",
+			schema: "",
+			queries: texts.Dedent(`
+			-- name: Foo :one
+			SELECT unknown_a;
+
+			-- name: Bar :one
+			SELECT 1;
+
+			-- name: Foo :one
+			SELECT 2;
+
+			-- name: Baz :one
+			SELECT unknown_b;
+			`),
+			wantErrMsgs: []string{
+				"found 3 errors in query files:\n",
+				"query.sql:2:8: query Foo: fetch field descriptions: column \"unknown_a\" does not exist\n",
+				"query.sql:7:1: duplicate query name Foo; first declared on line 1\n",
+				"query.sql:11:8: query Baz: fetch field descriptions: column \"unknown_b\" does not exist\n",
+			},
 		},
 	}
 	for _, tt := range tests {
@@ -60,7 +97,49 @@ func TestGenerate_Golang_Error(t *testing.T) {
 			if err == nil {
 				t.Fatal("expected error from generate")
 			}
-			assert.Contains(t, err.Error(), tt.wantErrMsg, "error message should contain substring")
+			for _, want := range tt.wantErrMsgs {
+				assert.Contains(t, err.Error(), want, "error message should contain substring")
+			}
+			assert.NoFileExists(t, filepath.Join(tmpDir, "query.sql.go"), "generate nothing with errors")
 		})
 	}
 }
+
+func TestGenerateProject_ErrorWritesNoPackage(t *testing.T) {
+	conn, cleanupFunc := pgtest.NewPostgresSchemaString(t, "")
+	defer cleanupFunc()
+	tmpDir := t.TempDir()
+	queries := map[string]string{
+		"alpha": "-- name: Alpha :one\nSELECT 1 AS one;\n",
+		"bravo": "-- name: Bravo :one\nSELECT unknown_col;\n",
+	}
+	var pkgs []GenerateOptions
+	for _, name := range []string{"alpha", "bravo"} {
+		dir := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		queryFile := filepath.Join(dir, "query.sql")
+		if err := os.WriteFile(queryFile, []byte(queries[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		pkgs = append(pkgs, GenerateOptions{
+			QueryFiles: []string{queryFile},
+			OutputDir:  dir,
+			GoPackage:  name,
+			Language:   LangGo,
+		})
+	}
+
+	err := GenerateProject(ProjectOptions{
+		ConnString: conn.Config().ConnString(),
+		Packages:   pkgs,
+	})
+
+	if err == nil {
+		t.Fatal("expected error from generate project")
+	}
+	assert.Contains(t, err.Error(), `column "unknown_col" does not exist`)
+	assert.NoFileExists(t, filepath.Join(tmpDir, "alpha", "query.sql.go"), "write no package if any package fails")
+	assert.NoFileExists(t, filepath.Join(tmpDir, "bravo", "query.sql.go"))
+}
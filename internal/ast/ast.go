@@ -70,12 +70,24 @@ type ResultKind string
 const (
 	ResultKindMany ResultKind = ":many"
 	ResultKindOne  ResultKind = ":one"
+	// ResultKindOpt returns at most one row, or nil if no rows match instead
+	// of an error.
+	ResultKindOpt  ResultKind = ":opt"
 	ResultKindExec ResultKind = ":exec"
+	// ResultKindExecRows runs a query without output columns and returns the
+	// number of rows affected.
+	ResultKindExecRows ResultKind = ":execrows"
+	// ResultKindCopyFrom inserts many rows with the Postgres COPY protocol. The
+	// query must be an INSERT with a VALUES list containing only params.
+	ResultKindCopyFrom ResultKind = ":copyfrom"
 )
 
 // Pragmas are options to control generated code for a single query.
 type Pragmas struct {
-	ProtobufType string // package qualified protocol buffer message type to use for output rows
+	ProtobufType string   // package qualified protocol buffer message type to use for output rows
+	ExpectRows   *int64   // if set, the number of rows an :exec or :execrows query must affect
+	NotNull      []string // output columns to treat as non-nullable regardless of inference
+	Nullable     []string // output columns to treat as nullable regardless of inference
 }
 
 // An query is represented by one of the following query nodes.
@@ -94,12 +106,22 @@ type (
 		SourceSQL   string        // the complete sql query as it appeared in the source file
 		PreparedSQL string        // the sql query with args replaced by $1, $2, etc.
 		ParamNames  []string      // the name of each param in the PreparedSQL, the nth entry is the $n+1 param
+		ParamNulls  []bool        // if each param in ParamNames is nullable, like pggen.narg('foo')
+		ArgSpans    []ArgSpan     // each arg in SourceSQL replaced by a param in PreparedSQL, in order
 		ResultKind  ResultKind    // the result output type
 		Pragmas     Pragmas       // optional query options
 		Semi        gotok.Pos     // position of the closing semicolon
 	}
 )
 
+// An ArgSpan is the span of an arg, like pggen.arg('foo'), in
+// SourceQuery.SourceSQL and the span of the param that replaced it, like $1, in
+// SourceQuery.PreparedSQL. Spans are 0-based byte offsets; hi is exclusive.
+type ArgSpan struct {
+	SourceLo, SourceHi     int
+	PreparedLo, PreparedHi int
+}
+
 func (q *BadQuery) Pos() gotok.Pos { return q.From }
 func (q *BadQuery) End() gotok.Pos { return q.To }
 func (q *BadQuery) Kind() NodeKind { return KindBadQuery }
@@ -110,6 +132,23 @@ func (q *SourceQuery) End() gotok.Pos { return q.Semi }
 func (q *SourceQuery) Kind() NodeKind { return KindTemplateQuery }
 func (*SourceQuery) queryNode()       {}
 
+// SourceOffset converts a 0-based byte offset in PreparedSQL to the byte
+// offset in SourceSQL. An offset in a param, like $1, converts to the start of
+// the arg that the param replaced.
+func (q *SourceQuery) SourceOffset(preparedOffset int) int {
+	delta := 0
+	for _, span := range q.ArgSpans {
+		switch {
+		case preparedOffset < span.PreparedLo:
+			return preparedOffset + delta
+		case preparedOffset < span.PreparedHi:
+			return span.SourceLo
+		}
+		delta = span.SourceHi - span.PreparedHi
+	}
+	return preparedOffset + delta
+}
+
 // ----------------------------------------------------------------------------
 // Files and packages
 
@@ -17,6 +17,14 @@ type Declarer interface {
 	Declare(pkgPath string) (string, error)
 }
 
+// ImportDeclarer is a Declarer whose declaration needs imports beyond the
+// imports the leader file always has.
+type ImportDeclarer interface {
+	Declarer
+	// Imports returns the fully qualified package paths the declaration uses.
+	Imports() []string
+}
+
 // DeclarerSet is a set of declarers, identified by the dedupe key.
 type DeclarerSet map[string]Declarer
 
@@ -144,6 +152,35 @@ func findOutputDeclsHelper(typ gotype.Type, decls DeclarerSet, hadCompositeParen
 	}
 }
 
+// FindPgx5Declarers finds all necessary Declarers for types that appear in
+// the input parameters or output rows with pgx v5. pgx v5 encodes and decodes
+// composite and enum types with the codecs registered by RegisterTypes, so
+// only the Go types need declarations. Returns nil if no declarers are needed.
+func FindPgx5Declarers(typ gotype.Type) DeclarerSet {
+	decls := NewDeclarerSet()
+	findPgx5DeclsHelper(typ, decls)
+	return decls
+}
+
+func findPgx5DeclsHelper(typ gotype.Type, decls DeclarerSet) {
+	switch typ := gotype.UnwrapNestedType(typ).(type) {
+	case *gotype.EnumType:
+		decls.AddAll(NewEnumTypeDeclarer(typ))
+
+	case *gotype.CompositeType:
+		decls.AddAll(NewCompositeTypeDeclarer(typ))
+		for _, childType := range typ.FieldTypes {
+			findPgx5DeclsHelper(childType, decls)
+		}
+
+	case *gotype.ArrayType:
+		findPgx5DeclsHelper(typ.Elem, decls)
+
+	default:
+		return
+	}
+}
+
 // ConstantDeclarer declares a new string literal.
 type ConstantDeclarer struct {
 	key string
@@ -246,3 +283,22 @@ func (tr *typeResolver) newArrayValue(name, elemName string, defaultVal func() p
 func NewTypeResolverDeclarer() ConstantDeclarer {
 	return NewConstantDeclarer("type_resolver::01_common", typeResolverBodyDecl)
 }
+
+const unexpectedRowsErrorDecl = `// UnexpectedRowsError is returned by a query with the expect-rows pragma when
+// the query affects a different number of rows than expected. The query's
+// changes are not rolled back; run the query in a transaction to undo them.
+type UnexpectedRowsError struct {
+	Query    string // name of the query, like "DeleteAuthor"
+	Expected int64  // rows the query must affect, from the expect-rows pragma
+	Actual   int64  // rows the query affected
+}
+
+func (e *UnexpectedRowsError) Error() string {
+	return fmt.Sprintf("query %s affected %d rows; expected %d", e.Query, e.Actual, e.Expected)
+}`
+
+// NewUnexpectedRowsErrorDeclarer declares the error returned by queries with
+// the expect-rows pragma.
+func NewUnexpectedRowsErrorDeclarer() ConstantDeclarer {
+	return NewConstantDeclarer("unexpected_rows_error", unexpectedRowsErrorDecl)
+}
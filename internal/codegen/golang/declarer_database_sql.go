@@ -0,0 +1,488 @@
+package golang
+
+import (
+	"github.com/atomicleads/pggen/internal/codegen/golang/gotype"
+	"strconv"
+	"strings"
+)
+
+// NameArrayScannerFunc returns the name of the function that creates a
+// sql.Scanner for a slice of enums or composite types.
+func NameArrayScannerFunc(typ *gotype.ArrayType) string {
+	return "scan" + databaseSQLArrayElemName(typ) + "Array"
+}
+
+// NameArrayValuerFunc returns the name of the function that creates a
+// driver.Valuer for a slice of enums or composite types.
+func NameArrayValuerFunc(typ *gotype.ArrayType) string {
+	return "value" + databaseSQLArrayElemName(typ) + "Array"
+}
+
+func databaseSQLArrayElemName(typ *gotype.ArrayType) string {
+	name := gotype.UnwrapNestedType(typ.Elem).BaseName()
+	if strings.HasPrefix(typ.Elem.BaseName(), "*") {
+		name += "Ptr"
+	}
+	return name
+}
+
+// isDatabaseSQLArray returns true if the array type needs a generated
+// sql.Scanner and driver.Valuer because the elements are enums or composite
+// types. Slices don't implement either interface.
+func isDatabaseSQLArray(typ *gotype.ArrayType) bool {
+	switch gotype.UnwrapNestedType(typ.Elem).(type) {
+	case *gotype.EnumType, *gotype.CompositeType:
+		return true
+	default:
+		return false
+	}
+}
+
+// FindDatabaseSQLDeclarers finds all necessary Declarers for types that appear
+// in the input parameters or output rows with database/sql. Enums and
+// composite types implement sql.Scanner and driver.Valuer, so the same
+// declarations work for inputs and outputs. Returns nil if no declarers are
+// needed.
+func FindDatabaseSQLDeclarers(typ gotype.Type) DeclarerSet {
+	decls := NewDeclarerSet()
+	findDatabaseSQLDeclsHelper(typ, decls)
+	return decls
+}
+
+func findDatabaseSQLDeclsHelper(typ gotype.Type, decls DeclarerSet) {
+	switch typ := gotype.UnwrapNestedType(typ).(type) {
+	case *gotype.EnumType:
+		decls.AddAll(
+			NewEnumTypeDeclarer(typ),
+			NewEnumScannerDeclarer(typ),
+			newDatabaseSQLHelperDeclarer(),
+		)
+
+	case *gotype.CompositeType:
+		decls.AddAll(
+			NewCompositeTypeDeclarer(typ),
+			NewCompositeScannerDeclarer(typ),
+			newDatabaseSQLHelperDeclarer(),
+			newDatabaseSQLCompositeHelperDeclarer(),
+		)
+		for _, childType := range typ.FieldTypes {
+			findDatabaseSQLDeclsHelper(childType, decls)
+		}
+
+	case *gotype.ArrayType:
+		if isDatabaseSQLArray(typ) {
+			decls.AddAll(
+				NewArrayScannerDeclarer(typ),
+				newDatabaseSQLHelperDeclarer(),
+				newDatabaseSQLArrayHelperDeclarer(),
+			)
+		}
+		findDatabaseSQLDeclsHelper(typ.Elem, decls)
+
+	default:
+		return
+	}
+}
+
+// EnumScannerDeclarer declares the sql.Scanner and driver.Valuer methods for
+// a Go enum.
+type EnumScannerDeclarer struct {
+	enum *gotype.EnumType
+}
+
+func NewEnumScannerDeclarer(enum *gotype.EnumType) EnumScannerDeclarer {
+	return EnumScannerDeclarer{enum: enum}
+}
+
+func (e EnumScannerDeclarer) DedupeKey() string {
+	return "enum_type::" + e.enum.Name + "::database_sql"
+}
+
+func (e EnumScannerDeclarer) Imports() []string {
+	return []string{"database/sql/driver"}
+}
+
+func (e EnumScannerDeclarer) Declare(string) (string, error) {
+	sb := &strings.Builder{}
+	dispatcher := strings.ToLower(e.enum.Name)[0]
+	// Scan
+	sb.WriteString("// Scan implements sql.Scanner.\n")
+	sb.WriteString("func (")
+	sb.WriteByte(dispatcher)
+	sb.WriteString(" *")
+	sb.WriteString(e.enum.Name)
+	sb.WriteString(") Scan(src interface{}) error {\n")
+	sb.WriteString("\tbuf, err := textBytes(src)\n")
+	sb.WriteString("\tif err != nil {\n")
+	sb.WriteString("\t\treturn err\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\tif buf == nil {\n")
+	sb.WriteString("\t\treturn fmt.Errorf(")
+	sb.WriteString(strconv.Quote("cannot scan NULL into " + e.enum.Name))
+	sb.WriteString(")\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\t*")
+	sb.WriteByte(dispatcher)
+	sb.WriteString(" = ")
+	sb.WriteString(e.enum.Name)
+	sb.WriteString("(buf)\n")
+	sb.WriteString("\treturn nil\n")
+	sb.WriteString("}\n\n")
+	// Value
+	sb.WriteString("// Value implements driver.Valuer.\n")
+	sb.WriteString("func (")
+	sb.WriteByte(dispatcher)
+	sb.WriteByte(' ')
+	sb.WriteString(e.enum.Name)
+	sb.WriteString(") Value() (driver.Value, error) { return string(")
+	sb.WriteByte(dispatcher)
+	sb.WriteString("), nil }")
+	return sb.String(), nil
+}
+
+// CompositeScannerDeclarer declares the sql.Scanner and driver.Valuer methods
+// for a Go struct that represents a Postgres composite type. Both methods use
+// the Postgres text format because database/sql drivers return unknown types
+// as text.
+type CompositeScannerDeclarer struct {
+	comp *gotype.CompositeType
+}
+
+func NewCompositeScannerDeclarer(comp *gotype.CompositeType) CompositeScannerDeclarer {
+	return CompositeScannerDeclarer{comp: comp}
+}
+
+func (c CompositeScannerDeclarer) DedupeKey() string {
+	return "composite::" + c.comp.Name + "::database_sql"
+}
+
+// Imports returns the packages of the field types in addition to the packages
+// for the methods. The leader file declares the struct but might not use the
+// composite type in any query.
+func (c CompositeScannerDeclarer) Imports() []string {
+	imports := NewImportSet()
+	imports.AddPackage("database/sql/driver")
+	imports.AddType(c.comp)
+	return imports.SortedPackages()
+}
+
+func (c CompositeScannerDeclarer) Declare(string) (string, error) {
+	sb := &strings.Builder{}
+	dispatcher := string(strings.ToLower(c.comp.Name)[0])
+	// Scan
+	sb.WriteString("// Scan implements sql.Scanner.\n")
+	sb.WriteString("func (")
+	sb.WriteString(dispatcher)
+	sb.WriteString(" *")
+	sb.WriteString(c.comp.Name)
+	sb.WriteString(") Scan(src interface{}) error {\n")
+	sb.WriteString("\treturn scanComposite(src")
+	for i, fieldType := range c.comp.FieldTypes {
+		sb.WriteString(", ")
+		dest := "&" + dispatcher + "." + c.comp.FieldNames[i]
+		if typ, ok := gotype.UnwrapNestedType(fieldType).(*gotype.ArrayType); ok && isDatabaseSQLArray(typ) {
+			dest = NameArrayScannerFunc(typ) + "(" + dest + ")"
+		}
+		sb.WriteString(dest)
+	}
+	sb.WriteString(")\n")
+	sb.WriteString("}\n\n")
+	// Value
+	sb.WriteString("// Value implements driver.Valuer.\n")
+	sb.WriteString("func (")
+	sb.WriteString(dispatcher)
+	sb.WriteByte(' ')
+	sb.WriteString(c.comp.Name)
+	sb.WriteString(") Value() (driver.Value, error) {\n")
+	sb.WriteString("\treturn valueComposite(")
+	for i, fieldType := range c.comp.FieldTypes {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		val := dispatcher + "." + c.comp.FieldNames[i]
+		if typ, ok := gotype.UnwrapNestedType(fieldType).(*gotype.ArrayType); ok && isDatabaseSQLArray(typ) {
+			val = NameArrayValuerFunc(typ) + "(" + val + ")"
+		}
+		sb.WriteString(val)
+	}
+	sb.WriteString(")\n")
+	sb.WriteString("}")
+	return sb.String(), nil
+}
+
+// ArrayScannerDeclarer declares the functions that create a sql.Scanner and a
+// driver.Valuer for a slice of enums or composite types.
+type ArrayScannerDeclarer struct {
+	typ *gotype.ArrayType
+}
+
+func NewArrayScannerDeclarer(typ *gotype.ArrayType) ArrayScannerDeclarer {
+	return ArrayScannerDeclarer{typ: typ}
+}
+
+func (a ArrayScannerDeclarer) DedupeKey() string {
+	return "array_database_sql::" + databaseSQLArrayElemName(a.typ)
+}
+
+func (a ArrayScannerDeclarer) Imports() []string {
+	return []string{"database/sql", "database/sql/driver"}
+}
+
+func (a ArrayScannerDeclarer) Declare(pkgPath string) (string, error) {
+	sb := &strings.Builder{}
+	scanFunc := NameArrayScannerFunc(a.typ)
+	valueFunc := NameArrayValuerFunc(a.typ)
+	sliceType := gotype.QualifyType(a.typ, pkgPath)
+	elemType := gotype.QualifyType(a.typ.Elem, pkgPath)
+	isPtr := strings.HasPrefix(elemType, "*")
+
+	// Scanner
+	sb.WriteString("// ")
+	sb.WriteString(scanFunc)
+	sb.WriteString(" returns a sql.Scanner that scans a Postgres array into dst.\n")
+	sb.WriteString("func ")
+	sb.WriteString(scanFunc)
+	sb.WriteString("(dst *")
+	sb.WriteString(sliceType)
+	sb.WriteString(") sql.Scanner {\n")
+	sb.WriteString("\treturn arrayScanner(func(elems []interface{}) error {\n")
+	sb.WriteString("\t\tif elems == nil {\n")
+	sb.WriteString("\t\t\t*dst = nil\n")
+	sb.WriteString("\t\t\treturn nil\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\tvs := make(")
+	sb.WriteString(sliceType)
+	sb.WriteString(", len(elems))\n")
+	sb.WriteString("\t\tfor i, elem := range elems {\n")
+	if isPtr {
+		sb.WriteString("\t\t\tif elem == nil {\n")
+		sb.WriteString("\t\t\t\tcontinue\n")
+		sb.WriteString("\t\t\t}\n")
+		sb.WriteString("\t\t\tvs[i] = new(")
+		sb.WriteString(strings.TrimPrefix(elemType, "*"))
+		sb.WriteString(")\n")
+	}
+	sb.WriteString("\t\t\tif err := vs[i].Scan(elem); err != nil {\n")
+	sb.WriteString("\t\t\t\treturn fmt.Errorf(\"scan array element %d: %w\", i, err)\n")
+	sb.WriteString("\t\t\t}\n")
+	sb.WriteString("\t\t}\n")
+	sb.WriteString("\t\t*dst = vs\n")
+	sb.WriteString("\t\treturn nil\n")
+	sb.WriteString("\t})\n")
+	sb.WriteString("}\n\n")
+
+	// Valuer
+	sb.WriteString("// ")
+	sb.WriteString(valueFunc)
+	sb.WriteString(" returns a driver.Valuer that encodes vs as a Postgres array.\n")
+	sb.WriteString("func ")
+	sb.WriteString(valueFunc)
+	sb.WriteString("(vs ")
+	sb.WriteString(sliceType)
+	sb.WriteString(") driver.Valuer {\n")
+	sb.WriteString("\tif vs == nil {\n")
+	sb.WriteString("\t\treturn arrayValuer(nil)\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\telems := make(arrayValuer, len(vs))\n")
+	sb.WriteString("\tfor i, v := range vs {\n")
+	sb.WriteString("\t\telems[i] = v\n")
+	sb.WriteString("\t}\n")
+	sb.WriteString("\treturn elems\n")
+	sb.WriteString("}")
+	return sb.String(), nil
+}
+
+// databaseSQLHelperDeclarer declares a constant string literal that needs
+// imports beyond the imports of every database/sql leader file.
+type databaseSQLHelperDeclarer struct {
+	ConstantDeclarer
+	imports []string
+}
+
+func (d databaseSQLHelperDeclarer) Imports() []string { return d.imports }
+
+const databaseSQLHelperDecl = `// textBytes returns the text representation of src, a value from a
+// database/sql driver, or nil if src is NULL.
+func textBytes(src interface{}) ([]byte, error) {
+	switch src := src.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(src), nil
+	case []byte:
+		return src, nil
+	default:
+		return nil, fmt.Errorf("cannot scan %T; want text", src)
+	}
+}
+
+// textValue encodes v, any value accepted by database/sql as a query
+// argument, in the Postgres text format. Returns false if v is NULL.
+func textValue(v interface{}) (string, bool, error) {
+	v, err := driver.DefaultParameterConverter.ConvertValue(v)
+	if err != nil {
+		return "", false, err
+	}
+	switch v := v.(type) {
+	case nil:
+		return "", false, nil
+	case string:
+		return v, true, nil
+	case []byte:
+		return "\\x" + hex.EncodeToString(v), true, nil
+	case int64:
+		return strconv.FormatInt(v, 10), true, nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), true, nil
+	case bool:
+		return strconv.FormatBool(v), true, nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), true, nil
+	default:
+		return "", false, fmt.Errorf("cannot encode %T", v)
+	}
+}
+
+// quoteText quotes s as an element of a Postgres array or composite type in
+// text format.
+func quoteText(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "\"", "\\\"")
+	return "\"" + s + "\""
+}`
+
+// newDatabaseSQLHelperDeclarer declares the text format helpers used by all
+// generated sql.Scanner and driver.Valuer implementations.
+func newDatabaseSQLHelperDeclarer() databaseSQLHelperDeclarer {
+	return databaseSQLHelperDeclarer{
+		ConstantDeclarer: NewConstantDeclarer("database_sql::00_common", databaseSQLHelperDecl),
+		imports:          []string{"database/sql/driver", "encoding/hex", "strconv", "strings", "time"},
+	}
+}
+
+const databaseSQLCompositeHelperDecl = `// scanComposite scans src, a Postgres composite type in text format, into
+// fields. Each field must implement sql.Scanner.
+func scanComposite(src interface{}, fields ...interface{}) error {
+	buf, err := textBytes(src)
+	if err != nil {
+		return err
+	}
+	if buf == nil {
+		return fmt.Errorf("cannot scan NULL into composite type")
+	}
+	scanner := pgtype.NewCompositeTextScanner(nil, buf)
+	for i, field := range fields {
+		if !scanner.Next() {
+			if err := scanner.Err(); err != nil {
+				return fmt.Errorf("scan composite field %d: %w", i, err)
+			}
+			return fmt.Errorf("composite type has %d fields; want %d", i, len(fields))
+		}
+		fieldScanner, ok := field.(sql.Scanner)
+		if !ok {
+			return fmt.Errorf("scan composite field %d: %T does not implement sql.Scanner", i, field)
+		}
+		var fieldSrc interface{}
+		if b := scanner.Bytes(); b != nil {
+			fieldSrc = string(b)
+		}
+		if err := fieldScanner.Scan(fieldSrc); err != nil {
+			return fmt.Errorf("scan composite field %d: %w", i, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// valueComposite encodes fields as a Postgres composite type in text format.
+func valueComposite(fields ...interface{}) (driver.Value, error) {
+	sb := &strings.Builder{}
+	sb.WriteByte('(')
+	for i, field := range fields {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		s, ok, err := textValue(field)
+		if err != nil {
+			return nil, fmt.Errorf("encode composite field %d: %w", i, err)
+		}
+		if ok {
+			sb.WriteString(quoteText(s)) // an empty, unquoted field is NULL
+		}
+	}
+	sb.WriteByte(')')
+	return sb.String(), nil
+}`
+
+// newDatabaseSQLCompositeHelperDeclarer declares the helpers used by the
+// sql.Scanner and driver.Valuer methods of composite types.
+func newDatabaseSQLCompositeHelperDeclarer() databaseSQLHelperDeclarer {
+	return databaseSQLHelperDeclarer{
+		ConstantDeclarer: NewConstantDeclarer("database_sql::01_composite", databaseSQLCompositeHelperDecl),
+		imports:          []string{"database/sql", "database/sql/driver", "github.com/jackc/pgtype", "strings"},
+	}
+}
+
+const databaseSQLArrayHelperDecl = `// arrayScanner is a sql.Scanner for a Postgres array in text format. The func
+// receives each element as a string, or nil for a NULL element, and a nil
+// slice if the array is NULL.
+type arrayScanner func(elems []interface{}) error
+
+func (a arrayScanner) Scan(src interface{}) error {
+	buf, err := textBytes(src)
+	if err != nil {
+		return err
+	}
+	if buf == nil {
+		return a(nil)
+	}
+	arr, err := pgtype.ParseUntypedTextArray(string(buf))
+	if err != nil {
+		return fmt.Errorf("scan array: %w", err)
+	}
+	elems := make([]interface{}, len(arr.Elements))
+	for i, elem := range arr.Elements {
+		if elem == "NULL" && !arr.Quoted[i] {
+			continue
+		}
+		elems[i] = elem
+	}
+	return a(elems)
+}
+
+// arrayValuer is a driver.Valuer that encodes the elements as a
+// one-dimensional Postgres array in text format. A nil arrayValuer encodes as
+// NULL.
+type arrayValuer []interface{}
+
+func (a arrayValuer) Value() (driver.Value, error) {
+	if a == nil {
+		return nil, nil
+	}
+	sb := &strings.Builder{}
+	sb.WriteByte('{')
+	for i, elem := range a {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		s, ok, err := textValue(elem)
+		if err != nil {
+			return nil, fmt.Errorf("encode array element %d: %w", i, err)
+		}
+		if !ok {
+			sb.WriteString("NULL")
+			continue
+		}
+		sb.WriteString(quoteText(s))
+	}
+	sb.WriteByte('}')
+	return sb.String(), nil
+}`
+
+// newDatabaseSQLArrayHelperDeclarer declares the helpers used by the
+// sql.Scanner and driver.Valuer for slices of enums or composite types.
+func newDatabaseSQLArrayHelperDeclarer() databaseSQLHelperDeclarer {
+	return databaseSQLHelperDeclarer{
+		ConstantDeclarer: NewConstantDeclarer("database_sql::02_array", databaseSQLArrayHelperDecl),
+		imports:          []string{"database/sql/driver", "github.com/jackc/pgtype", "strings"},
+	}
+}
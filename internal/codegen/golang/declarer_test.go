@@ -180,5 +180,32 @@ func TestDeclarers(t *testing.T) {
 
 			difftest.AssertSame(t, string(want), got)
 		})
+
+		t.Run(tt.name+"_database_sql", func(t *testing.T) {
+			golden := "testdata/declarer_" + tt.name + ".database_sql.golden"
+			decls := FindDatabaseSQLDeclarers(tt.typ).ListAll()
+			sb := &strings.Builder{}
+			for i, decl := range decls {
+				s, err := decl.Declare(tt.pkgPath)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sb.WriteString(s)
+				if i < len(decls)-1 {
+					sb.WriteString("\n\n")
+				}
+			}
+			got := sb.String()
+
+			if *update {
+				err := os.WriteFile(golden, []byte(got), 0644)
+				require.NoError(t, err)
+				return
+			}
+
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			difftest.AssertSame(t, string(want), got)
+		})
 	}
 }
@@ -1,15 +1,21 @@
 package golang
 
 import (
+	"bytes"
 	"fmt"
-	"github.com/atomicleads/pggen/internal/errs"
 	"os"
 	"path/filepath"
 	"strconv"
 	"text/template"
 )
 
-// Emitter writes a templated query file to a file.
+// GeneratedFile is the Go code generated for a single query file.
+type GeneratedFile struct {
+	Path     string // path of the output file, like "author/query.sql.go"
+	Contents []byte
+}
+
+// Emitter renders templated query files into Go code.
 type Emitter struct {
 	outDir string
 	tmpl   *template.Template
@@ -19,14 +25,28 @@ func NewEmitter(outDir string, tmpl *template.Template) Emitter {
 	return Emitter{outDir: outDir, tmpl: tmpl}
 }
 
-// EmitAllQueryFiles emits a query file for each TemplatedFile. Ensure that
-// emitted files don't clash by prefixing with the parent directory if
-// necessary.
-func (em Emitter) EmitAllQueryFiles(tfs []TemplatedFile) (mErr error) {
+// RenderAllQueryFiles renders a query file for each TemplatedFile without
+// writing the files. Ensure that rendered files don't clash by prefixing with
+// the parent directory if necessary.
+func (em Emitter) RenderAllQueryFiles(tfs []TemplatedFile) ([]GeneratedFile, error) {
 	outs := em.chooseOutputFiles(tfs)
+	files := make([]GeneratedFile, len(tfs))
 	for i, tf := range tfs {
-		if err := em.emitQueryFile(outs[i], tf); err != nil {
-			return err
+		out := filepath.Join(em.outDir, outs[i])
+		buf := &bytes.Buffer{}
+		if err := em.tmpl.ExecuteTemplate(buf, tf.templateName(), tf); err != nil {
+			return nil, fmt.Errorf("execute generated query file template %s: %w", out, err)
+		}
+		files[i] = GeneratedFile{Path: out, Contents: buf.Bytes()}
+	}
+	return files, nil
+}
+
+// WriteFiles writes each generated file, replacing existing files.
+func WriteFiles(files []GeneratedFile) error {
+	for _, file := range files {
+		if err := os.WriteFile(file.Path, file.Contents, 0644); err != nil {
+			return fmt.Errorf("write generated query file: %w", err)
 		}
 	}
 	return nil
@@ -92,17 +112,3 @@ func (em Emitter) chooseOutputFiles(tfs []TemplatedFile) []string {
 	}
 	return outNames
 }
-
-// emitQueryFile emits a single query file.
-func (em Emitter) emitQueryFile(outRelPath string, tf TemplatedFile) (mErr error) {
-	out := filepath.Join(em.outDir, outRelPath)
-	file, err := os.OpenFile(out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
-	defer errs.Capture(&mErr, file.Close, "close emit query file")
-	if err != nil {
-		return fmt.Errorf("open generated query file for writing: %w", err)
-	}
-	if err := em.tmpl.ExecuteTemplate(file, "gen_query", tf); err != nil {
-		return fmt.Errorf("execute generated query file template %s: %w", out, err)
-	}
-	return nil
-}
@@ -10,6 +10,33 @@ import (
 	"text/template"
 )
 
+// Instrumentation controls how the generated querier reports the queries it
+// runs.
+type Instrumentation string
+
+const (
+	// InstrumentationNone generates a querier without any instrumentation.
+	InstrumentationNone Instrumentation = "none"
+	// InstrumentationHooks generates a QueryHooks interface that the querier
+	// calls before and after each query.
+	InstrumentationHooks Instrumentation = "hooks"
+	// InstrumentationOTel generates the same code as InstrumentationHooks plus
+	// a QueryHooks implementation that records OpenTelemetry spans.
+	InstrumentationOTel Instrumentation = "otel"
+)
+
+// PgxVersion is the major version of pgx used by the generated code.
+type PgxVersion int
+
+const (
+	// PgxV4 generates code for github.com/jackc/pgx/v4 using the pgtype
+	// ValueTranscoder types from github.com/jackc/pgtype.
+	PgxV4 PgxVersion = 4
+	// PgxV5 generates code for github.com/jackc/pgx/v5 using the codecs
+	// registered in a pgtype.Map.
+	PgxV5 PgxVersion = 5
+)
+
 // GenerateOptions are options to control generated Go output.
 type GenerateOptions struct {
 	GoPkg     string
@@ -22,25 +49,82 @@ type GenerateOptions struct {
 	// How many params to inline when calling querier methods.
 	// Set to 0 to always create a struct for params.
 	InlineParamCount int
+	// How the generated querier reports queries. Defaults to
+	// InstrumentationNone if empty.
+	Instrumentation Instrumentation
+	// The major version of pgx used by the generated code. Defaults to PgxV4
+	// if zero.
+	PgxVersion PgxVersion
+	// Generate a querier backed by database/sql instead of pgx. Uses the
+	// pgtype types from pgx v4 for Postgres types without a database/sql
+	// equivalent, so PgxVersion must not be PgxV5.
+	DatabaseSQL bool
+	// A map from a protobuf package, like "erp.api", to the Go package path of
+	// the code generated by protoc-gen-go. Queries with the proto-type pragma
+	// return the Go struct of the message.
+	ProtoPackages map[string]string
 }
 
 // Generate emits generated Go files for each of the queryFiles.
 func Generate(opts GenerateOptions, queryFiles []codegen.QueryFile) error {
+	files, err := Render(opts, queryFiles)
+	if err != nil {
+		return err
+	}
+	if err := WriteFiles(files); err != nil {
+		return fmt.Errorf("emit generated Go code: %w", err)
+	}
+	return nil
+}
+
+// Render generates the Go files for each of the queryFiles without writing
+// them to the output directory.
+func Render(opts GenerateOptions, queryFiles []codegen.QueryFile) ([]GeneratedFile, error) {
 	pkgName := opts.GoPkg
 	if pkgName == "" {
 		pkgName = filepath.Base(opts.OutputDir)
 	}
+	instrumentation := opts.Instrumentation
+	switch instrumentation {
+	case "":
+		instrumentation = InstrumentationNone
+	case InstrumentationNone, InstrumentationHooks, InstrumentationOTel:
+		break // okay
+	default:
+		return nil, fmt.Errorf("unsupported instrumentation %q", instrumentation)
+	}
+	pgxVersion := opts.PgxVersion
+	switch pgxVersion {
+	case 0:
+		pgxVersion = PgxV4
+	case PgxV4, PgxV5:
+		break // okay
+	default:
+		return nil, fmt.Errorf("unsupported pgx version %d", pgxVersion)
+	}
+	if opts.DatabaseSQL && pgxVersion != PgxV4 {
+		return nil, fmt.Errorf("database/sql output requires pgx version 4 types; got pgx version %d", pgxVersion)
+	}
 	caser := casing.NewCaser()
 	caser.AddAcronyms(opts.Acronyms)
+	resolver := NewTypeResolver(caser, opts.TypeOverrides, pgxVersion)
+	if opts.DatabaseSQL {
+		resolver = NewDatabaseSQLTypeResolver(caser, opts.TypeOverrides)
+	}
 	templater := NewTemplater(TemplaterOpts{
 		Caser:            caser,
-		Resolver:         NewTypeResolver(caser, opts.TypeOverrides),
+		Resolver:         resolver,
 		Pkg:              pkgName,
 		InlineParamCount: opts.InlineParamCount,
+		Instrumentation:  instrumentation,
+		PgxVersion:       pgxVersion,
+		DatabaseSQL:      opts.DatabaseSQL,
+		ProtoPackages:    opts.ProtoPackages,
+		WorkDir:          opts.OutputDir,
 	})
 	templatedFiles, err := templater.TemplateAll(queryFiles)
 	if err != nil {
-		return fmt.Errorf("template all: %w", err)
+		return nil, fmt.Errorf("template all: %w", err)
 	}
 
 	// Order for reproducible results.
@@ -57,22 +141,29 @@ func Generate(opts GenerateOptions, queryFiles []codegen.QueryFile) error {
 
 	tmpl, err := parseQueryTemplate()
 	if err != nil {
-		return fmt.Errorf("parse generated Go code template: %w", err)
+		return nil, fmt.Errorf("parse generated Go code template: %w", err)
 	}
 	emitter := NewEmitter(opts.OutputDir, tmpl)
-	if err := emitter.EmitAllQueryFiles(templatedFiles); err != nil {
-		return fmt.Errorf("emit generated Go code: %w", err)
+	files, err := emitter.RenderAllQueryFiles(templatedFiles)
+	if err != nil {
+		return nil, fmt.Errorf("render generated Go code: %w", err)
 	}
-	return nil
+	return files, nil
 }
 
 //go:embed query.gotemplate
 var queryTemplate string
 
+//go:embed query_database_sql.gotemplate
+var queryDatabaseSQLTemplate string
+
 func parseQueryTemplate() (*template.Template, error) {
 	tmpl, err := template.New("gen_query").Parse(queryTemplate)
 	if err != nil {
 		return nil, fmt.Errorf("parse query.gotemplate: %w", err)
 	}
+	if _, err := tmpl.New("gen_query_database_sql").Parse(queryDatabaseSQLTemplate); err != nil {
+		return nil, fmt.Errorf("parse query_database_sql.gotemplate: %w", err)
+	}
 	return tmpl, nil
 }
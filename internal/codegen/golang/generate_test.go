@@ -0,0 +1,205 @@
+package golang
+
+import (
+	"github.com/atomicleads/pggen/internal/ast"
+	"github.com/atomicleads/pggen/internal/codegen"
+	"github.com/atomicleads/pggen/internal/difftest"
+	"github.com/atomicleads/pggen/internal/pg"
+	"github.com/atomicleads/pggen/internal/pginfer"
+	"github.com/stretchr/testify/require"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// Enum and composite types and the expect-rows pragma used by renderQueries.
+// renderStatusType has a mixed-case name in a schema besides public.
+var (
+	renderDeviceType = pg.EnumType{
+		ID:            16400,
+		Name:          "device_type",
+		QualifiedName: "public.device_type",
+		Labels:        []string{"phone", "laptop"},
+		Orders:        []float32{1, 2},
+	}
+	renderStatusType = pg.EnumType{
+		ID:            16405,
+		Name:          "DeviceStatus",
+		QualifiedName: `"Inventory"."DeviceStatus"`,
+		Labels:        []string{"active", "retired"},
+		Orders:        []float32{1, 2},
+	}
+	renderUserType = pg.CompositeType{
+		ID:            16410,
+		Name:          "user",
+		QualifiedName: `public."user"`,
+		ColumnNames:   []string{"id", "name"},
+		ColumnTypes:   []pg.Type{pg.Int8, pg.Text},
+	}
+	renderExpectOneRow = int64(1)
+)
+
+// renderQueries are the queries rendered for each output mode in TestRender.
+var renderQueries = []pginfer.TypedQuery{
+	{
+		Name:        "FindAuthorByID",
+		ResultKind:  ast.ResultKindOne,
+		Doc:         []string{"FindAuthorByID finds one author by ID."},
+		PreparedSQL: "SELECT author_id, first_name, suffix FROM author WHERE author_id = $1;",
+		Inputs: []pginfer.InputParam{
+			{PgName: "author_id", PgType: pg.Int4},
+		},
+		Outputs: []pginfer.OutputColumn{
+			{PgName: "author_id", PgType: pg.Int4, Nullable: false},
+			{PgName: "first_name", PgType: pg.Text, Nullable: false},
+			{PgName: "suffix", PgType: pg.Text, Nullable: true},
+		},
+	},
+	{
+		Name:        "FindOptionalAuthorByID",
+		ResultKind:  ast.ResultKindOpt,
+		PreparedSQL: "SELECT author_id, first_name FROM author WHERE author_id = $1;",
+		Inputs: []pginfer.InputParam{
+			{PgName: "author_id", PgType: pg.Int4},
+		},
+		Outputs: []pginfer.OutputColumn{
+			{PgName: "author_id", PgType: pg.Int4, Nullable: false},
+			{PgName: "first_name", PgType: pg.Text, Nullable: false},
+		},
+	},
+	{
+		Name:        "FindOptionalFirstName",
+		ResultKind:  ast.ResultKindOpt,
+		PreparedSQL: "SELECT first_name FROM author WHERE author_id = $1;",
+		Inputs: []pginfer.InputParam{
+			{PgName: "author_id", PgType: pg.Int4},
+		},
+		Outputs: []pginfer.OutputColumn{
+			{PgName: "first_name", PgType: pg.Text, Nullable: false},
+		},
+	},
+	{
+		Name:        "FindAuthorNames",
+		ResultKind:  ast.ResultKindMany,
+		PreparedSQL: "SELECT first_name FROM author WHERE last_name = $1 ORDER BY author_id;",
+		Inputs: []pginfer.InputParam{
+			{PgName: "last_name", PgType: pg.Text},
+		},
+		Outputs: []pginfer.OutputColumn{
+			{PgName: "first_name", PgType: pg.Text, Nullable: false},
+		},
+	},
+	{
+		Name:        "FindDevices",
+		ResultKind:  ast.ResultKindMany,
+		PreparedSQL: "SELECT type, status, owner FROM device;",
+		Outputs: []pginfer.OutputColumn{
+			{PgName: "type", PgType: renderDeviceType, Nullable: false},
+			{PgName: "status", PgType: renderStatusType, Nullable: false},
+			{PgName: "owner", PgType: renderUserType, Nullable: true},
+		},
+	},
+	{
+		Name:        "DeleteAuthors",
+		ResultKind:  ast.ResultKindExec,
+		PreparedSQL: "DELETE FROM author WHERE first_name = $1 AND last_name = $2;",
+		Inputs: []pginfer.InputParam{
+			{PgName: "first_name", PgType: pg.Text},
+			{PgName: "last_name", PgType: pg.Text},
+		},
+	},
+	{
+		Name:        "DeleteAuthorsByLastName",
+		ResultKind:  ast.ResultKindExecRows,
+		PreparedSQL: "DELETE FROM author WHERE last_name = $1;",
+		Inputs: []pginfer.InputParam{
+			{PgName: "last_name", PgType: pg.Text},
+		},
+	},
+	{
+		Name:        "UpdateAuthorSuffix",
+		ResultKind:  ast.ResultKindExecRows,
+		ExpectRows:  &renderExpectOneRow,
+		PreparedSQL: "UPDATE author SET suffix = $1 WHERE author_id = $2;",
+		Inputs: []pginfer.InputParam{
+			{PgName: "suffix", PgType: pg.Text, Nullable: true},
+			{PgName: "author_id", PgType: pg.Int4},
+		},
+	},
+	{
+		Name:        "DeleteAuthorByID",
+		ResultKind:  ast.ResultKindExec,
+		ExpectRows:  &renderExpectOneRow,
+		PreparedSQL: "DELETE FROM author WHERE author_id = $1;",
+		Inputs: []pginfer.InputParam{
+			{PgName: "author_id", PgType: pg.Int4},
+		},
+	},
+}
+
+// TestRender renders renderQueries for each output mode, compares the
+// generated code to the golden file, and compiles the golden file.
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name string
+		opts GenerateOptions
+	}{
+		{name: "pgx4"},
+		{name: "pgx4_hooks", opts: GenerateOptions{Instrumentation: InstrumentationHooks}},
+		{name: "pgx4_otel", opts: GenerateOptions{Instrumentation: InstrumentationOTel}},
+		{name: "pgx5", opts: GenerateOptions{PgxVersion: PgxV5}},
+		{name: "pgx5_hooks", opts: GenerateOptions{PgxVersion: PgxV5, Instrumentation: InstrumentationHooks}},
+		{name: "database_sql", opts: GenerateOptions{DatabaseSQL: true}},
+		{name: "database_sql_hooks", opts: GenerateOptions{DatabaseSQL: true, Instrumentation: InstrumentationHooks}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join("testdata", "render", tt.name)
+			opts := tt.opts
+			opts.GoPkg = tt.name
+			opts.OutputDir = dir
+			opts.InlineParamCount = 2
+			queryFiles := []codegen.QueryFile{{SourcePath: "query.sql", Queries: renderQueries}}
+			files, err := Render(opts, queryFiles)
+			require.NoError(t, err)
+
+			for _, file := range files {
+				if *update {
+					require.NoError(t, os.MkdirAll(dir, 0755))
+					require.NoError(t, os.WriteFile(file.Path, file.Contents, 0644))
+					continue
+				}
+				want, err := os.ReadFile(file.Path)
+				require.NoError(t, err)
+				difftest.AssertSame(t, string(want), string(file.Contents))
+			}
+
+			if testing.Short() {
+				return
+			}
+			out, err := exec.Command("go", "build", "./"+filepath.ToSlash(dir)).CombinedOutput()
+			if err != nil {
+				t.Fatalf("compile %s: %s\n%s", dir, err, out)
+			}
+		})
+	}
+}
+
+func TestRender_OptNullableColumn(t *testing.T) {
+	query := pginfer.TypedQuery{
+		Name:        "FindOptionalSuffix",
+		ResultKind:  ast.ResultKindOpt,
+		PreparedSQL: "SELECT suffix FROM author WHERE author_id = $1;",
+		Inputs: []pginfer.InputParam{
+			{PgName: "author_id", PgType: pg.Int4},
+		},
+		Outputs: []pginfer.OutputColumn{
+			{PgName: "suffix", PgType: pg.Text, Nullable: true},
+		},
+	}
+	opts := GenerateOptions{GoPkg: "author", OutputDir: t.TempDir()}
+	queryFiles := []codegen.QueryFile{{SourcePath: "query.sql", Queries: []pginfer.TypedQuery{query}}}
+	_, err := Render(opts, queryFiles)
+	require.ErrorContains(t, err, "query FindOptionalSuffix: :opt query returns the single nullable column suffix; use :one")
+}
@@ -0,0 +1,127 @@
+package gotype
+
+import (
+	"github.com/atomicleads/pggen/internal/pg"
+	"github.com/atomicleads/pggen/internal/pg/pgoid"
+	"github.com/jackc/pgtype"
+)
+
+// FindKnownTypeDatabaseSQLNullable returns the nullable type for
+// database/sql, like sql.NullString, if known, for a Postgres OID. Falls back
+// to the pgNative type. If there is no known type for the OID, returns nil.
+func FindKnownTypeDatabaseSQLNullable(oid pgtype.OID) (Type, bool) {
+	typ, ok := knownDatabaseSQLTypesByOID[oid]
+	if !ok {
+		return nil, false
+	}
+	if typ.nullable != nil {
+		return typ.nullable, true
+	}
+	return typ.pgNative, true
+}
+
+// FindKnownTypeDatabaseSQLNonNullable returns the non-nullable type for
+// database/sql like string, if known, for a Postgres OID. Falls back to the
+// nullable type and pgNative type. If there is no known type for the OID,
+// returns nil.
+func FindKnownTypeDatabaseSQLNonNullable(oid pgtype.OID) (Type, bool) {
+	typ, ok := knownDatabaseSQLTypesByOID[oid]
+	if !ok {
+		return nil, false
+	}
+	if typ.nonNullable != nil {
+		return typ.nonNullable, true
+	}
+	if typ.nullable != nil {
+		return typ.nullable, true
+	}
+	return typ.pgNative, true
+}
+
+// database/sql types prefixed with "SQL".
+var (
+	SQLNullBool    = MustParseKnownType("database/sql.NullBool", pg.Bool)
+	SQLNullInt16   = MustParseKnownType("database/sql.NullInt16", pg.Int2)
+	SQLNullInt32   = MustParseKnownType("database/sql.NullInt32", pg.Int4)
+	SQLNullInt64   = MustParseKnownType("database/sql.NullInt64", pg.Int8)
+	SQLNullString  = MustParseKnownType("database/sql.NullString", pg.Text)
+	SQLNullFloat64 = MustParseKnownType("database/sql.NullFloat64", pg.Float8)
+	Time           = MustParseKnownType("time.Time", pg.Timestamptz)
+)
+
+// knownDatabaseSQLTypesByOID is the database/sql equivalent of
+// knownTypesByOID. The sql.Null types replace pointers for nullable scalars
+// and every pgtype type implements sql.Scanner and driver.Valuer. Nullable
+// dates and times use pgtype instead of sql.NullTime because sql.NullTime
+// can't scan the text format used by composite type fields.
+//
+// Arrays always use the pgtype array types because database/sql can't scan
+// the text representation of an array into a Go slice. Omits the qchar,
+// record, and oid[] types because pgtype has no sql.Scanner for them.
+var knownDatabaseSQLTypesByOID = map[pgtype.OID]knownGoType{
+	pgtype.BoolOID:             {PgBool, SQLNullBool, Bool},
+	pgtype.NameOID:             {PgName, nil, nil},
+	pgtype.Int8OID:             {PgInt8, SQLNullInt64, Int64},
+	pgtype.Int2OID:             {PgInt2, SQLNullInt16, Int16},
+	pgtype.Int4OID:             {PgInt4, SQLNullInt32, Int32},
+	pgtype.TextOID:             {PgText, SQLNullString, String},
+	pgtype.ByteaOID:            {PgBytea, PgBytea, ByteSlice},
+	pgtype.OIDOID:              {PgOID, nil, nil},
+	pgtype.TIDOID:              {PgTID, nil, nil},
+	pgtype.XIDOID:              {PgXID, nil, nil},
+	pgtype.CIDOID:              {PgCID, nil, nil},
+	pgtype.JSONOID:             {PgJSON, nil, nil},
+	pgtype.PointOID:            {PgPoint, nil, nil},
+	pgtype.LsegOID:             {PgLseg, nil, nil},
+	pgtype.PathOID:             {PgPath, nil, nil},
+	pgtype.BoxOID:              {PgBox, nil, nil},
+	pgtype.PolygonOID:          {PgPolygon, nil, nil},
+	pgtype.LineOID:             {PgLine, nil, nil},
+	pgtype.CIDROID:             {PgCIDR, nil, nil},
+	pgtype.CIDRArrayOID:        {PgCIDRArray, nil, nil},
+	pgtype.Float4OID:           {PgFloat4, nil, Float32},
+	pgtype.Float8OID:           {PgFloat8, SQLNullFloat64, Float64},
+	pgtype.UnknownOID:          {PgUnknown, nil, nil},
+	pgtype.CircleOID:           {PgCircle, nil, nil},
+	pgtype.MacaddrOID:          {PgMacaddr, nil, nil},
+	pgtype.InetOID:             {PgInet, nil, nil},
+	pgtype.BoolArrayOID:        {PgBoolArray, nil, nil},
+	pgtype.ByteaArrayOID:       {PgByteaArray, nil, nil},
+	pgtype.Int2ArrayOID:        {PgInt2Array, nil, nil},
+	pgtype.Int4ArrayOID:        {PgInt4Array, nil, nil},
+	pgtype.TextArrayOID:        {PgTextArray, nil, nil},
+	pgtype.BPCharArrayOID:      {PgBPCharArray, nil, nil},
+	pgtype.VarcharArrayOID:     {PgVarcharArray, nil, nil},
+	pgtype.Int8ArrayOID:        {PgInt8Array, nil, nil},
+	pgtype.Float4ArrayOID:      {PgFloat4Array, nil, nil},
+	pgtype.Float8ArrayOID:      {PgFloat8Array, nil, nil},
+	pgtype.ACLItemOID:          {PgACLItem, nil, nil},
+	pgtype.ACLItemArrayOID:     {PgACLItemArray, nil, nil},
+	pgtype.InetArrayOID:        {PgInetArray, nil, nil},
+	pgoid.MacaddrArray:         {PgMacaddrArray, nil, nil},
+	pgtype.BPCharOID:           {PgBPChar, SQLNullString, String},
+	pgtype.VarcharOID:          {PgVarchar, SQLNullString, String},
+	pgtype.DateOID:             {PgDate, nil, Time},
+	pgtype.TimeOID:             {PgTime, nil, nil},
+	pgtype.TimestampOID:        {PgTimestamp, nil, Time},
+	pgtype.TimestampArrayOID:   {PgTimestampArray, nil, nil},
+	pgtype.DateArrayOID:        {PgDateArray, nil, nil},
+	pgtype.TimestamptzOID:      {PgTimestamptz, nil, Time},
+	pgtype.TimestamptzArrayOID: {PgTimestamptzArray, nil, nil},
+	pgtype.IntervalOID:         {PgInterval, nil, nil},
+	pgtype.NumericArrayOID:     {PgNumericArray, nil, nil},
+	pgtype.BitOID:              {PgBit, nil, nil},
+	pgtype.VarbitOID:           {PgVarbit, nil, nil},
+	pgoid.Void:                 {PgVoid, nil, nil},
+	pgtype.NumericOID:          {PgNumeric, nil, nil},
+	pgtype.UUIDOID:             {PgUUID, nil, nil},
+	pgtype.UUIDArrayOID:        {PgUUIDArray, nil, nil},
+	pgtype.JSONBOID:            {PgJSONB, nil, nil},
+	pgtype.JSONBArrayOID:       {PgJSONBArray, nil, nil},
+	pgtype.Int4rangeOID:        {PgInt4range, nil, nil},
+	pgtype.NumrangeOID:         {PgNumrange, nil, nil},
+	pgtype.TsrangeOID:          {PgTsrange, nil, nil},
+	pgtype.TstzrangeOID:        {PgTstzrange, nil, nil},
+	pgtype.DaterangeOID:        {PgDaterange, nil, nil},
+	pgtype.Int8rangeOID:        {PgInt8range, nil, nil},
+}
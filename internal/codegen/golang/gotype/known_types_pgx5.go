@@ -0,0 +1,183 @@
+package gotype
+
+import (
+	"github.com/atomicleads/pggen/internal/pg"
+	"github.com/atomicleads/pggen/internal/pg/pgoid"
+	"github.com/jackc/pgtype"
+)
+
+// FindKnownTypePgx5 returns the native pgx v5 type, like pgtype.Text, if known,
+// for a Postgres OID. If there is no known type, returns nil.
+func FindKnownTypePgx5(oid pgtype.OID) (Type, bool) {
+	typ, ok := knownPgx5TypesByOID[oid]
+	return typ.pgNative, ok
+}
+
+// FindKnownTypePgx5Nullable returns the nullable type for pgx v5, like
+// *string, if known, for a Postgres OID. Falls back to the pgNative type. If
+// there is no known type for the OID, returns nil.
+func FindKnownTypePgx5Nullable(oid pgtype.OID) (Type, bool) {
+	typ, ok := knownPgx5TypesByOID[oid]
+	if !ok {
+		return nil, false
+	}
+	if typ.nullable != nil {
+		return typ.nullable, true
+	}
+	return typ.pgNative, true
+}
+
+// FindKnownTypePgx5NonNullable returns the non-nullable type for pgx v5 like
+// string, if known, for a Postgres OID. Falls back to the nullable type and
+// pgNative type. If there is no known type for the OID, returns nil.
+func FindKnownTypePgx5NonNullable(oid pgtype.OID) (Type, bool) {
+	typ, ok := knownPgx5TypesByOID[oid]
+	if !ok {
+		return nil, false
+	}
+	if typ.nonNullable != nil {
+		return typ.nonNullable, true
+	}
+	if typ.nullable != nil {
+		return typ.nullable, true
+	}
+	return typ.pgNative, true
+}
+
+// Builtin go types only supported by pgx v5.
+var (
+	Byte               = MustParseKnownType("byte", pg.QChar)
+	Bytep              = MustParseKnownType("*byte", pg.QChar)
+	NetipPrefix        = MustParseKnownType("net/netip.Prefix", pg.Inet)
+	NetipPrefixSlice   = MustParseKnownType("[]net/netip.Prefix", pg.InetArray)
+	HardwareAddr       = MustParseKnownType("net.HardwareAddr", pg.Macaddr)
+	HardwareAddrSlice  = MustParseKnownType("[]net.HardwareAddr", pg.MacaddrArray)
+	ByteSliceSlice     = MustParseKnownType("[][]byte", pg.ByteaArray)
+	JSONByteSlice      = MustParseKnownType("[]byte", pg.JSON)
+	JSONBByteSlice     = MustParseKnownType("[]byte", pg.JSONB)
+	JSONBByteSliceList = MustParseKnownType("[][]byte", pg.JSONBArray)
+)
+
+// pgx v5 pgtype types prefixed with "pgx5".
+var (
+	Pgx5Bool             = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Bool", pg.Bool)
+	Pgx5Int2             = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Int2", pg.Int2)
+	Pgx5Int4             = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Int4", pg.Int4)
+	Pgx5Int8             = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Int8", pg.Int8)
+	Pgx5Uint32           = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Uint32", pg.OID)
+	Pgx5Text             = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Text", pg.Text)
+	Pgx5TID              = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.TID", pg.TID)
+	Pgx5Point            = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Point", pg.Point)
+	Pgx5Lseg             = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Lseg", pg.Lseg)
+	Pgx5Path             = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Path", pg.Path)
+	Pgx5Box              = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Box", pg.Box)
+	Pgx5Polygon          = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Polygon", pg.Polygon)
+	Pgx5Line             = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Line", pg.Line)
+	Pgx5Circle           = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Circle", pg.Circle)
+	Pgx5Float4           = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Float4", pg.Float4)
+	Pgx5Float8           = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Float8", pg.Float8)
+	Pgx5Date             = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Date", pg.Date)
+	Pgx5Time             = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Time", pg.Time)
+	Pgx5Timestamp        = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Timestamp", pg.Timestamp)
+	Pgx5Timestamptz      = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Timestamptz", pg.Timestamptz)
+	Pgx5Interval         = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Interval", pg.Interval)
+	Pgx5Numeric          = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Numeric", pg.Numeric)
+	Pgx5Bits             = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Bits", pg.Varbit)
+	Pgx5UUID             = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.UUID", pg.UUID)
+	Pgx5BoolArray        = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Bool", pg.BoolArray)
+	Pgx5Int2Array        = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Int2", pg.Int2Array)
+	Pgx5Int4Array        = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Int4", pg.Int4Array)
+	Pgx5Int8Array        = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Int8", pg.Int8Array)
+	Pgx5TextArray        = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Text", pg.TextArray)
+	Pgx5BPCharArray      = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Text", pg.BPCharArray)
+	Pgx5VarcharArray     = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Text", pg.VarcharArray)
+	Pgx5ACLItemArray     = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Text", pg.ACLItemArray)
+	Pgx5Float4Array      = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Float4", pg.Float4Array)
+	Pgx5Float8Array      = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Float8", pg.Float8Array)
+	Pgx5DateArray        = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Date", pg.DateArray)
+	Pgx5TimestampArray   = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Timestamp", pg.TimestampArray)
+	Pgx5TimestamptzArray = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Timestamptz", pg.TimestamptzArray)
+	Pgx5NumericArray     = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.Numeric", pg.NumericArray)
+	Pgx5UUIDArray        = MustParseKnownType("[]github.com/jackc/pgx/v5/pgtype.UUID", pg.UUIDArray)
+	Pgx5Int4range        = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Range[pgtype.Int4]", pg.Int4range)
+	Pgx5Int8range        = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Range[pgtype.Int8]", pg.Int8range)
+	Pgx5Numrange         = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Range[pgtype.Numeric]", pg.Numrange)
+	Pgx5Tsrange          = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Range[pgtype.Timestamp]", pg.Tsrange)
+	Pgx5Tstzrange        = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Range[pgtype.Timestamptz]", pg.Tstzrange)
+	Pgx5Daterange        = MustParseKnownType("github.com/jackc/pgx/v5/pgtype.Range[pgtype.Date]", pg.Daterange)
+)
+
+// knownPgx5TypesByOID is the pgx v5 equivalent of knownTypesByOID. pgx v5
+// removed most of the pgtype types without a NULL representation, like
+// pgtype.JSON and pgtype.Inet, in favor of the standard library types.
+//
+// Omits the record type because pgx v5 only decodes records into []any.
+var knownPgx5TypesByOID = map[pgtype.OID]knownGoType{
+	pgtype.BoolOID:             {Pgx5Bool, Boolp, Bool},
+	pgtype.QCharOID:            {Bytep, Bytep, Byte},
+	pgtype.NameOID:             {Pgx5Text, nil, nil},
+	pgtype.Int8OID:             {Pgx5Int8, Intp, Int},
+	pgtype.Int2OID:             {Pgx5Int2, Int16p, Int16},
+	pgtype.Int4OID:             {Pgx5Int4, Int32p, Int32},
+	pgtype.TextOID:             {Pgx5Text, Stringp, String},
+	pgtype.ByteaOID:            {ByteSlice, ByteSlice, ByteSlice},
+	pgtype.OIDOID:              {Pgx5Uint32, nil, nil},
+	pgtype.TIDOID:              {Pgx5TID, nil, nil},
+	pgtype.XIDOID:              {Pgx5Uint32, nil, nil},
+	pgtype.CIDOID:              {Pgx5Uint32, nil, nil},
+	pgtype.JSONOID:             {JSONByteSlice, nil, nil},
+	pgtype.PointOID:            {Pgx5Point, nil, nil},
+	pgtype.LsegOID:             {Pgx5Lseg, nil, nil},
+	pgtype.PathOID:             {Pgx5Path, nil, nil},
+	pgtype.BoxOID:              {Pgx5Box, nil, nil},
+	pgtype.PolygonOID:          {Pgx5Polygon, nil, nil},
+	pgtype.LineOID:             {Pgx5Line, nil, nil},
+	pgtype.CIDROID:             {NetipPrefix, nil, nil},
+	pgtype.CIDRArrayOID:        {NetipPrefixSlice, nil, nil},
+	pgtype.Float4OID:           {Pgx5Float4, nil, nil},
+	pgtype.Float8OID:           {Pgx5Float8, nil, nil},
+	pgoid.OIDArray:             {Uint32Slice, nil, nil},
+	pgtype.UnknownOID:          {Pgx5Text, nil, nil},
+	pgtype.CircleOID:           {Pgx5Circle, nil, nil},
+	pgtype.MacaddrOID:          {HardwareAddr, nil, nil},
+	pgtype.InetOID:             {NetipPrefix, nil, nil},
+	pgtype.BoolArrayOID:        {Pgx5BoolArray, nil, nil},
+	pgtype.ByteaArrayOID:       {ByteSliceSlice, nil, nil},
+	pgtype.Int2ArrayOID:        {Pgx5Int2Array, Int16pSlice, Int16Slice},
+	pgtype.Int4ArrayOID:        {Pgx5Int4Array, Int32pSlice, Int32Slice},
+	pgtype.TextArrayOID:        {Pgx5TextArray, StringSlice, nil},
+	pgtype.BPCharArrayOID:      {Pgx5BPCharArray, nil, nil},
+	pgtype.VarcharArrayOID:     {Pgx5VarcharArray, nil, nil},
+	pgtype.Int8ArrayOID:        {Pgx5Int8Array, IntpSlice, IntSlice},
+	pgtype.Float4ArrayOID:      {Pgx5Float4Array, Float32pSlice, Float32Slice},
+	pgtype.Float8ArrayOID:      {Pgx5Float8Array, Float64pSlice, Float64Slice},
+	pgtype.ACLItemOID:          {Pgx5Text, nil, nil},
+	pgtype.ACLItemArrayOID:     {Pgx5ACLItemArray, nil, nil},
+	pgtype.InetArrayOID:        {NetipPrefixSlice, nil, nil},
+	pgoid.MacaddrArray:         {HardwareAddrSlice, nil, nil},
+	pgtype.BPCharOID:           {Pgx5Text, nil, nil},
+	pgtype.VarcharOID:          {Pgx5Text, nil, nil},
+	pgtype.DateOID:             {Pgx5Date, nil, nil},
+	pgtype.TimeOID:             {Pgx5Time, nil, nil},
+	pgtype.TimestampOID:        {Pgx5Timestamp, nil, nil},
+	pgtype.TimestampArrayOID:   {Pgx5TimestampArray, nil, nil},
+	pgtype.DateArrayOID:        {Pgx5DateArray, nil, nil},
+	pgtype.TimestamptzOID:      {Pgx5Timestamptz, nil, nil},
+	pgtype.TimestamptzArrayOID: {Pgx5TimestamptzArray, nil, nil},
+	pgtype.IntervalOID:         {Pgx5Interval, nil, nil},
+	pgtype.NumericArrayOID:     {Pgx5NumericArray, nil, nil},
+	pgtype.BitOID:              {Pgx5Bits, nil, nil},
+	pgtype.VarbitOID:           {Pgx5Bits, nil, nil},
+	pgoid.Void:                 {PgVoid, nil, nil},
+	pgtype.NumericOID:          {Pgx5Numeric, nil, nil},
+	pgtype.UUIDOID:             {Pgx5UUID, nil, nil},
+	pgtype.UUIDArrayOID:        {Pgx5UUIDArray, nil, nil},
+	pgtype.JSONBOID:            {JSONBByteSlice, nil, nil},
+	pgtype.JSONBArrayOID:       {JSONBByteSliceList, nil, nil},
+	pgtype.Int4rangeOID:        {Pgx5Int4range, nil, nil},
+	pgtype.NumrangeOID:         {Pgx5Numrange, nil, nil},
+	pgtype.TsrangeOID:          {Pgx5Tsrange, nil, nil},
+	pgtype.TstzrangeOID:        {Pgx5Tstzrange, nil, nil},
+	pgtype.DaterangeOID:        {Pgx5Daterange, nil, nil},
+	pgtype.Int8rangeOID:        {Pgx5Int8range, nil, nil},
+}
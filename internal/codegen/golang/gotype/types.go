@@ -205,7 +205,13 @@ func ParseOpaqueType(qualType string, pgType pg.Type) (Type, error) {
 	if isPtr {
 		bs = bs[1:]
 	}
-	idx := bytes.LastIndexByte(bs, '.')
+	// Ignore dots in type arguments, like pgtype.Int4 in
+	// "github.com/jackc/pgx/v5/pgtype.Range[pgtype.Int4]".
+	typeArgsIdx := bytes.IndexByte(bs, '[')
+	if typeArgsIdx == -1 {
+		typeArgsIdx = len(bs)
+	}
+	idx := bytes.LastIndexByte(bs[:typeArgsIdx], '.')
 	name := string(bs[idx+1:])
 	var typ Type = &OpaqueType{Name: name}
 	// On array types, the PgType goes on the Array. In all other cases, it
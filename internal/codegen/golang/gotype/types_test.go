@@ -54,6 +54,13 @@ func TestMustParseKnownType(t *testing.T) {
 				Elem: &ImportType{PkgPath: "util/custom/times", Type: &OpaqueType{Name: "Interval"}},
 			},
 		},
+		{
+			qualType: "github.com/jackc/pgx/v5/pgtype.Range[pgtype.Int4]",
+			want: &ImportType{
+				PkgPath: "github.com/jackc/pgx/v5/pgtype",
+				Type:    &OpaqueType{Name: "Range[pgtype.Int4]"},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.qualType, func(t *testing.T) {
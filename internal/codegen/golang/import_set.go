@@ -20,16 +20,38 @@ func (s *ImportSet) AddPackage(p string) {
 	s.imports[p] = struct{}{}
 }
 
-// AddType adds all fully qualified package paths needed for type and any child
-// types.
+// AddType adds all fully qualified package paths needed to refer to type,
+// like the package of the element type of an array. Doesn't add the packages
+// used by the fields of composite types because composite types are declared
+// in the leader file; see AddDeclaredTypes.
 func (s *ImportSet) AddType(typ gotype.Type) {
 	s.AddPackage(typ.Import())
-	comp, ok := typ.(*gotype.CompositeType)
-	if !ok {
-		return
+	switch typ := typ.(type) {
+	case *gotype.ArrayType:
+		s.AddType(typ.Elem)
+	case *gotype.PointerType:
+		s.AddType(typ.Elem)
+	case *gotype.ImportType:
+		s.AddType(typ.Type)
 	}
-	for _, childType := range comp.FieldTypes {
-		s.AddType(childType)
+}
+
+// AddDeclaredTypes adds all fully qualified package paths needed to declare
+// the composite types in type and any child types, like the package of a
+// pgtype.Date field.
+func (s *ImportSet) AddDeclaredTypes(typ gotype.Type) {
+	switch typ := typ.(type) {
+	case *gotype.ArrayType:
+		s.AddDeclaredTypes(typ.Elem)
+	case *gotype.PointerType:
+		s.AddDeclaredTypes(typ.Elem)
+	case *gotype.ImportType:
+		s.AddDeclaredTypes(typ.Type)
+	case *gotype.CompositeType:
+		for _, childType := range typ.FieldTypes {
+			s.AddType(childType)
+			s.AddDeclaredTypes(childType)
+		}
 	}
 }
 
@@ -0,0 +1,465 @@
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/atomicleads/pggen/internal/codegen/golang/gotype"
+	goast "go/ast"
+	goparser "go/parser"
+	gotoken "go/token"
+	gotypes "go/types"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// protoMessage is the Go struct generated by protoc-gen-go for a protocol
+// buffer message.
+type protoMessage struct {
+	Name    string // protobuf message name, like "erp.api.Product"
+	PkgPath string // Go package path of the generated code
+	PkgName string // Go package name of the generated code, like "api"
+	GoName  string // Go type name, like "Product", or "Outer_Inner" for nested messages
+	Fields  []protoField
+}
+
+// protoField is a field of the Go struct generated for a protobuf message.
+type protoField struct {
+	ProtoName string // field name in the .proto file, like "first_name"
+	GoName    string // Go struct field name, like "FirstName"
+	// Go type of the field with the full package path, like
+	// "*google.golang.org/protobuf/types/known/timestamppb.Timestamp".
+	GoType string
+}
+
+// protoLoader finds the Go structs that protoc-gen-go generates for protobuf
+// messages. The loader reads the Go source instead of type checking the
+// package, so it works even if the package doesn't compile yet.
+type protoLoader struct {
+	packages map[string]string                    // protobuf package to Go package path
+	findDir  func(pkgPath string) (string, error) // directory of a Go package
+	messages map[string]protoMessage              // cache by protobuf message name
+}
+
+func newProtoLoader(packages map[string]string, workDir string) *protoLoader {
+	return &protoLoader{
+		packages: packages,
+		findDir: func(pkgPath string) (string, error) {
+			return goListDir(workDir, pkgPath)
+		},
+		messages: make(map[string]protoMessage),
+	}
+}
+
+// goListDir finds the directory of a Go package using the go command, which
+// resolves packages in the module of workDir and its dependencies.
+func goListDir(workDir, pkgPath string) (string, error) {
+	cmd := exec.Command("go", "list", "-f", "{{.Dir}}", pkgPath)
+	cmd.Dir = workDir
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("go list %s: %w: %s", pkgPath, err, strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// load finds the Go struct for a protobuf message name, like
+// "erp.api.Product".
+func (pl *protoLoader) load(name string) (protoMessage, error) {
+	if msg, ok := pl.messages[name]; ok {
+		return msg, nil
+	}
+	// Pick the longest protobuf package that prefixes the message name so that
+	// package "erp.api" wins over "erp" for "erp.api.Product".
+	protoPkg := ""
+	for pkg := range pl.packages {
+		if strings.HasPrefix(name, pkg+".") && len(pkg) > len(protoPkg) {
+			protoPkg = pkg
+		}
+	}
+	if protoPkg == "" {
+		return protoMessage{}, fmt.Errorf("no Go package for proto message %s; "+
+			"map the proto package to a Go package with --proto-package <protoPkg>=<goPkg>", name)
+	}
+	pkgPath := pl.packages[protoPkg]
+	// protoc-gen-go joins nested message names with an underscore.
+	goName := strings.ReplaceAll(strings.TrimPrefix(name, protoPkg+"."), ".", "_")
+
+	dir, err := pl.findDir(pkgPath)
+	if err != nil {
+		return protoMessage{}, fmt.Errorf("find Go package for proto message %s: %w", name, err)
+	}
+	msg, err := parseProtoMessage(dir, pkgPath, goName)
+	if err != nil {
+		return protoMessage{}, fmt.Errorf("load proto message %s: %w", name, err)
+	}
+	msg.Name = name
+	pl.messages[name] = msg
+	return msg, nil
+}
+
+// parseProtoMessage parses the Go files in dir to find the struct goName
+// generated by protoc-gen-go.
+func parseProtoMessage(dir, pkgPath, goName string) (protoMessage, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return protoMessage{}, fmt.Errorf("read package dir: %w", err)
+	}
+	fset := gotoken.NewFileSet()
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".go") || strings.HasSuffix(fileName, "_test.go") {
+			continue
+		}
+		file, err := goparser.ParseFile(fset, filepath.Join(dir, fileName), nil, goparser.SkipObjectResolution)
+		if err != nil {
+			return protoMessage{}, fmt.Errorf("parse Go file: %w", err)
+		}
+		structType := findStructType(file, goName)
+		if structType == nil {
+			continue
+		}
+		imports := fileImports(file)
+		msg := protoMessage{PkgPath: pkgPath, PkgName: file.Name.Name, GoName: goName}
+		for _, field := range structType.Fields.List {
+			if len(field.Names) == 0 || field.Tag == nil {
+				continue // embedded field
+			}
+			tag, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				return protoMessage{}, fmt.Errorf("unquote struct tag of %s.%s: %w", goName, field.Names[0].Name, err)
+			}
+			protoName := parseProtoTagName(reflect.StructTag(tag).Get("protobuf"))
+			if protoName == "" {
+				continue // internal state or a oneof field
+			}
+			msg.Fields = append(msg.Fields, protoField{
+				ProtoName: protoName,
+				GoName:    field.Names[0].Name,
+				GoType:    resolveTypeExpr(field.Type, pkgPath, imports),
+			})
+		}
+		return msg, nil
+	}
+	return protoMessage{}, fmt.Errorf("no struct type %s in Go package %s", goName, pkgPath)
+}
+
+func findStructType(file *goast.File, name string) *goast.StructType {
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*goast.GenDecl)
+		if !ok || genDecl.Tok != gotoken.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*goast.TypeSpec)
+			if typeSpec.Name.Name != name {
+				continue
+			}
+			if structType, ok := typeSpec.Type.(*goast.StructType); ok {
+				return structType
+			}
+		}
+	}
+	return nil
+}
+
+// fileImports maps the name of each import in file to the package path.
+func fileImports(file *goast.File) map[string]string {
+	imports := make(map[string]string, len(file.Imports))
+	for _, spec := range file.Imports {
+		pkgPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path.Base(pkgPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = pkgPath
+	}
+	return imports
+}
+
+// parseProtoTagName returns the field name from a protobuf struct tag like
+// "bytes,2,opt,name=first_name,json=firstName,proto3".
+func parseProtoTagName(tag string) string {
+	for _, part := range strings.Split(tag, ",") {
+		if name, ok := strings.CutPrefix(part, "name="); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// resolveTypeExpr returns the Go type of expr with the full package path of
+// any named types, like "*example.com/foo.Bar" for "*foo.Bar".
+func resolveTypeExpr(expr goast.Expr, pkgPath string, imports map[string]string) string {
+	switch expr := expr.(type) {
+	case *goast.Ident:
+		if !goast.IsExported(expr.Name) {
+			return expr.Name // builtin type
+		}
+		return pkgPath + "." + expr.Name
+	case *goast.StarExpr:
+		return "*" + resolveTypeExpr(expr.X, pkgPath, imports)
+	case *goast.ArrayType:
+		if expr.Len == nil {
+			return "[]" + resolveTypeExpr(expr.Elt, pkgPath, imports)
+		}
+	case *goast.SelectorExpr:
+		if pkg, ok := expr.X.(*goast.Ident); ok && imports[pkg.Name] != "" {
+			return imports[pkg.Name] + "." + expr.Sel.Name
+		}
+	}
+	return gotypes.ExprString(expr)
+}
+
+// TemplatedProto converts the output columns of a query with the proto-type
+// pragma into a protobuf message.
+type TemplatedProto struct {
+	Name     string                // protobuf message name, like "erp.api.Product"
+	PkgPath  string                // Go package path of the message
+	QualType string                // qualified Go type of the message, like "api.Product"
+	RowName  string                // unexported struct to scan rows into, like "findProductsRow"
+	Fields   []TemplatedProtoField // how to set each field, in column order
+}
+
+// TemplatedProtoField sets a field of a protobuf message from a column of the
+// row struct.
+type TemplatedProtoField struct {
+	GoName string // Go name of the message field, like "FirstName"
+	Value  string // expression for the field value, like "timestamppb.New(r.CreatedAt)"
+	// If set, a condition on the row to set the field, like "r.Suffix != nil".
+	// Otherwise, the field is always set.
+	Cond string
+	// If true, set the field to the address of a copy of Value, as needed for
+	// optional scalar fields.
+	Addr bool
+}
+
+const (
+	timestampPkg = "google.golang.org/protobuf/types/known/timestamppb"
+	wrappersPkg  = "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// protoWrapperCtors maps each wrapper message to the wrapperspb constructor
+// and the Go type of the wrapped value.
+var protoWrapperCtors = map[string]struct{ ctor, goType string }{
+	"*" + wrappersPkg + ".StringValue": {"String", "string"},
+	"*" + wrappersPkg + ".BoolValue":   {"Bool", "bool"},
+	"*" + wrappersPkg + ".Int32Value":  {"Int32", "int32"},
+	"*" + wrappersPkg + ".Int64Value":  {"Int64", "int64"},
+	"*" + wrappersPkg + ".UInt32Value": {"UInt32", "uint32"},
+	"*" + wrappersPkg + ".UInt64Value": {"UInt64", "uint64"},
+	"*" + wrappersPkg + ".FloatValue":  {"Float", "float32"},
+	"*" + wrappersPkg + ".DoubleValue": {"Double", "float64"},
+	"*" + wrappersPkg + ".BytesValue":  {"Bytes", "[]byte"},
+}
+
+// protoNullableColumns maps the Go types of nullable columns to the condition
+// that the column isn't null, the expression for the non-null value, and the
+// Go type of the value. %[1]s is the column field of the row struct.
+var protoNullableColumns = map[string]struct{ cond, value, goType string }{
+	// pgx v4 and database/sql
+	"github.com/jackc/pgtype.Text":        {"%[1]s.Status == pgtype.Present", "%[1]s.String", "string"},
+	"github.com/jackc/pgtype.Varchar":     {"%[1]s.Status == pgtype.Present", "%[1]s.String", "string"},
+	"github.com/jackc/pgtype.Bool":        {"%[1]s.Status == pgtype.Present", "%[1]s.Bool", "bool"},
+	"github.com/jackc/pgtype.Int2":        {"%[1]s.Status == pgtype.Present", "%[1]s.Int", "int16"},
+	"github.com/jackc/pgtype.Int4":        {"%[1]s.Status == pgtype.Present", "%[1]s.Int", "int32"},
+	"github.com/jackc/pgtype.Int8":        {"%[1]s.Status == pgtype.Present", "%[1]s.Int", "int64"},
+	"github.com/jackc/pgtype.Float4":      {"%[1]s.Status == pgtype.Present", "%[1]s.Float", "float32"},
+	"github.com/jackc/pgtype.Float8":      {"%[1]s.Status == pgtype.Present", "%[1]s.Float", "float64"},
+	"github.com/jackc/pgtype.Bytea":       {"%[1]s.Status == pgtype.Present", "%[1]s.Bytes", "[]byte"},
+	"github.com/jackc/pgtype.Date":        {"%[1]s.Status == pgtype.Present", "%[1]s.Time", "time.Time"},
+	"github.com/jackc/pgtype.Timestamp":   {"%[1]s.Status == pgtype.Present", "%[1]s.Time", "time.Time"},
+	"github.com/jackc/pgtype.Timestamptz": {"%[1]s.Status == pgtype.Present", "%[1]s.Time", "time.Time"},
+	// pgx v5
+	"github.com/jackc/pgx/v5/pgtype.Text":        {"%[1]s.Valid", "%[1]s.String", "string"},
+	"github.com/jackc/pgx/v5/pgtype.Bool":        {"%[1]s.Valid", "%[1]s.Bool", "bool"},
+	"github.com/jackc/pgx/v5/pgtype.Int2":        {"%[1]s.Valid", "%[1]s.Int16", "int16"},
+	"github.com/jackc/pgx/v5/pgtype.Int4":        {"%[1]s.Valid", "%[1]s.Int32", "int32"},
+	"github.com/jackc/pgx/v5/pgtype.Int8":        {"%[1]s.Valid", "%[1]s.Int64", "int64"},
+	"github.com/jackc/pgx/v5/pgtype.Float4":      {"%[1]s.Valid", "%[1]s.Float32", "float32"},
+	"github.com/jackc/pgx/v5/pgtype.Float8":      {"%[1]s.Valid", "%[1]s.Float64", "float64"},
+	"github.com/jackc/pgx/v5/pgtype.Date":        {"%[1]s.Valid", "%[1]s.Time", "time.Time"},
+	"github.com/jackc/pgx/v5/pgtype.Timestamp":   {"%[1]s.Valid", "%[1]s.Time", "time.Time"},
+	"github.com/jackc/pgx/v5/pgtype.Timestamptz": {"%[1]s.Valid", "%[1]s.Time", "time.Time"},
+	// database/sql
+	"database/sql.NullString":  {"%[1]s.Valid", "%[1]s.String", "string"},
+	"database/sql.NullBool":    {"%[1]s.Valid", "%[1]s.Bool", "bool"},
+	"database/sql.NullInt16":   {"%[1]s.Valid", "%[1]s.Int16", "int16"},
+	"database/sql.NullInt32":   {"%[1]s.Valid", "%[1]s.Int32", "int32"},
+	"database/sql.NullInt64":   {"%[1]s.Valid", "%[1]s.Int64", "int64"},
+	"database/sql.NullFloat64": {"%[1]s.Valid", "%[1]s.Float64", "float64"},
+	"database/sql.NullTime":    {"%[1]s.Valid", "%[1]s.Time", "time.Time"},
+}
+
+// protoScalarTypes are the Go types that protoc-gen-go uses for scalar
+// fields, plus the Go types of columns that convert to them.
+var protoScalarTypes = map[string]bool{
+	"string": true, "bool": true, "[]byte": true,
+	"int": true, "int16": true, "int32": true, "int64": true, "uint32": true, "uint64": true,
+	"float32": true, "float64": true,
+}
+
+// protoWidenings are the numeric Go types that each numeric Go type converts
+// to without losing precision or sign. int is at most 64 bits.
+var protoWidenings = map[string][]string{
+	"int":     {"int64"},
+	"int16":   {"int32", "int64", "float32", "float64"},
+	"int32":   {"int64", "float64"},
+	"uint32":  {"int64", "uint64", "float64"},
+	"float32": {"float64"},
+}
+
+// newTemplatedProto matches each output column to a field of the protobuf
+// message by name and builds the expression to set the field. Returns the
+// packages the expressions import.
+func newTemplatedProto(queryName, rowName, pkgPath string, msg protoMessage, outs []TemplatedColumn) (*TemplatedProto, []string, error) {
+	fieldsByName := make(map[string]protoField, len(msg.Fields))
+	for _, field := range msg.Fields {
+		fieldsByName[field.ProtoName] = field
+	}
+	qualType := msg.GoName
+	if msg.PkgPath != pkgPath {
+		qualType = msg.PkgName + "." + msg.GoName
+	}
+	proto := &TemplatedProto{Name: msg.Name, PkgPath: msg.PkgPath, QualType: qualType, RowName: rowName}
+	imports := []string{msg.PkgPath}
+	for _, out := range outs {
+		if _, ok := out.Type.(*gotype.VoidType); ok {
+			continue
+		}
+		field, ok := fieldsByName[out.PgName]
+		if !ok {
+			return nil, nil, fmt.Errorf("query %s: column %s has no matching field in proto message %s; "+
+				"rename the column to match a field name like %s",
+				queryName, out.PgName, msg.Name, joinProtoFieldNames(msg.Fields))
+		}
+		pf, pkg, err := newTemplatedProtoField("r."+out.UpperName, goTypeString(out.Type), field)
+		if err != nil {
+			return nil, nil, fmt.Errorf("query %s: column %s: proto message %s: %w", queryName, out.PgName, msg.Name, err)
+		}
+		if pkg != "" {
+			imports = append(imports, pkg)
+		}
+		proto.Fields = append(proto.Fields, pf)
+	}
+	return proto, imports, nil
+}
+
+// newTemplatedProtoField builds the expression to set field from the column
+// named colExpr with Go type colType. Returns the package the expression
+// imports, if any.
+func newTemplatedProtoField(colExpr, colType string, field protoField) (TemplatedProtoField, string, error) {
+	pf := TemplatedProtoField{GoName: field.GoName}
+	if colType == field.GoType {
+		pf.Value = colExpr
+		return pf, "", nil
+	}
+
+	// Unwrap nullable columns into the condition that the column isn't null
+	// and the non-null value.
+	cond, value, valueType := "", colExpr, colType
+	if elem, ok := strings.CutPrefix(colType, "*"); ok && protoScalarTypes[elem] {
+		cond, value, valueType = colExpr+" != nil", "*"+colExpr, elem
+	} else if nullable, ok := protoNullableColumns[colType]; ok {
+		cond = fmt.Sprintf(nullable.cond, colExpr)
+		value = fmt.Sprintf(nullable.value, colExpr)
+		valueType = nullable.goType
+	}
+	pf.Cond = cond
+
+	mismatchErr := fmt.Errorf("can't convert Go type %s to field %s of type %s", colType, field.GoName, field.GoType)
+	switch {
+	case field.GoType == "*"+timestampPkg+".Timestamp":
+		if valueType != "time.Time" {
+			return TemplatedProtoField{}, "", mismatchErr
+		}
+		pf.Value = "timestamppb.New(" + value + ")"
+		return pf, timestampPkg, nil
+
+	case protoWrapperCtors[field.GoType].ctor != "":
+		wrapper := protoWrapperCtors[field.GoType]
+		v, ok := convertProtoScalar(value, valueType, wrapper.goType)
+		if !ok {
+			return TemplatedProtoField{}, "", mismatchErr
+		}
+		pf.Value = "wrapperspb." + wrapper.ctor + "(" + v + ")"
+		return pf, wrappersPkg, nil
+
+	case protoScalarTypes[field.GoType]:
+		// A null column leaves the field as the zero value because proto3
+		// scalars can't represent null.
+		v, ok := convertProtoScalar(value, valueType, field.GoType)
+		if !ok {
+			return TemplatedProtoField{}, "", mismatchErr
+		}
+		pf.Value = v
+		return pf, "", nil
+
+	case strings.HasPrefix(field.GoType, "*") && protoScalarTypes[field.GoType[1:]]:
+		// Optional scalar field.
+		v, ok := convertProtoScalar(value, valueType, field.GoType[1:])
+		switch {
+		case !ok:
+			return TemplatedProtoField{}, "", mismatchErr
+		case cond == "" && v == value:
+			pf.Value = "&" + value // the row is a copy, so the message can own the field
+		default:
+			// Null columns and conversions aren't addressable, so copy the value.
+			pf.Value = v
+			pf.Addr = true
+		}
+		return pf, "", nil
+
+	default:
+		return TemplatedProtoField{}, "", mismatchErr
+	}
+}
+
+// convertProtoScalar converts value of Go type from to the Go type to. Only
+// converts between numeric types that widen without loss, like int32 to
+// int64. Narrowing, sign changes, and float to int conversions return false.
+func convertProtoScalar(value, from, to string) (string, bool) {
+	if from == to {
+		return value, true
+	}
+	for _, wider := range protoWidenings[from] {
+		if wider == to {
+			return to + "(" + value + ")", true
+		}
+	}
+	return "", false
+}
+
+// goTypeString returns the Go type with the full package path of named types,
+// like "*string" or "github.com/jackc/pgtype.Text".
+func goTypeString(typ gotype.Type) string {
+	switch typ := typ.(type) {
+	case *gotype.PointerType:
+		return "*" + goTypeString(typ.Elem)
+	case *gotype.ArrayType:
+		return "[]" + goTypeString(typ.Elem)
+	case *gotype.ImportType:
+		return typ.PkgPath + "." + typ.Type.BaseName()
+	default:
+		return typ.BaseName()
+	}
+}
+
+func joinProtoFieldNames(fields []protoField) string {
+	names := make([]string, len(fields))
+	for i, field := range fields {
+		names[i] = field.ProtoName
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
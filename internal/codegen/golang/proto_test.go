@@ -0,0 +1,240 @@
+package golang
+
+import (
+	"github.com/atomicleads/pggen/internal/codegen/golang/gotype"
+	"github.com/atomicleads/pggen/internal/pg"
+	"github.com/google/go-cmp/cmp"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testProtoPkgPath = "example.com/acme/api"
+
+func newTestProtoLoader(t *testing.T) *protoLoader {
+	t.Helper()
+	dir, err := filepath.Abs(filepath.Join("testdata", "proto"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &protoLoader{
+		packages: map[string]string{"acme": "example.com/acme", "acme.api": testProtoPkgPath},
+		findDir: func(pkgPath string) (string, error) {
+			if pkgPath != testProtoPkgPath {
+				t.Fatalf("find dir for unexpected package %s", pkgPath)
+			}
+			return dir, nil
+		},
+		messages: make(map[string]protoMessage),
+	}
+}
+
+func TestProtoLoader_Load(t *testing.T) {
+	tests := []struct {
+		name string
+		want protoMessage
+	}{
+		{
+			name: "acme.api.Author",
+			want: protoMessage{
+				Name:    "acme.api.Author",
+				PkgPath: testProtoPkgPath,
+				PkgName: "api",
+				GoName:  "Author",
+				Fields: []protoField{
+					{ProtoName: "author_id", GoName: "AuthorId", GoType: "int64"},
+					{ProtoName: "first_name", GoName: "FirstName", GoType: "string"},
+					{ProtoName: "suffix", GoName: "Suffix", GoType: "*" + wrappersPkg + ".StringValue"},
+					{ProtoName: "nickname", GoName: "Nickname", GoType: "*string"},
+					{ProtoName: "created_at", GoName: "CreatedAt", GoType: "*" + timestampPkg + ".Timestamp"},
+					{ProtoName: "age", GoName: "Age", GoType: "int32"},
+					{ProtoName: "rank", GoName: "Rank", GoType: "*int64"},
+					{ProtoName: "avatar", GoName: "Avatar", GoType: "[]byte"},
+				},
+			},
+		},
+		{
+			name: "acme.api.Author.Address",
+			want: protoMessage{
+				Name:    "acme.api.Author.Address",
+				PkgPath: testProtoPkgPath,
+				PkgName: "api",
+				GoName:  "Author_Address",
+				Fields: []protoField{
+					{ProtoName: "city", GoName: "City", GoType: "string"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestProtoLoader(t).load(tt.name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("load() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProtoLoader_Load_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		wantErr string
+	}{
+		{name: "other.api.Author", wantErr: "map the proto package to a Go package with --proto-package"},
+		{name: "acme.api.Missing", wantErr: "no struct type Missing in Go package example.com/acme/api"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newTestProtoLoader(t).load(tt.name)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("load() error = %v; want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewTemplatedProto(t *testing.T) {
+	msg, err := newTestProtoLoader(t).load("acme.api.Author")
+	if err != nil {
+		t.Fatal(err)
+	}
+	column := func(pgName, upperName, qualType string) TemplatedColumn {
+		return TemplatedColumn{PgName: pgName, UpperName: upperName, Type: gotype.MustParseKnownType(qualType, pg.Text)}
+	}
+	tests := []struct {
+		name       string
+		outs       []TemplatedColumn
+		want       []TemplatedProtoField
+		wantImport []string
+	}{
+		{
+			name: "same types",
+			outs: []TemplatedColumn{
+				column("author_id", "AuthorID", "int64"),
+				column("first_name", "FirstName", "string"),
+				column("avatar", "Avatar", "[]byte"),
+			},
+			want: []TemplatedProtoField{
+				{GoName: "AuthorId", Value: "r.AuthorID"},
+				{GoName: "FirstName", Value: "r.FirstName"},
+				{GoName: "Avatar", Value: "r.Avatar"},
+			},
+			wantImport: []string{testProtoPkgPath},
+		},
+		{
+			name: "scalar conversions",
+			outs: []TemplatedColumn{
+				column("author_id", "AuthorID", "int32"),
+				column("first_name", "FirstName", "github.com/jackc/pgtype.Text"),
+				column("age", "Age", "*int16"),
+			},
+			want: []TemplatedProtoField{
+				{GoName: "AuthorId", Value: "int64(r.AuthorID)"},
+				{GoName: "FirstName", Value: "r.FirstName.String", Cond: "r.FirstName.Status == pgtype.Present"},
+				{GoName: "Age", Value: "int32(*r.Age)", Cond: "r.Age != nil"},
+			},
+			wantImport: []string{testProtoPkgPath},
+		},
+		{
+			name: "well known types",
+			outs: []TemplatedColumn{
+				column("suffix", "Suffix", "github.com/jackc/pgx/v5/pgtype.Text"),
+				column("created_at", "CreatedAt", "time.Time"),
+			},
+			want: []TemplatedProtoField{
+				{GoName: "Suffix", Value: "wrapperspb.String(r.Suffix.String)", Cond: "r.Suffix.Valid"},
+				{GoName: "CreatedAt", Value: "timestamppb.New(r.CreatedAt)"},
+			},
+			wantImport: []string{testProtoPkgPath, wrappersPkg, timestampPkg},
+		},
+		{
+			name: "optional scalars",
+			outs: []TemplatedColumn{
+				column("nickname", "Nickname", "string"),
+				column("rank", "Rank", "int32"),
+			},
+			want: []TemplatedProtoField{
+				{GoName: "Nickname", Value: "&r.Nickname"},
+				{GoName: "Rank", Value: "int64(r.Rank)", Addr: true},
+			},
+			wantImport: []string{testProtoPkgPath},
+		},
+		{
+			name: "nullable optional scalar",
+			outs: []TemplatedColumn{
+				column("rank", "Rank", "database/sql.NullInt64"),
+			},
+			want: []TemplatedProtoField{
+				{GoName: "Rank", Value: "r.Rank.Int64", Cond: "r.Rank.Valid", Addr: true},
+			},
+			wantImport: []string{testProtoPkgPath},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, imports, err := newTemplatedProto("FindAuthor", "findAuthorRow", "example.com/acme/queries", msg, tt.outs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.QualType != "api.Author" || got.RowName != "findAuthorRow" {
+				t.Errorf("newTemplatedProto() got qualType %s and row %s; want api.Author and findAuthorRow", got.QualType, got.RowName)
+			}
+			if diff := cmp.Diff(tt.want, got.Fields); diff != "" {
+				t.Errorf("newTemplatedProto() fields mismatch (-want +got):\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantImport, imports); diff != "" {
+				t.Errorf("newTemplatedProto() imports mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestNewTemplatedProto_Error(t *testing.T) {
+	msg, err := newTestProtoLoader(t).load("acme.api.Author")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		out     TemplatedColumn
+		wantErr string
+	}{
+		{
+			name:    "unknown column",
+			out:     TemplatedColumn{PgName: "email", UpperName: "Email", Type: gotype.MustParseKnownType("string", pg.Text)},
+			wantErr: "column email has no matching field in proto message acme.api.Author; rename the column",
+		},
+		{
+			name:    "mismatched type",
+			out:     TemplatedColumn{PgName: "created_at", UpperName: "CreatedAt", Type: gotype.MustParseKnownType("string", pg.Text)},
+			wantErr: "can't convert Go type string to field CreatedAt",
+		},
+		{
+			name:    "scalar conversions - narrowing",
+			out:     TemplatedColumn{PgName: "age", UpperName: "Age", Type: gotype.MustParseKnownType("int64", pg.Int8)},
+			wantErr: "can't convert Go type int64 to field Age of type int32",
+		},
+		{
+			name:    "scalar conversions - sign change",
+			out:     TemplatedColumn{PgName: "author_id", UpperName: "AuthorID", Type: gotype.MustParseKnownType("uint64", pg.Int8)},
+			wantErr: "can't convert Go type uint64 to field AuthorId of type int64",
+		},
+		{
+			name:    "scalar conversions - float to int",
+			out:     TemplatedColumn{PgName: "rank", UpperName: "Rank", Type: gotype.MustParseKnownType("float64", pg.Float8)},
+			wantErr: "can't convert Go type float64 to field Rank of type *int64",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := newTemplatedProto("FindAuthor", "findAuthorRow", "", msg, []TemplatedColumn{tt.out})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("newTemplatedProto() error = %v; want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
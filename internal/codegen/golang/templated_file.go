@@ -29,19 +29,33 @@ type TemplatedFile struct {
 	IsLeader bool
 	// Any declarations this file should declare. Only set on leader.
 	Declarers []Declarer
+	// How the generated querier reports queries.
+	Instrumentation Instrumentation
+	// The major version of pgx used by the generated code.
+	PgxVersion PgxVersion
+	// Postgres types that RegisterTypes loads for pgx v5, ordered so that
+	// dependencies come first. Only set on leader.
+	RegisteredTypes []string
+	// True if the generated querier uses database/sql instead of pgx.
+	DatabaseSQL bool
 }
 
 // TemplatedQuery is a query with all information required to execute the
 // codegen template.
 type TemplatedQuery struct {
-	Name             string            // name of the query, from the comment preceding the query
-	SQLVarName       string            // name of the string variable containing the SQL
-	ResultKind       ast.ResultKind    // kind of result: :one, :many, or :exec
-	Doc              string            // doc from the source query file, formatted for Go
-	PreparedSQL      string            // SQL query, ready to run with PREPARE statement
-	Inputs           []TemplatedParam  // input parameters to the query
-	Outputs          []TemplatedColumn // output columns of the query
-	InlineParamCount int               // inclusive count of params that will be inlined
+	Name             string                 // name of the query, from the comment preceding the query
+	SQLVarName       string                 // name of the string variable containing the SQL
+	ResultKind       ast.ResultKind         // kind of result: :one, :opt, :many, :exec, :execrows, or :copyfrom
+	Doc              string                 // doc from the source query file, formatted for Go
+	PreparedSQL      string                 // SQL query, ready to run with PREPARE statement
+	Inputs           []TemplatedParam       // input parameters to the query
+	Outputs          []TemplatedColumn      // output columns of the query
+	InlineParamCount int                    // inclusive count of params that will be inlined
+	PgxVersion       PgxVersion             // major version of pgx used by the generated code
+	DatabaseSQL      bool                   // true if the generated code uses database/sql instead of pgx
+	CopyFrom         pginfer.CopyFromTarget // table and columns to insert into for :copyfrom queries
+	ExpectRows       *int64                 // if set, rows an :exec or :execrows query must affect
+	Proto            *TemplatedProto        // if set, the protobuf message to return for each row
 }
 
 type TemplatedParam struct {
@@ -60,14 +74,75 @@ type TemplatedColumn struct {
 	QualType  string // package qualified Go type to use for the column, like "pgtype.Text"
 }
 
-func (tf TemplatedFile) needsPgconnImport() bool {
+// HasQueryHooks returns true if the querier calls QueryHooks for each query.
+func (tf TemplatedFile) HasQueryHooks() bool {
+	return tf.Instrumentation == InstrumentationHooks || tf.Instrumentation == InstrumentationOTel
+}
+
+// HasOTelHooks returns true if the file should define the OpenTelemetry
+// implementation of QueryHooks.
+func (tf TemplatedFile) HasOTelHooks() bool {
+	return tf.Instrumentation == InstrumentationOTel
+}
+
+// IsPgxV5 returns true if the file should use pgx v5 instead of pgx v4.
+func (tf TemplatedFile) IsPgxV5() bool {
+	return tf.PgxVersion == PgxV5
+}
+
+// HasCopyFromQueries returns true if any file in the package has a :copyfrom
+// query, meaning genericConn must support CopyFrom.
+func (tf TemplatedFile) HasCopyFromQueries() bool {
+	for _, file := range tf.Pkg.Files {
+		for _, query := range file.Queries {
+			if query.ResultKind == ast.ResultKindCopyFrom {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// templateName returns the name of the template that generates the file.
+func (tf TemplatedFile) templateName() string {
+	if tf.DatabaseSQL {
+		return "gen_query_database_sql"
+	}
+	return "gen_query"
+}
+
+// needsCommandTagImport returns true if the file uses the package of the type
+// returned by :exec queries, pgconn.CommandTag for pgx or sql.Result for
+// database/sql.
+func (tf TemplatedFile) needsCommandTagImport() bool {
 	if tf.IsLeader {
-		// Leader files define genericConn.Exec which returns pgconn.CommandTag.
+		// Leader files define genericConn.Exec which returns the command tag.
 		return true
 	}
 	for _, query := range tf.Queries {
 		if query.ResultKind == ast.ResultKindExec {
-			return true // :exec queries return pgconn.CommandTag
+			return true // :exec queries return the command tag
+		}
+		if tf.DatabaseSQL && query.importsPackage("database/sql") {
+			return true // nullable types like sql.NullString
+		}
+	}
+	return false
+}
+
+// importsPackage returns true if any input or output type of the query is
+// from the package path pkg.
+func (tq TemplatedQuery) importsPackage(pkg string) bool {
+	imports := NewImportSet()
+	for _, input := range tq.Inputs {
+		imports.AddType(input.Type)
+	}
+	for _, output := range tq.Outputs {
+		imports.AddType(output.Type)
+	}
+	for _, p := range imports.SortedPackages() {
+		if p == pkg {
+			return true
 		}
 	}
 	return false
@@ -85,6 +160,9 @@ func (tq TemplatedQuery) EmitPreparedSQL() string {
 // a name and type based on the number of params. For use in a method
 // definition.
 func (tq TemplatedQuery) EmitParams() string {
+	if tq.ResultKind == ast.ResultKindCopyFrom {
+		return ", params []" + tq.Name + "Params"
+	}
 	if !tq.isInlineParams() {
 		return ", params " + tq.Name + "Params"
 	}
@@ -151,68 +229,128 @@ func (tq TemplatedQuery) EmitParamStruct() string {
 // EmitParamNames emits the TemplatedQuery.Inputs into comma separated names
 // for use in a method invocation.
 func (tq TemplatedQuery) EmitParamNames() string {
-	appendParam := func(sb *strings.Builder, typ gotype.Type, name string) {
-		switch typ := gotype.UnwrapNestedType(typ).(type) {
-		case *gotype.CompositeType:
+	sb := &strings.Builder{}
+	for _, input := range tq.Inputs {
+		sb.WriteString(", ")
+		if tq.isInlineParams() {
+			tq.writeParamArg(sb, input.Type, input.LowerName)
+		} else {
+			tq.writeParamArg(sb, input.Type, "params."+input.UpperName)
+		}
+	}
+	return sb.String()
+}
+
+// writeParamArg writes the expression to pass the param in the Go variable
+// name as a query argument, converting the param if needed.
+func (tq TemplatedQuery) writeParamArg(sb *strings.Builder, typ gotype.Type, name string) {
+	if tq.DatabaseSQL {
+		// Composites and enums implement driver.Valuer but slices of them
+		// need a wrapper.
+		if typ, ok := gotype.UnwrapNestedType(typ).(*gotype.ArrayType); ok && isDatabaseSQLArray(typ) {
+			sb.WriteString(NameArrayValuerFunc(typ))
+			sb.WriteString("(")
+			sb.WriteString(name)
+			sb.WriteString(")")
+			return
+		}
+		sb.WriteString(name)
+		return
+	}
+	if tq.PgxVersion == PgxV5 {
+		// The codecs from RegisterTypes encode composites and enums directly.
+		sb.WriteString(name)
+		return
+	}
+	switch typ := gotype.UnwrapNestedType(typ).(type) {
+	case *gotype.CompositeType:
+		sb.WriteString("q.types.")
+		sb.WriteString(NameCompositeInitFunc(typ))
+		sb.WriteString("(")
+		sb.WriteString(name)
+		sb.WriteString(")")
+	case *gotype.ArrayType:
+		if gotype.IsPgxSupportedArray(typ) {
+			sb.WriteString(name)
+			break
+		}
+		switch gotype.UnwrapNestedType(typ.Elem).(type) {
+		case *gotype.CompositeType, *gotype.EnumType:
 			sb.WriteString("q.types.")
-			sb.WriteString(NameCompositeInitFunc(typ))
+			sb.WriteString(NameArrayInitFunc(typ))
 			sb.WriteString("(")
 			sb.WriteString(name)
 			sb.WriteString(")")
-		case *gotype.ArrayType:
-			if gotype.IsPgxSupportedArray(typ) {
-				sb.WriteString(name)
-				break
-			}
-			switch gotype.UnwrapNestedType(typ.Elem).(type) {
-			case *gotype.CompositeType, *gotype.EnumType:
-				sb.WriteString("q.types.")
-				sb.WriteString(NameArrayInitFunc(typ))
-				sb.WriteString("(")
-				sb.WriteString(name)
-				sb.WriteString(")")
-			default:
-				sb.WriteString(name)
-			}
 		default:
 			sb.WriteString(name)
 		}
-	}
-	switch {
-	case tq.isInlineParams():
-		sb := &strings.Builder{}
-		for _, input := range tq.Inputs {
-			sb.WriteString(", ")
-			appendParam(sb, input.Type, input.LowerName)
-		}
-		return sb.String()
 	default:
-		sb := &strings.Builder{}
-		for _, input := range tq.Inputs {
+		sb.WriteString(name)
+	}
+}
+
+// EmitExpectRows emits the number of rows a query with the expect-rows pragma
+// must affect.
+func (tq TemplatedQuery) EmitExpectRows() (string, error) {
+	if tq.ExpectRows == nil {
+		return "", fmt.Errorf("cannot EmitExpectRows for query %s without the expect-rows pragma", tq.Name)
+	}
+	return strconv.FormatInt(*tq.ExpectRows, 10), nil
+}
+
+// EmitCopyFromTable emits the pgx.Identifier of the table for a :copyfrom
+// query, like pgx.Identifier{"public", "author"}.
+func (tq TemplatedQuery) EmitCopyFromTable() string {
+	return "pgx.Identifier{" + joinQuoted(tq.CopyFrom.Table) + "}"
+}
+
+// EmitCopyFromColumns emits the column names for a :copyfrom query, like
+// []string{"first_name", "last_name"}.
+func (tq TemplatedQuery) EmitCopyFromColumns() string {
+	return "[]string{" + joinQuoted(tq.CopyFrom.Columns) + "}"
+}
+
+// EmitCopyFromValues emits the comma separated values of the ith row for a
+// :copyfrom query, like "params[i].FirstName, params[i].LastName".
+func (tq TemplatedQuery) EmitCopyFromValues() string {
+	sb := &strings.Builder{}
+	for i, input := range tq.Inputs {
+		if i > 0 {
 			sb.WriteString(", ")
-			appendParam(sb, input.Type, "params."+input.UpperName)
 		}
-		return sb.String()
+		tq.writeParamArg(sb, input.Type, "params[i]."+input.UpperName)
 	}
+	return sb.String()
+}
+
+// joinQuoted quotes each string as a Go string literal and joins them with
+// commas.
+func joinQuoted(ss []string) string {
+	quoted := make([]string, len(ss))
+	for i, s := range ss {
+		quoted[i] = strconv.Quote(s)
+	}
+	return strings.Join(quoted, ", ")
 }
 
 func (tq TemplatedQuery) isInlineParams() bool {
-	return len(tq.Inputs) <= tq.InlineParamCount
+	// :copyfrom queries take a slice of param structs, one for each row.
+	return tq.ResultKind != ast.ResultKindCopyFrom && len(tq.Inputs) <= tq.InlineParamCount
 }
 
 // EmitRowScanArgs emits the args to scan a single row from a pgx.Row or
-// pgx.Rows.
+// pgx.Rows, or from a sql.Row or sql.Rows for database/sql.
 func (tq TemplatedQuery) EmitRowScanArgs() (string, error) {
 	switch tq.ResultKind {
-	case ast.ResultKindExec:
-		return "", fmt.Errorf("cannot EmitRowScanArgs for :exec query %s", tq.Name)
-	case ast.ResultKindMany, ast.ResultKindOne:
+	case ast.ResultKindExec, ast.ResultKindExecRows:
+		return "", fmt.Errorf("cannot EmitRowScanArgs for %s query %s", tq.ResultKind, tq.Name)
+	case ast.ResultKindMany, ast.ResultKindOne, ast.ResultKindOpt:
 		break // okay
 	default:
 		return "", fmt.Errorf("unhandled EmitRowScanArgs type: %s", tq.ResultKind)
 	}
 
-	hasOnlyOneNonVoid := len(removeVoidColumns(tq.Outputs)) == 1
+	hasOnlyOneNonVoid := !tq.hasRowStruct()
 	sb := strings.Builder{}
 	sb.Grow(15 * len(tq.Outputs))
 	for i, out := range tq.Outputs {
@@ -220,30 +358,40 @@ func (tq TemplatedQuery) EmitRowScanArgs() (string, error) {
 		case *gotype.ArrayType:
 			switch gotype.UnwrapNestedType(typ.Elem).(type) {
 			case *gotype.EnumType, *gotype.CompositeType:
+				if tq.DatabaseSQL {
+					sb.WriteString(NameArrayScannerFunc(typ))
+					sb.WriteString("(")
+					sb.WriteString(tq.scanDest(out, hasOnlyOneNonVoid))
+					sb.WriteString(")")
+					break
+				}
+				if tq.PgxVersion == PgxV5 {
+					sb.WriteString(tq.scanDest(out, hasOnlyOneNonVoid))
+					break
+				}
 				sb.WriteString(out.LowerName)
 				sb.WriteString("Array")
 			default:
-				if hasOnlyOneNonVoid {
-					sb.WriteString("&item")
-				} else {
-					sb.WriteString("&item.")
-					sb.WriteString(out.UpperName)
-				}
+				sb.WriteString(tq.scanDest(out, hasOnlyOneNonVoid))
 			}
 
 		case *gotype.CompositeType:
+			if tq.PgxVersion == PgxV5 || tq.DatabaseSQL {
+				sb.WriteString(tq.scanDest(out, hasOnlyOneNonVoid))
+				break
+			}
 			sb.WriteString(out.LowerName)
 			sb.WriteString("Row")
 
 		case *gotype.EnumType, *gotype.OpaqueType:
-			if hasOnlyOneNonVoid {
-				sb.WriteString("&item")
-			} else {
-				sb.WriteString("&item.")
-				sb.WriteString(out.UpperName)
-			}
+			sb.WriteString(tq.scanDest(out, hasOnlyOneNonVoid))
 
 		case *gotype.VoidType:
+			if tq.DatabaseSQL {
+				// database/sql requires a pointer for every column.
+				sb.WriteString("new(interface{})")
+				break
+			}
 			sb.WriteString("nil")
 
 		default:
@@ -256,17 +404,48 @@ func (tq TemplatedQuery) EmitRowScanArgs() (string, error) {
 	return sb.String(), nil
 }
 
+// scanDest returns the pointer to the item field that holds the scanned out
+// column.
+func (tq TemplatedQuery) scanDest(out TemplatedColumn, hasOnlyOneNonVoid bool) string {
+	if hasOnlyOneNonVoid {
+		return "&item"
+	}
+	return "&item." + out.UpperName
+}
+
+// hasRowStruct returns true if the query scans each row into a struct instead
+// of directly into the single output column.
+func (tq TemplatedQuery) hasRowStruct() bool {
+	return tq.Proto != nil || len(removeVoidColumns(tq.Outputs)) > 1
+}
+
 // EmitResultType returns the string representing the overall query result type,
 // meaning the return result.
 func (tq TemplatedQuery) EmitResultType() (string, error) {
 	outs := removeVoidColumns(tq.Outputs)
+	cmdTag := "pgconn.CommandTag"
+	if tq.DatabaseSQL {
+		cmdTag = "sql.Result"
+	}
+	if tq.Proto != nil {
+		switch tq.ResultKind {
+		case ast.ResultKindOne, ast.ResultKindOpt:
+			return "*" + tq.Proto.QualType, nil
+		case ast.ResultKindMany:
+			return "[]*" + tq.Proto.QualType, nil
+		}
+	}
 	switch tq.ResultKind {
 	case ast.ResultKindExec:
-		return "pgconn.CommandTag", nil
+		return cmdTag, nil
+	case ast.ResultKindExecRows:
+		return "int64", nil // number of rows affected
+	case ast.ResultKindCopyFrom:
+		return "int64", nil // number of rows copied
 	case ast.ResultKindMany:
 		switch len(outs) {
 		case 0:
-			return "pgconn.CommandTag", nil
+			return cmdTag, nil
 		case 1:
 			return "[]" + outs[0].QualType, nil
 		default:
@@ -275,12 +454,23 @@ func (tq TemplatedQuery) EmitResultType() (string, error) {
 	case ast.ResultKindOne:
 		switch len(outs) {
 		case 0:
-			return "pgconn.CommandTag", nil
+			return cmdTag, nil
 		case 1:
 			return outs[0].QualType, nil
 		default:
 			return tq.Name + "Row", nil
 		}
+	case ast.ResultKindOpt:
+		// A nil pointer means no rows matched. validateOptQuery rejects a single
+		// nullable column, so the pointer never wraps a nullable column type.
+		switch len(outs) {
+		case 0:
+			return "", fmt.Errorf("%s query %s has no output columns", tq.ResultKind, tq.Name)
+		case 1:
+			return "*" + outs[0].QualType, nil
+		default:
+			return "*" + tq.Name + "Row", nil
+		}
 	default:
 		return "", fmt.Errorf("unhandled EmitResultType kind: %s", tq.ResultKind)
 	}
@@ -291,6 +481,9 @@ func (tq TemplatedQuery) EmitResultType() (string, error) {
 // var declaration so that JSON serialization returns an empty array instead of
 // null.
 func (tq TemplatedQuery) EmitResultTypeInit(name string) (string, error) {
+	if tq.Proto != nil && tq.ResultKind != ast.ResultKindMany {
+		return "var " + name + " " + tq.Proto.RowName, nil
+	}
 	switch tq.ResultKind {
 	case ast.ResultKindOne:
 		result, err := tq.EmitResultType()
@@ -303,6 +496,20 @@ func (tq TemplatedQuery) EmitResultTypeInit(name string) (string, error) {
 		}
 		return "var " + name + " " + result, nil
 
+	case ast.ResultKindOpt:
+		result, err := tq.EmitResultType()
+		if err != nil {
+			return "", fmt.Errorf("create result type for EmitResultTypeInit: %w", err)
+		}
+		// Remove the pointer that signals a missing row. Return the address of
+		// the item if a row matches.
+		result = strings.TrimPrefix(result, "*")
+		isArr := strings.HasPrefix(result, "[]")
+		if isArr {
+			return name + " := " + result + "{}", nil
+		}
+		return "var " + name + " " + result, nil
+
 	case ast.ResultKindMany:
 		result, err := tq.EmitResultType()
 		if err != nil {
@@ -323,7 +530,11 @@ func (tq TemplatedQuery) EmitResultTypeInit(name string) (string, error) {
 }
 
 // EmitResultDecoders declares all initialization required for output types.
+// pgx v5 scans directly into the output types so needs no decoders.
 func (tq TemplatedQuery) EmitResultDecoders() (string, error) {
+	if tq.PgxVersion == PgxV5 {
+		return "", nil
+	}
 	sb := &strings.Builder{}
 	const indent = "\n\t" // 1 level indent inside querier method
 	for _, out := range tq.Outputs {
@@ -358,7 +569,16 @@ func (tq TemplatedQuery) EmitResultDecoders() (string, error) {
 // output struct.
 //
 // Copies pgtype.EnumArray fields into Go enum array types.
+//
+// pgx v5 scans directly into the output types so needs no assigns.
+//
+// zeroVal is the value returned alongside the error if an assign fails. An
+// empty zeroVal returns only the error, like in the Each method of :many
+// queries.
 func (tq TemplatedQuery) EmitResultAssigns(zeroVal string) (string, error) {
+	if tq.PgxVersion == PgxV5 {
+		return "", nil
+	}
 	sb := &strings.Builder{}
 	indent := "\n\t"
 	if tq.ResultKind == ast.ResultKindMany {
@@ -371,15 +591,15 @@ func (tq TemplatedQuery) EmitResultAssigns(zeroVal string) (string, error) {
 			sb.WriteString("if err := ")
 			sb.WriteString(out.LowerName)
 			sb.WriteString("Row.AssignTo(&item")
-			if len(removeVoidColumns(tq.Outputs)) > 1 {
+			if tq.hasRowStruct() {
 				sb.WriteRune('.')
 				sb.WriteString(out.UpperName)
 			}
 			sb.WriteString("); err != nil {")
 			sb.WriteString(indent)
 			sb.WriteString("\treturn ")
-			sb.WriteString(zeroVal)
-			sb.WriteString(", fmt.Errorf(\"assign ")
+			sb.WriteString(emitReturnPrefix(zeroVal))
+			sb.WriteString("fmt.Errorf(\"assign ")
 			sb.WriteString(tq.Name)
 			sb.WriteString(" row: %w\", err)")
 			sb.WriteString(indent)
@@ -391,15 +611,15 @@ func (tq TemplatedQuery) EmitResultAssigns(zeroVal string) (string, error) {
 				sb.WriteString("if err := ")
 				sb.WriteString(out.LowerName)
 				sb.WriteString("Array.AssignTo(&item")
-				if len(removeVoidColumns(tq.Outputs)) > 1 {
+				if tq.hasRowStruct() {
 					sb.WriteRune('.')
 					sb.WriteString(out.UpperName)
 				}
 				sb.WriteString("); err != nil {")
 				sb.WriteString(indent)
 				sb.WriteString("\treturn ")
-				sb.WriteString(zeroVal)
-				sb.WriteString(", fmt.Errorf(\"assign ")
+				sb.WriteString(emitReturnPrefix(zeroVal))
+				sb.WriteString("fmt.Errorf(\"assign ")
 				sb.WriteString(tq.Name)
 				sb.WriteString(" row: %w\", err)")
 				sb.WriteString(indent)
@@ -410,11 +630,34 @@ func (tq TemplatedQuery) EmitResultAssigns(zeroVal string) (string, error) {
 	return sb.String(), nil
 }
 
+// emitReturnPrefix returns the values to return before an error, like "nil, "
+// for zeroVal "nil", or nothing if zeroVal is empty.
+func emitReturnPrefix(zeroVal string) string {
+	if zeroVal == "" {
+		return ""
+	}
+	return zeroVal + ", "
+}
+
+// EmitResultEachElem returns the string representing the row passed to the
+// callback of the Each method of :many queries. The row has the same type as
+// an element of the slice returned by the :many query, including any pointer.
+func (tq TemplatedQuery) EmitResultEachElem() (string, error) {
+	result, err := tq.EmitResultType()
+	if err != nil {
+		return "", fmt.Errorf("unhandled EmitResultEachElem type: %w", err)
+	}
+	return strings.TrimPrefix(result, "[]"), nil
+}
+
 // EmitResultElem returns the string representing a single item in the overall
 // query result type. For :one and :exec queries, this is the same as
 // EmitResultType. For :many queries, this is the element type of the slice
 // result type.
 func (tq TemplatedQuery) EmitResultElem() (string, error) {
+	if tq.Proto != nil {
+		return tq.Proto.RowName, nil
+	}
 	result, err := tq.EmitResultType()
 	if err != nil {
 		return "", fmt.Errorf("unhandled EmitResultElem type: %w", err)
@@ -427,16 +670,23 @@ func (tq TemplatedQuery) EmitResultElem() (string, error) {
 }
 
 // EmitResultExpr returns the string representation of a single item to return
-// for :one queries or to append for :many queries. Useful for figuring out if
-// we need to use the address operator. Controls the string item and &item in:
+// for :one and :opt queries or to append for :many queries. Useful for
+// figuring out if we need to use the address operator. Controls the string
+// item and &item in:
 //
 //	items = append(items, item)
 //	items = append(items, &item)
 func (tq TemplatedQuery) EmitResultExpr(name string) (string, error) {
+	if tq.Proto != nil {
+		return name + ".proto()", nil // convert the row struct to the message
+	}
 	switch tq.ResultKind {
 	case ast.ResultKindOne:
 		return name, nil
 
+	case ast.ResultKindOpt:
+		return "&" + name, nil
+
 	case ast.ResultKindMany:
 		result, err := tq.EmitResultType()
 		if err != nil {
@@ -453,6 +703,16 @@ func (tq TemplatedQuery) EmitResultExpr(name string) (string, error) {
 	}
 }
 
+// EmitResultZero returns the value to return alongside an error for :one
+// queries. Returns nil for queries with the proto-type pragma so that callers
+// don't get a partial message.
+func (tq TemplatedQuery) EmitResultZero(name string) (string, error) {
+	if tq.Proto != nil {
+		return "nil", nil
+	}
+	return tq.EmitResultExpr(name)
+}
+
 // getLongestOutput returns the length of the longest name and type name in all
 // columns. Useful for struct definition alignment.
 func getLongestOutput(outs []TemplatedColumn) (int, int) {
@@ -479,17 +739,21 @@ func getLongestOutput(outs []TemplatedColumn) (int, int) {
 // needed.
 func (tq TemplatedQuery) EmitRowStruct() string {
 	switch tq.ResultKind {
-	case ast.ResultKindExec:
+	case ast.ResultKindExec, ast.ResultKindExecRows, ast.ResultKindCopyFrom:
 		return ""
-	case ast.ResultKindOne, ast.ResultKindMany:
+	case ast.ResultKindOne, ast.ResultKindOpt, ast.ResultKindMany:
 		outs := removeVoidColumns(tq.Outputs)
-		if len(outs) <= 1 {
+		if !tq.hasRowStruct() {
 			return "" // if there's only 1 output column, return it directly
 		}
+		rowName := tq.Name + "Row"
+		if tq.Proto != nil {
+			rowName = tq.Proto.RowName
+		}
 		sb := &strings.Builder{}
 		sb.WriteString("\n\ntype ")
-		sb.WriteString(tq.Name)
-		sb.WriteString("Row struct {\n")
+		sb.WriteString(rowName)
+		sb.WriteString(" struct {\n")
 		maxNameLen, maxTypeLen := getLongestOutput(outs)
 		for _, out := range outs {
 			// Name
@@ -506,6 +770,9 @@ func (tq TemplatedQuery) EmitRowStruct() string {
 			sb.WriteRune('\n')
 		}
 		sb.WriteString("}")
+		if tq.Proto != nil {
+			tq.writeProtoMethod(sb)
+		}
 		return sb.String()
 	default:
 		panic("unhandled result type: " + tq.ResultKind)
@@ -525,3 +792,74 @@ func removeVoidColumns(cols []TemplatedColumn) []TemplatedColumn {
 	}
 	return outs
 }
+
+// writeProtoMethod writes the proto method that converts the row struct into
+// the protobuf message for queries with the proto-type pragma.
+func (tq TemplatedQuery) writeProtoMethod(sb *strings.Builder) {
+	p := tq.Proto
+	sb.WriteString("\n\n// proto converts the row to the ")
+	sb.WriteString(p.Name)
+	sb.WriteString(" protobuf message.\n")
+	sb.WriteString("func (r ")
+	sb.WriteString(p.RowName)
+	sb.WriteString(") proto() *")
+	sb.WriteString(p.QualType)
+	sb.WriteString(" {\n")
+
+	// Set fields without a condition in the composite literal.
+	var literals, stmts []TemplatedProtoField
+	maxNameLen := 0
+	for _, field := range p.Fields {
+		if field.Cond != "" || field.Addr {
+			stmts = append(stmts, field)
+			continue
+		}
+		literals = append(literals, field)
+		maxNameLen = max(maxNameLen, len(field.GoName))
+	}
+	sb.WriteString("\tmsg := &")
+	sb.WriteString(p.QualType)
+	sb.WriteString("{")
+	if len(literals) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, field := range literals {
+		sb.WriteString("\t\t")
+		sb.WriteString(field.GoName)
+		sb.WriteString(": ")
+		sb.WriteString(strings.Repeat(" ", maxNameLen-len(field.GoName)))
+		sb.WriteString(field.Value)
+		sb.WriteString(",\n")
+	}
+	if len(literals) > 0 {
+		sb.WriteString("\t")
+	}
+	sb.WriteString("}\n")
+
+	// Set the remaining fields with statements, like only if the column isn't
+	// null.
+	for _, field := range stmts {
+		sb.WriteString("\t")
+		if field.Cond != "" {
+			sb.WriteString("if ")
+			sb.WriteString(field.Cond)
+			sb.WriteString(" ")
+		}
+		sb.WriteString("{\n")
+		if field.Addr {
+			sb.WriteString("\t\tv := ")
+			sb.WriteString(field.Value)
+			sb.WriteString("\n\t\tmsg.")
+			sb.WriteString(field.GoName)
+			sb.WriteString(" = &v\n")
+		} else {
+			sb.WriteString("\t\tmsg.")
+			sb.WriteString(field.GoName)
+			sb.WriteString(" = ")
+			sb.WriteString(field.Value)
+			sb.WriteString("\n")
+		}
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("\treturn msg\n}")
+}
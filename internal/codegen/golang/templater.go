@@ -2,10 +2,13 @@ package golang
 
 import (
 	"fmt"
+	"github.com/atomicleads/pggen/internal/ast"
 	"github.com/atomicleads/pggen/internal/casing"
 	"github.com/atomicleads/pggen/internal/codegen"
 	"github.com/atomicleads/pggen/internal/codegen/golang/gotype"
 	"github.com/atomicleads/pggen/internal/gomod"
+	"github.com/atomicleads/pggen/internal/pg"
+	"github.com/atomicleads/pggen/internal/pginfer"
 	"strconv"
 	"strings"
 	"unicode"
@@ -17,6 +20,10 @@ type Templater struct {
 	resolver         TypeResolver
 	pkg              string // Go package name
 	inlineParamCount int
+	instrumentation  Instrumentation
+	pgxVersion       PgxVersion
+	databaseSQL      bool
+	protos           *protoLoader
 }
 
 // TemplaterOpts is options to control the template logic.
@@ -26,6 +33,17 @@ type TemplaterOpts struct {
 	Pkg      string // Go package name
 	// How many params to inline when calling querier methods.
 	InlineParamCount int
+	// How the generated querier reports queries.
+	Instrumentation Instrumentation
+	// The major version of pgx used by the generated code.
+	PgxVersion PgxVersion
+	// Generate a querier backed by database/sql instead of pgx.
+	DatabaseSQL bool
+	// A map from a protobuf package to the Go package path of the code
+	// generated by protoc-gen-go, for queries with the proto-type pragma.
+	ProtoPackages map[string]string
+	// The directory to resolve Go packages from, typically the output dir.
+	WorkDir string
 }
 
 func NewTemplater(opts TemplaterOpts) Templater {
@@ -34,6 +52,10 @@ func NewTemplater(opts TemplaterOpts) Templater {
 		caser:            opts.Caser,
 		resolver:         opts.Resolver,
 		inlineParamCount: opts.InlineParamCount,
+		instrumentation:  opts.Instrumentation,
+		pgxVersion:       opts.PgxVersion,
+		databaseSQL:      opts.DatabaseSQL,
+		protos:           newProtoLoader(opts.ProtoPackages, opts.WorkDir),
 	}
 }
 
@@ -41,6 +63,9 @@ func NewTemplater(opts TemplaterOpts) Templater {
 func (tm Templater) TemplateAll(files []codegen.QueryFile) ([]TemplatedFile, error) {
 	goQueryFiles := make([]TemplatedFile, 0, len(files))
 	allDeclarers := NewDeclarerSet()
+	declaredImports := NewImportSet() // imports for the declarers in the leader
+	var allRegisteredTypes []string
+	seenRegisteredTypes := make(map[string]bool)
 
 	// Pick leader file to define common structs and interfaces via Declarer.
 	firstIndex := -1
@@ -60,26 +85,63 @@ func (tm Templater) TemplateAll(files []codegen.QueryFile) ([]TemplatedFile, err
 		}
 		goQueryFiles = append(goQueryFiles, goFile)
 		allDeclarers.AddAll(decls.ListAll()...)
+		for _, query := range goFile.Queries {
+			for _, input := range query.Inputs {
+				declaredImports.AddDeclaredTypes(input.Type)
+			}
+			if query.Proto != nil {
+				// The Querier returns the message instead of the output types.
+				declaredImports.AddPackage(query.Proto.PkgPath)
+				continue
+			}
+			for _, output := range query.Outputs {
+				declaredImports.AddDeclaredTypes(output.Type)
+			}
+		}
+		if tm.pgxVersion == PgxV5 {
+			for _, query := range goFile.Queries {
+				for _, input := range query.Inputs {
+					allRegisteredTypes = appendRegisteredTypes(allRegisteredTypes, seenRegisteredTypes, input.Type)
+				}
+				for _, output := range query.Outputs {
+					allRegisteredTypes = appendRegisteredTypes(allRegisteredTypes, seenRegisteredTypes, output.Type)
+				}
+			}
+		}
 	}
 
 	// Add declarers to leader file.
-	goQueryFiles[firstIndex].Declarers = allDeclarers.ListAll()
+	leader := &goQueryFiles[firstIndex]
+	leader.Declarers = allDeclarers.ListAll()
+	leader.RegisteredTypes = allRegisteredTypes
+	leaderImports := declaredImports
+	for _, pkg := range leader.Imports {
+		leaderImports.AddPackage(pkg)
+	}
+	for _, decl := range leader.Declarers {
+		if decl, ok := decl.(ImportDeclarer); ok {
+			for _, pkg := range decl.Imports() {
+				leaderImports.AddPackage(pkg)
+			}
+		}
+	}
+	leader.Imports = leaderImports.SortedPackages()
 
-	// Remove unneeded pgconn import if possible.
+	// Remove unneeded command tag import if possible.
 	for i, file := range goQueryFiles {
-		if file.needsPgconnImport() {
+		if file.needsCommandTagImport() {
 			continue
 		}
-		pgconnIdx := -1
+		cmdTagIdx := -1
 		imports := file.Imports
 		for i, pkg := range imports {
-			if pkg == "github.com/jackc/pgconn" {
-				pgconnIdx = i
+			if pkg == tm.commandTagPackage() {
+				cmdTagIdx = i
 				break
 			}
 		}
-		if pgconnIdx > -1 {
-			copy(imports[pgconnIdx:], imports[pgconnIdx+1:])
+		if cmdTagIdx > -1 {
+			copy(imports[cmdTagIdx:], imports[cmdTagIdx+1:])
 			goQueryFiles[i].Imports = imports[:len(imports)-1]
 		}
 	}
@@ -113,10 +175,24 @@ func (tm Templater) templateFile(file codegen.QueryFile, isLeader bool) (Templat
 	imports := NewImportSet()
 	imports.AddPackage("context")
 	imports.AddPackage("fmt")
-	imports.AddPackage("github.com/jackc/pgconn")
+	imports.AddPackage(tm.commandTagPackage())
+	if !tm.databaseSQL {
+		imports.AddPackage(tm.pgxPackage()) // Scan methods use pgx.BatchResults
+	}
 	if isLeader {
-		imports.AddPackage("github.com/jackc/pgtype")
-		imports.AddPackage("github.com/jackc/pgx/v4")
+		imports.AddPackage("errors")
+		if tm.pgxVersion != PgxV5 && !tm.databaseSQL {
+			imports.AddPackage("github.com/jackc/pgtype") // for typeResolver
+		}
+		switch tm.instrumentation {
+		case InstrumentationHooks:
+			imports.AddPackage("time")
+		case InstrumentationOTel:
+			imports.AddPackage("time")
+			imports.AddPackage("go.opentelemetry.io/otel/attribute")
+			imports.AddPackage("go.opentelemetry.io/otel/codes")
+			imports.AddPackage("go.opentelemetry.io/otel/trace")
+		}
 	}
 
 	pkgPath := ""
@@ -132,6 +208,17 @@ func (tm Templater) templateFile(file codegen.QueryFile, isLeader bool) (Templat
 	queries := make([]TemplatedQuery, 0, len(file.Queries))
 	declarers := NewDeclarerSet()
 	for _, query := range file.Queries {
+		if tm.databaseSQL && query.ResultKind == ast.ResultKindCopyFrom {
+			return TemplatedFile{}, nil, fmt.Errorf("query %s: %s queries require pgx because database/sql doesn't support COPY",
+				query.Name, query.ResultKind)
+		}
+		if err := validateOptQuery(query); err != nil {
+			return TemplatedFile{}, nil, err
+		}
+		if query.ExpectRows != nil {
+			declarers.AddAll(NewUnexpectedRowsErrorDeclarer())
+		}
+
 		// Build doc string.
 		docs := strings.Builder{}
 		avgCharsPerLine := 40
@@ -148,7 +235,7 @@ func (tm Templater) templateFile(file codegen.QueryFile, isLeader bool) (Templat
 		// Build inputs.
 		inputs := make([]TemplatedParam, len(query.Inputs))
 		for i, input := range query.Inputs {
-			goType, err := tm.resolver.Resolve(input.PgType /*nullable*/, false, pkgPath)
+			goType, err := tm.resolver.Resolve(input.PgType, input.Nullable, pkgPath)
 			if err != nil {
 				return TemplatedFile{}, nil, err
 			}
@@ -160,8 +247,14 @@ func (tm Templater) templateFile(file codegen.QueryFile, isLeader bool) (Templat
 				Type:      goType,
 				RawName:   query.Inputs[i],
 			}
-			ds := FindInputDeclarers(goType).ListAll()
-			declarers.AddAll(ds...)
+			switch {
+			case tm.databaseSQL:
+				declarers.AddAll(FindDatabaseSQLDeclarers(goType).ListAll()...)
+			case tm.pgxVersion == PgxV5:
+				declarers.AddAll(FindPgx5Declarers(goType).ListAll()...)
+			default:
+				declarers.AddAll(FindInputDeclarers(goType).ListAll()...)
+			}
 		}
 
 		// Build outputs.
@@ -179,8 +272,39 @@ func (tm Templater) templateFile(file codegen.QueryFile, isLeader bool) (Templat
 				Type:      goType,
 				QualType:  gotype.QualifyType(goType, pkgPath),
 			}
-			ds := FindOutputDeclarers(goType).ListAll()
-			declarers.AddAll(ds...)
+			switch {
+			case tm.databaseSQL:
+				declarers.AddAll(FindDatabaseSQLDeclarers(goType).ListAll()...)
+			case tm.pgxVersion == PgxV5:
+				declarers.AddAll(FindPgx5Declarers(goType).ListAll()...)
+			default:
+				declarers.AddAll(FindOutputDeclarers(goType).ListAll()...)
+			}
+		}
+
+		// Build the protobuf message conversion.
+		var proto *TemplatedProto
+		if query.ProtobufType != "" {
+			switch query.ResultKind {
+			case ast.ResultKindOne, ast.ResultKindOpt, ast.ResultKindMany:
+				break // okay
+			default:
+				return TemplatedFile{}, nil, fmt.Errorf("query %s: proto-type pragma requires a query that returns rows; got result kind %s",
+					query.Name, query.ResultKind)
+			}
+			msg, err := tm.protos.load(query.ProtobufType)
+			if err != nil {
+				return TemplatedFile{}, nil, fmt.Errorf("query %s: %w", query.Name, err)
+			}
+			rowName := tm.caser.ToLowerGoIdent(query.Name) + "Row"
+			p, pkgs, err := newTemplatedProto(query.Name, rowName, pkgPath, msg, outputs)
+			if err != nil {
+				return TemplatedFile{}, nil, err
+			}
+			for _, pkg := range pkgs {
+				imports.AddPackage(pkg)
+			}
+			proto = p
 		}
 
 		queries = append(queries, TemplatedQuery{
@@ -192,19 +316,109 @@ func (tm Templater) templateFile(file codegen.QueryFile, isLeader bool) (Templat
 			Inputs:           inputs,
 			Outputs:          outputs,
 			InlineParamCount: tm.inlineParamCount,
+			PgxVersion:       tm.pgxVersion,
+			DatabaseSQL:      tm.databaseSQL,
+			CopyFrom:         query.CopyFrom,
+			ExpectRows:       query.ExpectRows,
+			Proto:            proto,
 		})
 	}
 
 	return TemplatedFile{
-		PkgPath:    pkgPath,
-		GoPkg:      tm.pkg,
-		SourcePath: file.SourcePath,
-		Queries:    queries,
-		Imports:    imports.SortedPackages(),
-		IsLeader:   isLeader,
+		PkgPath:         pkgPath,
+		GoPkg:           tm.pkg,
+		SourcePath:      file.SourcePath,
+		Queries:         queries,
+		Imports:         imports.SortedPackages(),
+		IsLeader:        isLeader,
+		Instrumentation: tm.instrumentation,
+		PgxVersion:      tm.pgxVersion,
+		DatabaseSQL:     tm.databaseSQL,
 	}, declarers, nil
 }
 
+// pgxPackage returns the import path of the pgx package.
+func (tm Templater) pgxPackage() string {
+	if tm.pgxVersion == PgxV5 {
+		return "github.com/jackc/pgx/v5"
+	}
+	return "github.com/jackc/pgx/v4"
+}
+
+// pgconnPackage returns the import path of the pgconn package that matches
+// the pgx version.
+func (tm Templater) pgconnPackage() string {
+	if tm.pgxVersion == PgxV5 {
+		return "github.com/jackc/pgx/v5/pgconn"
+	}
+	return "github.com/jackc/pgconn"
+}
+
+// commandTagPackage returns the import path of the package that declares the
+// type returned by :exec queries.
+func (tm Templater) commandTagPackage() string {
+	if tm.databaseSQL {
+		return "database/sql" // sql.Result
+	}
+	return tm.pgconnPackage() // pgconn.CommandTag
+}
+
+// validateOptQuery errors if query is an :opt query that returns a single
+// nullable column. The nil pointer for a missing row would wrap a pointer or
+// a pgtype value for the column, so a missing row and a null value would be
+// easy to mix up.
+func validateOptQuery(query pginfer.TypedQuery) error {
+	if query.ResultKind != ast.ResultKindOpt || query.ProtobufType != "" {
+		return nil
+	}
+	var outs []pginfer.OutputColumn
+	for _, out := range query.Outputs {
+		if _, ok := out.PgType.(pg.VoidType); !ok {
+			outs = append(outs, out)
+		}
+	}
+	if len(outs) != 1 || !outs[0].Nullable {
+		return nil
+	}
+	return fmt.Errorf("query %s: %s query returns the single nullable column %s; "+
+		"use :one and check for ErrNotFound, or mark the column with the not-null pragma",
+		query.Name, query.ResultKind, outs[0].PgName)
+}
+
+// appendRegisteredTypes appends the schema-qualified Postgres names of typ and
+// its descendants that pgx v5 must load with conn.LoadType before use. The
+// qualified names work for mixed-case names and for types outside the
+// search_path. Appends dependencies before the types that use them because
+// LoadType for a composite or array type needs the child types registered
+// already. Skips names in seen.
+func appendRegisteredTypes(names []string, seen map[string]bool, typ gotype.Type) []string {
+	var name string
+	switch typ := gotype.UnwrapNestedType(typ).(type) {
+	case *gotype.EnumType:
+		name = typ.PgEnum.QualifiedName
+	case *gotype.CompositeType:
+		for _, fieldType := range typ.FieldTypes {
+			names = appendRegisteredTypes(names, seen, fieldType)
+		}
+		name = typ.PgComposite.QualifiedName
+	case *gotype.ArrayType:
+		switch gotype.UnwrapNestedType(typ.Elem).(type) {
+		case *gotype.CompositeType, *gotype.EnumType:
+			names = appendRegisteredTypes(names, seen, typ.Elem)
+			name = typ.PgArray.QualifiedName
+		default:
+			return names // pgx already knows arrays of builtin types
+		}
+	default:
+		return names
+	}
+	if seen[name] {
+		return names
+	}
+	seen[name] = true
+	return append(names, name)
+}
+
 // chooseUpperName converts pgName into a capitalized Go identifier name.
 // If it's not possible to convert pgName into an identifier, uses fallback with
 // a suffix using idx.
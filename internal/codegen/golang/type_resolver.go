@@ -11,18 +11,29 @@ import (
 
 // TypeResolver handles the mapping between Postgres and Go types.
 type TypeResolver struct {
-	caser     casing.Caser
-	overrides map[string]string
+	caser       casing.Caser
+	overrides   map[string]string
+	pgxVersion  PgxVersion // which pgx types to use for known Postgres types
+	databaseSQL bool       // use database/sql types for known Postgres types
 }
 
-func NewTypeResolver(c casing.Caser, overrides map[string]string) TypeResolver {
+func NewTypeResolver(c casing.Caser, overrides map[string]string, pgxVersion PgxVersion) TypeResolver {
 	overs := make(map[string]string, len(overrides))
 	for k, v := range overrides {
 		for _, alias := range listAliases(k) {
 			overs[alias] = v
 		}
 	}
-	return TypeResolver{caser: c, overrides: overs}
+	return TypeResolver{caser: c, overrides: overs, pgxVersion: pgxVersion}
+}
+
+// NewDatabaseSQLTypeResolver creates a TypeResolver that maps known Postgres
+// types to types that implement sql.Scanner and driver.Valuer, like
+// sql.NullString and pgtype.Int4Array.
+func NewDatabaseSQLTypeResolver(c casing.Caser, overrides map[string]string) TypeResolver {
+	tr := NewTypeResolver(c, overrides, PgxV4)
+	tr.databaseSQL = true
+	return tr
 }
 
 // Resolve maps a Postgres type to a Go type.
@@ -39,9 +50,18 @@ func (tr TypeResolver) Resolve(pgt pg.Type, nullable bool, pkgPath string) (goty
 	// Known type.
 	var typ gotype.Type
 	var isKnownType bool
-	if nullable {
+	switch {
+	case tr.databaseSQL && nullable:
+		typ, isKnownType = gotype.FindKnownTypeDatabaseSQLNullable(pgt.OID())
+	case tr.databaseSQL:
+		typ, isKnownType = gotype.FindKnownTypeDatabaseSQLNonNullable(pgt.OID())
+	case tr.pgxVersion == PgxV5 && nullable:
+		typ, isKnownType = gotype.FindKnownTypePgx5Nullable(pgt.OID())
+	case tr.pgxVersion == PgxV5:
+		typ, isKnownType = gotype.FindKnownTypePgx5NonNullable(pgt.OID())
+	case nullable:
 		typ, isKnownType = gotype.FindKnownTypeNullable(pgt.OID())
-	} else {
+	default:
 		typ, isKnownType = gotype.FindKnownTypeNonNullable(pgt.OID())
 	}
 	if isKnownType {
@@ -49,6 +69,10 @@ func (tr TypeResolver) Resolve(pgt pg.Type, nullable bool, pkgPath string) (goty
 		case *gotype.ArrayType:
 			arrTyp, ok := pgt.(pg.ArrayType)
 			if !ok {
+				// []byte maps to scalar Postgres types like bytea and json.
+				if elem, isOpaque := typ.Elem.(*gotype.OpaqueType); isOpaque && elem.Name == "byte" {
+					return typ, nil
+				}
 				return nil, fmt.Errorf("resolve known type %q does not have pg array type %q", typ, pgt)
 			}
 			typ.PgArray = arrTyp
@@ -25,11 +25,13 @@ func TestTypeResolver_Resolve(t *testing.T) {
 		Values: []string{"macos", "ios", "web"},
 	}
 	tests := []struct {
-		name      string
-		overrides map[string]string
-		pgType    pg.Type
-		nullable  bool
-		want      gotype.Type
+		name        string
+		overrides   map[string]string
+		pgxVersion  PgxVersion
+		databaseSQL bool
+		pgType      pg.Type
+		nullable    bool
+		want        gotype.Type
 	}{
 		{
 			name:   "enum",
@@ -49,6 +51,67 @@ func TestTypeResolver_Resolve(t *testing.T) {
 			pgType: pg.VoidType{},
 			want:   &gotype.VoidType{},
 		},
+		{
+			name:       "pgx v5 nullable text",
+			pgxVersion: PgxV5,
+			pgType:     pg.Text,
+			nullable:   true,
+			want:       &gotype.PointerType{Elem: &gotype.OpaqueType{Name: "string", PgType: pg.Text}},
+		},
+		{
+			name:       "pgx v5 inet",
+			pgxVersion: PgxV5,
+			pgType:     pg.Inet,
+			nullable:   true,
+			want: &gotype.ImportType{
+				PkgPath: "net/netip",
+				Type:    &gotype.OpaqueType{Name: "Prefix", PgType: pg.Inet},
+			},
+		},
+		{
+			name:       "pgx v5 int4range",
+			pgxVersion: PgxV5,
+			pgType:     pg.Int4range,
+			want: &gotype.ImportType{
+				PkgPath: "github.com/jackc/pgx/v5/pgtype",
+				Type:    &gotype.OpaqueType{Name: "Range[pgtype.Int4]", PgType: pg.Int4range},
+			},
+		},
+		{
+			name:        "database/sql nullable text",
+			databaseSQL: true,
+			pgType:      pg.Text,
+			nullable:    true,
+			want: &gotype.ImportType{
+				PkgPath: "database/sql",
+				Type:    &gotype.OpaqueType{Name: "NullString", PgType: pg.Text},
+			},
+		},
+		{
+			name:        "database/sql text",
+			databaseSQL: true,
+			pgType:      pg.Text,
+			want:        &gotype.OpaqueType{Name: "string", PgType: pg.Text},
+		},
+		{
+			name:        "database/sql nullable date",
+			databaseSQL: true,
+			pgType:      pg.Date,
+			nullable:    true,
+			want: &gotype.ImportType{
+				PkgPath: "github.com/jackc/pgtype",
+				Type:    &gotype.OpaqueType{Name: "Date", PgType: pg.Date},
+			},
+		},
+		{
+			name:        "database/sql int4 array",
+			databaseSQL: true,
+			pgType:      pg.Int4Array,
+			want: &gotype.ImportType{
+				PkgPath: "github.com/jackc/pgtype",
+				Type:    &gotype.OpaqueType{Name: "Int4Array", PgType: pg.Int4Array},
+			},
+		},
 		{
 			name:      "override",
 			overrides: map[string]string{"custom_type": "example.com/custom.QualType"},
@@ -168,7 +231,14 @@ func TestTypeResolver_Resolve(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			resolver := NewTypeResolver(caser, tt.overrides)
+			pgxVersion := PgxV4
+			if tt.pgxVersion != 0 {
+				pgxVersion = tt.pgxVersion
+			}
+			resolver := NewTypeResolver(caser, tt.overrides, pgxVersion)
+			if tt.databaseSQL {
+				resolver = NewDatabaseSQLTypeResolver(caser, tt.overrides)
+			}
 			got, err := resolver.Resolve(tt.pgType, tt.nullable, testPkgPath)
 			if err != nil {
 				t.Fatal(err)
@@ -231,7 +301,7 @@ func TestType_QualifyRel(t *testing.T) {
 
 func TestCreateCompositeType(t *testing.T) {
 	caser := casing.NewCaser()
-	resolver := NewTypeResolver(caser, nil)
+	resolver := NewTypeResolver(caser, nil, PgxV4)
 	tests := []struct {
 		pkgPath string
 		pgType  pg.CompositeType
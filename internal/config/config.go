@@ -0,0 +1,189 @@
+// Package config loads a pggen project file, like pggen.yaml, that declares
+// several Go packages to generate against one Postgres database.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// FileNames are the names of project files that pggen looks for in the
+// current directory, in order of preference.
+var FileNames = []string{"pggen.yaml", "pggen.yml", "pggen.toml"}
+
+// Config is a pggen project file. The shared options apply to every package.
+// Keys use the same names as the pggen gen go flags, but in the plural for
+// lists and maps, like schema-globs for --schema-glob.
+type Config struct {
+	// Path of the project file. Relative paths in the file are relative to the
+	// directory of the project file.
+	Path string `yaml:"-" toml:"-"`
+
+	PostgresConnection string            `yaml:"postgres-connection" toml:"postgres-connection"`
+	PostgresIsolation  string            `yaml:"postgres-isolation" toml:"postgres-isolation"`
+	PostgresBackend    string            `yaml:"postgres-backend" toml:"postgres-backend"`
+	PostgresBinDir     string            `yaml:"postgres-bin-dir" toml:"postgres-bin-dir"`
+	SchemaGlobs        []string          `yaml:"schema-globs" toml:"schema-globs"`
+	MigrationsDir      string            `yaml:"migrations-dir" toml:"migrations-dir"`
+	MigrationsFormat   string            `yaml:"migrations-format" toml:"migrations-format"`
+	Acronyms           []string          `yaml:"acronyms" toml:"acronyms"`
+	GoTypes            map[string]string `yaml:"go-types" toml:"go-types"`
+	ProtoPackages      map[string]string `yaml:"proto-packages" toml:"proto-packages"`
+	InlineParamCount   *int              `yaml:"inline-param-count" toml:"inline-param-count"`
+	Instrumentation    string            `yaml:"instrumentation" toml:"instrumentation"`
+	PgxVersion         int               `yaml:"pgx-version" toml:"pgx-version"`
+	DatabaseSQL        bool              `yaml:"database-sql" toml:"database-sql"`
+	NotNullFuncs       []string          `yaml:"not-null-funcs" toml:"not-null-funcs"`
+	Packages           []Package         `yaml:"packages" toml:"packages"`
+}
+
+// Package is a single Go package to generate. Acronyms and GoTypes add to
+// the shared options of the Config, replacing shared entries with the same
+// key.
+type Package struct {
+	QueryGlobs []string          `yaml:"query-globs" toml:"query-globs"`
+	OutputDir  string            `yaml:"output-dir" toml:"output-dir"`
+	GoPackage  string            `yaml:"go-package" toml:"go-package"`
+	Acronyms   []string          `yaml:"acronyms" toml:"acronyms"`
+	GoTypes    map[string]string `yaml:"go-types" toml:"go-types"`
+}
+
+// Find returns the path of the first project file in dir from FileNames.
+// Returns an empty string if dir has no project file.
+func Find(dir string) (string, error) {
+	for _, name := range FileNames {
+		p := filepath.Join(dir, name)
+		if _, err := os.Stat(p); err != nil {
+			if !os.IsNotExist(err) {
+				return "", fmt.Errorf("stat project file %s: %w", p, err)
+			}
+			continue
+		}
+		return p, nil
+	}
+	return "", nil
+}
+
+// Load reads and validates the project file at path. Decodes TOML for a
+// .toml extension and YAML otherwise. Resolves the globs and output
+// directories, the migrations dir, and the Postgres bin dir, relative to the
+// directory of path.
+func Load(path string) (Config, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("read project file: %w", err)
+	}
+	cfg, err := parse(bs, filepath.Ext(path))
+	if err != nil {
+		return Config{}, fmt.Errorf("parse project file %s: %w", path, err)
+	}
+	if err := cfg.validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid project file %s: %w", path, err)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("absolute path for project file: %w", err)
+	}
+	cfg.Path = absPath
+	cfg.resolvePaths(filepath.Dir(absPath))
+	return cfg, nil
+}
+
+func parse(bs []byte, ext string) (Config, error) {
+	cfg := Config{}
+	if ext == ".toml" {
+		md, err := toml.Decode(string(bs), &cfg)
+		if err != nil {
+			return Config{}, err
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			return Config{}, fmt.Errorf("unknown keys: %s", strings.Join(keys, ", "))
+		}
+		return cfg, nil
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(bs))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+func (cfg Config) validate() error {
+	if len(cfg.Packages) == 0 {
+		return fmt.Errorf("at least one package must be declared in packages")
+	}
+	outDirs := make(map[string]int, len(cfg.Packages))
+	for i, pkg := range cfg.Packages {
+		if len(pkg.QueryGlobs) == 0 {
+			return fmt.Errorf("package %d: query-globs must have at least one glob", i+1)
+		}
+		if pkg.OutputDir == "" {
+			return fmt.Errorf("package %d: output-dir must be set", i+1)
+		}
+		dir := filepath.Clean(pkg.OutputDir)
+		if prev, ok := outDirs[dir]; ok {
+			return fmt.Errorf("package %d: output-dir %s is already used by package %d", i+1, pkg.OutputDir, prev)
+		}
+		outDirs[dir] = i + 1
+	}
+	return nil
+}
+
+func (cfg *Config) resolvePaths(dir string) {
+	resolve := func(p string) string {
+		if filepath.IsAbs(p) {
+			return p
+		}
+		return filepath.Join(dir, p)
+	}
+	for i, glob := range cfg.SchemaGlobs {
+		cfg.SchemaGlobs[i] = resolve(glob)
+	}
+	if cfg.MigrationsDir != "" {
+		cfg.MigrationsDir = resolve(cfg.MigrationsDir)
+	}
+	if cfg.PostgresBinDir != "" {
+		cfg.PostgresBinDir = resolve(cfg.PostgresBinDir)
+	}
+	for i := range cfg.Packages {
+		pkg := &cfg.Packages[i]
+		for j, glob := range pkg.QueryGlobs {
+			pkg.QueryGlobs[j] = resolve(glob)
+		}
+		pkg.OutputDir = resolve(pkg.OutputDir)
+	}
+}
+
+// PackageAcronyms returns the shared acronyms followed by the acronyms of
+// pkg, so that package acronyms replace shared acronyms for the same word.
+func (cfg Config) PackageAcronyms(pkg Package) []string {
+	acronyms := make([]string, 0, len(cfg.Acronyms)+len(pkg.Acronyms))
+	acronyms = append(acronyms, cfg.Acronyms...)
+	return append(acronyms, pkg.Acronyms...)
+}
+
+// PackageGoTypes returns the shared type overrides merged with the type
+// overrides of pkg.
+func (cfg Config) PackageGoTypes(pkg Package) map[string]string {
+	goTypes := make(map[string]string, len(cfg.GoTypes)+len(pkg.GoTypes))
+	for pgType, goType := range cfg.GoTypes {
+		goTypes[pgType] = goType
+	}
+	for pgType, goType := range pkg.GoTypes {
+		goTypes[pgType] = goType
+	}
+	return goTypes
+}
@@ -0,0 +1,188 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLoad(t *testing.T) {
+	inlineParamCount := 0
+	tests := []struct {
+		name     string
+		fileName string
+		contents string
+		want     func(dir string) Config
+	}{
+		{
+			name:     "yaml",
+			fileName: "pggen.yaml",
+			contents: `
+postgres-backend: local
+postgres-bin-dir: bin
+schema-globs: [migrations/*.sql]
+migrations-dir: db/migrations
+migrations-format: goose
+acronyms: [api]
+go-types:
+  text: string
+inline-param-count: 0
+not-null-funcs: [slugify]
+packages:
+  - query-globs: [author/*.sql]
+    output-dir: author
+  - query-globs: [/abs/book/*.sql]
+    output-dir: /abs/book
+    go-package: books
+    acronyms: [apis=APIs]
+    go-types:
+      int4: int
+`,
+			want: func(dir string) Config {
+				return Config{
+					Path:             filepath.Join(dir, "pggen.yaml"),
+					PostgresBackend:  "local",
+					PostgresBinDir:   filepath.Join(dir, "bin"),
+					SchemaGlobs:      []string{filepath.Join(dir, "migrations/*.sql")},
+					MigrationsDir:    filepath.Join(dir, "db/migrations"),
+					MigrationsFormat: "goose",
+					Acronyms:         []string{"api"},
+					GoTypes:          map[string]string{"text": "string"},
+					InlineParamCount: &inlineParamCount,
+					NotNullFuncs:     []string{"slugify"},
+					Packages: []Package{
+						{QueryGlobs: []string{filepath.Join(dir, "author/*.sql")}, OutputDir: filepath.Join(dir, "author")},
+						{
+							QueryGlobs: []string{"/abs/book/*.sql"},
+							OutputDir:  "/abs/book",
+							GoPackage:  "books",
+							Acronyms:   []string{"apis=APIs"},
+							GoTypes:    map[string]string{"int4": "int"},
+						},
+					},
+				}
+			},
+		},
+		{
+			name:     "toml",
+			fileName: "pggen.toml",
+			contents: `
+postgres-connection = "user=postgres"
+postgres-isolation = "database"
+pgx-version = 5
+instrumentation = "hooks"
+
+[proto-packages]
+"acme.api" = "example.com/acme/api"
+
+[[packages]]
+query-globs = ["author/*.sql"]
+output-dir = "author"
+`,
+			want: func(dir string) Config {
+				return Config{
+					Path:               filepath.Join(dir, "pggen.toml"),
+					PostgresConnection: "user=postgres",
+					PostgresIsolation:  "database",
+					PgxVersion:         5,
+					Instrumentation:    "hooks",
+					ProtoPackages:      map[string]string{"acme.api": "example.com/acme/api"},
+					Packages: []Package{
+						{QueryGlobs: []string{filepath.Join(dir, "author/*.sql")}, OutputDir: filepath.Join(dir, "author")},
+					},
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tt.fileName)
+			if err := os.WriteFile(path, []byte(tt.contents), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			got, err := Load(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want(dir), got); diff != "" {
+				t.Errorf("Load() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLoad_Error(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		contents string
+		wantErr  string
+	}{
+		{
+			name:     "no packages",
+			fileName: "pggen.yaml",
+			contents: "schema-globs: [schema.sql]\n",
+			wantErr:  "at least one package must be declared",
+		},
+		{
+			name:     "unknown yaml key",
+			fileName: "pggen.yaml",
+			contents: "query-globs: [query.sql]\n",
+			wantErr:  "field query-globs not found",
+		},
+		{
+			name:     "unknown toml key",
+			fileName: "pggen.toml",
+			contents: "[[packages]]\nquery-glob = [\"query.sql\"]\noutput-dir = \"out\"\n",
+			wantErr:  "unknown keys: packages.query-glob",
+		},
+		{
+			name:     "missing output dir",
+			fileName: "pggen.yaml",
+			contents: "packages:\n  - query-globs: [query.sql]\n",
+			wantErr:  "package 1: output-dir must be set",
+		},
+		{
+			name:     "duplicate output dir",
+			fileName: "pggen.yaml",
+			contents: "packages:\n  - {query-globs: [a.sql], output-dir: out}\n  - {query-globs: [b.sql], output-dir: ./out}\n",
+			wantErr:  "package 2: output-dir ./out is already used by package 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.fileName)
+			if err := os.WriteFile(path, []byte(tt.contents), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v; want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	got, err := Find(dir)
+	if err != nil || got != "" {
+		t.Fatalf("Find() in empty dir = %q, %v; want empty path", got, err)
+	}
+	for _, name := range []string{"pggen.toml", "pggen.yaml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err = Find(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(dir, "pggen.yaml"); got != want {
+		t.Errorf("Find() = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,201 @@
+// Package migrations reads the up migrations from the migration directory of
+// a migration tool, like goose or golang-migrate, so pggen can load the schema
+// from the migrations.
+package migrations
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Format is the migration tool that created a migrations directory.
+type Format string
+
+const (
+	// FormatGoose is a goose directory of <version>_<name>.sql files with
+	// "-- +goose Up" and "-- +goose Down" annotations.
+	FormatGoose Format = "goose"
+	// FormatGolangMigrate is a golang-migrate directory of
+	// <version>_<name>.up.sql and <version>_<name>.down.sql files.
+	FormatGolangMigrate Format = "golang-migrate"
+	// FormatDbmate is a dbmate directory of <version>_<name>.sql files with
+	// "-- migrate:up" and "-- migrate:down" sections.
+	FormatDbmate Format = "dbmate"
+	// FormatSQLMigrate is a sql-migrate directory of *.sql files with
+	// "-- +migrate Up" and "-- +migrate Down" annotations.
+	FormatSQLMigrate Format = "sql-migrate"
+	// FormatAtlas is an Atlas directory of <version>_<name>.sql files that only
+	// contain up migrations.
+	FormatAtlas Format = "atlas"
+)
+
+// Migration is the up portion of a single migration file.
+type Migration struct {
+	Version string // version from the file name, like "20230102150405" or "001"
+	Path    string // path of the migration file
+	Up      string // SQL of the up migration
+}
+
+// Read reads the up migrations in dir, sorted by version. Ignores files that
+// the migration tool ignores, like the down files of golang-migrate and the
+// atlas.sum file.
+func Read(dir string, format Format) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read migrations dir: %w", err)
+	}
+	var migrations []Migration
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		path := filepath.Join(dir, name)
+		if format == FormatGoose && strings.HasSuffix(name, ".go") {
+			return nil, fmt.Errorf("goose Go migration %s is not supported; use SQL migrations", path)
+		}
+		if !strings.HasSuffix(name, ".sql") {
+			continue
+		}
+		if format == FormatGolangMigrate && !strings.HasSuffix(name, ".up.sql") {
+			continue
+		}
+		version, err := parseVersion(name, format)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", path, err)
+		}
+		up, err := readUp(path, format)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: %w", path, err)
+		}
+		migrations = append(migrations, Migration{Version: version, Path: path, Up: up})
+	}
+
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return lessVersion(migrations[i].Version, migrations[j].Version)
+	})
+	for i := 1; i < len(migrations); i++ {
+		if !lessVersion(migrations[i-1].Version, migrations[i].Version) {
+			return nil, fmt.Errorf("migrations %s and %s have the same version %s",
+				migrations[i-1].Path, migrations[i].Path, migrations[i].Version)
+		}
+	}
+	return migrations, nil
+}
+
+var numericPrefixRegexp = regexp.MustCompile(`^[0-9]+`)
+
+// parseVersion returns the version in the file name of a migration. The
+// version is the numeric prefix of the name. sql-migrate sorts migrations
+// without a numeric prefix by name, so the version is the whole name.
+func parseVersion(name string, format Format) (string, error) {
+	version := numericPrefixRegexp.FindString(name)
+	if version != "" {
+		return version, nil
+	}
+	if format == FormatSQLMigrate {
+		return name, nil
+	}
+	return "", fmt.Errorf("file name must start with a numeric version, like 001_create_author.sql")
+}
+
+// lessVersion compares numeric versions by value and other versions by name.
+// Numeric versions sort before other versions.
+func lessVersion(a, b string) bool {
+	aNum, bNum := isNumeric(a), isNumeric(b)
+	switch {
+	case aNum && bNum:
+		a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	case aNum != bNum:
+		return aNum
+	default:
+		return a < b
+	}
+}
+
+func isNumeric(s string) bool {
+	return numericPrefixRegexp.FindString(s) == s
+}
+
+// readUp returns the up portion of a migration file.
+func readUp(path string, format Format) (string, error) {
+	bs, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("read migration: %w", err)
+	}
+	switch format {
+	case FormatGolangMigrate, FormatAtlas:
+		return string(bs), nil
+	case FormatGoose:
+		return extractUp(string(bs), "-- +goose Up", "-- +goose Down")
+	case FormatDbmate:
+		return extractUp(string(bs), "-- migrate:up", "-- migrate:down")
+	case FormatSQLMigrate:
+		return extractUp(string(bs), "-- +migrate Up", "-- +migrate Down")
+	default:
+		return "", fmt.Errorf("unknown migration format %q", format)
+	}
+}
+
+// extractUp returns the lines between the up marker and the down marker, or
+// the end of the file. Matches markers case-insensitively at the start of a
+// line, ignoring options after the marker, like "-- migrate:up transaction:false".
+// Replaces the markers and the lines outside the up section with empty lines
+// so that errors in the up migration have the line numbers of the file.
+func extractUp(contents, upMarker, downMarker string) (string, error) {
+	sb := &strings.Builder{}
+	inUp, foundUp := false, false
+	s := bufio.NewScanner(strings.NewReader(contents))
+	s.Buffer(nil, 64*1024*1024)
+	for s.Scan() {
+		line := s.Text()
+		switch {
+		case hasMarker(line, upMarker):
+			inUp, foundUp = true, true
+		case hasMarker(line, downMarker):
+			inUp = false
+		case inUp:
+			sb.WriteString(line)
+		}
+		sb.WriteByte('\n')
+	}
+	if err := s.Err(); err != nil {
+		return "", fmt.Errorf("scan migration: %w", err)
+	}
+	if !foundUp {
+		return "", fmt.Errorf("missing %q marker", upMarker)
+	}
+	return sb.String(), nil
+}
+
+func hasMarker(line, marker string) bool {
+	line = strings.TrimSpace(line)
+	if len(line) < len(marker) || !strings.EqualFold(line[:len(marker)], marker) {
+		return false
+	}
+	rest := line[len(marker):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t'
+}
+
+// WriteUp writes the up portion of each migration to a numbered *.sql file in
+// dir and returns the paths of the files in order.
+func WriteUp(dir string, migrations []Migration) ([]string, error) {
+	files := make([]string, len(migrations))
+	for i, m := range migrations {
+		path := filepath.Join(dir, fmt.Sprintf("%04d_%s", i+1, filepath.Base(m.Path)))
+		if err := os.WriteFile(path, []byte(m.Up), 0644); err != nil {
+			return nil, fmt.Errorf("write up migration: %w", err)
+		}
+		files[i] = path
+	}
+	return files, nil
+}
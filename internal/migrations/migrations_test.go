@@ -0,0 +1,172 @@
+package migrations
+
+import (
+	"github.com/atomicleads/pggen/internal/texts"
+	"github.com/google/go-cmp/cmp"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		files  map[string]string
+		want   []Migration // Path is relative to the migrations dir
+	}{
+		{
+			name:   "goose",
+			format: FormatGoose,
+			files: map[string]string{
+				"00010_add_age.sql": texts.Dedent(`
+					-- +goose Up
+					ALTER TABLE author ADD COLUMN age int;
+					-- +goose Down
+					ALTER TABLE author DROP COLUMN age;
+				`),
+				"00002_create_author.sql": texts.Dedent(`
+					-- +goose up
+					-- +goose StatementBegin
+					CREATE TABLE author (id int);
+					-- +goose StatementEnd
+
+					-- +goose down
+					DROP TABLE author;
+				`),
+				"README.md": "not a migration",
+			},
+			want: []Migration{
+				{Version: "00002", Path: "00002_create_author.sql", Up: "\n-- +goose StatementBegin\nCREATE TABLE author (id int);\n-- +goose StatementEnd\n\n\n\n"},
+				{Version: "00010", Path: "00010_add_age.sql", Up: "\nALTER TABLE author ADD COLUMN age int;\n\n\n"},
+			},
+		},
+		{
+			name:   "golang-migrate",
+			format: FormatGolangMigrate,
+			files: map[string]string{
+				"1_create_author.up.sql":   "CREATE TABLE author (id int);\n",
+				"1_create_author.down.sql": "DROP TABLE author;\n",
+				"2_add_age.up.sql":         "ALTER TABLE author ADD COLUMN age int;\n",
+				"2_add_age.down.sql":       "ALTER TABLE author DROP COLUMN age;\n",
+			},
+			want: []Migration{
+				{Version: "1", Path: "1_create_author.up.sql", Up: "CREATE TABLE author (id int);\n"},
+				{Version: "2", Path: "2_add_age.up.sql", Up: "ALTER TABLE author ADD COLUMN age int;\n"},
+			},
+		},
+		{
+			name:   "dbmate",
+			format: FormatDbmate,
+			files: map[string]string{
+				"20230102150405_create_author.sql": texts.Dedent(`
+					-- migrate:up transaction:false
+					CREATE TABLE author (id int);
+
+					-- migrate:down
+					DROP TABLE author;
+				`),
+			},
+			want: []Migration{
+				{Version: "20230102150405", Path: "20230102150405_create_author.sql", Up: "\nCREATE TABLE author (id int);\n\n\n\n"},
+			},
+		},
+		{
+			name:   "sql-migrate",
+			format: FormatSQLMigrate,
+			files: map[string]string{
+				"2_add_age.sql":       "-- +migrate Up\nALTER TABLE author ADD COLUMN age int;\n-- +migrate Down\n",
+				"1_create_author.sql": "-- +migrate Up\nCREATE TABLE author (id int);\n",
+				"seed.sql":            "-- +migrate Up\nINSERT INTO author VALUES (1);\n",
+			},
+			want: []Migration{
+				{Version: "1", Path: "1_create_author.sql", Up: "\nCREATE TABLE author (id int);\n"},
+				{Version: "2", Path: "2_add_age.sql", Up: "\nALTER TABLE author ADD COLUMN age int;\n\n"},
+				{Version: "seed.sql", Path: "seed.sql", Up: "\nINSERT INTO author VALUES (1);\n"},
+			},
+		},
+		{
+			name:   "atlas",
+			format: FormatAtlas,
+			files: map[string]string{
+				"20230102150405_create_author.sql": "CREATE TABLE author (id int);\n",
+				"atlas.sum":                        "h1:abc=\n",
+			},
+			want: []Migration{
+				{Version: "20230102150405", Path: "20230102150405_create_author.sql", Up: "CREATE TABLE author (id int);\n"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, contents := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got, err := Read(dir, tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range tt.want {
+				tt.want[i].Path = filepath.Join(dir, tt.want[i].Path)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("Read() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestRead_Error(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  Format
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "missing up marker",
+			format:  FormatGoose,
+			files:   map[string]string{"1_create.sql": "CREATE TABLE author (id int);\n"},
+			wantErr: `missing "-- +goose Up" marker`,
+		},
+		{
+			name:    "goose go migration",
+			format:  FormatGoose,
+			files:   map[string]string{"1_create.go": "package migrations\n"},
+			wantErr: "goose Go migration",
+		},
+		{
+			name:    "missing version",
+			format:  FormatDbmate,
+			files:   map[string]string{"create.sql": "-- migrate:up\n"},
+			wantErr: "file name must start with a numeric version",
+		},
+		{
+			name:   "duplicate version",
+			format: FormatAtlas,
+			files: map[string]string{
+				"1_create.sql":  "CREATE TABLE author (id int);\n",
+				"01_create.sql": "CREATE TABLE book (id int);\n",
+			},
+			wantErr: "have the same version",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, contents := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, err := Read(dir, tt.format)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Read() error = %v; want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
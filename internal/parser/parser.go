@@ -202,7 +202,7 @@ func (p *parser) errorExpected(pos gotok.Pos, msg string) {
 }
 
 // Regexp to extract query annotations that control output.
-var annotationRegexp = regexp.MustCompile(`name: ([a-zA-Z0-9_$]+)[ \t]+(:many|:one|:exec)[ \t]*(.*)`)
+var annotationRegexp = regexp.MustCompile(`name: ([a-zA-Z0-9_$]+)[ \t]+(:many|:one|:opt|:execrows|:execresult|:exec|:copyfrom)[ \t]*(.*)`)
 
 func (p *parser) parseQuery() ast.Query {
 	if p.trace {
@@ -213,16 +213,14 @@ func (p *parser) parseQuery() ast.Query {
 	sql := &strings.Builder{}
 	pos := p.pos
 
-	names := make([]argPos, 0, 4) // all pggen.arg names in order, can be duplicated
+	names := make([]argPos, 0, 4) // all pggen.arg and pggen.narg names in order, can be duplicated
 	for p.tok != token.Semicolon {
 		if p.tok == token.EOF || p.tok == token.Illegal {
 			p.error(p.pos, "unterminated query (no semicolon): "+string(p.src[pos:p.pos]))
 			return &ast.BadQuery{From: pos, To: p.pos}
 		}
-		hasPggenArg := strings.HasSuffix(p.lit, "pggen.arg(") ||
-			strings.HasSuffix(p.lit, "pggen.arg (")
-		if p.tok == token.QueryFragment && hasPggenArg {
-			arg, ok := p.parsePggenArg()
+		if fn := pggenArgFunc(p.lit); p.tok == token.QueryFragment && fn != "" {
+			arg, ok := p.parsePggenArg(fn)
 			if !ok {
 				return &ast.BadQuery{From: pos, To: p.pos}
 			}
@@ -258,9 +256,21 @@ func (p *parser) parseQuery() ast.Query {
 		p.error(pos, "invalid query pragma: "+err.Error())
 		return &ast.BadQuery{From: pos, To: p.pos}
 	}
+	if annotations[2] == ":execresult" {
+		// Match :execresult so it's not parsed as :exec with a "result" pragma.
+		p.error(pos, "unsupported result kind :execresult; use :exec, which already returns the "+
+			"pgconn.CommandTag, or the sql.Result with --database-sql")
+		return &ast.BadQuery{From: pos, To: p.pos}
+	}
+	resultKind := ast.ResultKind(annotations[2])
+	if pragmas.ExpectRows != nil && resultKind != ast.ResultKindExec && resultKind != ast.ResultKindExecRows {
+		p.error(pos, fmt.Sprintf("invalid query pragma: expect-rows only applies to %s and %s queries; got %s",
+			ast.ResultKindExec, ast.ResultKindExecRows, resultKind))
+		return &ast.BadQuery{From: pos, To: p.pos}
+	}
 
 	templateSQL := sql.String()
-	preparedSQL, params := prepareSQL(templateSQL, names)
+	preparedSQL, params, nulls, spans := prepareSQL(templateSQL, names)
 
 	return &ast.SourceQuery{
 		Name:        annotations[1],
@@ -269,13 +279,16 @@ func (p *parser) parseQuery() ast.Query {
 		SourceSQL:   templateSQL,
 		PreparedSQL: preparedSQL,
 		ParamNames:  params,
-		ResultKind:  ast.ResultKind(annotations[2]),
+		ParamNulls:  nulls,
+		ArgSpans:    spans,
+		ResultKind:  resultKind,
 		Pragmas:     pragmas,
 		Semi:        semi,
 	}
 }
 
-// parsePragmas parses optional pragmas for a query like proto-type=foo.bar.Msg.
+// parsePragmas parses optional pragmas for a query like proto-type=foo.bar.Msg,
+// expect-rows=1, or not-null=foo,bar.
 func parsePragmas(allPragmas string) (ast.Pragmas, error) {
 	if allPragmas == "" {
 		return ast.Pragmas{}, nil
@@ -295,13 +308,50 @@ func parsePragmas(allPragmas string) (ast.Pragmas, error) {
 				return ast.Pragmas{}, err
 			}
 			qp.ProtobufType = p
+		case "expect-rows":
+			n, err := strconv.ParseInt(val, 10, 64)
+			if err != nil || n < 0 {
+				return ast.Pragmas{}, fmt.Errorf("invalid expect-rows, must be a non-negative integer; got %q", val)
+			}
+			qp.ExpectRows = &n
+		case "not-null":
+			cols, err := parsePragmaColumns(key, val)
+			if err != nil {
+				return ast.Pragmas{}, err
+			}
+			qp.NotNull = append(qp.NotNull, cols...)
+		case "nullable":
+			cols, err := parsePragmaColumns(key, val)
+			if err != nil {
+				return ast.Pragmas{}, err
+			}
+			qp.Nullable = append(qp.Nullable, cols...)
 		default:
 			return ast.Pragmas{}, fmt.Errorf("unsupported pramga %q", key)
 		}
 	}
+	for _, notNull := range qp.NotNull {
+		for _, nullable := range qp.Nullable {
+			if notNull == nullable {
+				return ast.Pragmas{}, fmt.Errorf("column %q cannot be both not-null and nullable", notNull)
+			}
+		}
+	}
 	return qp, nil
 }
 
+// parsePragmaColumns parses the comma separated output column names of a
+// pragma like not-null=foo,bar.
+func parsePragmaColumns(key, val string) ([]string, error) {
+	cols := strings.Split(val, ",")
+	for _, col := range cols {
+		if col == "" {
+			return nil, fmt.Errorf("invalid %s, must be a comma separated list of column names; got %q", key, val)
+		}
+	}
+	return cols, nil
+}
+
 // validateProtoMsgType checks that val is a valid message name.
 // https://developers.google.com/protocol-buffers/docs/reference/proto3-spec#identifiers
 func validateProtoMsgType(val string) (string, error) {
@@ -329,53 +379,86 @@ func validateProtoMsgType(val string) (string, error) {
 
 // argPos is the name and position of expression like pggen.arg('foo').
 type argPos struct {
-	lo, hi int
-	name   string
+	lo, hi   int
+	name     string
+	nullable bool // if the arg is nullable, like pggen.narg('foo') or pggen.arg('foo', nullable)
 }
 
-// parsePggenArg parses the name from: pggen.arg('foo') and pos for the start
-// and end.
-func (p *parser) parsePggenArg() (argPos, bool) {
+// pggenArgFunc returns the pggen function, "pggen.arg" or "pggen.narg", if the
+// query fragment ends with the start of a call like "pggen.arg(". Returns the
+// empty string otherwise.
+func pggenArgFunc(lit string) string {
+	for _, fn := range []string{"pggen.arg", "pggen.narg"} {
+		if strings.HasSuffix(lit, fn+"(") || strings.HasSuffix(lit, fn+" (") {
+			return fn
+		}
+	}
+	return ""
+}
+
+// parsePggenArg parses the name from: pggen.arg('foo') or pggen.narg('foo')
+// and pos for the start and end. fn is the pggen function, like "pggen.arg".
+func (p *parser) parsePggenArg(fn string) (argPos, bool) {
 	lo := int(p.pos) + strings.LastIndex(p.lit, "pggen") - 1
 	p.next() // consume query fragment that contains "pggen.arg("
 	if p.tok != token.String {
-		p.error(p.pos, `expected string literal after "pggen.arg("`)
+		p.error(p.pos, `expected string literal after "`+fn+`("`)
 		return argPos{}, false
 	}
 	if len(p.lit) < 3 || p.lit[0] != '\'' || p.lit[len(p.lit)-1] != '\'' {
-		p.error(p.pos, `expected single-quoted string literal after "pggen.arg("`)
+		p.error(p.pos, `expected single-quoted string literal after "`+fn+`("`)
 		return argPos{}, false
 	}
 	name := p.lit[1 : len(p.lit)-1]
 	p.next() // consume string literal
 	if p.tok != token.QueryFragment {
-		p.error(p.pos, `expected query fragment after parsing pggen.arg string`)
+		p.error(p.pos, `expected query fragment after parsing `+fn+` string`)
 		return argPos{}, false
 	}
-	if !strings.HasPrefix(p.lit, ")") {
-		p.error(p.pos, `expected closing paren ")" after parsing pggen.arg string`)
+	if strings.HasPrefix(p.lit, ")") {
+		hi := int(p.pos)
+		return argPos{lo: lo, hi: hi, name: name, nullable: fn == "pggen.narg"}, true
+	}
+	if fn == "pggen.narg" {
+		p.error(p.pos, `expected closing paren ")" after parsing pggen.narg string`)
 		return argPos{}, false
 	}
-	hi := int(p.pos)
-	return argPos{lo: lo, hi: hi, name: name}, true
+	if marker := nullableArgRegexp.FindString(p.lit); marker != "" {
+		hi := int(p.pos) + len(marker) - 1
+		return argPos{lo: lo, hi: hi, name: name, nullable: true}, true
+	}
+	p.error(p.pos, `expected closing paren ")" or ", nullable)" after parsing pggen.arg string`)
+	return argPos{}, false
 }
 
-// prepareSQL replaces each pggen.arg with the $n, respecting the order that the
-// arg first appeared. Args with the same name use the same $n.
-func prepareSQL(sql string, args []argPos) (string, []string) {
+// nullableArgRegexp matches the nullable marker and closing paren of
+// pggen.arg('foo', nullable).
+var nullableArgRegexp = regexp.MustCompile(`^\s*,\s*nullable\s*\)`)
+
+// prepareSQL replaces each pggen.arg and pggen.narg with the $n, respecting the
+// order that the arg first appeared. Args with the same name use the same $n.
+// Returns the span of each replaced arg so errors in the prepared SQL map back
+// to the source. A param is nullable if any arg with the param name is
+// nullable.
+func prepareSQL(sql string, args []argPos) (string, []string, []bool, []ast.ArgSpan) {
 	if len(args) == 0 {
-		return sql, nil
+		return sql, nil, nil, nil
 	}
 	// Figure out order of each params.
 	paramOrders := make(map[string]int, len(args))
 	params := make([]string, 0, len(args))
+	nulls := make([]bool, 0, len(args))
 	idx := 1
 	for _, arg := range args {
 		if _, ok := paramOrders[arg.name]; !ok {
 			params = append(params, arg.name)
+			nulls = append(nulls, false)
 			paramOrders[arg.name] = idx
 			idx++
 		}
+		if arg.nullable {
+			nulls[paramOrders[arg.name]-1] = true
+		}
 	}
 
 	// Replace each pggen.arg with the prepare order, like $1. We're not using
@@ -383,16 +466,19 @@ func prepareSQL(sql string, args []argPos) (string, []string) {
 	bs := []byte(sql)
 	sb := &strings.Builder{}
 	sb.Grow(len(sql))
+	spans := make([]ast.ArgSpan, len(args))
 	prev := 0
-	for _, arg := range args {
+	for i, arg := range args {
 		sb.Write(bs[prev:arg.lo])
+		lo := sb.Len()
 		sb.WriteByte('$')
 		sb.WriteString(strconv.Itoa(paramOrders[arg.name]))
+		spans[i] = ast.ArgSpan{SourceLo: arg.lo, SourceHi: arg.hi, PreparedLo: lo, PreparedHi: sb.Len()}
 		prev = arg.hi
 	}
 	sb.Write(bs[prev:])
 
-	return sb.String(), params
+	return sb.String(), params, nulls, spans
 }
 
 // ----------------------------------------------------------------------------
@@ -5,6 +5,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	gotok "go/token"
+	"strings"
 	"testing"
 )
 
@@ -13,7 +14,7 @@ func ignoreCommentPos() cmp.Option {
 }
 
 func ignoreQueryPos() cmp.Option {
-	return cmpopts.IgnoreFields(ast.SourceQuery{}, "Start", "Semi")
+	return cmpopts.IgnoreFields(ast.SourceQuery{}, "Start", "Semi", "ArgSpans")
 }
 
 func TestParseFile_Queries(t *testing.T) {
@@ -49,9 +50,57 @@ func TestParseFile_Queries(t *testing.T) {
 				SourceSQL:   "SELECT pggen.arg('Bar');",
 				PreparedSQL: "SELECT $1;",
 				ParamNames:  []string{"Bar"},
+				ParamNulls:  []bool{false},
 				ResultKind:  ast.ResultKindExec,
 			},
 		},
+		{
+			"-- name: Qux :copyfrom\nINSERT INTO foo (bar) VALUES (pggen.arg('Bar'));",
+			&ast.SourceQuery{
+				Name:        "Qux",
+				Doc:         &ast.CommentGroup{List: []*ast.LineComment{{Text: "-- name: Qux :copyfrom"}}},
+				SourceSQL:   "INSERT INTO foo (bar) VALUES (pggen.arg('Bar'));",
+				PreparedSQL: "INSERT INTO foo (bar) VALUES ($1);",
+				ParamNames:  []string{"Bar"},
+				ParamNulls:  []bool{false},
+				ResultKind:  ast.ResultKindCopyFrom,
+			},
+		},
+		{
+			"-- name: Qux :opt\nSELECT pggen.arg('Bar');",
+			&ast.SourceQuery{
+				Name:        "Qux",
+				Doc:         &ast.CommentGroup{List: []*ast.LineComment{{Text: "-- name: Qux :opt"}}},
+				SourceSQL:   "SELECT pggen.arg('Bar');",
+				PreparedSQL: "SELECT $1;",
+				ParamNames:  []string{"Bar"},
+				ParamNulls:  []bool{false},
+				ResultKind:  ast.ResultKindOpt,
+			},
+		},
+		{
+			"-- name: Qux :execrows\nDELETE FROM foo;",
+			&ast.SourceQuery{
+				Name:        "Qux",
+				Doc:         &ast.CommentGroup{List: []*ast.LineComment{{Text: "-- name: Qux :execrows"}}},
+				SourceSQL:   "DELETE FROM foo;",
+				PreparedSQL: "DELETE FROM foo;",
+				ParamNames:  nil,
+				ResultKind:  ast.ResultKindExecRows,
+			},
+		},
+		{
+			"-- name: Qux :execrows expect-rows=1\nDELETE FROM foo;",
+			&ast.SourceQuery{
+				Name:        "Qux",
+				Doc:         &ast.CommentGroup{List: []*ast.LineComment{{Text: "-- name: Qux :execrows expect-rows=1"}}},
+				SourceSQL:   "DELETE FROM foo;",
+				PreparedSQL: "DELETE FROM foo;",
+				ParamNames:  nil,
+				ResultKind:  ast.ResultKindExecRows,
+				Pragmas:     ast.Pragmas{ExpectRows: ptrInt64(1)},
+			},
+		},
 		{
 			"-- name: Qux   :exec\nSELECT pggen.arg ('Bar');",
 			&ast.SourceQuery{
@@ -60,6 +109,7 @@ func TestParseFile_Queries(t *testing.T) {
 				SourceSQL:   "SELECT pggen.arg ('Bar');",
 				PreparedSQL: "SELECT $1;",
 				ParamNames:  []string{"Bar"},
+				ParamNulls:  []bool{false},
 				ResultKind:  ast.ResultKindExec,
 			},
 		},
@@ -71,6 +121,7 @@ func TestParseFile_Queries(t *testing.T) {
 				SourceSQL:   "SELECT pggen.arg('A$_$$B123');",
 				PreparedSQL: "SELECT $1;",
 				ParamNames:  []string{"A$_$$B123"},
+				ParamNulls:  []bool{false},
 				ResultKind:  ast.ResultKindOne,
 			},
 		},
@@ -82,6 +133,7 @@ func TestParseFile_Queries(t *testing.T) {
 				SourceSQL:   "SELECT pggen.arg('Bar'), pggen.arg('Qux'), pggen.arg('Bar');",
 				PreparedSQL: "SELECT $1, $2, $1;",
 				ParamNames:  []string{"Bar", "Qux"},
+				ParamNulls:  []bool{false, false},
 				ResultKind:  ast.ResultKindMany,
 			},
 		},
@@ -93,6 +145,7 @@ func TestParseFile_Queries(t *testing.T) {
 				SourceSQL:   "SELECT /*pggen.arg('Bar'),*/ pggen.arg('Qux'), pggen.arg('Bar');",
 				PreparedSQL: "SELECT /*pggen.arg('Bar'),*/ $1, $2;",
 				ParamNames:  []string{"Qux", "Bar"},
+				ParamNulls:  []bool{false, false},
 				ResultKind:  ast.ResultKindMany,
 			},
 		},
@@ -120,6 +173,54 @@ func TestParseFile_Queries(t *testing.T) {
 				Pragmas:     ast.Pragmas{ProtobufType: "Bar"},
 			},
 		},
+		{
+			"-- name: Qux :many not-null=foo,bar nullable=baz\nSELECT 1;",
+			&ast.SourceQuery{
+				Name:        "Qux",
+				Doc:         &ast.CommentGroup{List: []*ast.LineComment{{Text: "-- name: Qux :many not-null=foo,bar nullable=baz"}}},
+				SourceSQL:   "SELECT 1;",
+				PreparedSQL: "SELECT 1;",
+				ParamNames:  nil,
+				ResultKind:  ast.ResultKindMany,
+				Pragmas:     ast.Pragmas{NotNull: []string{"foo", "bar"}, Nullable: []string{"baz"}},
+			},
+		},
+		{
+			"-- name: Qux :many\nSELECT pggen.arg('Bar', nullable), pggen.arg('Qux'), pggen.arg('Bar');",
+			&ast.SourceQuery{
+				Name:        "Qux",
+				Doc:         &ast.CommentGroup{List: []*ast.LineComment{{Text: "-- name: Qux :many"}}},
+				SourceSQL:   "SELECT pggen.arg('Bar', nullable), pggen.arg('Qux'), pggen.arg('Bar');",
+				PreparedSQL: "SELECT $1, $2, $1;",
+				ParamNames:  []string{"Bar", "Qux"},
+				ParamNulls:  []bool{true, false},
+				ResultKind:  ast.ResultKindMany,
+			},
+		},
+		{
+			"-- name: Qux :many\nSELECT * FROM foo WHERE (pggen.narg('Bar') IS NULL OR bar = pggen.narg ('Bar')) AND qux = pggen.arg('Qux');",
+			&ast.SourceQuery{
+				Name:        "Qux",
+				Doc:         &ast.CommentGroup{List: []*ast.LineComment{{Text: "-- name: Qux :many"}}},
+				SourceSQL:   "SELECT * FROM foo WHERE (pggen.narg('Bar') IS NULL OR bar = pggen.narg ('Bar')) AND qux = pggen.arg('Qux');",
+				PreparedSQL: "SELECT * FROM foo WHERE ($1 IS NULL OR bar = $1) AND qux = $2;",
+				ParamNames:  []string{"Bar", "Qux"},
+				ParamNulls:  []bool{true, false},
+				ResultKind:  ast.ResultKindMany,
+			},
+		},
+		{
+			"-- name: Qux :one\nSELECT pggen.arg('Bar' ,nullable );",
+			&ast.SourceQuery{
+				Name:        "Qux",
+				Doc:         &ast.CommentGroup{List: []*ast.LineComment{{Text: "-- name: Qux :one"}}},
+				SourceSQL:   "SELECT pggen.arg('Bar' ,nullable );",
+				PreparedSQL: "SELECT $1;",
+				ParamNames:  []string{"Bar"},
+				ParamNulls:  []bool{true},
+				ResultKind:  ast.ResultKindOne,
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -139,6 +240,67 @@ func TestParseFile_Queries(t *testing.T) {
 
 }
 
+func TestParseFile_SourceOffset(t *testing.T) {
+	src := "-- name: Qux :many\nSELECT pggen.arg('Bar') AS bar, pggen.arg('Qux'), pggen.arg('Bar') FROM foo;"
+	f, err := ParseFile(gotok.NewFileSet(), "", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := f.Queries[0].(*ast.SourceQuery)
+	if q.PreparedSQL != "SELECT $1 AS bar, $2, $1 FROM foo;" {
+		t.Fatalf("unexpected prepared SQL: %s", q.PreparedSQL)
+	}
+	tests := []struct {
+		prepared string // text at the prepared offset
+		source   string // want text at the source offset
+	}{
+		{"SELECT", "SELECT"},
+		{"$1 AS", "pggen.arg('Bar') AS"},
+		{"1 AS", "pggen.arg('Bar') AS"},
+		{"AS bar", "AS bar"},
+		{"$2", "pggen.arg('Qux')"},
+		{"$1 FROM", "pggen.arg('Bar') FROM"},
+		{"FROM foo", "FROM foo"},
+		{";", ";"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prepared, func(t *testing.T) {
+			off := strings.Index(q.PreparedSQL, tt.prepared)
+			got := q.SourceOffset(off)
+			if !strings.HasPrefix(q.SourceSQL[got:], tt.source) {
+				t.Errorf("SourceOffset(%d) = %d, at %q; want offset of %q", off, got, q.SourceSQL[got:], tt.source)
+			}
+		})
+	}
+}
+
+func TestParseFile_Queries_Error(t *testing.T) {
+	tests := []struct {
+		src     string
+		wantErr string
+	}{
+		{"-- name: Qux :one expect-rows=1\nSELECT 1;", "expect-rows only applies to :exec and :execrows queries"},
+		{"-- name: Qux :execresult\nDELETE FROM foo;", "unsupported result kind :execresult; use :exec"},
+		{"-- name: Qux :exec expect-rows=-1\nDELETE FROM foo;", "invalid expect-rows"},
+		{"-- name: Qux :exec expect-rows=one\nDELETE FROM foo;", "invalid expect-rows"},
+		{"-- name: Qux :one not-null=\nSELECT 1;", "invalid not-null"},
+		{"-- name: Qux :one nullable=foo,,bar\nSELECT 1;", "invalid nullable"},
+		{"-- name: Qux :one not-null=foo nullable=foo\nSELECT 1;", "cannot be both not-null and nullable"},
+		{"-- name: Qux :one\nSELECT pggen.arg('Bar', null);", `expected closing paren ")" or ", nullable)"`},
+		{"-- name: Qux :one\nSELECT pggen.narg('Bar', nullable);", `expected closing paren ")" after parsing pggen.narg string`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.src, func(t *testing.T) {
+			_, err := ParseFile(gotok.NewFileSet(), "", tt.src, Trace)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("ParseFile() error = %v; want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func ptrInt64(n int64) *int64 { return &n }
+
 func TestParseFile_Queries_Fuzz(t *testing.T) {
 	tests := []struct {
 		src string
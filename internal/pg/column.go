@@ -2,6 +2,7 @@ package pg
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/atomicleads/pggen/internal/texts"
 	"github.com/jackc/pgtype"
@@ -21,6 +22,9 @@ type Column struct {
 	Number    uint16     // pg_attribute.attnum: the number of column starting from 1
 	Type      Type       // pg_attribute.atttypid: data type of the column
 	Null      bool       // pg_attribute.attnotnull: represents a not-null constraint
+	// pg_attribute.atttypid: name of the data type of the column, like "text".
+	// Only set by FetchRelationColumns.
+	TypeName string
 }
 
 // ColumnKey is a composite key of a table OID and the number of the column
@@ -116,3 +120,68 @@ func fetchCachedColumns(keys []ColumnKey) ([]Column, error) {
 	}
 	return cols, nil
 }
+
+// FetchRelationColumns fetches meta information about the columns of the table
+// named relation in schema. If schema is empty, finds the table visible in the
+// search path. Doesn't cache the columns because the table name might refer to
+// a different table after the schema changes.
+func FetchRelationColumns(conn *pgx.Conn, schema, relation string) ([]Column, error) {
+	q := texts.Dedent(`
+		SELECT cls.oid         AS table_oid,
+					 cls.relname     AS table_name,
+					 attr.attname    AS col_name,
+					 attr.attnum     AS col_num,
+					 attr.attnotnull AS col_null,
+					 attr.atttypid::regtype::text AS col_type
+		FROM pg_class cls
+					 JOIN pg_namespace ns ON (ns.oid = cls.relnamespace)
+					 JOIN pg_attribute attr ON (attr.attrelid = cls.oid)
+		WHERE cls.relname = $2
+			AND CASE WHEN $1 = '' THEN pg_table_is_visible(cls.oid) ELSE ns.nspname = $1 END
+			AND attr.attnum > 0
+			AND NOT attr.attisdropped
+		ORDER BY attr.attnum
+	`)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	rows, err := conn.Query(ctx, q, schema, relation)
+	if err != nil {
+		return nil, fmt.Errorf("fetch relation columns: %w", err)
+	}
+	defer rows.Close()
+	var cols []Column
+	for rows.Next() {
+		col := Column{}
+		notNull := false
+		if err := rows.Scan(&col.TableOID, &col.TableName, &col.Name, &col.Number, &notNull, &col.TypeName); err != nil {
+			return nil, fmt.Errorf("scan fetch relation column row: %w", err)
+		}
+		col.Null = !notNull
+		cols = append(cols, col)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close fetch relation column rows: %w", err)
+	}
+	return cols, nil
+}
+
+// FetchViewDefinition fetches the query of the view or materialized view with
+// the OID. Returns false if the relation isn't a view.
+func FetchViewDefinition(conn *pgx.Conn, oid pgtype.OID) (string, bool, error) {
+	q := texts.Dedent(`
+		SELECT pg_get_viewdef(cls.oid) AS view_def
+		FROM pg_class cls
+		WHERE cls.oid = $1
+			AND cls.relkind IN ('v', 'm')
+	`)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	def := ""
+	if err := conn.QueryRow(ctx, q, oid).Scan(&def); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("fetch view definition: %w", err)
+	}
+	return def, true, nil
+}
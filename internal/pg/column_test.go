@@ -74,6 +74,49 @@ func TestFetchColumns(t *testing.T) {
 	}
 }
 
+func TestFetchRelationColumns(t *testing.T) {
+	schema := texts.Dedent(`
+		CREATE SCHEMA other;
+		CREATE TABLE author ( first_name text NOT NULL, dropped int, last_name text);
+		ALTER TABLE author DROP COLUMN dropped;
+		CREATE TABLE other.author ( id int NOT NULL );
+	`)
+	conn, cleanup := pgtest.NewPostgresSchemaString(t, schema)
+	defer cleanup()
+	tests := []struct {
+		schema   string
+		relation string
+		want     []Column
+	}{
+		{
+			"", "author",
+			[]Column{
+				{Name: "first_name", TableName: "author", Number: 1, Null: false, TypeName: "text"},
+				{Name: "last_name", TableName: "author", Number: 3, Null: true, TypeName: "text"},
+			},
+		},
+		{
+			"other", "author",
+			[]Column{{Name: "id", TableName: "author", Number: 1, Null: false, TypeName: "integer"}},
+		},
+		{"other", "missing", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schema+"."+tt.relation, func(t *testing.T) {
+			cols, err := FetchRelationColumns(conn, tt.schema, tt.relation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range cols {
+				cols[i].TableOID = 0
+			}
+			if diff := cmp.Diff(tt.want, cols); diff != "" {
+				t.Errorf("FetchRelationColumns() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func findTableOID(t *testing.T, conn *pgx.Conn, table string) pgtype.OID {
 	sql := texts.Dedent(`
 		SELECT oid AS table_oid
@@ -0,0 +1,79 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/atomicleads/pggen/internal/texts"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+	"strings"
+	"time"
+)
+
+// Func stores information about a Postgres function.
+// https://www.postgresql.org/docs/13/catalog-pg-proc.html
+type Func struct {
+	OID        pgtype.OID // pg_proc.oid
+	Schema     string     // pg_namespace.nspname: schema of the function
+	Name       string     // pg_proc.proname: function name
+	Kind       FuncKind   // pg_proc.prokind: function, procedure, aggregate, or window function
+	Strict     bool       // pg_proc.proisstrict: returns null if any argument is null
+	ReturnType string     // pg_proc.prorettype: name of the return type, like "text"
+}
+
+// FuncKind is the kind of function in pg_proc.prokind.
+type FuncKind string
+
+//goland:noinspection GoUnusedConst
+const (
+	FuncKindFunction  FuncKind = "f"
+	FuncKindProcedure FuncKind = "p"
+	FuncKindAggregate FuncKind = "a"
+	FuncKindWindow    FuncKind = "w"
+)
+
+// FetchFunc fetches the function named name in schema that has the argument
+// types argTypes, like "text" or "character varying". If schema is empty, finds
+// the function visible in the search path. Returns false if no function has
+// exactly the argument types, like when the function has polymorphic
+// arguments.
+func FetchFunc(conn *pgx.Conn, schema, name string, argTypes []string) (Func, bool, error) {
+	sig := &strings.Builder{}
+	if schema != "" {
+		sig.WriteString(quoteIdent(schema))
+		sig.WriteString(".")
+	}
+	sig.WriteString(quoteIdent(name))
+	sig.WriteString("(")
+	sig.WriteString(strings.Join(argTypes, ", "))
+	sig.WriteString(")")
+
+	q := texts.Dedent(`
+		SELECT proc.oid                       AS func_oid,
+					 ns.nspname                     AS func_schema,
+					 proc.proname                   AS func_name,
+					 proc.prokind::text             AS func_kind,
+					 proc.proisstrict               AS func_strict,
+					 proc.prorettype::regtype::text AS func_return_type
+		FROM pg_proc proc
+					 JOIN pg_namespace ns ON (ns.oid = proc.pronamespace)
+		WHERE proc.oid = to_regprocedure($1)
+	`)
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	fn := Func{}
+	row := conn.QueryRow(ctx, q, sig.String())
+	if err := row.Scan(&fn.OID, &fn.Schema, &fn.Name, &fn.Kind, &fn.Strict, &fn.ReturnType); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return Func{}, false, nil
+		}
+		return Func{}, false, fmt.Errorf("fetch function %s: %w", sig.String(), err)
+	}
+	return fn, true, nil
+}
+
+// quoteIdent quotes a Postgres identifier so the identifier keeps its case.
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
@@ -0,0 +1,62 @@
+package pg
+
+import (
+	"github.com/atomicleads/pggen/internal/pgtest"
+	"github.com/atomicleads/pggen/internal/texts"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"testing"
+)
+
+func TestFetchFunc(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchemaString(t, texts.Dedent(`
+		CREATE SCHEMA other;
+		CREATE FUNCTION other."Slugify"(s text) RETURNS text
+			LANGUAGE sql IMMUTABLE AS $$ SELECT lower(s) $$;
+	`))
+	defer cleanup()
+	tests := []struct {
+		schema   string
+		name     string
+		argTypes []string
+		want     Func
+		wantOK   bool
+	}{
+		{
+			"", "lower", []string{"text"},
+			Func{Schema: "pg_catalog", Name: "lower", Kind: FuncKindFunction, Strict: true, ReturnType: "text"},
+			true,
+		},
+		{
+			"", "now", nil,
+			Func{Schema: "pg_catalog", Name: "now", Kind: FuncKindFunction, Strict: true, ReturnType: "timestamp with time zone"},
+			true,
+		},
+		{
+			"", "max", []string{"integer"},
+			Func{Schema: "pg_catalog", Name: "max", Kind: FuncKindAggregate, Strict: false, ReturnType: "integer"},
+			true,
+		},
+		{
+			"other", "Slugify", []string{"text"},
+			Func{Schema: "other", Name: "Slugify", Kind: FuncKindFunction, Strict: false, ReturnType: "text"},
+			true,
+		},
+		{"", "lower", []string{"int4range"}, Func{}, false},
+		{"", "Slugify", []string{"text"}, Func{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.schema+"."+tt.name, func(t *testing.T) {
+			got, ok, err := FetchFunc(conn, tt.schema, tt.name, tt.argTypes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.wantOK {
+				t.Fatalf("FetchFunc() ok = %t; want %t", ok, tt.wantOK)
+			}
+			if diff := cmp.Diff(tt.want, got, cmpopts.IgnoreFields(Func{}, "OID")); diff != "" {
+				t.Errorf("FetchFunc() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -23,6 +23,8 @@ SELECT
   typ.oid           AS oid,
   -- typename: Data type name.
   typ.typname::text AS type_name,
+  -- The schema-qualified type name, quoted if needed, for pgx LoadType.
+  quote_ident(ns.nspname) || '.' || quote_ident(typ.typname) AS qualified_name,
   enum.enum_oids    AS child_oids,
   enum.enum_orders  AS orders,
   enum.enum_labels  AS labels,
@@ -39,6 +41,7 @@ SELECT
   COALESCE(typ.typdefault, '')    AS default_expr
 FROM pg_type typ
   JOIN enums enum ON typ.oid = enum.enum_type
+  JOIN pg_namespace ns ON typ.typnamespace = ns.oid
 WHERE typ.typisdefined
   AND typ.typtype = 'e'
   AND typ.oid = ANY (pggen.arg('OIDs')::oid[]);
@@ -48,6 +51,8 @@ SELECT
   arr_typ.oid           AS oid,
   -- typename: Data type name.
   arr_typ.typname::text AS type_name,
+  -- The schema-qualified type name, quoted if needed, for pgx LoadType.
+  quote_ident(ns.nspname) || '.' || quote_ident(arr_typ.typname) AS qualified_name,
   elem_typ.oid          AS elem_oid,
   -- typtype: b for a base type, c for a composite type (e.g., a table's
   -- row type), d for a domain, e for an enum type, p for a pseudo-type,
@@ -55,6 +60,7 @@ SELECT
   arr_typ.typtype       AS type_kind
 FROM pg_type arr_typ
   JOIN pg_type elem_typ ON arr_typ.typelem = elem_typ.oid
+  JOIN pg_namespace ns ON arr_typ.typnamespace = ns.oid
 WHERE arr_typ.typisdefined
   AND arr_typ.typtype = 'b' -- Array types are base types
   -- If typelem is not 0 then it identifies another row in pg_type. The current
@@ -96,6 +102,8 @@ WITH table_cols AS (
 )
 SELECT
   typ.typname::text AS table_type_name,
+  -- The schema-qualified type name, quoted if needed, for pgx LoadType.
+  quote_ident(ns.nspname) || '.' || quote_ident(typ.typname) AS qualified_name,
   typ.oid           AS table_type_oid,
   table_name,
   col_names,
@@ -105,6 +113,7 @@ SELECT
   col_type_names
 FROM pg_type typ
   JOIN table_cols cols ON typ.typrelid = cols.table_oid
+  JOIN pg_namespace ns ON typ.typnamespace = ns.oid
 WHERE typ.oid = ANY (pggen.arg('oids')::oid[])
   AND typ.typtype = 'c';
 
@@ -4,6 +4,7 @@ package pg
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgtype"
@@ -11,25 +12,84 @@ import (
 )
 
 // Querier is a typesafe Go interface backed by SQL queries.
+//
+// Methods starting with Queue enqueue a query to run later in a pgx.Batch.
+// After calling SendBatch on pgx.Conn, pgxpool.Pool, or pgx.Tx, use the Scan
+// methods to parse the results in the same order the queries were queued.
 type Querier interface {
 	FindEnumTypes(ctx context.Context, oids []uint32) ([]FindEnumTypesRow, error)
+	// FindEnumTypesEach runs FindEnumTypes and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindEnumTypesEach(ctx context.Context, oids []uint32, fn func(row FindEnumTypesRow) error) error
+	// QueueFindEnumTypes enqueues a FindEnumTypes query into batch to be executed
+	// later by the batch.
+	QueueFindEnumTypes(batch genericBatch, oids []uint32)
+	// FindEnumTypesScan scans the result of an executed QueueFindEnumTypes query.
+	FindEnumTypesScan(results pgx.BatchResults) ([]FindEnumTypesRow, error)
 
 	FindArrayTypes(ctx context.Context, oids []uint32) ([]FindArrayTypesRow, error)
+	// FindArrayTypesEach runs FindArrayTypes and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindArrayTypesEach(ctx context.Context, oids []uint32, fn func(row FindArrayTypesRow) error) error
+	// QueueFindArrayTypes enqueues a FindArrayTypes query into batch to be executed
+	// later by the batch.
+	QueueFindArrayTypes(batch genericBatch, oids []uint32)
+	// FindArrayTypesScan scans the result of an executed QueueFindArrayTypes query.
+	FindArrayTypesScan(results pgx.BatchResults) ([]FindArrayTypesRow, error)
 
 	// A composite type represents a row or record, defined implicitly for each
 	// table, or explicitly with CREATE TYPE.
 	// https://www.postgresql.org/docs/13/rowtypes.html
 	FindCompositeTypes(ctx context.Context, oids []uint32) ([]FindCompositeTypesRow, error)
+	// FindCompositeTypesEach runs FindCompositeTypes and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindCompositeTypesEach(ctx context.Context, oids []uint32, fn func(row FindCompositeTypesRow) error) error
+	// QueueFindCompositeTypes enqueues a FindCompositeTypes query into batch to be executed
+	// later by the batch.
+	QueueFindCompositeTypes(batch genericBatch, oids []uint32)
+	// FindCompositeTypesScan scans the result of an executed QueueFindCompositeTypes query.
+	FindCompositeTypesScan(results pgx.BatchResults) ([]FindCompositeTypesRow, error)
 
 	// Recursively expands all given OIDs to all descendants through composite
 	// types.
 	FindDescendantOIDs(ctx context.Context, oids []uint32) ([]pgtype.OID, error)
+	// FindDescendantOIDsEach runs FindDescendantOIDs and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindDescendantOIDsEach(ctx context.Context, oids []uint32, fn func(row pgtype.OID) error) error
+	// QueueFindDescendantOIDs enqueues a FindDescendantOIDs query into batch to be executed
+	// later by the batch.
+	QueueFindDescendantOIDs(batch genericBatch, oids []uint32)
+	// FindDescendantOIDsScan scans the result of an executed QueueFindDescendantOIDs query.
+	FindDescendantOIDsScan(results pgx.BatchResults) ([]pgtype.OID, error)
 
 	FindOIDByName(ctx context.Context, name string) (pgtype.OID, error)
+	// QueueFindOIDByName enqueues a FindOIDByName query into batch to be executed
+	// later by the batch.
+	QueueFindOIDByName(batch genericBatch, name string)
+	// FindOIDByNameScan scans the result of an executed QueueFindOIDByName query.
+	FindOIDByNameScan(results pgx.BatchResults) (pgtype.OID, error)
 
 	FindOIDName(ctx context.Context, oid pgtype.OID) (pgtype.Name, error)
+	// QueueFindOIDName enqueues a FindOIDName query into batch to be executed
+	// later by the batch.
+	QueueFindOIDName(batch genericBatch, oid pgtype.OID)
+	// FindOIDNameScan scans the result of an executed QueueFindOIDName query.
+	FindOIDNameScan(results pgx.BatchResults) (pgtype.Name, error)
 
 	FindOIDNames(ctx context.Context, oid []uint32) ([]FindOIDNamesRow, error)
+	// FindOIDNamesEach runs FindOIDNames and calls fn with each row as it's scanned
+	// instead of collecting all rows in memory. Stops at the first error from
+	// fn and returns it unwrapped.
+	FindOIDNamesEach(ctx context.Context, oid []uint32, fn func(row FindOIDNamesRow) error) error
+	// QueueFindOIDNames enqueues a FindOIDNames query into batch to be executed
+	// later by the batch.
+	QueueFindOIDNames(batch genericBatch, oid []uint32)
+	// FindOIDNamesScan scans the result of an executed QueueFindOIDNames query.
+	FindOIDNamesScan(results pgx.BatchResults) ([]FindOIDNamesRow, error)
 }
 
 type DBQuerier struct {
@@ -58,6 +118,14 @@ type genericConn interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 }
 
+// genericBatch batches queries to send in a single network request to a
+// Postgres server. This is usually backed by *pgx.Batch.
+type genericBatch interface {
+	// Queue queues a query to batch b. query can be an SQL query or the name of a
+	// prepared statement. See Queue on *pgx.Batch.
+	Queue(query string, arguments ...interface{})
+}
+
 // NewQuerier creates a DBQuerier that implements Querier. conn is typically
 // *pgx.Conn, pgx.Tx, or *pgxpool.Pool.
 func NewQuerier(conn genericConn) *DBQuerier {
@@ -65,8 +133,118 @@ func NewQuerier(conn genericConn) *DBQuerier {
 }
 
 // WithTx creates a new DBQuerier that uses the transaction to run all queries.
+// The new querier keeps the configuration of q.
 func (q *DBQuerier) WithTx(tx pgx.Tx) (*DBQuerier, error) {
-	return &DBQuerier{conn: tx}, nil
+	return q.withConn(tx), nil
+}
+
+// withConn creates a copy of q that runs all queries on conn.
+func (q *DBQuerier) withConn(conn genericConn) *DBQuerier {
+	q2 := *q
+	q2.conn = conn
+	return &q2
+}
+
+// txBeginner begins a top-level transaction. This is usually backed by
+// *pgx.Conn or *pgxpool.Pool.
+type txBeginner interface {
+	BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error)
+}
+
+// TxOptions controls the transaction started by BeginTxFunc.
+type TxOptions struct {
+	// Isolation level and access mode of the transaction. Ignored for nested
+	// transactions, which use a savepoint in the enclosing transaction.
+	pgx.TxOptions
+	// How many times to rerun the transaction after a serialization failure
+	// (SQLSTATE 40001). Zero disables retries. Nested transactions never retry
+	// because Postgres aborts the enclosing transaction on a serialization
+	// failure.
+	MaxRetries int
+}
+
+// BeginFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. See BeginTxFunc.
+func (q *DBQuerier) BeginFunc(ctx context.Context, fn func(q *DBQuerier) error) error {
+	return q.BeginTxFunc(ctx, TxOptions{}, fn)
+}
+
+// BeginTxFunc runs fn in a transaction with a DBQuerier that keeps the
+// configuration of q. BeginTxFunc commits the transaction if fn returns nil
+// and rolls it back otherwise, including if fn panics.
+//
+// If q already runs queries in a transaction, like a querier from WithTx or
+// passed to fn, BeginTxFunc creates a savepoint so that a failed nested call
+// only rolls back its own changes.
+func (q *DBQuerier) BeginTxFunc(ctx context.Context, opts TxOptions, fn func(q *DBQuerier) error) error {
+	if tx, ok := q.conn.(pgx.Tx); ok {
+		return q.runTx(ctx, tx.Begin, fn)
+	}
+	beginner, ok := q.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("begin transaction: %T does not support transactions", q.conn)
+	}
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return beginner.BeginTx(ctx, opts.TxOptions)
+	}
+	for attempt := 0; ; attempt++ {
+		err := q.runTx(ctx, begin, fn)
+		if attempt >= opts.MaxRetries || !isSerializationFailure(err) {
+			return err
+		}
+	}
+}
+
+// runTx runs fn in the transaction created by begin.
+func (q *DBQuerier) runTx(ctx context.Context, begin func(context.Context) (pgx.Tx, error), fn func(q *DBQuerier) error) (mErr error) {
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() {
+		// Rollback is a no-op if the transaction was committed.
+		if err := tx.Rollback(ctx); err != nil && !errors.Is(err, pgx.ErrTxClosed) && mErr == nil {
+			mErr = fmt.Errorf("rollback transaction: %w", err)
+		}
+	}()
+	if err := fn(q.withConn(tx)); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// isSerializationFailure returns true if err is a Postgres serialization
+// failure, meaning the transaction might succeed if retried.
+func isSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "40001"
+}
+
+// ErrNotFound is returned, wrapped, by :one queries that match no rows. The
+// error also matches pgx.ErrNoRows with errors.Is.
+var ErrNotFound = errors.New("no rows found")
+
+// notFoundError marks a no rows error as ErrNotFound.
+type notFoundError struct{ err error }
+
+func (e notFoundError) Error() string        { return e.err.Error() }
+func (e notFoundError) Unwrap() error        { return e.err }
+func (e notFoundError) Is(target error) bool { return target == ErrNotFound }
+
+// isNoRows returns true if err means the query matched no rows.
+func isNoRows(err error) bool {
+	return errors.Is(err, pgx.ErrNoRows)
+}
+
+// wrapNoRows marks err as ErrNotFound if the query matched no rows.
+func wrapNoRows(err error) error {
+	if isNoRows(err) {
+		return notFoundError{err: err}
+	}
+	return err
 }
 
 // typeResolver looks up the pgtype.ValueTranscoder by Postgres type name.
@@ -122,6 +300,8 @@ SELECT
   typ.oid           AS oid,
   -- typename: Data type name.
   typ.typname::text AS type_name,
+  -- The schema-qualified type name, quoted if needed, for pgx LoadType.
+  quote_ident(ns.nspname) || '.' || quote_ident(typ.typname) AS qualified_name,
   enum.enum_oids    AS child_oids,
   enum.enum_orders  AS orders,
   enum.enum_labels  AS labels,
@@ -138,18 +318,20 @@ SELECT
   COALESCE(typ.typdefault, '')    AS default_expr
 FROM pg_type typ
   JOIN enums enum ON typ.oid = enum.enum_type
+  JOIN pg_namespace ns ON typ.typnamespace = ns.oid
 WHERE typ.typisdefined
   AND typ.typtype = 'e'
   AND typ.oid = ANY ($1::oid[]);`
 
 type FindEnumTypesRow struct {
-	OID         pgtype.OID   `json:"oid"`
-	TypeName    string       `json:"type_name"`
-	ChildOIDs   []int        `json:"child_oids"`
-	Orders      []float32    `json:"orders"`
-	Labels      []string     `json:"labels"`
-	TypeKind    pgtype.QChar `json:"type_kind"`
-	DefaultExpr string       `json:"default_expr"`
+	OID           pgtype.OID   `json:"oid"`
+	TypeName      string       `json:"type_name"`
+	QualifiedName string       `json:"qualified_name"`
+	ChildOIDs     []int        `json:"child_oids"`
+	Orders        []float32    `json:"orders"`
+	Labels        []string     `json:"labels"`
+	TypeKind      pgtype.QChar `json:"type_kind"`
+	DefaultExpr   string       `json:"default_expr"`
 }
 
 // FindEnumTypes implements Querier.FindEnumTypes.
@@ -163,7 +345,7 @@ func (q *DBQuerier) FindEnumTypes(ctx context.Context, oids []uint32) ([]FindEnu
 	items := []FindEnumTypesRow{}
 	for rows.Next() {
 		var item FindEnumTypesRow
-		if err := rows.Scan(&item.OID, &item.TypeName, &item.ChildOIDs, &item.Orders, &item.Labels, &item.TypeKind, &item.DefaultExpr); err != nil {
+		if err := rows.Scan(&item.OID, &item.TypeName, &item.QualifiedName, &item.ChildOIDs, &item.Orders, &item.Labels, &item.TypeKind, &item.DefaultExpr); err != nil {
 			return nil, fmt.Errorf("scan FindEnumTypes row: %w", err)
 		}
 		items = append(items, item)
@@ -174,10 +356,62 @@ func (q *DBQuerier) FindEnumTypes(ctx context.Context, oids []uint32) ([]FindEnu
 	return items, err
 }
 
+// FindEnumTypesEach implements Querier.FindEnumTypesEach.
+func (q *DBQuerier) FindEnumTypesEach(ctx context.Context, oids []uint32, fn func(row FindEnumTypesRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindEnumTypes")
+	rows, err := q.conn.Query(ctx, findEnumTypesSQL, oids)
+	if err != nil {
+		return fmt.Errorf("query FindEnumTypesEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item FindEnumTypesRow
+		if err := rows.Scan(&item.OID, &item.TypeName, &item.QualifiedName, &item.ChildOIDs, &item.Orders, &item.Labels, &item.TypeKind, &item.DefaultExpr); err != nil {
+			return fmt.Errorf("scan FindEnumTypesEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindEnumTypesEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindEnumTypes implements Querier.QueueFindEnumTypes.
+func (q *DBQuerier) QueueFindEnumTypes(batch genericBatch, oids []uint32) {
+	batch.Queue(findEnumTypesSQL, oids)
+}
+
+// FindEnumTypesScan implements Querier.FindEnumTypesScan.
+func (q *DBQuerier) FindEnumTypesScan(results pgx.BatchResults) ([]FindEnumTypesRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindEnumTypesScan: %w", err)
+	}
+	defer rows.Close()
+	items := []FindEnumTypesRow{}
+	for rows.Next() {
+		var item FindEnumTypesRow
+		if err := rows.Scan(&item.OID, &item.TypeName, &item.QualifiedName, &item.ChildOIDs, &item.Orders, &item.Labels, &item.TypeKind, &item.DefaultExpr); err != nil {
+			return nil, fmt.Errorf("scan FindEnumTypesScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindEnumTypesScan rows: %w", err)
+	}
+	return items, err
+}
+
 const findArrayTypesSQL = `SELECT
   arr_typ.oid           AS oid,
   -- typename: Data type name.
   arr_typ.typname::text AS type_name,
+  -- The schema-qualified type name, quoted if needed, for pgx LoadType.
+  quote_ident(ns.nspname) || '.' || quote_ident(arr_typ.typname) AS qualified_name,
   elem_typ.oid          AS elem_oid,
   -- typtype: b for a base type, c for a composite type (e.g., a table's
   -- row type), d for a domain, e for an enum type, p for a pseudo-type,
@@ -185,6 +419,7 @@ const findArrayTypesSQL = `SELECT
   arr_typ.typtype       AS type_kind
 FROM pg_type arr_typ
   JOIN pg_type elem_typ ON arr_typ.typelem = elem_typ.oid
+  JOIN pg_namespace ns ON arr_typ.typnamespace = ns.oid
 WHERE arr_typ.typisdefined
   AND arr_typ.typtype = 'b' -- Array types are base types
   -- If typelem is not 0 then it identifies another row in pg_type. The current
@@ -204,10 +439,11 @@ WHERE arr_typ.typisdefined
   AND arr_typ.oid = ANY ($1::oid[]);`
 
 type FindArrayTypesRow struct {
-	OID      pgtype.OID   `json:"oid"`
-	TypeName string       `json:"type_name"`
-	ElemOID  pgtype.OID   `json:"elem_oid"`
-	TypeKind pgtype.QChar `json:"type_kind"`
+	OID           pgtype.OID   `json:"oid"`
+	TypeName      string       `json:"type_name"`
+	QualifiedName string       `json:"qualified_name"`
+	ElemOID       pgtype.OID   `json:"elem_oid"`
+	TypeKind      pgtype.QChar `json:"type_kind"`
 }
 
 // FindArrayTypes implements Querier.FindArrayTypes.
@@ -221,7 +457,7 @@ func (q *DBQuerier) FindArrayTypes(ctx context.Context, oids []uint32) ([]FindAr
 	items := []FindArrayTypesRow{}
 	for rows.Next() {
 		var item FindArrayTypesRow
-		if err := rows.Scan(&item.OID, &item.TypeName, &item.ElemOID, &item.TypeKind); err != nil {
+		if err := rows.Scan(&item.OID, &item.TypeName, &item.QualifiedName, &item.ElemOID, &item.TypeKind); err != nil {
 			return nil, fmt.Errorf("scan FindArrayTypes row: %w", err)
 		}
 		items = append(items, item)
@@ -232,6 +468,56 @@ func (q *DBQuerier) FindArrayTypes(ctx context.Context, oids []uint32) ([]FindAr
 	return items, err
 }
 
+// FindArrayTypesEach implements Querier.FindArrayTypesEach.
+func (q *DBQuerier) FindArrayTypesEach(ctx context.Context, oids []uint32, fn func(row FindArrayTypesRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindArrayTypes")
+	rows, err := q.conn.Query(ctx, findArrayTypesSQL, oids)
+	if err != nil {
+		return fmt.Errorf("query FindArrayTypesEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item FindArrayTypesRow
+		if err := rows.Scan(&item.OID, &item.TypeName, &item.QualifiedName, &item.ElemOID, &item.TypeKind); err != nil {
+			return fmt.Errorf("scan FindArrayTypesEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindArrayTypesEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindArrayTypes implements Querier.QueueFindArrayTypes.
+func (q *DBQuerier) QueueFindArrayTypes(batch genericBatch, oids []uint32) {
+	batch.Queue(findArrayTypesSQL, oids)
+}
+
+// FindArrayTypesScan implements Querier.FindArrayTypesScan.
+func (q *DBQuerier) FindArrayTypesScan(results pgx.BatchResults) ([]FindArrayTypesRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindArrayTypesScan: %w", err)
+	}
+	defer rows.Close()
+	items := []FindArrayTypesRow{}
+	for rows.Next() {
+		var item FindArrayTypesRow
+		if err := rows.Scan(&item.OID, &item.TypeName, &item.QualifiedName, &item.ElemOID, &item.TypeKind); err != nil {
+			return nil, fmt.Errorf("scan FindArrayTypesScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindArrayTypesScan rows: %w", err)
+	}
+	return items, err
+}
+
 const findCompositeTypesSQL = `WITH table_cols AS (
   SELECT
     cls.relname                                         AS table_name,
@@ -250,6 +536,8 @@ const findCompositeTypesSQL = `WITH table_cols AS (
 )
 SELECT
   typ.typname::text AS table_type_name,
+  -- The schema-qualified type name, quoted if needed, for pgx LoadType.
+  quote_ident(ns.nspname) || '.' || quote_ident(typ.typname) AS qualified_name,
   typ.oid           AS table_type_oid,
   table_name,
   col_names,
@@ -259,11 +547,13 @@ SELECT
   col_type_names
 FROM pg_type typ
   JOIN table_cols cols ON typ.typrelid = cols.table_oid
+  JOIN pg_namespace ns ON typ.typnamespace = ns.oid
 WHERE typ.oid = ANY ($1::oid[])
   AND typ.typtype = 'c';`
 
 type FindCompositeTypesRow struct {
 	TableTypeName string           `json:"table_type_name"`
+	QualifiedName string           `json:"qualified_name"`
 	TableTypeOID  pgtype.OID       `json:"table_type_oid"`
 	TableName     pgtype.Name      `json:"table_name"`
 	ColNames      []string         `json:"col_names"`
@@ -284,7 +574,7 @@ func (q *DBQuerier) FindCompositeTypes(ctx context.Context, oids []uint32) ([]Fi
 	items := []FindCompositeTypesRow{}
 	for rows.Next() {
 		var item FindCompositeTypesRow
-		if err := rows.Scan(&item.TableTypeName, &item.TableTypeOID, &item.TableName, &item.ColNames, &item.ColOIDs, &item.ColOrders, &item.ColNotNulls, &item.ColTypeNames); err != nil {
+		if err := rows.Scan(&item.TableTypeName, &item.QualifiedName, &item.TableTypeOID, &item.TableName, &item.ColNames, &item.ColOIDs, &item.ColOrders, &item.ColNotNulls, &item.ColTypeNames); err != nil {
 			return nil, fmt.Errorf("scan FindCompositeTypes row: %w", err)
 		}
 		items = append(items, item)
@@ -295,6 +585,56 @@ func (q *DBQuerier) FindCompositeTypes(ctx context.Context, oids []uint32) ([]Fi
 	return items, err
 }
 
+// FindCompositeTypesEach implements Querier.FindCompositeTypesEach.
+func (q *DBQuerier) FindCompositeTypesEach(ctx context.Context, oids []uint32, fn func(row FindCompositeTypesRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindCompositeTypes")
+	rows, err := q.conn.Query(ctx, findCompositeTypesSQL, oids)
+	if err != nil {
+		return fmt.Errorf("query FindCompositeTypesEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item FindCompositeTypesRow
+		if err := rows.Scan(&item.TableTypeName, &item.QualifiedName, &item.TableTypeOID, &item.TableName, &item.ColNames, &item.ColOIDs, &item.ColOrders, &item.ColNotNulls, &item.ColTypeNames); err != nil {
+			return fmt.Errorf("scan FindCompositeTypesEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindCompositeTypesEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindCompositeTypes implements Querier.QueueFindCompositeTypes.
+func (q *DBQuerier) QueueFindCompositeTypes(batch genericBatch, oids []uint32) {
+	batch.Queue(findCompositeTypesSQL, oids)
+}
+
+// FindCompositeTypesScan implements Querier.FindCompositeTypesScan.
+func (q *DBQuerier) FindCompositeTypesScan(results pgx.BatchResults) ([]FindCompositeTypesRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindCompositeTypesScan: %w", err)
+	}
+	defer rows.Close()
+	items := []FindCompositeTypesRow{}
+	for rows.Next() {
+		var item FindCompositeTypesRow
+		if err := rows.Scan(&item.TableTypeName, &item.QualifiedName, &item.TableTypeOID, &item.TableName, &item.ColNames, &item.ColOIDs, &item.ColOrders, &item.ColNotNulls, &item.ColTypeNames); err != nil {
+			return nil, fmt.Errorf("scan FindCompositeTypesScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindCompositeTypesScan rows: %w", err)
+	}
+	return items, err
+}
+
 const findDescendantOIDsSQL = `WITH RECURSIVE oid_descs(oid) AS (
   -- Base case.
   SELECT oid
@@ -345,6 +685,56 @@ func (q *DBQuerier) FindDescendantOIDs(ctx context.Context, oids []uint32) ([]pg
 	return items, err
 }
 
+// FindDescendantOIDsEach implements Querier.FindDescendantOIDsEach.
+func (q *DBQuerier) FindDescendantOIDsEach(ctx context.Context, oids []uint32, fn func(row pgtype.OID) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindDescendantOIDs")
+	rows, err := q.conn.Query(ctx, findDescendantOIDsSQL, oids)
+	if err != nil {
+		return fmt.Errorf("query FindDescendantOIDsEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item pgtype.OID
+		if err := rows.Scan(&item); err != nil {
+			return fmt.Errorf("scan FindDescendantOIDsEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindDescendantOIDsEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindDescendantOIDs implements Querier.QueueFindDescendantOIDs.
+func (q *DBQuerier) QueueFindDescendantOIDs(batch genericBatch, oids []uint32) {
+	batch.Queue(findDescendantOIDsSQL, oids)
+}
+
+// FindDescendantOIDsScan implements Querier.FindDescendantOIDsScan.
+func (q *DBQuerier) FindDescendantOIDsScan(results pgx.BatchResults) ([]pgtype.OID, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindDescendantOIDsScan: %w", err)
+	}
+	defer rows.Close()
+	items := []pgtype.OID{}
+	for rows.Next() {
+		var item pgtype.OID
+		if err := rows.Scan(&item); err != nil {
+			return nil, fmt.Errorf("scan FindDescendantOIDsScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindDescendantOIDsScan rows: %w", err)
+	}
+	return items, err
+}
+
 const findOIDByNameSQL = `SELECT oid
 FROM pg_type
 WHERE typname::text = $1
@@ -357,7 +747,22 @@ func (q *DBQuerier) FindOIDByName(ctx context.Context, name string) (pgtype.OID,
 	row := q.conn.QueryRow(ctx, findOIDByNameSQL, name)
 	var item pgtype.OID
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query FindOIDByName: %w", err)
+		return item, fmt.Errorf("query FindOIDByName: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueFindOIDByName implements Querier.QueueFindOIDByName.
+func (q *DBQuerier) QueueFindOIDByName(batch genericBatch, name string) {
+	batch.Queue(findOIDByNameSQL, name)
+}
+
+// FindOIDByNameScan implements Querier.FindOIDByNameScan.
+func (q *DBQuerier) FindOIDByNameScan(results pgx.BatchResults) (pgtype.OID, error) {
+	row := results.QueryRow()
+	var item pgtype.OID
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan FindOIDByNameScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -372,7 +777,22 @@ func (q *DBQuerier) FindOIDName(ctx context.Context, oid pgtype.OID) (pgtype.Nam
 	row := q.conn.QueryRow(ctx, findOIDNameSQL, oid)
 	var item pgtype.Name
 	if err := row.Scan(&item); err != nil {
-		return item, fmt.Errorf("query FindOIDName: %w", err)
+		return item, fmt.Errorf("query FindOIDName: %w", wrapNoRows(err))
+	}
+	return item, nil
+}
+
+// QueueFindOIDName implements Querier.QueueFindOIDName.
+func (q *DBQuerier) QueueFindOIDName(batch genericBatch, oid pgtype.OID) {
+	batch.Queue(findOIDNameSQL, oid)
+}
+
+// FindOIDNameScan implements Querier.FindOIDNameScan.
+func (q *DBQuerier) FindOIDNameScan(results pgx.BatchResults) (pgtype.Name, error) {
+	row := results.QueryRow()
+	var item pgtype.Name
+	if err := row.Scan(&item); err != nil {
+		return item, fmt.Errorf("scan FindOIDNameScan row: %w", wrapNoRows(err))
 	}
 	return item, nil
 }
@@ -409,6 +829,56 @@ func (q *DBQuerier) FindOIDNames(ctx context.Context, oid []uint32) ([]FindOIDNa
 	return items, err
 }
 
+// FindOIDNamesEach implements Querier.FindOIDNamesEach.
+func (q *DBQuerier) FindOIDNamesEach(ctx context.Context, oid []uint32, fn func(row FindOIDNamesRow) error) error {
+	ctx = context.WithValue(ctx, "pggen_query_name", "FindOIDNames")
+	rows, err := q.conn.Query(ctx, findOIDNamesSQL, oid)
+	if err != nil {
+		return fmt.Errorf("query FindOIDNamesEach: %w", err)
+	}
+	// Close discards any rows left unread if fn stops early.
+	defer rows.Close()
+	for rows.Next() {
+		var item FindOIDNamesRow
+		if err := rows.Scan(&item.OID, &item.Name, &item.Kind); err != nil {
+			return fmt.Errorf("scan FindOIDNamesEach row: %w", err)
+		}
+		if err := fn(item); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("close FindOIDNamesEach rows: %w", err)
+	}
+	return nil
+}
+
+// QueueFindOIDNames implements Querier.QueueFindOIDNames.
+func (q *DBQuerier) QueueFindOIDNames(batch genericBatch, oid []uint32) {
+	batch.Queue(findOIDNamesSQL, oid)
+}
+
+// FindOIDNamesScan implements Querier.FindOIDNamesScan.
+func (q *DBQuerier) FindOIDNamesScan(results pgx.BatchResults) ([]FindOIDNamesRow, error) {
+	rows, err := results.Query()
+	if err != nil {
+		return nil, fmt.Errorf("query FindOIDNamesScan: %w", err)
+	}
+	defer rows.Close()
+	items := []FindOIDNamesRow{}
+	for rows.Next() {
+		var item FindOIDNamesRow
+		if err := rows.Scan(&item.OID, &item.Name, &item.Kind); err != nil {
+			return nil, fmt.Errorf("scan FindOIDNamesScan row: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("close FindOIDNamesScan rows: %w", err)
+	}
+	return items, err
+}
+
 // textPreferrer wraps a pgtype.ValueTranscoder and sets the preferred encoding
 // format to text instead binary (the default). pggen uses the text format
 // when the OID is unknownOID because the binary format requires the OID.
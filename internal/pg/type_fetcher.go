@@ -109,11 +109,12 @@ func (tf *TypeFetcher) findEnumTypes(ctx context.Context, uncached map[pgtype.OI
 			childOIDs[i] = pgtype.OID(oidUint32)
 		}
 		types[i] = EnumType{
-			ID:        enum.OID,
-			Name:      enum.TypeName,
-			Labels:    enum.Labels,
-			Orders:    enum.Orders,
-			ChildOIDs: childOIDs,
+			ID:            enum.OID,
+			Name:          enum.TypeName,
+			QualifiedName: enum.QualifiedName,
+			Labels:        enum.Labels,
+			Orders:        enum.Orders,
+			ChildOIDs:     childOIDs,
 		}
 	}
 	return types, nil
@@ -153,10 +154,11 @@ func (tf *TypeFetcher) findCompositeTypes(ctx context.Context, uncached map[pgty
 			}
 		}
 		typ := CompositeType{
-			ID:          row.TableTypeOID,
-			Name:        row.TableName.String,
-			ColumnNames: colNames,
-			ColumnTypes: colTypes,
+			ID:            row.TableTypeOID,
+			Name:          row.TableName.String,
+			QualifiedName: row.QualifiedName,
+			ColumnNames:   colNames,
+			ColumnTypes:   colTypes,
 		}
 		tf.cache.addType(typ)
 		types = append(types, typ)
@@ -194,9 +196,10 @@ func (tf *TypeFetcher) findArrayTypes(ctx context.Context, uncached map[pgtype.O
 			return nil, fmt.Errorf("find type for array elem %s oid=%d", row.TypeName, row.OID)
 		}
 		types[i] = ArrayType{
-			ID:   row.OID,
-			Name: row.TypeName,
-			Elem: elemType,
+			ID:            row.OID,
+			Name:          row.TypeName,
+			QualifiedName: row.QualifiedName,
+			Elem:          elemType,
 		}
 	}
 	return types, nil
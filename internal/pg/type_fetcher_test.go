@@ -234,9 +234,9 @@ func TestNewTypeFetcher(t *testing.T) {
 			}
 
 			opts := cmp.Options{
-				cmpopts.IgnoreFields(EnumType{}, "ChildOIDs", "ID"),
-				cmpopts.IgnoreFields(CompositeType{}, "ID"),
-				cmpopts.IgnoreFields(ArrayType{}, "ID"),
+				cmpopts.IgnoreFields(EnumType{}, "ChildOIDs", "ID", "QualifiedName"),
+				cmpopts.IgnoreFields(CompositeType{}, "ID", "QualifiedName"),
+				cmpopts.IgnoreFields(ArrayType{}, "ID", "QualifiedName"),
 			}
 			sortTypes(wantTypes)
 			sortTypes(gotTypes)
@@ -247,6 +247,45 @@ func TestNewTypeFetcher(t *testing.T) {
 	}
 }
 
+func TestNewTypeFetcher_QualifiedName(t *testing.T) {
+	conn, cleanup := pgtest.NewPostgresSchemaString(t, texts.Dedent(`
+		CREATE TYPE "DeviceStatus" AS ENUM ('active', 'retired');
+		CREATE TYPE "user" AS (id int8, status "DeviceStatus");
+	`))
+	defer cleanup()
+	querier := NewQuerier(conn)
+	var schema string
+	if err := conn.QueryRow(context.Background(), "SELECT current_schema()").Scan(&schema); err != nil {
+		t.Fatal(err)
+	}
+
+	fetcher := NewTypeFetcher(conn)
+	oid := findOIDVal(t, "_user", querier)
+	types, err := fetcher.FindTypesByOIDs(uint32(oid))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string, len(types))
+	for _, typ := range types {
+		switch typ := typ.(type) {
+		case EnumType:
+			got[typ.Name] = typ.QualifiedName
+		case CompositeType:
+			got[typ.Name] = typ.QualifiedName
+		case ArrayType:
+			got[typ.Name] = typ.QualifiedName
+		}
+	}
+	want := map[string]string{
+		"DeviceStatus": schema + `."DeviceStatus"`,
+		"user":         schema + `."user"`,
+		"_user":        schema + `."_user"`,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("FindTypesByOIDs() qualified names mismatch (-want +got):\n%s", diff)
+	}
+}
+
 // Get the OID by name if fetchOID was a string, or just return the OID.
 func findOIDVal(t *testing.T, fetchOID interface{}, querier *DBQuerier) pgtype.OID {
 	switch rawOID := fetchOID.(type) {
@@ -64,6 +64,9 @@ type (
 		// The name of the type, like _int4. Array types in Postgres typically
 		// begin with an underscore. From pg_type.typname.
 		Name string
+		// The schema-qualified name of the type, quoted if needed, like
+		// pg_catalog._int4.
+		QualifiedName string
 		// pg_type.typelem: the element type of the array
 		Elem Type
 	}
@@ -74,6 +77,9 @@ type (
 		//     CREATE TYPE device_type AS ENUM ('foo');
 		// From pg_type.typname.
 		Name string
+		// The schema-qualified name of the enum, quoted if needed, like
+		// public.device_type or "Inventory"."DeviceType".
+		QualifiedName string
 		// All textual labels for this enum in sort order.
 		Labels []string
 		// When an enum type is created, its members are assigned sort-order
@@ -98,10 +104,11 @@ type (
 	// CompositeType is a type containing multiple columns and is represented as
 	// a class. https://www.postgresql.org/docs/13/catalog-pg-class.html
 	CompositeType struct {
-		ID          pgtype.OID // pg_class.oid: row identifier
-		Name        string     // pg_class.relname: name of the composite type
-		ColumnNames []string   // pg_attribute.attname: names of the column, in order
-		ColumnTypes []Type     // pg_attribute JOIN pg_type: information about columns of the composite type
+		ID            pgtype.OID // pg_class.oid: row identifier
+		Name          string     // pg_class.relname: name of the composite type
+		QualifiedName string     // schema-qualified type name, quoted if needed, like public."user"
+		ColumnNames   []string   // pg_attribute.attname: names of the column, in order
+		ColumnTypes   []Type     // pg_attribute JOIN pg_type: information about columns of the composite type
 	}
 
 	// UnknownType is a Postgres type that's not a well-known type in
@@ -9,10 +9,12 @@ import (
 	"errors"
 	"fmt"
 	"github.com/atomicleads/pggen/internal/errs"
+	"github.com/atomicleads/pggen/internal/pgload"
 	"github.com/atomicleads/pggen/internal/ports"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	dockerClient "github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/docker/go-connections/nat"
 	"github.com/jackc/pgx/v4"
 	"io"
@@ -49,15 +51,21 @@ func Start(ctx context.Context, initScripts []string) (client *Client, mErr erro
 	if err != nil {
 		return nil, fmt.Errorf("run container: %w", err)
 	}
-	// Enrich logs with Docker container logs.
+	// Enrich logs with Docker container logs. If an init script failed, report
+	// the failed statement in the script instead of all the logs.
 	defer func() {
 		if mErr != nil {
 			logs, err := c.GetContainerLogs()
 			if err != nil {
 				mErr = errors.Join(mErr, err)
-			} else {
-				mErr = fmt.Errorf("%w\nContainer logs for container ID %s\n\n%s", mErr, containerID, logs)
+				return
 			}
+			if stmtErr, ok := pgload.ParsePsqlError(logs, initScriptPaths(initScripts)); ok {
+				slog.DebugContext(ctx, "init script failed", slog.String("container_logs", logs))
+				mErr = fmt.Errorf("run init script: %w", stmtErr)
+				return
+			}
+			mErr = fmt.Errorf("%w\nContainer logs for container ID %s\n\n%s", mErr, containerID, logs)
 		}
 	}()
 	// Cleanup the container after we're done.
@@ -97,21 +105,39 @@ func (c *Client) GetContainerLogs() (logs string, mErr error) {
 	if err != nil {
 		return "", fmt.Errorf("get container logs: %w", err)
 	}
-	bs, err := io.ReadAll(logsR)
-	if err != nil {
+	// Without a TTY, Docker multiplexes stdout and stderr into one stream.
+	buf := &bytes.Buffer{}
+	if _, err := stdcopy.StdCopy(buf, buf, logsR); err != nil {
 		return "", fmt.Errorf("reall all container logs: %w", err)
 	}
-	return string(bs), nil
+	return buf.String(), nil
+}
+
+// initScriptNames returns the file name of each init script in the Docker
+// image. Makes each init script run in the order it was given using a numeric
+// prefix.
+func initScriptNames(initScripts []string) []string {
+	names := make([]string, len(initScripts))
+	for i, script := range initScripts {
+		names[i] = fmt.Sprintf("%03d_%s", i, filepath.Base(script))
+	}
+	return names
+}
+
+// initScriptPaths maps the path of each init script in the Docker image to the
+// path of the script on the host.
+func initScriptPaths(initScripts []string) map[string]string {
+	paths := make(map[string]string, len(initScripts))
+	for i, name := range initScriptNames(initScripts) {
+		paths[initScriptDir+name] = initScripts[i]
+	}
+	return paths
 }
 
 // buildImage creates a new Postgres Docker image with the given init scripts
 // copied into the Postgres entry point.
 func (c *Client) buildImage(ctx context.Context, initScripts []string) (id string, mErr error) {
-	// Make each init script run in the order it was given using a numeric prefix.
-	initTarNames := make([]string, len(initScripts))
-	for i, script := range initScripts {
-		initTarNames[i] = fmt.Sprintf("%03d_%s", i, filepath.Base(script))
-	}
+	initTarNames := initScriptNames(initScripts)
 
 	// Create Dockerfile with template.
 	dockerfileBuf := &bytes.Buffer{}
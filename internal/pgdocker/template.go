@@ -5,6 +5,10 @@ type pgTemplate struct {
 	InitScripts []string
 }
 
+// initScriptDir is the directory of the init scripts that the Postgres image
+// runs when it creates the database.
+const initScriptDir = "/docker-entrypoint-initdb.d/"
+
 const dockerfileTemplate = `
 {{- /*gotype: github.com/atomicleads/pggen/internal/pgdocker.pgTemplate*/ -}}
 {{- define "dockerfile" -}}
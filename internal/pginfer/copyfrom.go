@@ -0,0 +1,119 @@
+package pginfer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/atomicleads/pggen/internal/ast"
+)
+
+// CopyFromTarget is the table and columns that a :copyfrom query inserts
+// into using the Postgres COPY protocol.
+type CopyFromTarget struct {
+	// The table name, optionally qualified by the schema, like
+	// ["public", "author"]. Unquoted identifiers are lowercase, matching how
+	// Postgres folds unquoted names.
+	Table []string
+	// The column names in the same order as the query params.
+	Columns []string
+}
+
+const copyFromShape = "INSERT INTO tbl (col1, col2) VALUES (pggen.arg('col1'), pggen.arg('col2'))"
+
+// copyFromRegexp matches the prepared SQL of a :copyfrom query, capturing the
+// table name, the column list, and the values list.
+var copyFromRegexp = regexp.MustCompile(`(?is)^\s*INSERT\s+INTO\s+(.+?)\s*\((.*)\)\s*VALUES\s*\((.*)\)\s*;?\s*$`)
+
+var unquotedIdentRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_$]*$`)
+
+// parseCopyFromTarget extracts the table and columns of a :copyfrom query. The
+// query must insert a single row of values where each value is a distinct
+// param in the same order as the columns, because the COPY protocol copies
+// raw column values without evaluating expressions.
+func parseCopyFromTarget(query *ast.SourceQuery) (CopyFromTarget, error) {
+	groups := copyFromRegexp.FindStringSubmatch(query.PreparedSQL)
+	if groups == nil {
+		return CopyFromTarget{}, fmt.Errorf("query %s has result kind %s but isn't an INSERT with a VALUES list; want the form %s",
+			query.Name, query.ResultKind, copyFromShape)
+	}
+
+	table, err := parseIdentList(groups[1], '.')
+	if err != nil {
+		return CopyFromTarget{}, fmt.Errorf("query %s: parse copy table name: %w", query.Name, err)
+	}
+	if len(table) > 2 {
+		return CopyFromTarget{}, fmt.Errorf("query %s: copy table name %q has too many parts; want table or schema.table",
+			query.Name, strings.TrimSpace(groups[1]))
+	}
+
+	columns, err := parseIdentList(groups[2], ',')
+	if err != nil {
+		return CopyFromTarget{}, fmt.Errorf("query %s: parse copy column names: %w", query.Name, err)
+	}
+
+	values := strings.Split(groups[3], ",")
+	if len(values) != len(columns) {
+		return CopyFromTarget{}, fmt.Errorf("query %s has %d columns but %d values; want the form %s",
+			query.Name, len(columns), len(values), copyFromShape)
+	}
+	for i, value := range values {
+		if want := "$" + strconv.Itoa(i+1); strings.TrimSpace(value) != want {
+			return CopyFromTarget{}, fmt.Errorf("query %s: value for column %s must be a pggen.arg not used by another column; "+
+				"COPY doesn't evaluate expressions; want the form %s",
+				query.Name, columns[i], copyFromShape)
+		}
+	}
+	if len(query.ParamNames) != len(columns) {
+		return CopyFromTarget{}, fmt.Errorf("query %s has %d params but %d columns; want one param per column",
+			query.Name, len(query.ParamNames), len(columns))
+	}
+
+	return CopyFromTarget{Table: table, Columns: columns}, nil
+}
+
+// parseIdentList splits s into Postgres identifiers separated by sep, like the
+// column list "a, \"B\"" with sep ','. Ignores sep inside quoted identifiers.
+func parseIdentList(s string, sep rune) ([]string, error) {
+	var idents []string
+	start := 0
+	inQuote := false
+	for i, r := range s {
+		switch {
+		case r == '"':
+			inQuote = !inQuote // an escaped quote "" toggles twice
+		case r == sep && !inQuote:
+			ident, err := parseIdent(s[start:i])
+			if err != nil {
+				return nil, err
+			}
+			idents = append(idents, ident)
+			start = i + 1
+		}
+	}
+	if inQuote {
+		return nil, fmt.Errorf("unterminated quoted identifier in %q", strings.TrimSpace(s))
+	}
+	ident, err := parseIdent(s[start:])
+	if err != nil {
+		return nil, err
+	}
+	return append(idents, ident), nil
+}
+
+// parseIdent parses a single quoted or unquoted Postgres identifier.
+func parseIdent(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		ident := strings.ReplaceAll(s[1:len(s)-1], `""`, `"`)
+		if ident == "" {
+			return "", fmt.Errorf("empty quoted identifier")
+		}
+		return ident, nil
+	}
+	if !unquotedIdentRegexp.MatchString(s) {
+		return "", fmt.Errorf("invalid identifier %q", s)
+	}
+	return strings.ToLower(s), nil
+}
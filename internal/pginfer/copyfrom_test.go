@@ -0,0 +1,84 @@
+package pginfer
+
+import (
+	"testing"
+
+	"github.com/atomicleads/pggen/internal/ast"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseCopyFromTarget(t *testing.T) {
+	tests := []struct {
+		name   string
+		sql    string
+		params []string
+		want   CopyFromTarget
+	}{
+		{
+			name:   "simple",
+			sql:    "INSERT INTO author (first_name, last_name) VALUES ($1, $2);",
+			params: []string{"first_name", "last_name"},
+			want:   CopyFromTarget{Table: []string{"author"}, Columns: []string{"first_name", "last_name"}},
+		},
+		{
+			name:   "schema qualified",
+			sql:    "insert into Public.Author(First_Name)\n  values ($1)",
+			params: []string{"first_name"},
+			want:   CopyFromTarget{Table: []string{"public", "author"}, Columns: []string{"first_name"}},
+		},
+		{
+			name:   "quoted identifiers",
+			sql:    `INSERT INTO "my.schema"."Author" ("a,b", "say ""hi""") VALUES ($1, $2);`,
+			params: []string{"ab", "hi"},
+			want:   CopyFromTarget{Table: []string{"my.schema", "Author"}, Columns: []string{"a,b", `say "hi"`}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := &ast.SourceQuery{
+				Name:        "Copy",
+				PreparedSQL: tt.sql,
+				ParamNames:  tt.params,
+				ResultKind:  ast.ResultKindCopyFrom,
+			}
+			got, err := parseCopyFromTarget(query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Errorf("parseCopyFromTarget() mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParseCopyFromTarget_Error(t *testing.T) {
+	tests := []struct {
+		name   string
+		sql    string
+		params []string
+	}{
+		{"select", "SELECT $1;", []string{"a"}},
+		{"returning", "INSERT INTO author (a) VALUES ($1) RETURNING id;", []string{"a"}},
+		{"on conflict", "INSERT INTO author (a) VALUES ($1) ON CONFLICT DO NOTHING;", []string{"a"}},
+		{"multiple rows", "INSERT INTO author (a) VALUES ($1), ($2);", []string{"a", "b"}},
+		{"expression", "INSERT INTO author (a) VALUES (lower($1));", []string{"a"}},
+		{"reused param", "INSERT INTO author (a, b) VALUES ($1, $1);", []string{"a"}},
+		{"column count", "INSERT INTO author (a, b) VALUES ($1);", []string{"a"}},
+		{"unterminated quote", `INSERT INTO author ("a) VALUES ($1);`, []string{"a"}},
+		{"too many table parts", "INSERT INTO db.public.author (a) VALUES ($1);", []string{"a"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := &ast.SourceQuery{
+				Name:        "Copy",
+				PreparedSQL: tt.sql,
+				ParamNames:  tt.params,
+				ResultKind:  ast.ResultKindCopyFrom,
+			}
+			if got, err := parseCopyFromTarget(query); err == nil {
+				t.Fatalf("parseCopyFromTarget() want error; got %+v", got)
+			}
+		})
+	}
+}
@@ -1,42 +1,700 @@
 package pginfer
 
 import (
-	"github.com/atomicleads/pggen/internal/ast"
+	"errors"
+	"fmt"
 	"github.com/atomicleads/pggen/internal/pg"
+	"github.com/atomicleads/pggen/internal/pgplan"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"regexp"
+	"strconv"
 	"strings"
-	"unicode"
 )
 
-// isColNullable tries to prove the column is not nullable. Strive for
-// correctness here: it's better to assume a column is nullable when we can't
-// know for sure.
-func isColNullable(query *ast.SourceQuery, plan Plan, out string, column pg.Column) bool {
-	switch {
-	case len(out) == 0:
-		// No output? Not sure what this means but do the check here so that we
-		// don't have to do it in each case below.
-		return false
-	case strings.HasPrefix(out, "'"):
-		return false // literal string can't be null
-	case unicode.IsDigit(rune(out[0])):
-		return false // literal number can't be null
+// exprNull describes whether the value of an output expression of a plan node
+// can be null.
+type exprNull struct {
+	null bool
+	// nulled is set if the value can be null even when the expression is a
+	// column of a table with a not-null constraint, like a column from the
+	// nullable side of an outer join, or a group key of a grouping set.
+	nulled bool
+	// The name of the type of the expression, like "text", if known. Finds the
+	// function for a function call with the expression as an argument.
+	typ string
+}
+
+// unknownNull is the nullability of an expression we can't analyze.
+var unknownNull = exprNull{null: true, nulled: true}
+
+// nullCatalog fetches the catalog information for the nullability analysis.
+type nullCatalog interface {
+	FetchRelationColumns(schema, relation string) ([]pg.Column, error)
+	FetchFunc(schema, name string, argTypes []string) (pg.Func, bool, error)
+}
+
+// connCatalog is the nullCatalog for a Postgres connection.
+type connCatalog struct {
+	conn *pgx.Conn
+}
+
+func (c connCatalog) FetchRelationColumns(schema, relation string) ([]pg.Column, error) {
+	return pg.FetchRelationColumns(c.conn, schema, relation)
+}
+
+func (c connCatalog) FetchFunc(schema, name string, argTypes []string) (pg.Func, bool, error) {
+	return pg.FetchFunc(c.conn, schema, name, argTypes)
+}
+
+// nullAnalysis infers which output expressions of the nodes in a query plan
+// can be null by walking the plan from the leaf nodes up. Strive for
+// correctness here: it's better to assume an expression is nullable when we
+// can't know for sure.
+type nullAnalysis struct {
+	catalog nullCatalog
+	// The user functions that never return null, like "slugify" for the
+	// function in any schema, or "my_schema.slugify".
+	notNullFuncs map[string]bool
+	// If each param of the query is nullable; the nth entry is the $n+1 param.
+	// A param declared with pggen.arg is not null but pggen.narg is.
+	paramNulls []bool
+	relations  map[string]map[string]pg.Column // columns by name by table
+	funcs      map[string]funcLookup           // functions by signature
+	ctes       map[string][]exprNull           // output nullability by CTE name
+}
+
+type funcLookup struct {
+	fn pg.Func
+	ok bool
+}
+
+func newNullAnalysis(catalog nullCatalog, notNullFuncs []string, paramNulls []bool) *nullAnalysis {
+	a := &nullAnalysis{
+		catalog:      catalog,
+		notNullFuncs: make(map[string]bool, len(notNullFuncs)),
+		paramNulls:   paramNulls,
+		relations:    make(map[string]map[string]pg.Column),
+		funcs:        make(map[string]funcLookup),
+		ctes:         make(map[string][]exprNull),
+	}
+	for _, name := range notNullFuncs {
+		a.notNullFuncs[name] = true
+	}
+	return a
+}
+
+// analyze returns the nullability of each output expression of node. For
+// nodes without output expressions that combine the rows of the children, like
+// Append, returns the nullability of each output column.
+func (a *nullAnalysis) analyze(node pgplan.Node) ([]exprNull, error) {
+	// Analyze the subplans first because the CTE subplans compute the rows
+	// that CTE Scan nodes in the other children read.
+	var inputs []pgplan.Node
+	for _, child := range node.Children() {
+		base := child.Base()
+		if base.ParentRelationship != pgplan.ParentRelationshipInitPlan &&
+			base.ParentRelationship != pgplan.ParentRelationshipSubPlan {
+			inputs = append(inputs, child)
+			continue
+		}
+		nulls, err := a.analyze(child)
+		if err != nil {
+			return nil, err
+		}
+		if cteName, ok := strings.CutPrefix(base.SubplanName, "CTE "); ok {
+			a.ctes[cteName] = nulls
+		}
+	}
+	inputNulls := make([][]exprNull, len(inputs))
+	for i, input := range inputs {
+		nulls, err := a.analyze(input)
+		if err != nil {
+			return nil, err
+		}
+		inputNulls[i] = nulls
+	}
+
+	outs := node.Output()
+	switch node := node.(type) {
+	case pgplan.Append, pgplan.MergeAppend, pgplan.RecursiveUnion:
+		// Each output column is the column at the same position of every child.
+		// The value doesn't come from a single table column.
+		nulls := make([]exprNull, 0, len(outs))
+		for _, childNulls := range inputNulls {
+			for i, n := range childNulls {
+				if i == len(nulls) {
+					nulls = append(nulls, exprNull{nulled: true, typ: n.typ})
+				}
+				nulls[i].null = nulls[i].null || n.null
+			}
+		}
+		return nulls, nil
+
+	case pgplan.SeqScan:
+		return a.analyzeScan(node.Relation, outs)
+	case pgplan.SampleScan:
+		return a.analyzeScan(node.Relation, outs)
+	case pgplan.IndexScan:
+		return a.analyzeScan(node.Relation, outs)
+	case pgplan.IndexOnlyScan:
+		return a.analyzeScan(node.Relation, outs)
+	case pgplan.BitmapHeapScan:
+		return a.analyzeScan(node.Relation, outs)
+	case pgplan.TidScan:
+		return a.analyzeScan(node.Relation, outs)
+	case pgplan.TidRangeScan:
+		return a.analyzeScan(node.Relation, outs)
+	case pgplan.ForeignScan:
+		return a.analyzeScan(node.Relation, outs)
+
+	case pgplan.ModifyTable:
+		// The RETURNING clause outputs the columns of the modified table or of
+		// the tables in the FROM or USING clause.
+		rel := pgplan.Relation{RelationName: node.RelationName, Schema: node.Schema, Alias: node.Alias}
+		env := newNullEnv(inputs, inputNulls)
+		return a.evalRelationOutputs(rel, outs, env)
+
+	case pgplan.SubqueryScan:
+		if len(inputNulls) != 1 {
+			return a.evalOutputs(outs, nullEnv{})
+		}
+		return a.evalAliasOutputs(node.Alias, inputNulls[0], outs)
+	case pgplan.CteScan:
+		cteNulls, ok := a.ctes[node.CTEName]
+		if !ok {
+			return a.evalOutputs(outs, nullEnv{})
+		}
+		return a.evalAliasOutputs(node.Alias, cteNulls, outs)
+
+	case pgplan.NestLoop:
+		return a.evalJoinOutputs(node.JoinType, inputs, inputNulls, outs)
+	case pgplan.MergeJoin:
+		return a.evalJoinOutputs(node.JoinType, inputs, inputNulls, outs)
+	case pgplan.HashJoin:
+		return a.evalJoinOutputs(node.JoinType, inputs, inputNulls, outs)
+	case pgplan.Join:
+		return a.evalJoinOutputs(node.JoinType, inputs, inputNulls, outs)
+
+	case pgplan.Agg:
+		env := newNullEnv(inputs, inputNulls)
+		if node.GroupingSets {
+			// The rows for a grouping set have null for the group keys not in the
+			// set. Aggregates like count(*) are still not null.
+			for expr, n := range env.exprs {
+				env.exprs[expr] = exprNull{null: true, nulled: true, typ: n.typ}
+			}
+			for i, n := range env.positions {
+				env.positions[i] = exprNull{null: true, nulled: true, typ: n.typ}
+			}
+		}
+		return a.evalOutputs(outs, env)
+
 	default:
-		// try below
+		return a.evalOutputs(outs, newNullEnv(inputs, inputNulls))
+	}
+}
+
+// analyzeScan returns the nullability of the output expressions of a scan
+// node that reads the table rel.
+func (a *nullAnalysis) analyzeScan(rel pgplan.Relation, outs []string) ([]exprNull, error) {
+	return a.evalRelationOutputs(rel, outs, nullEnv{})
+}
+
+// evalRelationOutputs returns the nullability of each output expression. An
+// output that's a column of the table rel is null if the column doesn't have
+// a not-null constraint.
+func (a *nullAnalysis) evalRelationOutputs(rel pgplan.Relation, outs []string, env nullEnv) ([]exprNull, error) {
+	cols, err := a.relationColumns(rel)
+	if err != nil {
+		return nil, err
 	}
+	env.exprs = copyExprs(env.exprs)
+	for _, out := range outs {
+		alias, name, ok := parseColumnRef(out)
+		if !ok || alias != rel.Alias {
+			continue
+		}
+		if col, ok := cols[name]; ok {
+			env.exprs[out] = exprNull{null: col.Null, typ: col.TypeName}
+		}
+	}
+	return a.evalOutputs(outs, env)
+}
 
-	// A plain select query (possibly with a LIMIT clause) with no joins where
-	// the column comes from a table and has a not-null constraint. Not full
-	// proof because of cross-join with comma syntax.
-	if (plan.Type == PlanResult || plan.Type == PlanLimit) &&
-		!strings.Contains(strings.ToLower(query.PreparedSQL), "join") &&
-		!column.Null {
-		return false
+// relationColumns returns the columns of the table rel by name.
+func (a *nullAnalysis) relationColumns(rel pgplan.Relation) (map[string]pg.Column, error) {
+	if rel.RelationName == "" {
+		return nil, nil
+	}
+	key := rel.Schema + "." + rel.RelationName
+	if cols, ok := a.relations[key]; ok {
+		return cols, nil
 	}
+	cols, err := a.catalog.FetchRelationColumns(rel.Schema, rel.RelationName)
+	if err != nil {
+		return nil, fmt.Errorf("fetch columns of table %s for nullability: %w", rel.RelationName, err)
+	}
+	byName := make(map[string]pg.Column, len(cols))
+	for _, col := range cols {
+		byName[col.Name] = col
+	}
+	a.relations[key] = byName
+	return byName, nil
+}
+
+// evalJoinOutputs returns the nullability of the output expressions of a join
+// node. The outer child is the first input and the inner child is the second.
+func (a *nullAnalysis) evalJoinOutputs(joinType pgplan.JoinType, inputs []pgplan.Node, inputNulls [][]exprNull, outs []string) ([]exprNull, error) {
+	if len(inputs) != 2 {
+		return a.evalOutputs(outs, nullEnv{})
+	}
+	nullOuter := joinType == pgplan.JoinRight || joinType == pgplan.JoinFull
+	nullInner := joinType == pgplan.JoinLeft || joinType == pgplan.JoinFull
+	env := nullEnv{exprs: make(map[string]exprNull)}
+	for i, nullSide := range []bool{nullOuter, nullInner} {
+		for j, out := range inputs[i].Output() {
+			n := unknownNull
+			if j < len(inputNulls[i]) {
+				n = inputNulls[i][j]
+			}
+			if nullSide {
+				n = exprNull{null: true, nulled: true, typ: n.typ}
+			}
+			env.exprs[out] = n
+		}
+	}
+	return a.evalOutputs(outs, env)
+}
 
-	// A returning clause in an insert, update, or delete statement. The column
-	// must come from the underlying table and must have a not null constraint.
-	if plan.Type == PlanModifyTable && plan.Relation == column.TableName && !column.Null {
+// evalAliasOutputs returns the nullability of the output expressions of a
+// node that reads the rows of a subquery or CTE with the output nullability
+// inputNulls. An output that's a column of the subquery, like "alias.col", is
+// not null only if every subquery output is not null because the EXPLAIN
+// output doesn't say which subquery output the column is.
+func (a *nullAnalysis) evalAliasOutputs(alias string, inputNulls []exprNull, outs []string) ([]exprNull, error) {
+	col := exprNull{}
+	for _, n := range inputNulls {
+		col.null = col.null || n.null
+		col.nulled = col.nulled || n.nulled
+	}
+	env := nullEnv{exprs: make(map[string]exprNull)}
+	for _, out := range outs {
+		if a, _, ok := parseColumnRef(out); ok && a == alias {
+			env.exprs[out] = col
+		}
+	}
+	return a.evalOutputs(outs, env)
+}
+
+func (a *nullAnalysis) evalOutputs(outs []string, env nullEnv) ([]exprNull, error) {
+	nulls := make([]exprNull, len(outs))
+	for i, out := range outs {
+		if _, ok := env.exprs[out]; !ok && i < len(env.positions) {
+			nulls[i] = env.positions[i]
+			continue
+		}
+		n, err := a.eval(out, env)
+		if err != nil {
+			return nil, err
+		}
+		nulls[i] = n
+	}
+	return nulls, nil
+}
+
+// nullEnv is the nullability of the expressions a node can reference.
+type nullEnv struct {
+	// The nullability of the output expressions of the child nodes by the
+	// expression text. A node references the output of a child with the same
+	// text.
+	exprs map[string]exprNull
+	// The nullability of each output column of a single child without output
+	// expressions, like Append. The node outputs the columns in order.
+	positions []exprNull
+}
+
+func newNullEnv(inputs []pgplan.Node, inputNulls [][]exprNull) nullEnv {
+	env := nullEnv{exprs: make(map[string]exprNull)}
+	for i, input := range inputs {
+		outs := input.Output()
+		if len(outs) == 0 && len(inputs) == 1 {
+			env.positions = inputNulls[i]
+		}
+		for j, out := range outs {
+			if j < len(inputNulls[i]) {
+				env.exprs[out] = inputNulls[i][j]
+			}
+		}
+	}
+	return env
+}
+
+func copyExprs(exprs map[string]exprNull) map[string]exprNull {
+	c := make(map[string]exprNull, len(exprs))
+	for expr, n := range exprs {
+		c[expr] = n
+	}
+	return c
+}
+
+var (
+	integerLiteralRegexp = regexp.MustCompile(`^-?[0-9]+$`)
+	numericLiteralRegexp = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?([eE][-+]?[0-9]+)?$`)
+	castTypeRegexp       = regexp.MustCompile(`^("[^"]+"|[a-z_][a-z0-9_ ]*)(\.("[^"]+"|[a-z_][a-z0-9_]*))?(\([0-9, ]+\))?(\[\])*$`)
+	identRegexp          = regexp.MustCompile(`^("([^"]|"")+"|[a-zA-Z_][a-zA-Z0-9_$]*)`)
+	paramRegexp          = regexp.MustCompile(`^\$([0-9]+)$`)
+)
+
+// notNullAggFuncs are the aggregate and window functions that never return
+// null.
+var notNullAggFuncs = map[string]bool{
+	"count":        true,
+	"row_number":   true,
+	"rank":         true,
+	"dense_rank":   true,
+	"percent_rank": true,
+	"cume_dist":    true,
+}
+
+// nullableBuiltinFuncPrefixes are the prefixes of the names of strict built-in
+// functions that can return null even if no argument is null, like
+// regexp_match when the string doesn't match, or to_regclass when the table
+// doesn't exist.
+var nullableBuiltinFuncPrefixes = []string{
+	"array_",
+	"col_description",
+	"current_schema",
+	"current_setting",
+	"has_",
+	"inet_",
+	"json",
+	"obj_description",
+	"pg_",
+	"regexp_match",
+	"regexp_substr",
+	"shobj_description",
+	"substring",
+	"to_reg",
+	"txid_",
+	"unnest",
+	"xpath",
+}
+
+// eval returns the nullability of expr, an output expression as deparsed by
+// EXPLAIN VERBOSE.
+func (a *nullAnalysis) eval(expr string, env nullEnv) (exprNull, error) {
+	expr = strings.TrimSpace(expr)
+	if n, ok := env.exprs[expr]; ok {
+		return n, nil
+	}
+	switch {
+	case expr == "":
+		return unknownNull, nil
+	case integerLiteralRegexp.MatchString(expr):
+		if _, err := strconv.ParseInt(expr, 10, 32); err == nil {
+			return exprNull{typ: "integer"}, nil
+		}
+		return exprNull{}, nil
+	case numericLiteralRegexp.MatchString(expr):
+		return exprNull{typ: "numeric"}, nil
+	case expr == "true", expr == "false":
+		return exprNull{typ: "boolean"}, nil
+	case isStringLiteral(expr):
+		return exprNull{}, nil
+	case paramRegexp.MatchString(expr):
+		n, _ := strconv.Atoi(expr[1:])
+		if n >= 1 && n <= len(a.paramNulls) && !a.paramNulls[n-1] {
+			return exprNull{}, nil
+		}
+		return unknownNull, nil
+	case expr[0] == '(' && closingParen(expr, 0) == len(expr)-1:
+		return a.eval(expr[1:len(expr)-1], env)
+	}
+	if base, typ, ok := cutTopLevel(expr, "::"); ok && castTypeRegexp.MatchString(typ) {
+		// A cast of a value is null only if the value is null.
+		n, err := a.eval(base, env)
+		if err != nil {
+			return exprNull{}, err
+		}
+		return exprNull{null: n.null, nulled: true, typ: typ}, nil
+	}
+	if name, args, ok := parseFuncCall(expr); ok {
+		switch {
+		case notNullAggFuncs[name]:
+			return exprNull{nulled: true}, nil
+		case name == "COALESCE":
+			// Null only if every argument is null.
+			n := exprNull{null: true, nulled: true}
+			for _, arg := range splitTopLevel(args, ',') {
+				argNull, err := a.eval(arg, env)
+				if err != nil {
+					return exprNull{}, err
+				}
+				n.null = n.null && argNull.null
+				if n.typ == "" {
+					n.typ = argNull.typ
+				}
+			}
+			return n, nil
+		default:
+			return a.evalFuncCall(name, args, env)
+		}
+	}
+	return unknownNull, nil
+}
+
+// evalFuncCall returns the nullability of a call of the function name with
+// args. A strict function returns null if any argument is null. A strict
+// built-in function returns non-null if no argument is null, except for the
+// functions in nullableBuiltinFuncPrefixes. A user function in notNullFuncs
+// returns non-null if the function isn't strict or no argument is null.
+func (a *nullAnalysis) evalFuncCall(name, args string, env nullEnv) (exprNull, error) {
+	schema, fnName, ok := parseFuncName(name)
+	if !ok {
+		return unknownNull, nil // SQL syntax like EXTRACT(year FROM a.ts)
+	}
+	var argNulls []exprNull
+	if strings.TrimSpace(args) != "" {
+		for _, arg := range splitTopLevel(args, ',') {
+			n, err := a.eval(arg, env)
+			if err != nil {
+				return exprNull{}, err
+			}
+			argNulls = append(argNulls, n)
+		}
+	}
+	argTypes := make([]string, len(argNulls))
+	argsNull := false
+	for i, n := range argNulls {
+		if n.typ == "" {
+			return unknownNull, nil // can't find the function without the types
+		}
+		argTypes[i] = n.typ
+		argsNull = argsNull || n.null
+	}
+	fn, ok, err := a.fetchFunc(schema, fnName, argTypes)
+	if err != nil {
+		return exprNull{}, err
+	}
+	if !ok || fn.Kind != pg.FuncKindFunction {
+		return unknownNull, nil
+	}
+	n := exprNull{null: true, nulled: true}
+	if !strings.HasPrefix(fn.ReturnType, "any") {
+		n.typ = fn.ReturnType // polymorphic types depend on the arguments
+	}
+	switch {
+	case a.notNullFuncs[fn.Name] || a.notNullFuncs[fn.Schema+"."+fn.Name]:
+		n.null = fn.Strict && argsNull
+	case fn.Schema == "pg_catalog" && fn.Strict && !isNullableBuiltinFunc(fn.Name):
+		n.null = argsNull
+	}
+	return n, nil
+}
+
+// fetchFunc fetches the function named name in schema with the argument
+// types argTypes. Returns false if no function matches.
+func (a *nullAnalysis) fetchFunc(schema, name string, argTypes []string) (pg.Func, bool, error) {
+	key := schema + "." + name + "(" + strings.Join(argTypes, ", ") + ")"
+	if lookup, ok := a.funcs[key]; ok {
+		return lookup.fn, lookup.ok, nil
+	}
+	fn, ok, err := a.catalog.FetchFunc(schema, name, argTypes)
+	if pgErr := (*pgconn.PgError)(nil); errors.As(err, &pgErr) {
+		// Postgres couldn't parse the signature, like for an argument type
+		// that's not a type name; assume no function matches.
+		fn, ok, err = pg.Func{}, false, nil
+	}
+	if err != nil {
+		return pg.Func{}, false, fmt.Errorf("fetch function %s for nullability: %w", name, err)
+	}
+	a.funcs[key] = funcLookup{fn: fn, ok: ok}
+	return fn, ok, nil
+}
+
+func isNullableBuiltinFunc(name string) bool {
+	for _, prefix := range nullableBuiltinFuncPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStringLiteral returns true if expr is a single-quoted string, like 'foo'.
+func isStringLiteral(expr string) bool {
+	if len(expr) < 2 || expr[0] != '\'' {
 		return false
 	}
-	return true // we can't figure it out; assume nullable
+	return skipQuoted(expr, 0) == len(expr)
+}
+
+// parseColumnRef parses a column reference qualified by the table alias, like
+// author.first_name or "Foo"."Bar".
+func parseColumnRef(expr string) (alias, name string, ok bool) {
+	alias, rest, ok := cutIdent(expr)
+	if !ok || !strings.HasPrefix(rest, ".") {
+		return "", "", false
+	}
+	name, rest, ok = cutIdent(rest[1:])
+	if !ok || rest != "" {
+		return "", "", false
+	}
+	return alias, name, true
+}
+
+// cutIdent parses the identifier at the start of s and returns the
+// unquoted identifier and the rest of s.
+func cutIdent(s string) (ident, rest string, ok bool) {
+	m := identRegexp.FindString(s)
+	if m == "" {
+		return "", "", false
+	}
+	if m[0] == '"' {
+		return strings.ReplaceAll(m[1:len(m)-1], `""`, `"`), s[len(m):], true
+	}
+	return m, s[len(m):], true
+}
+
+// parseFuncCall parses a function call, like count(*), public.slugify(a.name),
+// or row_number() OVER (?), and returns the function name as written and the
+// arguments.
+func parseFuncCall(expr string) (name, args string, ok bool) {
+	open := strings.IndexByte(expr, '(')
+	if open <= 0 {
+		return "", "", false
+	}
+	name = expr[:open]
+	_, rest, ok := cutIdent(name)
+	if ok && strings.HasPrefix(rest, ".") {
+		_, rest, ok = cutIdent(rest[1:])
+	}
+	if !ok || rest != "" {
+		return "", "", false
+	}
+	end := closingParen(expr, open)
+	if end < 0 {
+		return "", "", false
+	}
+	suffix := strings.TrimSpace(expr[end+1:])
+	for suffix != "" {
+		// A window function or an aggregate with a filter, like
+		// count(*) FILTER (WHERE (a.id > 1)) OVER (?).
+		keyword, rest, _ := strings.Cut(suffix, " ")
+		if keyword != "OVER" && keyword != "FILTER" {
+			return "", "", false
+		}
+		rest = strings.TrimSpace(rest)
+		if !strings.HasPrefix(rest, "(") {
+			return "", "", false
+		}
+		restEnd := closingParen(rest, 0)
+		if restEnd < 0 {
+			return "", "", false
+		}
+		suffix = strings.TrimSpace(rest[restEnd+1:])
+	}
+	return name, expr[open+1 : end], true
+}
+
+// parseFuncName parses the name of a function call, like lower or
+// "MySchema".slugify, into the schema, if any, and the unquoted name. Returns
+// false for SQL syntax that looks like a function call, like
+// EXTRACT(year FROM a.ts), because EXPLAIN writes the keywords in uppercase
+// and always quotes uppercase identifiers.
+func parseFuncName(name string) (schema, fnName string, ok bool) {
+	parts := make([]string, 0, 2)
+	for rest := name; ; {
+		ident, after, ok := cutIdent(rest)
+		if !ok || rest[0] != '"' && ident != strings.ToLower(ident) {
+			return "", "", false
+		}
+		parts = append(parts, ident)
+		if after == "" {
+			break
+		}
+		if after[0] != '.' || len(parts) == 2 {
+			return "", "", false
+		}
+		rest = after[1:]
+	}
+	if len(parts) == 2 {
+		return parts[0], parts[1], true
+	}
+	return "", parts[0], true
+}
+
+// closingParen returns the index of the paren that closes the paren at
+// s[open], or -1 if the paren isn't closed.
+func closingParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); {
+		switch s[i] {
+		case '\'', '"':
+			i = skipQuoted(s, i)
+			continue
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+		i++
+	}
+	return -1
+}
+
+// skipQuoted returns the index after the quoted string or identifier that
+// starts at s[start]. A doubled quote inside the string is an escaped quote.
+func skipQuoted(s string, start int) int {
+	quote := s[start]
+	for i := start + 1; i < len(s); i++ {
+		if s[i] != quote {
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == quote {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return len(s) + 1 // unterminated
+}
+
+// cutTopLevel slices s around the first instance of sep that's not inside
+// parens or quotes.
+func cutTopLevel(s, sep string) (before, after string, found bool) {
+	depth := 0
+	for i := 0; i < len(s); {
+		switch {
+		case s[i] == '\'' || s[i] == '"':
+			i = skipQuoted(s, i)
+			continue
+		case s[i] == '(' || s[i] == '[':
+			depth++
+		case s[i] == ')' || s[i] == ']':
+			depth--
+		case depth == 0 && strings.HasPrefix(s[i:], sep):
+			return s[:i], s[i+len(sep):], true
+		}
+		i++
+	}
+	return s, "", false
+}
+
+// splitTopLevel splits s around each instance of sep that's not inside parens
+// or quotes.
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	for {
+		before, after, found := cutTopLevel(s, string(sep))
+		parts = append(parts, before)
+		if !found {
+			return parts
+		}
+		s = after
+	}
 }
@@ -0,0 +1,311 @@
+package pginfer
+
+import (
+	"github.com/atomicleads/pggen/internal/pg"
+	"github.com/atomicleads/pggen/internal/pgplan"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// fakeCatalog is a nullCatalog with the tables and functions in memory.
+type fakeCatalog struct {
+	relations map[string][]pg.Column // columns by table name
+	funcs     map[string]pg.Func     // functions by signature, like "lower(text)"
+}
+
+func (c fakeCatalog) FetchRelationColumns(_, relation string) ([]pg.Column, error) {
+	return c.relations[relation], nil
+}
+
+func (c fakeCatalog) FetchFunc(schema, name string, argTypes []string) (pg.Func, bool, error) {
+	sig := name + "(" + strings.Join(argTypes, ", ") + ")"
+	if schema != "" {
+		sig = schema + "." + sig
+	}
+	fn, ok := c.funcs[sig]
+	return fn, ok, nil
+}
+
+func TestNullAnalysis_Eval(t *testing.T) {
+	builtin := func(name, returnType string) pg.Func {
+		return pg.Func{Schema: "pg_catalog", Name: name, Kind: pg.FuncKindFunction, Strict: true, ReturnType: returnType}
+	}
+	catalog := fakeCatalog{funcs: map[string]pg.Func{
+		"lower(text)":              builtin("lower", "text"),
+		"now()":                    builtin("now", "timestamp with time zone"),
+		"regexp_match(text, text)": builtin("regexp_match", "text[]"),
+		"max(integer)":             {Schema: "pg_catalog", Name: "max", Kind: pg.FuncKindAggregate, ReturnType: "integer"},
+		"slugify(text)":            {Schema: "public", Name: "slugify", Kind: pg.FuncKindFunction, Strict: true, ReturnType: "text"},
+		"public.slugify(text)":     {Schema: "public", Name: "slugify", Kind: pg.FuncKindFunction, Strict: true, ReturnType: "text"},
+		"public.whisper(text)":     {Schema: "public", Name: "whisper", Kind: pg.FuncKindFunction, Strict: true, ReturnType: "text"},
+		"other.shout(text)":        {Schema: "other", Name: "shout", Kind: pg.FuncKindFunction, Strict: false, ReturnType: "text"},
+		"first_elem(anyarray)":     {Schema: "public", Name: "first_elem", Kind: pg.FuncKindFunction, ReturnType: "anyelement"},
+		"Quoted.MixedCase(text)":   {Schema: "Quoted", Name: "MixedCase", Kind: pg.FuncKindFunction, Strict: true, ReturnType: "text"},
+	}}
+	env := nullEnv{exprs: map[string]exprNull{
+		"a.id":    {typ: "integer"},
+		"a.name":  {null: true, typ: "text"},
+		"a.other": {},
+		"b.id":    unknownNull,
+		`"Q".x`:   {},
+	}}
+	tests := []struct {
+		expr string
+		want exprNull
+	}{
+		{"a.id", exprNull{typ: "integer"}},
+		{"a.name", exprNull{null: true, typ: "text"}},
+		{"b.id", unknownNull},
+		{`"Q".x`, exprNull{}},
+		{"1", exprNull{typ: "integer"}},
+		{"10000000000", exprNull{}},
+		{"-1.5e3", exprNull{typ: "numeric"}},
+		{"'foo'", exprNull{}},
+		{"'it''s'", exprNull{}},
+		{"true", exprNull{typ: "boolean"}},
+		{"NULL::text", exprNull{null: true, nulled: true, typ: "text"}},
+		{"'foo'::text", exprNull{nulled: true, typ: "text"}},
+		{"'{1}'::integer[]", exprNull{nulled: true, typ: "integer[]"}},
+		{"'a'::character varying(10)", exprNull{nulled: true, typ: "character varying(10)"}},
+		{"(a.id)::text", exprNull{nulled: true, typ: "text"}},
+		{"(a.name)::text", exprNull{null: true, nulled: true, typ: "text"}},
+		{"((a.id))", exprNull{typ: "integer"}},
+		{"(a.id + 1)", unknownNull},
+		{"'a'::text || 'b'::text", unknownNull},
+		{"count(*)", exprNull{nulled: true}},
+		{"count(a.name)", exprNull{nulled: true}},
+		{"count(*) FILTER (WHERE (a.id > 1))", exprNull{nulled: true}},
+		{"row_number() OVER (?)", exprNull{nulled: true}},
+		{"count(*) OVER w", unknownNull},
+		{"max(a.id)", unknownNull},
+		{"COALESCE(a.name, 'x'::text)", exprNull{nulled: true, typ: "text"}},
+		{"COALESCE(a.name, b.id)", exprNull{null: true, nulled: true, typ: "text"}},
+		{"COALESCE(a.name, ','::text)", exprNull{nulled: true, typ: "text"}},
+		{"$1", exprNull{}},
+		{"$2", unknownNull},
+		{"$3", unknownNull},
+		{"($1)::text", exprNull{nulled: true, typ: "text"}},
+		{"($2)::text", exprNull{null: true, nulled: true, typ: "text"}},
+		{"lower(($1)::text)", exprNull{nulled: true, typ: "text"}},
+		{"lower(a.name)", exprNull{null: true, nulled: true, typ: "text"}},
+		{"lower((a.id)::text)", exprNull{nulled: true, typ: "text"}},
+		{"lower(a.other)", unknownNull},
+		{"upper((a.id)::text)", unknownNull},
+		{"now()", exprNull{nulled: true, typ: "timestamp with time zone"}},
+		{"regexp_match((a.id)::text, 'x'::text)", exprNull{null: true, nulled: true, typ: "text[]"}},
+		{"lower(regexp_match((a.id)::text, 'x'::text))", unknownNull},
+		{"slugify((a.id)::text)", exprNull{nulled: true, typ: "text"}},
+		{"public.slugify(a.name)", exprNull{null: true, nulled: true, typ: "text"}},
+		{"public.whisper((a.id)::text)", exprNull{null: true, nulled: true, typ: "text"}},
+		{"other.shout(a.name)", exprNull{nulled: true, typ: "text"}},
+		{"first_elem('{1}'::anyarray)", exprNull{null: true, nulled: true}},
+		{`"Quoted"."MixedCase"('a'::text)`, exprNull{null: true, nulled: true, typ: "text"}},
+		{"EXTRACT(year FROM a.ts)", unknownNull},
+		{"GREATEST(a.id, 1)", unknownNull},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			a := newNullAnalysis(catalog, []string{"slugify", "other.shout"}, []bool{false, true})
+			got, err := a.eval(tt.expr, env)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNullAnalysis_Analyze(t *testing.T) {
+	author := pgplan.Relation{RelationName: "author", Schema: "public", Alias: "a"}
+	book := pgplan.Relation{RelationName: "book", Schema: "public", Alias: "b"}
+	catalog := fakeCatalog{relations: map[string][]pg.Column{
+		"author": {
+			{Name: "id", Null: false, TypeName: "integer"},
+			{Name: "name", Null: true, TypeName: "text"},
+		},
+		"book": {
+			{Name: "id", Null: false, TypeName: "integer"},
+			{Name: "author_id", Null: false, TypeName: "integer"},
+		},
+	}}
+	authorScan := pgplan.SeqScan{
+		Plan:     pgplan.Plan{Outs: []string{"a.id", "a.name"}, ParentRelationship: pgplan.ParentRelationshipOuter},
+		Relation: author,
+	}
+	bookScan := pgplan.Hash{Plan: pgplan.Plan{
+		Outs:               []string{"b.id", "b.author_id"},
+		ParentRelationship: pgplan.ParentRelationshipInner,
+		Nodes: []pgplan.Node{pgplan.SeqScan{
+			Plan:     pgplan.Plan{Outs: []string{"b.id", "b.author_id"}, ParentRelationship: pgplan.ParentRelationshipOuter},
+			Relation: book,
+		}},
+	}}
+	join := func(joinType pgplan.JoinType) pgplan.Node {
+		return pgplan.HashJoin{
+			Plan: pgplan.Plan{
+				Outs:  []string{"a.id", "a.name", "b.id"},
+				Nodes: []pgplan.Node{authorScan, bookScan},
+			},
+			JoinType: joinType,
+		}
+	}
+	result := func(outs ...string) pgplan.Node {
+		return pgplan.Result{Plan: pgplan.Plan{Outs: outs, ParentRelationship: pgplan.ParentRelationshipMember}}
+	}
+
+	tests := []struct {
+		name string
+		plan pgplan.Node
+		want []exprNull
+	}{
+		{
+			name: "scan",
+			plan: authorScan,
+			want: []exprNull{{typ: "integer"}, {null: true, typ: "text"}},
+		},
+		{
+			name: "inner join",
+			plan: join(pgplan.JoinInner),
+			want: []exprNull{{typ: "integer"}, {null: true, typ: "text"}, {typ: "integer"}},
+		},
+		{
+			name: "left join",
+			plan: join(pgplan.JoinLeft),
+			want: []exprNull{{typ: "integer"}, {null: true, typ: "text"}, {null: true, nulled: true, typ: "integer"}},
+		},
+		{
+			name: "right join",
+			plan: join(pgplan.JoinRight),
+			want: []exprNull{{null: true, nulled: true, typ: "integer"}, {null: true, nulled: true, typ: "text"}, {typ: "integer"}},
+		},
+		{
+			name: "full join",
+			plan: join(pgplan.JoinFull),
+			want: []exprNull{{null: true, nulled: true, typ: "integer"}, {null: true, nulled: true, typ: "text"}, {null: true, nulled: true, typ: "integer"}},
+		},
+		{
+			name: "left join through sort",
+			plan: pgplan.Sort{
+				Plan: pgplan.Plan{
+					Outs:  []string{"a.id", "b.id", "COALESCE(b.id, 0)"},
+					Nodes: []pgplan.Node{join(pgplan.JoinLeft)},
+				},
+				SortKey: []string{"a.id"},
+			},
+			want: []exprNull{{typ: "integer"}, {null: true, nulled: true, typ: "integer"}, {nulled: true, typ: "integer"}},
+		},
+		{
+			name: "count",
+			plan: pgplan.Agg{Plan: pgplan.Plan{
+				Outs:  []string{"count(*)", "max(a.id)", "a.id"},
+				Nodes: []pgplan.Node{authorScan},
+			}},
+			want: []exprNull{{nulled: true}, unknownNull, {typ: "integer"}},
+		},
+		{
+			name: "grouping sets",
+			plan: pgplan.Agg{
+				Plan: pgplan.Plan{
+					Outs:  []string{"a.id", "count(*)"},
+					Nodes: []pgplan.Node{authorScan},
+				},
+				GroupingSets: true,
+			},
+			want: []exprNull{{null: true, nulled: true, typ: "integer"}, {nulled: true}},
+		},
+		{
+			name: "union",
+			plan: pgplan.Unique{Plan: pgplan.Plan{
+				Outs: []string{"(1)", "('a'::text)"},
+				Nodes: []pgplan.Node{pgplan.Sort{Plan: pgplan.Plan{
+					Outs: []string{"(1)", "('a'::text)"},
+					Nodes: []pgplan.Node{pgplan.Append{Plan: pgplan.Plan{
+						Nodes: []pgplan.Node{
+							result("1", "'a'::text"),
+							result("2", "NULL::text"),
+						},
+					}}},
+				}}},
+			}},
+			want: []exprNull{{nulled: true, typ: "integer"}, {null: true, nulled: true, typ: "text"}},
+		},
+		{
+			name: "subquery scan",
+			plan: pgplan.SubqueryScan{
+				Plan: pgplan.Plan{
+					Outs: []string{"s.id", "1"},
+					Nodes: []pgplan.Node{pgplan.SeqScan{
+						Plan:     pgplan.Plan{Outs: []string{"b.id", "b.author_id"}, ParentRelationship: pgplan.ParentRelationshipSubquery},
+						Relation: book,
+					}},
+				},
+				Alias: "s",
+			},
+			want: []exprNull{{}, {typ: "integer"}},
+		},
+		{
+			name: "subquery scan with nullable output",
+			plan: pgplan.SubqueryScan{
+				Plan: pgplan.Plan{
+					Outs:  []string{"s.id"},
+					Nodes: []pgplan.Node{authorScan},
+				},
+				Alias: "s",
+			},
+			want: []exprNull{{null: true}},
+		},
+		{
+			name: "CTE scan",
+			plan: pgplan.CteScan{
+				Plan: pgplan.Plan{
+					Outs: []string{"c.author_id"},
+					Nodes: []pgplan.Node{pgplan.HashJoin{
+						Plan: pgplan.Plan{
+							Outs:               []string{"a.id", "b.author_id"},
+							ParentRelationship: pgplan.ParentRelationshipInitPlan,
+							SubplanName:        "CTE c",
+							Nodes:              []pgplan.Node{authorScan, bookScan},
+						},
+						JoinType: pgplan.JoinLeft,
+					}},
+				},
+				CTEName: "c",
+				Alias:   "c",
+			},
+			want: []exprNull{unknownNull},
+		},
+		{
+			name: "unknown CTE",
+			plan: pgplan.CteScan{
+				Plan:    pgplan.Plan{Outs: []string{"c.id"}},
+				CTEName: "c",
+				Alias:   "c",
+			},
+			want: []exprNull{unknownNull},
+		},
+		{
+			name: "returning",
+			plan: pgplan.ModifyTable{
+				Plan: pgplan.Plan{
+					Outs:  []string{"author.id", "author.name"},
+					Nodes: []pgplan.Node{result("1", "NULL::text")},
+				},
+				Operation:    pgplan.OperationInsert,
+				RelationName: "author",
+				Schema:       "public",
+				Alias:        "author",
+			},
+			want: []exprNull{{typ: "integer"}, {null: true, typ: "text"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newNullAnalysis(catalog, nil, nil).analyze(tt.plan)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
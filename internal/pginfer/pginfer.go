@@ -4,11 +4,14 @@ import (
 	"context"
 	"fmt"
 	"github.com/jackc/pgconn"
+	gotok "go/token"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/atomicleads/pggen/internal/ast"
 	"github.com/atomicleads/pggen/internal/pg"
+	"github.com/atomicleads/pggen/internal/pgplan"
 	"github.com/jackc/pgproto3/v2"
 	"github.com/jackc/pgtype"
 	"github.com/jackc/pgx/v4"
@@ -22,7 +25,7 @@ type TypedQuery struct {
 	// Name of the query, from the comment preceding the query. Like 'FindAuthors'
 	// in the source SQL: "-- name: FindAuthors :many"
 	Name string
-	// The result output kind, :one, :many, or :exec.
+	// The result output kind, :one, :opt, :many, :exec, :execrows, or :copyfrom.
 	ResultKind ast.ResultKind
 	// The comment lines preceding the query, without the SQL comment syntax and
 	// excluding the :name line.
@@ -37,6 +40,11 @@ type TypedQuery struct {
 	// Qualified protocol buffer message type to use for each output row, like
 	// "erp.api.Product". If empty, generate our own Row type.
 	ProtobufType string
+	// The table and columns to insert into for :copyfrom queries.
+	CopyFrom CopyFromTarget
+	// If set, the number of rows an :exec or :execrows query must affect, from
+	// the expect-rows pragma.
+	ExpectRows *int64
 }
 
 // InputParam is an input parameter for a prepared query.
@@ -45,6 +53,10 @@ type InputParam struct {
 	PgName string
 	// The postgres type of this param as reported by Postgres.
 	PgType pg.Type
+	// If the param can be null, like pggen.narg('FirstName'). Postgres
+	// doesn't report param nullability, so params are non-nullable unless
+	// marked.
+	Nullable bool
 }
 
 // OutputColumn is a single column output from a select query or returning
@@ -56,21 +68,29 @@ type OutputColumn struct {
 	PgType pg.Type
 	// If the type can be null; depends on the query. A column defined
 	// with a NOT NULL constraint can still be null in the output with a left
-	// join. Nullability is determined using rudimentary control-flow analysis.
+	// join. Nullability is determined by analyzing the query plan.
 	Nullable bool
 }
 
 type Inferrer struct {
-	conn        *pgx.Conn
-	typeFetcher *pg.TypeFetcher
+	conn         *pgx.Conn
+	typeFetcher  *pg.TypeFetcher
+	notNullFuncs []string
+	views        map[pgtype.OID][]bool // nullability of view columns by view OID
 }
 
 // NewInferrer infers information about a query by running the query on
 // Postgres and extracting information from the catalog tables.
-func NewInferrer(conn *pgx.Conn) *Inferrer {
+//
+// notNullFuncs are the user functions that never return null, like "slugify"
+// for the function in any schema, or "my_schema.slugify". A call of the
+// function is non-null if the function isn't strict or no argument is null.
+func NewInferrer(conn *pgx.Conn, notNullFuncs []string) *Inferrer {
 	return &Inferrer{
-		conn:        conn,
-		typeFetcher: pg.NewTypeFetcher(conn),
+		conn:         conn,
+		typeFetcher:  pg.NewTypeFetcher(conn),
+		notNullFuncs: notNullFuncs,
+		views:        make(map[pgtype.OID][]bool),
 	}
 }
 
@@ -79,13 +99,27 @@ func (inf *Inferrer) InferTypes(query *ast.SourceQuery) (TypedQuery, error) {
 	if err != nil {
 		return TypedQuery{}, fmt.Errorf("infer output types for query: %w", err)
 	}
-	if query.ResultKind != ast.ResultKindExec && len(outputs) == 0 {
+	var copyFrom CopyFromTarget
+	if query.ResultKind == ast.ResultKindCopyFrom {
+		if len(outputs) > 0 {
+			return TypedQuery{}, fmt.Errorf(
+				"query %s has incompatible result kind %s; the query returns columns; "+
+					"remove the RETURNING clause because COPY can't return rows",
+				query.Name, query.ResultKind)
+		}
+		copyFrom, err = parseCopyFromTarget(query)
+		if err != nil {
+			return TypedQuery{}, err
+		}
+	}
+	isExec := query.ResultKind == ast.ResultKindExec || query.ResultKind == ast.ResultKindExecRows
+	if !isExec && query.ResultKind != ast.ResultKindCopyFrom && len(outputs) == 0 {
 		return TypedQuery{}, fmt.Errorf(
 			"query %s has incompatible result kind %s; the query doesn't return any columns; "+
 				"use :exec if query shouldn't return any columns",
 			query.Name, query.ResultKind)
 	}
-	if query.ResultKind != ast.ResultKindExec && countVoids(outputs) == len(outputs) {
+	if !isExec && query.ResultKind != ast.ResultKindCopyFrom && countVoids(outputs) == len(outputs) {
 		return TypedQuery{}, fmt.Errorf(
 			"query %s has incompatible result kind %s; the query only has void columns; "+
 				"use :exec if query shouldn't return any columns",
@@ -100,6 +134,8 @@ func (inf *Inferrer) InferTypes(query *ast.SourceQuery) (TypedQuery, error) {
 		Inputs:       inputs,
 		Outputs:      outputs,
 		ProtobufType: query.Pragmas.ProtobufType,
+		CopyFrom:     copyFrom,
+		ExpectRows:   query.Pragmas.ExpectRows,
 	}, nil
 }
 
@@ -139,7 +175,11 @@ func (inf *Inferrer) prepareTypes(query *ast.SourceQuery) (_a []InputParam, _ []
 				msg += "\n          a RETURNING clause (this query is marked " + string(query.ResultKind) + ")."
 				msg += "\n          Use :exec if you don't need the query output."
 			}
-			return nil, nil, fmt.Errorf(msg+"\n    %w", pgErr)
+			return nil, nil, &PrepareError{
+				Pos: sourcePos(query, pgErr),
+				Err: pgErr,
+				msg: msg + "\n    " + pgErr.Error(),
+			}
 		}
 		return nil, nil, fmt.Errorf("prepare query to infer types: %w", err)
 	}
@@ -162,8 +202,9 @@ func (inf *Inferrer) prepareTypes(query *ast.SourceQuery) (_a []InputParam, _ []
 				return nil, nil, fmt.Errorf("no postgres type name found for parameter %s with oid %d", query.ParamNames[i], oid)
 			}
 			inputParams = append(inputParams, InputParam{
-				PgName: query.ParamNames[i],
-				PgType: inputType,
+				PgName:   query.ParamNames[i],
+				PgType:   inputType,
+				Nullable: i < len(query.ParamNulls) && query.ParamNulls[i],
 			})
 		}
 	}
@@ -183,6 +224,9 @@ func (inf *Inferrer) prepareTypes(query *ast.SourceQuery) (_a []InputParam, _ []
 	if err != nil {
 		return nil, nil, fmt.Errorf("infer output type nullability: %w", err)
 	}
+	if err := applyNullabilityPragmas(query, stmtDesc.Fields, nullables); err != nil {
+		return nil, nil, err
+	}
 
 	// Create output columns
 	var outputColumns []OutputColumn
@@ -200,13 +244,80 @@ func (inf *Inferrer) prepareTypes(query *ast.SourceQuery) (_a []InputParam, _ []
 	return inputParams, outputColumns, nil
 }
 
+// applyNullabilityPragmas overrides the inferred nullability of the output
+// columns named in the not-null and nullable pragmas. Returns an error if a
+// pragma names a column the query doesn't return.
+func applyNullabilityPragmas(query *ast.SourceQuery, descs []pgproto3.FieldDescription, nullables []bool) error {
+	apply := func(pragma string, cols []string, nullable bool) error {
+		for _, col := range cols {
+			found := false
+			for i, desc := range descs {
+				if string(desc.Name) == col {
+					nullables[i] = nullable
+					found = true
+				}
+			}
+			if !found {
+				return fmt.Errorf("%s pragma names column %q but query %s has no output column with that name", pragma, col, query.Name)
+			}
+		}
+		return nil
+	}
+	if err := apply("not-null", query.Pragmas.NotNull, false); err != nil {
+		return err
+	}
+	return apply("nullable", query.Pragmas.Nullable, true)
+}
+
+// PrepareError is an error from Postgres preparing a query to infer the
+// types, like a syntax error or an unknown column.
+type PrepareError struct {
+	// The position of the error in the query file, or gotok.NoPos if Postgres
+	// didn't report a position.
+	Pos gotok.Pos
+	Err *pgconn.PgError
+	msg string // Err with the error fields and hints from pggen
+}
+
+func (e *PrepareError) Error() string { return e.msg }
+
+func (e *PrepareError) Unwrap() error { return e.Err }
+
+// sourcePos converts the position of a Postgres error, a 1-based character
+// index in the prepared SQL, to the position in the query file.
+func sourcePos(query *ast.SourceQuery, pgErr *pgconn.PgError) gotok.Pos {
+	if pgErr.Position <= 0 {
+		return gotok.NoPos
+	}
+	offset := 0
+	for i := int32(1); i < pgErr.Position && offset < len(query.PreparedSQL); i++ {
+		_, size := utf8.DecodeRuneInString(query.PreparedSQL[offset:])
+		offset += size
+	}
+	return query.Start + gotok.Pos(query.SourceOffset(offset))
+}
+
 // inferOutputNullability infers which of the output columns produced by the
 // query and described by descs can be null.
 func (inf *Inferrer) inferOutputNullability(query *ast.SourceQuery, descs []pgproto3.FieldDescription) ([]bool, error) {
+	return inf.inferNullability(query.PreparedSQL, len(query.ParamNames), query.ParamNulls, descs)
+}
+
+// inferNullability infers which of the output columns produced by sql with
+// nParams params and described by descs can be null. Analyzes the query plan
+// to find which relation each output comes from, like the nullable side of an
+// outer join. paramNulls is if each param is nullable; a missing entry means
+// the param is nullable.
+func (inf *Inferrer) inferNullability(sql string, nParams int, paramNulls []bool, descs []pgproto3.FieldDescription) ([]bool, error) {
 	if len(descs) == 0 {
 		return nil, nil
 	}
-	plan, err := inf.explainQuery(query)
+	plan, err := pgplan.ExplainQuery(inf.conn, sql, nParams)
+	if err != nil {
+		return nil, fmt.Errorf("explain prepared query: %w", err)
+	}
+	analysis := newNullAnalysis(connCatalog{conn: inf.conn}, inf.notNullFuncs, paramNulls)
+	outNulls, err := analysis.analyze(plan)
 	if err != nil {
 		return nil, err
 	}
@@ -226,29 +337,61 @@ func (inf *Inferrer) inferOutputNullability(query *ast.SourceQuery, descs []pgpr
 	}
 
 	// The nth entry determines if the output column described by descs[n] is
-	// nullable. plan.Outputs might contain more entries than cols because the
-	// plan output also contains information like sort columns.
+	// nullable. The plan outputs might contain more entries than descs because
+	// the plan output also contains information like sort columns.
 	nullables := make([]bool, len(descs))
 	for i := range nullables {
-		nullables[i] = true // assume nullable until proven otherwise
-	}
-	for i, col := range cols {
-		if i == len(plan.Outputs) {
-			// plan.Outputs might not have the same output because the top level node
-			// joins child outputs like with append.
-			break
+		if i >= len(outNulls) {
+			nullables[i] = true // assume nullable until proven otherwise
+			continue
+		}
+		nullables[i] = outNulls[i].null
+		// Postgres describes the table or view column of an output that's a
+		// plain column reference, even through subqueries and CTEs. The output
+		// is not null if the column is not null and no outer join nulls it.
+		if !nullables[i] || cols[i].TableOID == 0 || outNulls[i].nulled {
+			continue
+		}
+		if !cols[i].Null {
+			nullables[i] = false
+			continue
+		}
+		viewNulls, err := inf.inferViewNullability(cols[i].TableOID)
+		if err != nil {
+			return nil, err
+		}
+		if n := int(cols[i].Number) - 1; n < len(viewNulls) && !viewNulls[n] {
+			nullables[i] = false
 		}
-		nullables[i] = isColNullable(query, plan, plan.Outputs[i], col)
 	}
 	return nullables, nil
 }
 
-func createParamArgs(query *ast.SourceQuery) []interface{} {
-	args := make([]interface{}, len(query.ParamNames))
-	for i := range query.ParamNames {
-		args[i] = nil
+// inferViewNullability infers which columns of the view with the OID can be
+// null. The columns of a view never have a not-null constraint, so infers the
+// nullability of the view query instead. Returns nil if the relation isn't a
+// view.
+func (inf *Inferrer) inferViewNullability(oid pgtype.OID) ([]bool, error) {
+	if nulls, ok := inf.views[oid]; ok {
+		return nulls, nil
+	}
+	inf.views[oid] = nil // a view can't reference itself but don't loop forever
+	def, ok, err := pg.FetchViewDefinition(inf.conn, oid)
+	if err != nil || !ok {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	stmtDesc, err := inf.conn.PgConn().Prepare(ctx, "", def, nil)
+	if err != nil {
+		return nil, fmt.Errorf("prepare definition of view oid=%d: %w", oid, err)
+	}
+	nulls, err := inf.inferNullability(def, 0, nil, stmtDesc.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("infer nullability of view oid=%d: %w", oid, err)
 	}
-	return args
+	inf.views[oid] = nulls
+	return nulls, nil
 }
 
 func extractDoc(query *ast.SourceQuery) []string {
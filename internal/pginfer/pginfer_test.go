@@ -5,13 +5,17 @@ import (
 	"errors"
 	"github.com/atomicleads/pggen/internal/ast"
 	"github.com/atomicleads/pggen/internal/difftest"
+	"github.com/atomicleads/pggen/internal/parser"
 	"github.com/atomicleads/pggen/internal/pg"
 	"github.com/atomicleads/pggen/internal/pgtest"
 	"github.com/atomicleads/pggen/internal/texts"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgproto3/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	gotok "go/token"
 	"testing"
 )
 
@@ -24,6 +28,17 @@ func TestInferrer_InferTypes(t *testing.T) {
 			suffix text NULL
 		);
 
+		CREATE VIEW author_names AS
+			SELECT DISTINCT first_name, suffix FROM author;
+
+		CREATE VIEW author_pairs AS
+			SELECT a1.first_name, a2.first_name AS next_name
+			FROM author a1
+				LEFT JOIN author a2 ON a2.author_id = a1.author_id + 1;
+
+		CREATE VIEW author_pair_names AS
+			SELECT first_name, next_name FROM author_pairs LIMIT 10;
+
 		CREATE TYPE device_type AS enum (
 			'phone',
 			'laptop'
@@ -38,6 +53,7 @@ func TestInferrer_InferTypes(t *testing.T) {
 	deviceTypeArrOID, err := q.FindOIDByName(context.Background(), "_device_type")
 	require.NoError(t, err)
 
+	one := int64(1)
 	tests := []struct {
 		name  string
 		query *ast.SourceQuery
@@ -72,7 +88,7 @@ func TestInferrer_InferTypes(t *testing.T) {
 				ResultKind:  ast.ResultKindMany,
 				PreparedSQL: "SELECT 1 AS num UNION SELECT 2 AS num",
 				Outputs: []OutputColumn{
-					{PgName: "num", PgType: pg.Int4, Nullable: true},
+					{PgName: "num", PgType: pg.Int4, Nullable: false},
 				},
 			},
 		},
@@ -170,11 +186,125 @@ func TestInferrer_InferTypes(t *testing.T) {
 				Inputs: []InputParam{
 					{PgName: "FirstName", PgType: pg.Text},
 				},
+				Outputs: []OutputColumn{
+					{PgName: "first_name", PgType: pg.Text, Nullable: false},
+				},
+			},
+		},
+		{
+			name: "left join",
+			query: &ast.SourceQuery{
+				Name: "LeftJoin",
+				PreparedSQL: texts.Dedent(`
+					SELECT a1.first_name, a2.first_name AS other_name, count(*) AS num,
+						COALESCE(a2.last_name, '') AS last_name
+					FROM author a1
+						LEFT JOIN author a2 ON a2.author_id = a1.author_id + 1
+					GROUP BY a1.first_name, a2.first_name, a2.last_name;
+				`),
+				ResultKind: ast.ResultKindMany,
+			},
+			want: TypedQuery{
+				Name:       "LeftJoin",
+				ResultKind: ast.ResultKindMany,
+				PreparedSQL: texts.Dedent(`
+					SELECT a1.first_name, a2.first_name AS other_name, count(*) AS num,
+						COALESCE(a2.last_name, '') AS last_name
+					FROM author a1
+						LEFT JOIN author a2 ON a2.author_id = a1.author_id + 1
+					GROUP BY a1.first_name, a2.first_name, a2.last_name;
+				`),
+				Outputs: []OutputColumn{
+					{PgName: "first_name", PgType: pg.Text, Nullable: false},
+					{PgName: "other_name", PgType: pg.Text, Nullable: true},
+					{PgName: "num", PgType: pg.Int8, Nullable: false},
+					{PgName: "last_name", PgType: pg.Text, Nullable: false},
+				},
+			},
+		},
+		{
+			name: "strict function",
+			query: &ast.SourceQuery{
+				Name:        "StrictFunc",
+				PreparedSQL: "SELECT lower(first_name) AS first, lower(suffix) AS suffix FROM author;",
+				ResultKind:  ast.ResultKindMany,
+			},
+			want: TypedQuery{
+				Name:        "StrictFunc",
+				ResultKind:  ast.ResultKindMany,
+				PreparedSQL: "SELECT lower(first_name) AS first, lower(suffix) AS suffix FROM author;",
+				Outputs: []OutputColumn{
+					{PgName: "first", PgType: pg.Text, Nullable: false},
+					{PgName: "suffix", PgType: pg.Text, Nullable: true},
+				},
+			},
+		},
+		{
+			name: "view",
+			query: &ast.SourceQuery{
+				Name:        "View",
+				PreparedSQL: "SELECT first_name, suffix FROM author_names;",
+				ResultKind:  ast.ResultKindMany,
+			},
+			want: TypedQuery{
+				Name:        "View",
+				ResultKind:  ast.ResultKindMany,
+				PreparedSQL: "SELECT first_name, suffix FROM author_names;",
+				Outputs: []OutputColumn{
+					{PgName: "first_name", PgType: pg.Text, Nullable: false},
+					{PgName: "suffix", PgType: pg.Text, Nullable: true},
+				},
+			},
+		},
+		{
+			name: "view of view with left join",
+			query: &ast.SourceQuery{
+				Name:        "ViewOfView",
+				PreparedSQL: "SELECT p.first_name, p.next_name FROM author_pair_names p;",
+				ResultKind:  ast.ResultKindMany,
+			},
+			want: TypedQuery{
+				Name:        "ViewOfView",
+				ResultKind:  ast.ResultKindMany,
+				PreparedSQL: "SELECT p.first_name, p.next_name FROM author_pair_names p;",
+				Outputs: []OutputColumn{
+					{PgName: "first_name", PgType: pg.Text, Nullable: false},
+					{PgName: "next_name", PgType: pg.Text, Nullable: true},
+				},
+			},
+		},
+		{
+			name: "view on nullable side of left join",
+			query: &ast.SourceQuery{
+				Name:        "LeftJoinView",
+				PreparedSQL: "SELECT n.first_name FROM author a LEFT JOIN author_names n ON n.first_name = a.last_name;",
+				ResultKind:  ast.ResultKindMany,
+			},
+			want: TypedQuery{
+				Name:        "LeftJoinView",
+				ResultKind:  ast.ResultKindMany,
+				PreparedSQL: "SELECT n.first_name FROM author a LEFT JOIN author_names n ON n.first_name = a.last_name;",
 				Outputs: []OutputColumn{
 					{PgName: "first_name", PgType: pg.Text, Nullable: true},
 				},
 			},
 		},
+		{
+			name: "subquery",
+			query: &ast.SourceQuery{
+				Name:        "Subquery",
+				PreparedSQL: "SELECT s.last_name FROM (SELECT last_name, suffix FROM author OFFSET 0) s;",
+				ResultKind:  ast.ResultKindMany,
+			},
+			want: TypedQuery{
+				Name:        "Subquery",
+				ResultKind:  ast.ResultKindMany,
+				PreparedSQL: "SELECT s.last_name FROM (SELECT last_name, suffix FROM author OFFSET 0) s;",
+				Outputs: []OutputColumn{
+					{PgName: "last_name", PgType: pg.Text, Nullable: false},
+				},
+			},
+		},
 		{
 			name: "delete by author ID",
 			query: &ast.SourceQuery{
@@ -195,6 +325,94 @@ func TestInferrer_InferTypes(t *testing.T) {
 				Outputs: nil,
 			},
 		},
+		{
+			name: "nullable param",
+			query: &ast.SourceQuery{
+				Name:        "FindBySuffix",
+				PreparedSQL: "SELECT author_id FROM author WHERE ($1::text IS NULL OR suffix = $1) AND first_name = $2;",
+				ParamNames:  []string{"Suffix", "FirstName"},
+				ParamNulls:  []bool{true, false},
+				ResultKind:  ast.ResultKindMany,
+			},
+			want: TypedQuery{
+				Name:        "FindBySuffix",
+				ResultKind:  ast.ResultKindMany,
+				PreparedSQL: "SELECT author_id FROM author WHERE ($1::text IS NULL OR suffix = $1) AND first_name = $2;",
+				Inputs: []InputParam{
+					{PgName: "Suffix", PgType: pg.Text, Nullable: true},
+					{PgName: "FirstName", PgType: pg.Text, Nullable: false},
+				},
+				Outputs: []OutputColumn{
+					{PgName: "author_id", PgType: pg.Int4, Nullable: false},
+				},
+			},
+		},
+		{
+			name: "param outputs",
+			query: &ast.SourceQuery{
+				Name:        "ParamOutputs",
+				PreparedSQL: "SELECT $1::text AS arg, $2::text AS narg;",
+				ParamNames:  []string{"Arg", "Narg"},
+				ParamNulls:  []bool{false, true},
+				ResultKind:  ast.ResultKindOne,
+			},
+			want: TypedQuery{
+				Name:        "ParamOutputs",
+				ResultKind:  ast.ResultKindOne,
+				PreparedSQL: "SELECT $1::text AS arg, $2::text AS narg;",
+				Inputs: []InputParam{
+					{PgName: "Arg", PgType: pg.Text},
+					{PgName: "Narg", PgType: pg.Text, Nullable: true},
+				},
+				Outputs: []OutputColumn{
+					{PgName: "arg", PgType: pg.Text, Nullable: false},
+					{PgName: "narg", PgType: pg.Text, Nullable: true},
+				},
+			},
+		},
+		{
+			name: "delete by author ID expect one row",
+			query: &ast.SourceQuery{
+				Name:        "DeleteAuthorByIDRows",
+				PreparedSQL: "DELETE FROM author WHERE author_id = $1;",
+				ParamNames:  []string{"AuthorID"},
+				ResultKind:  ast.ResultKindExecRows,
+				Pragmas:     ast.Pragmas{ExpectRows: &one},
+			},
+			want: TypedQuery{
+				Name:        "DeleteAuthorByIDRows",
+				ResultKind:  ast.ResultKindExecRows,
+				PreparedSQL: "DELETE FROM author WHERE author_id = $1;",
+				Inputs: []InputParam{
+					{PgName: "AuthorID", PgType: pg.Int4},
+				},
+				Outputs:    nil,
+				ExpectRows: &one,
+			},
+		},
+		{
+			name: "copy authors",
+			query: &ast.SourceQuery{
+				Name:        "CopyAuthors",
+				PreparedSQL: "INSERT INTO author (first_name, last_name) VALUES ($1, $2);",
+				ParamNames:  []string{"FirstName", "LastName"},
+				ResultKind:  ast.ResultKindCopyFrom,
+			},
+			want: TypedQuery{
+				Name:        "CopyAuthors",
+				ResultKind:  ast.ResultKindCopyFrom,
+				PreparedSQL: "INSERT INTO author (first_name, last_name) VALUES ($1, $2);",
+				Inputs: []InputParam{
+					{PgName: "FirstName", PgType: pg.Text},
+					{PgName: "LastName", PgType: pg.Text},
+				},
+				Outputs: nil,
+				CopyFrom: CopyFromTarget{
+					Table:   []string{"author"},
+					Columns: []string{"first_name", "last_name"},
+				},
+			},
+		},
 		{
 			name: "delete by author id returning",
 			query: &ast.SourceQuery{
@@ -315,16 +533,40 @@ func TestInferrer_InferTypes(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "pragma nullability overrides",
+			query: &ast.SourceQuery{
+				Name:        "PragmaNullability",
+				PreparedSQL: "SELECT first_name, suffix FROM author WHERE last_name = $1;",
+				ParamNames:  []string{"LastName"},
+				ParamNulls:  []bool{true},
+				ResultKind:  ast.ResultKindMany,
+				Pragmas:     ast.Pragmas{NotNull: []string{"suffix"}, Nullable: []string{"first_name"}},
+			},
+			want: TypedQuery{
+				Name:        "PragmaNullability",
+				ResultKind:  ast.ResultKindMany,
+				PreparedSQL: "SELECT first_name, suffix FROM author WHERE last_name = $1;",
+				Inputs: []InputParam{
+					{PgName: "LastName", PgType: pg.Text, Nullable: true},
+				},
+				Outputs: []OutputColumn{
+					{PgName: "first_name", PgType: pg.Text, Nullable: true},
+					{PgName: "suffix", PgType: pg.Text, Nullable: false},
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			inferrer := NewInferrer(conn)
+			inferrer := NewInferrer(conn, nil)
 			got, err := inferrer.InferTypes(tt.query)
 			if err != nil {
 				t.Fatal(err)
 			}
 			opts := cmp.Options{
-				cmpopts.IgnoreFields(pg.EnumType{}, "ChildOIDs"),
+				cmpopts.IgnoreFields(pg.EnumType{}, "ChildOIDs", "QualifiedName"),
+				cmpopts.IgnoreFields(pg.ArrayType{}, "QualifiedName"),
 			}
 			difftest.AssertSame(t, tt.want, got, opts)
 		})
@@ -376,10 +618,22 @@ func TestInferrer_InferTypes_Error(t *testing.T) {
 					"the query only has void columns; " +
 					"use :exec if query shouldn't return any columns"),
 		},
+		{
+			&ast.SourceQuery{
+				Name:        "CopyAuthorsReturning",
+				PreparedSQL: "INSERT INTO author (first_name, last_name) VALUES ($1, $2) RETURNING author_id;",
+				ParamNames:  []string{"FirstName", "LastName"},
+				ResultKind:  ast.ResultKindCopyFrom,
+			},
+			errors.New(
+				"query CopyAuthorsReturning has incompatible result kind :copyfrom; " +
+					"the query returns columns; " +
+					"remove the RETURNING clause because COPY can't return rows"),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.query.Name, func(t *testing.T) {
-			inferrer := NewInferrer(conn)
+			inferrer := NewInferrer(conn, nil)
 			got, err := inferrer.InferTypes(tt.query)
 			assert.Equal(t, TypedQuery{}, got, "InferTypes should error and return empty TypedQuery struct")
 			assert.Equal(t, tt.want, err, "InferType error should match")
@@ -387,6 +641,66 @@ func TestInferrer_InferTypes_Error(t *testing.T) {
 	}
 }
 
+func TestApplyNullabilityPragmas(t *testing.T) {
+	descs := []pgproto3.FieldDescription{{Name: []byte("foo")}, {Name: []byte("bar")}}
+	tests := []struct {
+		name    string
+		pragmas ast.Pragmas
+		want    []bool
+		wantErr string
+	}{
+		{"none", ast.Pragmas{}, []bool{true, false}, ""},
+		{"not-null", ast.Pragmas{NotNull: []string{"foo"}}, []bool{false, false}, ""},
+		{"nullable", ast.Pragmas{Nullable: []string{"bar"}}, []bool{true, true}, ""},
+		{"both", ast.Pragmas{NotNull: []string{"foo"}, Nullable: []string{"bar"}}, []bool{false, true}, ""},
+		{"unknown column", ast.Pragmas{NotNull: []string{"qux"}}, nil,
+			`not-null pragma names column "qux" but query Foo has no output column with that name`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query := &ast.SourceQuery{Name: "Foo", Pragmas: tt.pragmas}
+			nullables := []bool{true, false}
+			err := applyNullabilityPragmas(query, descs, nullables)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("applyNullabilityPragmas() error = %v; want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tt.want, nullables)
+		})
+	}
+}
+
+func TestSourcePos(t *testing.T) {
+	src := "-- name: Foo :one\nSELECT 'é', pggen.arg('a'), nope;"
+	fset := gotok.NewFileSet()
+	f, err := parser.ParseFile(fset, "query.sql", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := f.Queries[0].(*ast.SourceQuery)
+	tests := []struct {
+		name     string
+		position int32
+		want     string
+	}{
+		{"no position", 0, "-"},
+		{"start", 1, "query.sql:2:1"},
+		{"param", 13, "query.sql:2:14"},
+		{"after multibyte char and param", 17, "query.sql:2:30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := sourcePos(query, &pgconn.PgError{Position: tt.position})
+			assert.Equal(t, tt.want, fset.Position(pos).String())
+		})
+	}
+}
+
 func newCommentGroup(lines ...string) *ast.CommentGroup {
 	cs := make([]*ast.LineComment, len(lines))
 	for i, line := range lines {
@@ -0,0 +1,246 @@
+package pgload
+
+import (
+	"errors"
+	"fmt"
+	"github.com/atomicleads/pggen/internal/texts"
+	"github.com/jackc/pgconn"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// StatementError is an error from running a statement of a schema file,
+// located in the file.
+type StatementError struct {
+	File string // path of the schema file
+	// 1-based line of the error in File. The line of the start of the statement
+	// if Postgres didn't report a position.
+	Line int
+	Col  int // 1-based byte column of the error in File, or 0 if unknown
+	// The failed statement that starts at StatementLine and StatementCol in
+	// File.
+	Statement     string
+	StatementLine int
+	StatementCol  int
+	Detail        string // detail from Postgres, if any
+	Hint          string // hint from Postgres, if any
+	Err           error  // the error from Postgres
+}
+
+func (e *StatementError) Error() string {
+	sb := &strings.Builder{}
+	sb.WriteString(e.File)
+	sb.WriteString(":")
+	sb.WriteString(strconv.Itoa(e.Line))
+	if e.Col > 0 {
+		sb.WriteString(":")
+		sb.WriteString(strconv.Itoa(e.Col))
+	}
+	sb.WriteString(": ")
+	sb.WriteString(e.Err.Error())
+	sb.WriteString("\n")
+	sb.WriteString(e.snippet())
+	if e.Detail != "" {
+		sb.WriteString("DETAIL: ")
+		sb.WriteString(e.Detail)
+		sb.WriteString("\n")
+	}
+	if e.Hint != "" {
+		sb.WriteString("HINT: ")
+		sb.WriteString(e.Hint)
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+func (e *StatementError) Unwrap() error { return e.Err }
+
+// snippetContext is the number of statement lines to show before and after
+// the line of the error.
+const snippetContext = 3
+
+// snippet returns the lines of the statement around the error with the line
+// numbers of the file and a caret under the column of the error.
+func (e *StatementError) snippet() string {
+	lines := strings.Split(e.Statement, "\n")
+	if len(lines) > 0 && e.StatementCol > 1 {
+		// Indent the first line to its column in the file so the caret lines up.
+		lines[0] = strings.Repeat(" ", e.StatementCol-1) + lines[0]
+	}
+	errIdx := e.Line - e.StatementLine
+	first, last := max(0, errIdx-snippetContext), min(len(lines)-1, errIdx+snippetContext)
+	width := len(strconv.Itoa(e.StatementLine + last))
+	sb := &strings.Builder{}
+	if first > 0 {
+		fmt.Fprintf(sb, "%*s | ...\n", width, "")
+	}
+	for i := first; i <= last; i++ {
+		fmt.Fprintf(sb, "%*d | %s\n", width, e.StatementLine+i, lines[i])
+		if i == errIdx && e.Col > 0 {
+			fmt.Fprintf(sb, "%*s | %s^\n", width, "", texts.CaretIndent(lines[i], e.Col))
+		}
+	}
+	if last < len(lines)-1 {
+		fmt.Fprintf(sb, "%*s | ...\n", width, "")
+	}
+	return sb.String()
+}
+
+// newStatementError locates the error from running the statement it in the
+// schema file. Uses the position of a Postgres error to find the line and
+// column.
+func newStatementError(file string, it item, err error) *StatementError {
+	stmtErr := &StatementError{
+		File:          file,
+		Line:          it.line,
+		Statement:     it.text,
+		StatementLine: it.line,
+		StatementCol:  it.col,
+		Err:           err,
+	}
+	pgErr := &pgconn.PgError{}
+	if !errors.As(err, &pgErr) {
+		return stmtErr
+	}
+	stmtErr.Detail = pgErr.Detail
+	stmtErr.Hint = pgErr.Hint
+	if pgErr.Position > 0 {
+		stmtErr.Line, stmtErr.Col = positionInFile(it, int(pgErr.Position))
+	}
+	return stmtErr
+}
+
+// positionInFile converts a 1-based character position in the statement to a
+// line and byte column in the file.
+func positionInFile(it item, pos int) (line, col int) {
+	line, col = it.line, it.col
+	text := it.text
+	for i := 1; i < pos && len(text) > 0; i++ {
+		r, size := utf8.DecodeRuneInString(text)
+		text = text[size:]
+		if r == '\n' {
+			line++
+			col = 1
+		} else {
+			col += size
+		}
+	}
+	return line, col
+}
+
+var (
+	psqlErrorRegexp = regexp.MustCompile(`(?m)^psql:(.+?):(\d+): ERROR:  (.*)$`)
+	psqlLineRegexp  = regexp.MustCompile(`^LINE (\d+): (.*)$`)
+)
+
+// ParsePsqlError finds the first error that psql reported for a schema file
+// in output, like the logs of the Postgres Docker image, and locates it in
+// the schema file. scripts maps the file name that psql ran, like
+// /docker-entrypoint-initdb.d/001_schema.sql, to the path of the schema file.
+// Returns false if output has no psql error for a *.sql file in scripts.
+func ParsePsqlError(output string, scripts map[string]string) (*StatementError, bool) {
+	m := psqlErrorRegexp.FindStringSubmatchIndex(output)
+	if m == nil {
+		return nil, false
+	}
+	psqlFile := output[m[2]:m[3]]
+	file, ok := scripts[psqlFile]
+	if !ok || !strings.HasSuffix(file, ".sql") {
+		return nil, false
+	}
+	psqlLine, _ := strconv.Atoi(output[m[4]:m[5]])
+	stmts := findStatements(file, psqlLine)
+	if len(stmts) == 0 {
+		return nil, false
+	}
+	stmtErr := &StatementError{
+		File: file,
+		Err:  errors.New("ERROR: " + output[m[6]:m[7]]),
+	}
+	setStatement := func(stmt item) {
+		stmtErr.Line = stmt.line
+		stmtErr.Statement = stmt.text
+		stmtErr.StatementLine = stmt.line
+		stmtErr.StatementCol = stmt.col
+	}
+	setStatement(stmts[0])
+
+	// psql prints the line of the statement with the error and a caret under
+	// the position, followed by the detail and hint, like:
+	//
+	//   LINE 2:   foo text
+	//                 ^
+	//   HINT:  Perhaps you meant ...
+	lines := strings.Split(output[m[1]:], "\n")
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimRight(lines[i], "\r")
+		if lm := psqlLineRegexp.FindStringSubmatch(line); lm != nil && i+1 < len(lines) {
+			stmtLine, _ := strconv.Atoi(lm[1])
+			// Many statements can end on the line that psql reports. Use the
+			// statement with the line that psql printed.
+			for _, stmt := range stmts {
+				if stmtLines := strings.Split(stmt.text, "\n"); stmtLine <= len(stmtLines) && stmtLines[stmtLine-1] == lm[2] {
+					setStatement(stmt)
+					break
+				}
+			}
+			stmtErr.Line = stmtErr.StatementLine + stmtLine - 1
+			prefixLen := len(line) - len(lm[2])
+			caret := strings.IndexByte(lines[i+1], '^')
+			// psql elides the start of long lines with "...".
+			if caret >= prefixLen && !strings.HasPrefix(lm[2], "...") {
+				// The caret is under a character; convert to a byte column.
+				runes := []rune(lm[2])
+				stmtErr.Col = len(string(runes[:min(caret-prefixLen, len(runes))])) + 1
+				if stmtLine == 1 {
+					stmtErr.Col += stmtErr.StatementCol - 1
+				}
+			}
+			i++
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "DETAIL:  "):
+			stmtErr.Detail = strings.TrimPrefix(line, "DETAIL:  ")
+		case strings.HasPrefix(line, "HINT:  "):
+			stmtErr.Hint = strings.TrimPrefix(line, "HINT:  ")
+		default:
+			return stmtErr, true
+		}
+	}
+	return stmtErr, true
+}
+
+// findStatements returns the statements in file that end on line, the line
+// psql reports for an error. Returns the last statement that starts before
+// line if no statement ends on line.
+func findStatements(file string, line int) []item {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	s := newScanner(f, nil)
+	var last item
+	var stmts []item
+	for {
+		it, err := s.next()
+		if err != nil || it.line > line {
+			break
+		}
+		if it.kind == itemMeta {
+			continue
+		}
+		last = it
+		if it.endLine == line {
+			stmts = append(stmts, it)
+		}
+	}
+	if len(stmts) == 0 && last.text != "" {
+		stmts = append(stmts, last)
+	}
+	return stmts
+}
@@ -0,0 +1,183 @@
+package pgload
+
+import (
+	"errors"
+	"github.com/atomicleads/pggen/internal/texts"
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStatementError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		it   item
+		err  error
+		want string
+	}{
+		{
+			name: "position",
+			it:   item{line: 3, col: 1, text: "CREATE TABLE bar (\n  id int,\n  name txt\n);"},
+			err: &pgconn.PgError{
+				Severity: "ERROR",
+				Code:     "42704",
+				Message:  `type "txt" does not exist`,
+				Hint:     "Check the type name.",
+				Position: 37,
+			},
+			want: texts.Dedent(`
+				schema.sql:5:8: ERROR: type "txt" does not exist (SQLSTATE 42704)
+				3 | CREATE TABLE bar (
+				4 |   id int,
+				5 |   name txt
+				  |        ^
+				6 | );
+				HINT: Check the type name.
+			`),
+		},
+		{
+			name: "no position",
+			it:   item{line: 1, col: 4, text: "INSERT INTO foo VALUES (1);"},
+			err:  errors.New("boom"),
+			want: texts.Dedent(`
+				schema.sql:1: boom
+				1 |    INSERT INTO foo VALUES (1);
+			`),
+		},
+		{
+			name: "long statement",
+			it:   item{line: 1, col: 1, text: "SELECT\n1,\n2,\n3,\n4,\n5,\n6,\n7,\nx\nFROM foo;"},
+			err: &pgconn.PgError{
+				Severity: "ERROR",
+				Code:     "42703",
+				Message:  `column "x" does not exist`,
+				Position: 29,
+			},
+			want: texts.Dedent(`
+				schema.sql:9:1: ERROR: column "x" does not exist (SQLSTATE 42703)
+				   | ...
+				 6 | 5,
+				 7 | 6,
+				 8 | 7,
+				 9 | x
+				   | ^
+				10 | FROM foo;
+			`),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := newStatementError("schema.sql", tt.it, tt.err)
+			assert.Equal(t, tt.want, err.Error())
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestParsePsqlError(t *testing.T) {
+	dir := t.TempDir()
+	schemaFile := filepath.Join(dir, "schema.sql")
+	schema := texts.Dedent(`
+		CREATE TABLE foo (id int); INSERT INTO foo VALUES ('a');
+
+		CREATE TABLE bar (
+		  id int,
+		  name txt
+		);
+
+		CREATE TABLE café (
+		  prénom txt
+		);
+	`)
+	if err := os.WriteFile(schemaFile, []byte(schema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	scripts := map[string]string{
+		"/docker-entrypoint-initdb.d/000_schema.sql": schemaFile,
+		"/docker-entrypoint-initdb.d/001_setup.sh":   filepath.Join(dir, "setup.sh"),
+	}
+
+	tests := []struct {
+		name   string
+		output string
+		want   *StatementError
+	}{
+		{
+			name: "multiline statement",
+			output: "CREATE TABLE\n" +
+				"psql:/docker-entrypoint-initdb.d/000_schema.sql:6: ERROR:  type \"txt\" does not exist\n" +
+				"LINE 3:   name txt\n" +
+				"               ^\n" +
+				"HINT:  Check the type name.\n",
+			want: &StatementError{
+				File:          schemaFile,
+				Line:          5,
+				Col:           8,
+				Statement:     "CREATE TABLE bar (\n  id int,\n  name txt\n);",
+				StatementLine: 3,
+				StatementCol:  1,
+				Hint:          "Check the type name.",
+				Err:           errors.New(`ERROR: type "txt" does not exist`),
+			},
+		},
+		{
+			name: "second statement on line",
+			output: "psql:/docker-entrypoint-initdb.d/000_schema.sql:1: ERROR:  invalid input syntax for type integer: \"a\"\n" +
+				"LINE 1: INSERT INTO foo VALUES ('a');\n" +
+				"                                ^\n" +
+				"\n" +
+				"PostgreSQL init process complete; ready for start up.\n",
+			want: &StatementError{
+				File:          schemaFile,
+				Line:          1,
+				Col:           52,
+				Statement:     "INSERT INTO foo VALUES ('a');",
+				StatementLine: 1,
+				StatementCol:  28,
+				Err:           errors.New(`ERROR: invalid input syntax for type integer: "a"`),
+			},
+		},
+		{
+			name: "multi-byte runes before caret",
+			output: "psql:/docker-entrypoint-initdb.d/000_schema.sql:10: ERROR:  type \"txt\" does not exist\n" +
+				"LINE 2:   prénom txt\n" +
+				"                 ^\n",
+			want: &StatementError{
+				File:          schemaFile,
+				Line:          9,
+				Col:           11,
+				Statement:     "CREATE TABLE café (\n  prénom txt\n);",
+				StatementLine: 8,
+				StatementCol:  1,
+				Err:           errors.New(`ERROR: type "txt" does not exist`),
+			},
+		},
+		{
+			name:   "unknown file",
+			output: "psql:<stdin>:6: ERROR:  syntax error at or near \"tabel\"\n",
+		},
+		{
+			name:   "shell script",
+			output: "psql:/docker-entrypoint-initdb.d/001_setup.sh:1: ERROR:  syntax error\n",
+		},
+		{
+			name:   "no error",
+			output: "CREATE TABLE\nINSERT 0 1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParsePsqlError(tt.output, scripts)
+			if tt.want == nil {
+				assert.False(t, ok, "want no error found; got %v", got)
+				return
+			}
+			if !assert.True(t, ok, "want error found") {
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,291 @@
+// Package pgload loads schema files into an existing Postgres database like
+// the official Postgres Docker image loads init scripts with psql.
+package pgload
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/atomicleads/pggen/internal/errs"
+	"github.com/jackc/pgx/v4"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// LoadFiles runs each schema file on conn in order. Supports the same files
+// as the Docker entrypoint:
+//
+//   - *.sql files run like psql, one statement at a time, including COPY FROM
+//     STDIN blocks and the common psql meta-commands, like \i, \set, and
+//     \connect.
+//   - *.sql.gz files run like *.sql files after decompressing.
+//   - *.sh files run with the PG* environment variables set to connect to the
+//     database of conn. Executable files run directly and other files run with
+//     bash.
+//
+// Like psql with ON_ERROR_STOP, LoadFiles stops at the first error. Each file
+// starts with no psql variables and on the database of conn. Since psql runs
+// each file in a new session, LoadFiles restores the search_path of conn
+// after each file, like after the set_config call in pg_dump output.
+func LoadFiles(ctx context.Context, conn *pgx.Conn, files []string) error {
+	for _, file := range files {
+		if err := loadFile(ctx, conn, file); err != nil {
+			// A statement error already includes the file.
+			if stmtErr := (*StatementError)(nil); errors.As(err, &stmtErr) {
+				return err
+			}
+			return fmt.Errorf("load schema file %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// loader runs the statements and meta-commands of a single schema file.
+type loader struct {
+	cur     *pgx.Conn           // connection that statements run on, changed by \connect
+	opened  []*pgx.Conn         // connections opened by \connect
+	vars    map[string]string   // psql variables set by \set
+	running map[string]struct{} // absolute paths of the files being run, to detect \i cycles
+}
+
+func loadFile(ctx context.Context, conn *pgx.Conn, file string) (mErr error) {
+	switch {
+	case strings.HasSuffix(file, ".sh"):
+		return runShell(ctx, conn, file)
+	case strings.HasSuffix(file, ".sql"), strings.HasSuffix(file, ".sql.gz"):
+		break // okay
+	default:
+		return fmt.Errorf("unsupported schema file; must be *.sql, *.sql.gz, or *.sh")
+	}
+
+	var searchPath string
+	if err := conn.QueryRow(ctx, "SELECT current_setting('search_path')").Scan(&searchPath); err != nil {
+		return fmt.Errorf("get search_path: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(ctx, "SELECT set_config('search_path', $1, false)", searchPath); err != nil {
+			mErr = errors.Join(mErr, fmt.Errorf("restore search_path: %w", err))
+		}
+	}()
+
+	l := &loader{
+		cur:     conn,
+		vars:    make(map[string]string),
+		running: make(map[string]struct{}),
+	}
+	defer func() {
+		for _, c := range l.opened {
+			if err := c.Close(ctx); err != nil {
+				mErr = errors.Join(mErr, fmt.Errorf("close \\connect connection: %w", err))
+			}
+		}
+	}()
+	return l.runFile(ctx, file)
+}
+
+// runFile runs a *.sql or *.sql.gz file.
+func (l *loader) runFile(ctx context.Context, file string) (mErr error) {
+	absPath, err := filepath.Abs(file)
+	if err != nil {
+		return fmt.Errorf("resolve absolute path: %w", err)
+	}
+	if _, ok := l.running[absPath]; ok {
+		return fmt.Errorf("file includes itself")
+	}
+	l.running[absPath] = struct{}{}
+	defer delete(l.running, absPath)
+
+	f, err := os.Open(file)
+	if err != nil {
+		return fmt.Errorf("open file: %w", err)
+	}
+	defer errs.Capture(&mErr, f.Close, "close file")
+	var r io.Reader = f
+	if strings.HasSuffix(file, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("read gzip file: %w", err)
+		}
+		r = gz
+	}
+
+	s := newScanner(r, l.vars)
+	for {
+		it, err := s.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := l.run(ctx, file, it); err != nil {
+			if stmtErr := (*StatementError)(nil); errors.As(err, &stmtErr) {
+				return err
+			}
+			return fmt.Errorf("line %d: %w", it.line, err)
+		}
+	}
+}
+
+func (l *loader) run(ctx context.Context, file string, it item) error {
+	switch it.kind {
+	case itemStatement:
+		if _, err := l.cur.Exec(ctx, it.text); err != nil {
+			return newStatementError(file, it, err)
+		}
+		return nil
+	case itemCopy:
+		if _, err := l.cur.PgConn().CopyFrom(ctx, strings.NewReader(it.data), it.text); err != nil {
+			return newStatementError(file, it, err)
+		}
+		return nil
+	case itemMeta:
+		return l.runMeta(ctx, file, it)
+	default:
+		return fmt.Errorf("unhandled script item kind: %d", it.kind)
+	}
+}
+
+// runMeta runs a psql meta-command. Ignores meta-commands that only affect
+// the output of psql.
+func (l *loader) runMeta(ctx context.Context, file string, it item) error {
+	switch it.text {
+	case "i", "include":
+		if len(it.args) == 0 {
+			return fmt.Errorf(`\%s: missing file name`, it.text)
+		}
+		if err := l.runFile(ctx, it.args[0]); err != nil {
+			return fmt.Errorf(`\%s %s: %w`, it.text, it.args[0], err)
+		}
+		return nil
+	case "ir", "include_relative":
+		if len(it.args) == 0 {
+			return fmt.Errorf(`\%s: missing file name`, it.text)
+		}
+		path := it.args[0]
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(filepath.Dir(file), path)
+		}
+		if err := l.runFile(ctx, path); err != nil {
+			return fmt.Errorf(`\%s %s: %w`, it.text, it.args[0], err)
+		}
+		return nil
+	case "set":
+		if len(it.args) > 0 {
+			l.vars[it.args[0]] = strings.Join(it.args[1:], "")
+		}
+		return nil
+	case "unset":
+		if len(it.args) > 0 {
+			delete(l.vars, it.args[0])
+		}
+		return nil
+	case "c", "connect":
+		return l.connect(ctx, it.args)
+	case "echo", "qecho", "warn":
+		slog.DebugContext(ctx, "schema file echo", slog.String("file", file), slog.String("message", strings.Join(it.args, " ")))
+		return nil
+	case "encoding", "pset", "timing", "restrict", "unrestrict":
+		return nil
+	case ".":
+		return fmt.Errorf(`\. outside of COPY FROM STDIN data`)
+	default:
+		return fmt.Errorf(`unsupported psql meta-command \%s`, it.text)
+	}
+}
+
+// connect runs \connect [dbname [username]] by connecting to the database on
+// the same server as the original connection. A dbname or username of "-"
+// keeps the current value. The new connection keeps the search_path of the
+// current connection, which might be set by set_config instead of the
+// connection config, like with schema isolation.
+func (l *loader) connect(ctx context.Context, args []string) error {
+	var positional []string
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "-reuse-previous") {
+			positional = append(positional, arg)
+		}
+	}
+	var searchPath string
+	if err := l.cur.QueryRow(ctx, "SELECT current_setting('search_path')").Scan(&searchPath); err != nil {
+		return fmt.Errorf(`\connect: get search_path: %w`, err)
+	}
+	cfg := l.cur.Config().Copy()
+	if cfg.RuntimeParams == nil {
+		cfg.RuntimeParams = make(map[string]string)
+	}
+	cfg.RuntimeParams["search_path"] = searchPath
+	if len(positional) > 0 && positional[0] != "-" {
+		if strings.Contains(positional[0], "=") {
+			return fmt.Errorf(`\connect: connection strings are not supported; use a database name`)
+		}
+		cfg.Database = positional[0]
+	}
+	if len(positional) > 1 && positional[1] != "-" {
+		cfg.User = positional[1]
+	}
+	if len(positional) > 2 {
+		return fmt.Errorf(`\connect: host and port are not supported`)
+	}
+	conn, err := pgx.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return fmt.Errorf(`\connect to database %s: %w`, cfg.Database, err)
+	}
+	l.opened = append(l.opened, conn)
+	l.cur = conn
+	return nil
+}
+
+// runShell runs a shell script with the libpq environment variables set to
+// connect to the database of conn. Passes the search_path of conn in
+// PGOPTIONS so that the script sees the same schema as conn.
+func runShell(ctx context.Context, conn *pgx.Conn, file string) error {
+	var searchPath string
+	if err := conn.QueryRow(ctx, "SELECT current_setting('search_path')").Scan(&searchPath); err != nil {
+		return fmt.Errorf("get search_path: %w", err)
+	}
+	stat, err := os.Stat(file)
+	if err != nil {
+		return fmt.Errorf("stat shell script: %w", err)
+	}
+	var cmd *exec.Cmd
+	if stat.Mode()&0111 != 0 {
+		cmd = exec.CommandContext(ctx, file)
+	} else {
+		cmd = exec.CommandContext(ctx, "bash", file)
+	}
+	cfg := conn.Config()
+	cmd.Env = append(os.Environ(),
+		"PGHOST="+cfg.Host,
+		"PGPORT="+strconv.Itoa(int(cfg.Port)),
+		"PGUSER="+cfg.User,
+		"PGDATABASE="+cfg.Database,
+		"PGOPTIONS=-c search_path="+escapeOption(searchPath),
+		// Set by the official Postgres Docker image for init scripts.
+		"POSTGRES_USER="+cfg.User,
+		"POSTGRES_DB="+cfg.Database,
+	)
+	if cfg.Password != "" {
+		cmd.Env = append(cmd.Env, "PGPASSWORD="+cfg.Password)
+	}
+	output := &bytes.Buffer{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("run shell script: %w\n%s", err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+// escapeOption escapes backslashes and spaces in a PGOPTIONS value.
+func escapeOption(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, " ", `\ `)
+}
@@ -0,0 +1,138 @@
+package pgload
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"github.com/atomicleads/pggen/internal/pgtest"
+	"github.com/atomicleads/pggen/internal/texts"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadFiles(t *testing.T) {
+	conn, cleanupFunc := pgtest.NewPostgresSchemaString(t, "")
+	defer cleanupFunc()
+	dir := t.TempDir()
+	writeFile := func(name string, contents []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, contents, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	writeFile("author.sql", []byte("CREATE TABLE author (id int, name text);\n"))
+	schemaFile := writeFile("01_schema.sql", []byte(texts.Dedent(`
+		\set ON_ERROR_STOP on
+		\set tbl author
+		\ir author.sql
+		COPY :tbl (id, name) FROM stdin;
+		1	alice
+		\.
+		SELECT pg_catalog.set_config('search_path', '', false);
+	`)))
+	gzBuf := &bytes.Buffer{}
+	gzW := gzip.NewWriter(gzBuf)
+	if _, err := gzW.Write([]byte("INSERT INTO author VALUES (2, 'bob');\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzW.Close(); err != nil {
+		t.Fatal(err)
+	}
+	gzFile := writeFile("02_data.sql.gz", gzBuf.Bytes())
+	envFile := filepath.Join(dir, "env")
+	shFile := writeFile("03_env.sh", []byte(`echo "$PGDATABASE $PGOPTIONS" > `+envFile+"\n"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var searchPath string
+	if err := conn.QueryRow(ctx, "SHOW search_path").Scan(&searchPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFiles(ctx, conn, []string{schemaFile, gzFile, shFile}); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	rows, err := conn.Query(ctx, "SELECT name FROM author ORDER BY id")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"alice", "bob"}, names, "rows loaded by COPY and the gzip file")
+
+	env, err := os.ReadFile(envFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantEnv := conn.Config().Database + " -c search_path=" + escapeOption(searchPath)
+	assert.Equal(t, wantEnv, strings.TrimSpace(string(env)), "shell script environment")
+}
+
+func TestLoadFiles_Error(t *testing.T) {
+	conn, cleanupFunc := pgtest.NewPostgresSchemaString(t, "")
+	defer cleanupFunc()
+	schemaFile := filepath.Join(t.TempDir(), "schema.sql")
+	contents := "CREATE TABLE foo (id int);\n\nCREATE TABLE bar (\n  id int,\n  name txt\n);\n"
+	if err := os.WriteFile(schemaFile, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	err := LoadFiles(context.Background(), conn, []string{schemaFile})
+	stmtErr := (*StatementError)(nil)
+	if !errors.As(err, &stmtErr) {
+		t.Fatalf("want *StatementError; got %v", err)
+	}
+	assert.Equal(t, schemaFile, stmtErr.File)
+	assert.Equal(t, 5, stmtErr.Line, "error line")
+	assert.Equal(t, 8, stmtErr.Col, "error column")
+	assert.Equal(t, 3, stmtErr.StatementLine, "statement line")
+	assert.Contains(t, err.Error(), "schema.sql:5:8: ERROR: type \"txt\" does not exist")
+}
+
+func TestLoadFiles_ConnectKeepsSearchPath(t *testing.T) {
+	conn, cleanupFunc := pgtest.NewPostgresSchemaString(t, "")
+	defer cleanupFunc()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// Like schema isolation, set the search_path with set_config so that it's
+	// not part of the connection config.
+	if _, err := conn.Exec(ctx, "CREATE SCHEMA pgload_isolated"); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if _, err := conn.Exec(ctx, "DROP SCHEMA pgload_isolated CASCADE"); err != nil {
+			t.Error(err)
+		}
+	}()
+	if _, err := conn.Exec(ctx, "SELECT set_config('search_path', 'pgload_isolated', false)"); err != nil {
+		t.Fatal(err)
+	}
+	schemaFile := filepath.Join(t.TempDir(), "schema.sql")
+	if err := os.WriteFile(schemaFile, []byte("\\connect -\nCREATE TABLE foo (id int);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadFiles(ctx, conn, []string{schemaFile}); err != nil {
+		t.Fatal(err)
+	}
+
+	var schema string
+	if err := conn.QueryRow(ctx, "SELECT relnamespace::regnamespace::text FROM pg_class WHERE oid = 'pgload_isolated.foo'::regclass").Scan(&schema); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "pgload_isolated", schema, "schema of table created after \\connect")
+}
@@ -0,0 +1,365 @@
+package pgload
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v4"
+	"io"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+type itemKind int
+
+const (
+	itemStatement itemKind = iota // a SQL statement
+	itemCopy                      // a COPY FROM STDIN statement and its data
+	itemMeta                      // a psql meta-command, like \set
+)
+
+// item is a single unit of a psql script to run in order.
+type item struct {
+	kind    itemKind
+	line    int      // 1-based line where the item starts
+	col     int      // 1-based column where the statement starts
+	endLine int      // line where the statement ends, like the line of the semicolon
+	text    string   // the SQL statement, or the meta-command name without the backslash
+	args    []string // arguments of the meta-command
+	data    string   // rows of a COPY FROM STDIN statement, up to the \. terminator
+}
+
+// scanner splits a psql script into statements and meta-commands like psql.
+// A semicolon outside of quotes, dollar quotes, and comments ends a
+// statement. A line that starts with a backslash between statements is a
+// meta-command. Like psql, drops the whitespace and line comments before a
+// statement so that positions in Postgres errors are relative to the first
+// token of the statement.
+//
+// The scanner interpolates psql variables from vars into SQL statements
+// outside of quotes: :name as the value, :'name' as a quoted literal, and
+// :"name" as a quoted identifier. Like psql, the scanner leaves references to
+// undefined variables as is.
+type scanner struct {
+	r    *bufio.Reader
+	vars map[string]string
+	eof  bool
+
+	lineNo     int
+	buf        strings.Builder // text of the current statement
+	hasContent bool            // if buf has more than whitespace and comments
+	startLine  int             // line of the first content in buf
+	startCol   int             // column of the first content in buf
+	startOff   int             // offset of the first content in buf
+
+	quote      byte   // the open quote, either ' or ", or 0
+	escapeStr  bool   // if the open single quote is an E'' string
+	dollarTag  string // the open dollar quote tag, like $body$
+	blockDepth int    // nesting depth of /* */ comments
+
+	queue []item
+}
+
+func newScanner(r io.Reader, vars map[string]string) *scanner {
+	return &scanner{r: bufio.NewReader(r), vars: vars}
+}
+
+// next returns the next item in the script. Returns io.EOF after the last
+// item.
+func (s *scanner) next() (item, error) {
+	for len(s.queue) == 0 {
+		if s.eof {
+			return item{}, io.EOF
+		}
+		line, err := s.readLine()
+		if errors.Is(err, io.EOF) {
+			s.eof = true
+			// Like psql, run the last statement even without a semicolon.
+			if s.hasContent {
+				s.queue = append(s.queue, s.takeStatement())
+			}
+			continue
+		}
+		if err != nil {
+			return item{}, err
+		}
+		if s.atStatementStart() && strings.HasPrefix(strings.TrimSpace(line), `\`) {
+			s.queue = append(s.queue, s.parseMeta(line))
+			s.buf.Reset()
+			continue
+		}
+		if err := s.scanLine(line); err != nil {
+			return item{}, err
+		}
+	}
+	it := s.queue[0]
+	s.queue = s.queue[1:]
+	return it, nil
+}
+
+// readLine returns the next line without the line terminator.
+func (s *scanner) readLine() (string, error) {
+	line, err := s.r.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", err
+	}
+	s.lineNo++
+	line = strings.TrimSuffix(line, "\n")
+	return strings.TrimSuffix(line, "\r"), nil
+}
+
+// atStatementStart returns true if the scanner is between statements, outside
+// of any quote or comment.
+func (s *scanner) atStatementStart() bool {
+	return !s.hasContent && s.quote == 0 && s.dollarTag == "" && s.blockDepth == 0
+}
+
+var dollarTagRegexp = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+
+var variableRegexp = regexp.MustCompile(`^:(?:'([A-Za-z0-9_]+)'|"([A-Za-z0-9_]+)"|([A-Za-z0-9_]+))`)
+
+// scanLine adds the line to the current statement and queues each statement
+// that ends in the line.
+func (s *scanner) scanLine(line string) error {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case s.blockDepth > 0:
+			switch {
+			case strings.HasPrefix(line[i:], "/*"):
+				s.blockDepth++
+				s.buf.WriteString("/*")
+				i++
+			case strings.HasPrefix(line[i:], "*/"):
+				s.blockDepth--
+				s.buf.WriteString("*/")
+				i++
+			default:
+				s.buf.WriteByte(c)
+			}
+
+		case s.quote != 0:
+			s.buf.WriteByte(c)
+			switch {
+			case s.escapeStr && c == '\\' && i+1 < len(line):
+				s.buf.WriteByte(line[i+1])
+				i++
+			case c == s.quote && i+1 < len(line) && line[i+1] == s.quote:
+				s.buf.WriteByte(line[i+1]) // doubled quote
+				i++
+			case c == s.quote:
+				s.quote = 0
+			}
+
+		case s.dollarTag != "":
+			if strings.HasPrefix(line[i:], s.dollarTag) {
+				s.buf.WriteString(s.dollarTag)
+				i += len(s.dollarTag) - 1
+				s.dollarTag = ""
+			} else {
+				s.buf.WriteByte(c)
+			}
+
+		case strings.HasPrefix(line[i:], "--"):
+			s.buf.WriteString(line[i:])
+			i = len(line)
+
+		case strings.HasPrefix(line[i:], "/*"):
+			s.blockDepth = 1
+			s.buf.WriteString("/*")
+			i++
+
+		case c == '\'' || c == '"':
+			s.markContent(i)
+			s.quote = c
+			s.escapeStr = c == '\'' && i > 0 && (line[i-1] == 'E' || line[i-1] == 'e') &&
+				(i == 1 || !isIdentChar(line[i-2]))
+			s.buf.WriteByte(c)
+
+		case c == '$' && (i == 0 || !isIdentChar(line[i-1])) && dollarTagRegexp.MatchString(line[i:]):
+			s.markContent(i)
+			s.dollarTag = dollarTagRegexp.FindString(line[i:])
+			s.buf.WriteString(s.dollarTag)
+			i += len(s.dollarTag) - 1
+
+		case c == ':' && strings.HasPrefix(line[i:], "::"):
+			s.markContent(i)
+			s.buf.WriteString("::")
+			i++
+
+		case c == ':':
+			s.markContent(i)
+			ref, value, ok := s.interpolate(line[i:])
+			if !ok {
+				s.buf.WriteByte(c)
+				continue
+			}
+			s.buf.WriteString(value)
+			i += len(ref) - 1
+
+		case c == ';':
+			s.markContent(i)
+			s.buf.WriteByte(c)
+			stmt := s.takeStatement()
+			if !copyFromStdinRegexp.MatchString(stmt.text) {
+				s.queue = append(s.queue, stmt)
+				continue
+			}
+			// Like psql, the COPY data starts on the next line, so ignore the
+			// rest of the line.
+			data, err := s.readCopyData()
+			if err != nil {
+				return fmt.Errorf("read COPY data for statement at line %d: %w", stmt.line, err)
+			}
+			stmt.kind = itemCopy
+			stmt.data = data
+			s.queue = append(s.queue, stmt)
+			return nil
+
+		default:
+			if c != ' ' && c != '\t' && c != '\r' {
+				s.markContent(i)
+			}
+			s.buf.WriteByte(c)
+		}
+	}
+	s.buf.WriteByte('\n')
+	return nil
+}
+
+// markContent records the start of the statement at index i of the current
+// line, if the statement has no content yet.
+func (s *scanner) markContent(i int) {
+	if !s.hasContent {
+		s.hasContent = true
+		s.startLine = s.lineNo
+		s.startCol = i + 1
+		s.startOff = s.buf.Len()
+	}
+}
+
+// interpolate returns the variable reference at the start of text and its
+// value. Returns false if text doesn't start with a reference to a defined
+// variable.
+func (s *scanner) interpolate(text string) (ref string, value string, ok bool) {
+	m := variableRegexp.FindStringSubmatch(text)
+	if m == nil {
+		return "", "", false
+	}
+	switch {
+	case m[1] != "":
+		v, ok := s.vars[m[1]]
+		return m[0], quoteLiteral(v), ok
+	case m[2] != "":
+		v, ok := s.vars[m[2]]
+		return m[0], pgx.Identifier{v}.Sanitize(), ok
+	default:
+		v, ok := s.vars[m[3]]
+		return m[0], v, ok
+	}
+}
+
+// takeStatement returns the current statement and resets the buffer.
+func (s *scanner) takeStatement() item {
+	stmt := item{
+		kind:    itemStatement,
+		line:    s.startLine,
+		col:     s.startCol,
+		endLine: s.lineNo,
+		text:    strings.TrimRightFunc(s.buf.String()[s.startOff:], unicode.IsSpace),
+	}
+	s.buf.Reset()
+	s.hasContent = false
+	s.startLine, s.startCol, s.startOff = 0, 0, 0
+	return stmt
+}
+
+var copyFromStdinRegexp = regexp.MustCompile(`(?is)^COPY\b.*\bFROM\s+STDIN\b`)
+
+// readCopyData reads the lines after a COPY FROM STDIN statement up to the \.
+// terminator.
+func (s *scanner) readCopyData() (string, error) {
+	sb := &strings.Builder{}
+	for {
+		line, err := s.readLine()
+		if errors.Is(err, io.EOF) {
+			s.eof = true
+			return "", fmt.Errorf(`missing \. terminator`)
+		}
+		if err != nil {
+			return "", err
+		}
+		if line == `\.` {
+			return sb.String(), nil
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+}
+
+// parseMeta parses a meta-command line, like \set foo bar. Single quotes group
+// an argument with spaces. An argument of the form :name is the value of the
+// variable.
+func (s *scanner) parseMeta(line string) item {
+	fields := splitMetaArgs(strings.TrimPrefix(strings.TrimSpace(line), `\`))
+	it := item{kind: itemMeta, line: s.lineNo}
+	if len(fields) == 0 {
+		return it
+	}
+	it.text = fields[0]
+	for _, arg := range fields[1:] {
+		if strings.HasPrefix(arg, ":") {
+			if v, ok := s.vars[arg[1:]]; ok {
+				arg = v
+			}
+		}
+		it.args = append(it.args, arg)
+	}
+	return it
+}
+
+// splitMetaArgs splits a meta-command line on whitespace. Single quotes group
+// an argument with spaces and a doubled single quote is a literal quote.
+func splitMetaArgs(line string) []string {
+	var args []string
+	sb := &strings.Builder{}
+	inArg, inQuote := false, false
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case inQuote && c == '\'' && i+1 < len(line) && line[i+1] == '\'':
+			sb.WriteByte('\'')
+			i++
+		case c == '\'':
+			inQuote = !inQuote
+			inArg = true
+		case !inQuote && (c == ' ' || c == '\t'):
+			if inArg {
+				args = append(args, sb.String())
+				sb.Reset()
+				inArg = false
+			}
+		default:
+			sb.WriteByte(c)
+			inArg = true
+		}
+	}
+	if inArg {
+		args = append(args, sb.String())
+	}
+	return args
+}
+
+// quoteLiteral quotes s as a SQL string literal like PQescapeLiteral.
+func quoteLiteral(s string) string {
+	quoted := "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	if strings.Contains(s, `\`) {
+		quoted = "E" + strings.ReplaceAll(quoted, `\`, `\\`)
+	}
+	return quoted
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
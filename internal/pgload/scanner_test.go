@@ -0,0 +1,124 @@
+package pgload
+
+import (
+	"errors"
+	"github.com/atomicleads/pggen/internal/texts"
+	"github.com/google/go-cmp/cmp"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestScanner(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		vars   map[string]string
+		want   []item
+	}{
+		{
+			name: "statements",
+			script: texts.Dedent(`
+				CREATE TABLE foo (id int);
+				-- comment; with semicolon
+				INSERT INTO foo VALUES (1); INSERT INTO foo VALUES (2);
+			`),
+			want: []item{
+				{kind: itemStatement, line: 1, col: 1, endLine: 1, text: "CREATE TABLE foo (id int);"},
+				{kind: itemStatement, line: 3, col: 1, endLine: 3, text: "INSERT INTO foo VALUES (1);"},
+				{kind: itemStatement, line: 3, col: 29, endLine: 3, text: "INSERT INTO foo VALUES (2);"},
+			},
+		},
+		{
+			name: "quotes and comments",
+			script: texts.Dedent(`
+				SELECT 'a;b', E'c\';d', "e;f", $$g;h$$, $tag$i;$$j$tag$ /* k; /* l; */ m; */;
+				SELECT 2
+			`),
+			want: []item{
+				{kind: itemStatement, line: 1, col: 1, endLine: 1, text: `SELECT 'a;b', E'c\';d', "e;f", $$g;h$$, $tag$i;$$j$tag$ /* k; /* l; */ m; */;`},
+				{kind: itemStatement, line: 2, col: 1, endLine: 2, text: "SELECT 2"},
+			},
+		},
+		{
+			name: "multiline function",
+			script: texts.Dedent(`
+				CREATE FUNCTION f() RETURNS int AS $$
+				  SELECT 1;
+				$$ LANGUAGE sql;
+			`),
+			want: []item{
+				{kind: itemStatement, line: 1, col: 1, endLine: 3, text: "CREATE FUNCTION f() RETURNS int AS $$\n  SELECT 1;\n$$ LANGUAGE sql;"},
+			},
+		},
+		{
+			name: "meta-commands",
+			script: texts.Dedent(`
+				\set ON_ERROR_STOP on
+				-- comment
+				\connect 'my db'
+				SELECT 1;
+				\i other.sql
+			`),
+			want: []item{
+				{kind: itemMeta, line: 1, text: "set", args: []string{"ON_ERROR_STOP", "on"}},
+				{kind: itemMeta, line: 3, text: "connect", args: []string{"my db"}},
+				{kind: itemStatement, line: 4, col: 1, endLine: 4, text: "SELECT 1;"},
+				{kind: itemMeta, line: 5, text: "i", args: []string{"other.sql"}},
+			},
+		},
+		{
+			name: "copy from stdin",
+			script: texts.Dedent(`
+				COPY public.foo (id, name) FROM stdin;
+				1	alice
+				2	bob; with 'quote
+				\.
+				SELECT 1;
+			`),
+			want: []item{
+				{kind: itemCopy, line: 1, col: 1, endLine: 1, text: "COPY public.foo (id, name) FROM stdin;", data: "1\talice\n2\tbob; with 'quote\n"},
+				{kind: itemStatement, line: 5, col: 1, endLine: 5, text: "SELECT 1;"},
+			},
+		},
+		{
+			name: "variables",
+			script: texts.Dedent(`
+				SELECT :n::int, :'s', :"t", ':n', :undefined;
+				\echo :n
+			`),
+			vars: map[string]string{"n": "1", "s": `it's \ok`, "t": "Tbl"},
+			want: []item{
+				{kind: itemStatement, line: 1, col: 1, endLine: 1, text: `SELECT 1::int, E'it''s \\ok', "Tbl", ':n', :undefined;`},
+				{kind: itemMeta, line: 2, text: "echo", args: []string{"1"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScanner(strings.NewReader(tt.script), tt.vars)
+			var got []item
+			for {
+				it, err := s.next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, it)
+			}
+			if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(item{})); diff != "" {
+				t.Errorf("scanner items mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestScanner_CopyMissingTerminator(t *testing.T) {
+	s := newScanner(strings.NewReader("COPY foo FROM stdin;\n1\n"), nil)
+	_, err := s.next()
+	if err == nil || !strings.Contains(err.Error(), `missing \. terminator`) {
+		t.Errorf("want missing terminator error; got %v", err)
+	}
+}
@@ -0,0 +1,299 @@
+// Package pglocal starts a one-off Postgres cluster from the Postgres binaries
+// installed on the machine so pggen can introspect the schema without Docker.
+package pglocal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/atomicleads/pggen/internal/errs"
+	"github.com/atomicleads/pggen/internal/pgload"
+	"github.com/atomicleads/pggen/internal/ports"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// geteuid returns the effective user ID; a var so tests can fake running as
+// root.
+var geteuid = os.Geteuid
+
+// Client controls the running Postgres cluster.
+type Client struct {
+	bins       binaries
+	dataDir    string // temp dir of the cluster, removed by Stop
+	logFile    string // server log of the cluster
+	port       ports.Port
+	started    bool // if the server started
+	connString string
+}
+
+// binaries are the paths of the Postgres binaries used to run a cluster.
+type binaries struct {
+	dir    string // directory of the binaries, empty if found on PATH
+	initdb string
+	pgCtl  string
+	psql   string
+}
+
+// Start creates a Postgres cluster in a temp dir, starts the server on an
+// available port, and runs the init scripts in order. Finds the binaries in
+// binDir, or on PATH if binDir is empty.
+//
+// Runs init scripts like the official Postgres Docker image: *.sql and
+// *.sql.gz files with psql, executable *.sh files directly, and other *.sh
+// files with bash.
+func Start(ctx context.Context, binDir string, initScripts []string) (client *Client, mErr error) {
+	now := time.Now()
+	// initdb refuses to run as root, so fail before creating anything.
+	if geteuid() == 0 {
+		return nil, fmt.Errorf("local postgres backend can't run as root because initdb refuses to; " +
+			"run pggen as a non-root user or use --postgres-backend docker")
+	}
+	bins, err := findBinaries(binDir)
+	if err != nil {
+		return nil, err
+	}
+	dataDir, err := os.MkdirTemp("", "pggen-postgres-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir for postgres cluster: %w", err)
+	}
+	c := &Client{
+		bins:    bins,
+		dataDir: dataDir,
+		logFile: filepath.Join(dataDir, "postgres.log"),
+	}
+	// Cleanup the cluster if anything fails.
+	defer func() {
+		if mErr != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := c.Stop(ctx); err != nil {
+				slog.ErrorContext(ctx, "stop pglocal client", slog.String("error", err.Error()))
+			}
+		}
+	}()
+	// Enrich errors with the server log, unless an init script failed on a
+	// statement that the error already reports.
+	defer func() {
+		stmtErr := (*pgload.StatementError)(nil)
+		if mErr != nil && c.started && !errors.As(mErr, &stmtErr) {
+			logs, err := c.GetLogs()
+			if err != nil {
+				mErr = errors.Join(mErr, err)
+			} else {
+				mErr = fmt.Errorf("%w\nPostgres server logs:\n\n%s", mErr, logs)
+			}
+		}
+	}()
+
+	if err := c.initCluster(ctx); err != nil {
+		return nil, fmt.Errorf("init postgres cluster: %w", err)
+	}
+	port, err := ports.FindAvailable()
+	if err != nil {
+		return nil, fmt.Errorf("find available port: %w", err)
+	}
+	c.port = port
+	if err := c.startServer(ctx); err != nil {
+		return nil, fmt.Errorf("start postgres server: %w", err)
+	}
+	c.connString = fmt.Sprintf("host=127.0.0.1 port=%d user=postgres dbname=postgres", port)
+	for _, script := range initScripts {
+		if err := c.runInitScript(ctx, script); err != nil {
+			if stmtErr, ok := pgload.ParsePsqlError(err.Error(), map[string]string{script: script}); ok {
+				return nil, fmt.Errorf("run init script: %w", stmtErr)
+			}
+			return nil, fmt.Errorf("run init script %s: %w", script, err)
+		}
+	}
+	slog.DebugContext(ctx, "started local postgres", slog.Duration("start_duration", time.Since(now)))
+	return c, nil
+}
+
+// findBinaries finds the Postgres binaries in binDir or on PATH.
+func findBinaries(binDir string) (binaries, error) {
+	find := func(name string) (string, error) {
+		if binDir == "" {
+			p, err := exec.LookPath(name)
+			if err != nil {
+				return "", fmt.Errorf("find %s on PATH; use --postgres-bin-dir to set the "+
+					"directory of the Postgres binaries, like /usr/lib/postgresql/16/bin: %w", name, err)
+			}
+			return p, nil
+		}
+		p := filepath.Join(binDir, name)
+		if _, err := os.Stat(p); err != nil {
+			return "", fmt.Errorf("find %s in postgres bin dir: %w", name, err)
+		}
+		return p, nil
+	}
+	bins := binaries{dir: binDir}
+	var err error
+	if bins.initdb, err = find("initdb"); err != nil {
+		return binaries{}, err
+	}
+	if bins.pgCtl, err = find("pg_ctl"); err != nil {
+		return binaries{}, err
+	}
+	// pg_ctl runs the postgres binary from its own directory, so only check
+	// that it exists for a better error message.
+	if _, err := find("postgres"); err != nil {
+		return binaries{}, err
+	}
+	if bins.psql, err = find("psql"); err != nil {
+		return binaries{}, err
+	}
+	return bins, nil
+}
+
+// initCluster creates the cluster with a trusted postgres superuser.
+func (c *Client) initCluster(ctx context.Context) error {
+	return c.run(ctx, c.bins.initdb,
+		"--pgdata", filepath.Join(c.dataDir, "data"),
+		"--username", "postgres",
+		"--auth", "trust",
+		"--encoding", "UTF8",
+		"--no-sync",
+	)
+}
+
+// startServer starts the server and waits until it accepts connections. The
+// server only listens on localhost and puts the Unix socket in the temp dir
+// so it doesn't need write access to the default socket directory.
+func (c *Client) startServer(ctx context.Context) error {
+	serverOpts := strings.Join([]string{
+		"-p", strconv.Itoa(c.port),
+		"-c", "listen_addresses=127.0.0.1",
+		// pg_ctl passes the options through the shell.
+		"-c", "unix_socket_directories='" + c.dataDir + "'",
+		"-c", "fsync=off",
+		"-c", "full_page_writes=off",
+	}, " ")
+	err := c.run(ctx, c.bins.pgCtl, "start",
+		"--pgdata", filepath.Join(c.dataDir, "data"),
+		"--log", c.logFile,
+		"--options", serverOpts,
+		"--wait",
+		"--timeout", "10",
+	)
+	c.started = true // the server might be running even if pg_ctl failed
+	return err
+}
+
+// runInitScript runs a single init script on the cluster, like the
+// docker-entrypoint.sh script of the official Postgres Docker image.
+func (c *Client) runInitScript(ctx context.Context, script string) (mErr error) {
+	psqlArgs := []string{"-v", "ON_ERROR_STOP=1", "--no-psqlrc", "--quiet"}
+	switch {
+	case strings.HasSuffix(script, ".sh"):
+		// The Docker image runs executable scripts and sources the rest.
+		stat, err := os.Stat(script)
+		if err != nil {
+			return fmt.Errorf("stat init script: %w", err)
+		}
+		if stat.Mode()&0111 != 0 {
+			return c.run(ctx, script)
+		}
+		return c.run(ctx, "bash", script)
+	case strings.HasSuffix(script, ".sql"):
+		return c.run(ctx, c.bins.psql, append(psqlArgs, "--file", script)...)
+	case strings.HasSuffix(script, ".sql.gz"):
+		f, err := os.Open(script)
+		if err != nil {
+			return fmt.Errorf("open init script: %w", err)
+		}
+		defer errs.Capture(&mErr, f.Close, "close init script")
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return fmt.Errorf("read gzip init script: %w", err)
+		}
+		cmd := c.command(ctx, c.bins.psql, psqlArgs...)
+		cmd.Stdin = gz
+		return runCommand(cmd)
+	default:
+		return fmt.Errorf("unsupported init script; must be *.sql, *.sql.gz, or *.sh")
+	}
+}
+
+// run runs a command with the environment of the cluster.
+func (c *Client) run(ctx context.Context, name string, args ...string) error {
+	return runCommand(c.command(ctx, name, args...))
+}
+
+// command creates a command with the libpq environment variables set to
+// connect to the cluster, and the Postgres binaries first on PATH.
+func (c *Client) command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...)
+	env := os.Environ()
+	if c.bins.dir != "" {
+		env = append(env, "PATH="+c.bins.dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	}
+	cmd.Env = append(env,
+		"PGHOST=127.0.0.1",
+		"PGPORT="+strconv.Itoa(c.port),
+		"PGUSER=postgres",
+		"PGDATABASE=postgres",
+		// Set by the official Postgres Docker image for init scripts.
+		"POSTGRES_USER=postgres",
+		"POSTGRES_DB=postgres",
+	)
+	return cmd
+}
+
+func runCommand(cmd *exec.Cmd) error {
+	output := &bytes.Buffer{}
+	cmd.Stdout = output
+	cmd.Stderr = output
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w\n%s", filepath.Base(cmd.Path), err, strings.TrimSpace(output.String()))
+	}
+	return nil
+}
+
+// GetLogs returns the server log of the cluster. Useful to enrich output when
+// pggen fails to query the database.
+func (c *Client) GetLogs() (string, error) {
+	bs, err := os.ReadFile(c.logFile)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("read postgres server log: %w", err)
+	}
+	return string(bs), nil
+}
+
+// ConnString returns the connection string to connect to the started
+// Postgres cluster.
+func (c *Client) ConnString() (string, error) {
+	if c.connString == "" {
+		return "", fmt.Errorf("conn string not set; did postgres start correctly")
+	}
+	return c.connString, nil
+}
+
+// Stop stops the server, if running, and removes the cluster.
+func (c *Client) Stop(ctx context.Context) error {
+	var stopErr error
+	if c.started {
+		stopErr = c.run(ctx, c.bins.pgCtl, "stop",
+			"--pgdata", filepath.Join(c.dataDir, "data"),
+			"--mode", "immediate",
+			"--wait",
+		)
+		if stopErr == nil {
+			c.started = false
+		}
+	}
+	if err := os.RemoveAll(c.dataDir); err != nil {
+		return errors.Join(stopErr, fmt.Errorf("remove postgres cluster dir: %w", err))
+	}
+	if stopErr != nil {
+		return fmt.Errorf("stop postgres server: %w", stopErr)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package pglocal
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFakeBinaries writes shell scripts that stand in for the Postgres
+// binaries. Each script appends its name, arguments, and stdin for psql to
+// the file in the PGGEN_TEST_LOG environment variable.
+func writeFakeBinaries(t *testing.T) string {
+	t.Helper()
+	binDir := t.TempDir()
+	for _, name := range []string{"initdb", "pg_ctl", "postgres", "psql"} {
+		script := "#!/bin/sh\n" +
+			`echo "` + name + ` $*" >> "$PGGEN_TEST_LOG"` + "\n"
+		if name == "psql" {
+			script += `case "$*" in *--file*) ;; *) cat >> "$PGGEN_TEST_LOG" ;; esac` + "\n"
+		}
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return binDir
+}
+
+// fakeEuid makes Start see uid as the effective user ID for the test.
+func fakeEuid(t *testing.T, uid int) {
+	t.Helper()
+	prev := geteuid
+	geteuid = func() int { return uid }
+	t.Cleanup(func() { geteuid = prev })
+}
+
+func TestStart_InitScripts(t *testing.T) {
+	fakeEuid(t, 1000)
+	binDir := writeFakeBinaries(t)
+	logFile := filepath.Join(t.TempDir(), "log")
+	t.Setenv("PGGEN_TEST_LOG", logFile)
+
+	scriptDir := t.TempDir()
+	sqlScript := filepath.Join(scriptDir, "01_schema.sql")
+	if err := os.WriteFile(sqlScript, []byte("CREATE TABLE foo (id int);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	gzScript := filepath.Join(scriptDir, "02_data.sql.gz")
+	gzBuf := &bytes.Buffer{}
+	gzW := gzip.NewWriter(gzBuf)
+	if _, err := gzW.Write([]byte("INSERT INTO foo VALUES (1);\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := gzW.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(gzScript, gzBuf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	shScript := filepath.Join(scriptDir, "03_setup.sh")
+	sh := `echo "setup.sh $PGHOST $PGUSER $PGDATABASE $(command -v psql)" >> "$PGGEN_TEST_LOG"`
+	if err := os.WriteFile(shScript, []byte(sh), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	client, err := Start(ctx, binDir, []string{sqlScript, gzScript, shScript})
+	if err != nil {
+		t.Fatal(err)
+	}
+	connString, err := client.ConnString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, connString, "host=127.0.0.1 port=")
+	dataDir := client.dataDir
+	if err := client.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dataDir); !os.IsNotExist(err) {
+		t.Errorf("want cluster dir %s removed after stop; got stat error %v", dataDir, err)
+	}
+
+	bs, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(bs)), "\n")
+	wantPrefixes := []string{
+		"initdb --pgdata " + filepath.Join(dataDir, "data") + " --username postgres --auth trust",
+		"pg_ctl start --pgdata " + filepath.Join(dataDir, "data"),
+		"psql -v ON_ERROR_STOP=1 --no-psqlrc --quiet --file " + sqlScript,
+		"psql -v ON_ERROR_STOP=1 --no-psqlrc --quiet",
+		"INSERT INTO foo VALUES (1);",
+		"setup.sh 127.0.0.1 postgres postgres " + filepath.Join(binDir, "psql"),
+		"pg_ctl stop --pgdata " + filepath.Join(dataDir, "data") + " --mode immediate",
+	}
+	if !assert.Len(t, lines, len(wantPrefixes), "log lines:\n%s", bs) {
+		return
+	}
+	for i, want := range wantPrefixes {
+		assert.True(t, strings.HasPrefix(lines[i], want), "line %d: want prefix %q; got %q", i, want, lines[i])
+	}
+}
+
+func TestStart_Error(t *testing.T) {
+	fakeEuid(t, 1000)
+	binDir := writeFakeBinaries(t)
+	t.Setenv("PGGEN_TEST_LOG", filepath.Join(t.TempDir(), "log"))
+	tests := []struct {
+		name    string
+		binDir  string
+		scripts []string
+		wantErr string
+	}{
+		{
+			name:    "missing binaries",
+			binDir:  t.TempDir(),
+			wantErr: "find initdb in postgres bin dir",
+		},
+		{
+			name:    "unsupported init script",
+			binDir:  binDir,
+			scripts: []string{"schema.txt"},
+			wantErr: "run init script schema.txt: unsupported init script",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Start(context.Background(), tt.binDir, tt.scripts)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Start() error = %v; want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestStart_ScriptStatementError(t *testing.T) {
+	fakeEuid(t, 1000)
+	binDir := writeFakeBinaries(t)
+	t.Setenv("PGGEN_TEST_LOG", filepath.Join(t.TempDir(), "log"))
+	psql := "#!/bin/sh\n" +
+		`for f; do :; done` + "\n" + // the last argument is the script
+		`echo "psql:$f:2: ERROR:  type \"txt\" does not exist" >&2` + "\n" +
+		`echo "LINE 2:   name txt" >&2` + "\n" +
+		`echo "               ^" >&2` + "\n" +
+		"exit 3\n"
+	if err := os.WriteFile(filepath.Join(binDir, "psql"), []byte(psql), 0755); err != nil {
+		t.Fatal(err)
+	}
+	script := filepath.Join(t.TempDir(), "schema.sql")
+	if err := os.WriteFile(script, []byte("CREATE TABLE foo (\n  name txt);\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Start(context.Background(), binDir, []string{script})
+	if err == nil {
+		t.Fatal("want error; got nil")
+	}
+	assert.Contains(t, err.Error(), script+`:2:8: ERROR: type "txt" does not exist`)
+	assert.NotContains(t, err.Error(), "Postgres server logs", "server logs for statement error")
+}
+
+func TestStart_Root(t *testing.T) {
+	fakeEuid(t, 0)
+	logFile := filepath.Join(t.TempDir(), "log")
+	t.Setenv("PGGEN_TEST_LOG", logFile)
+	_, err := Start(context.Background(), writeFakeBinaries(t), nil)
+	if err == nil {
+		t.Fatal("want error; got nil")
+	}
+	assert.Contains(t, err.Error(), "can't run as root")
+	assert.Contains(t, err.Error(), "--postgres-backend docker")
+	if _, err := os.Stat(logFile); !os.IsNotExist(err) {
+		t.Errorf("want no postgres binaries run as root; got stat error %v", err)
+	}
+}
@@ -9,10 +9,14 @@ type Node interface {
 	Output() []string
 	// Children returns the direct children of the node, or nil if none exist.
 	Children() []Node
+	// Base returns the fields common to all nodes.
+	Base() Plan
 }
 
 // NodeKind is the top-level node plan type that Postgres plans for executing
 // query. https://www.postgresql.org/docs/13/executor.html
+//
+// Each kind is the "Node Type" of the node in the EXPLAIN output.
 type NodeKind string
 
 //goland:noinspection GoUnusedConst
@@ -22,40 +26,42 @@ const (
 	KindProjectSet          NodeKind = "ProjectSet"
 	KindModifyTable         NodeKind = "ModifyTable"
 	KindAppend              NodeKind = "Append"
-	KindMergeAppend         NodeKind = "MergeAppend"
-	KindRecursiveUnion      NodeKind = "RecursiveUnion"
+	KindMergeAppend         NodeKind = "Merge Append"
+	KindRecursiveUnion      NodeKind = "Recursive Union"
 	KindBitmapAnd           NodeKind = "BitmapAnd"
 	KindBitmapOr            NodeKind = "BitmapOr"
 	KindScan                NodeKind = "Scan"
-	KindSeqScan             NodeKind = "SeqScan"
-	KindSampleScan          NodeKind = "SampleScan"
-	KindIndexScan           NodeKind = "IndexScan"
-	KindIndexOnlyScan       NodeKind = "IndexOnlyScan"
-	KindBitmapIndexScan     NodeKind = "BitmapIndexScan"
-	KindBitmapHeapScan      NodeKind = "BitmapHeapScan"
-	KindTidScan             NodeKind = "TidScan"
-	KindSubqueryScan        NodeKind = "SubqueryScan"
-	KindFunctionScan        NodeKind = "FunctionScan"
-	KindValuesScan          NodeKind = "ValuesScan"
-	KindTableFuncScan       NodeKind = "TableFuncScan"
-	KindCteScan             NodeKind = "CteScan"
-	KindNamedTuplestoreScan NodeKind = "NamedTuplestoreScan"
-	KindWorkTableScan       NodeKind = "WorkTableScan"
-	KindForeignScan         NodeKind = "ForeignScan"
-	KindCustomScan          NodeKind = "CustomScan"
+	KindSeqScan             NodeKind = "Seq Scan"
+	KindSampleScan          NodeKind = "Sample Scan"
+	KindIndexScan           NodeKind = "Index Scan"
+	KindIndexOnlyScan       NodeKind = "Index Only Scan"
+	KindBitmapIndexScan     NodeKind = "Bitmap Index Scan"
+	KindBitmapHeapScan      NodeKind = "Bitmap Heap Scan"
+	KindTidScan             NodeKind = "Tid Scan"
+	KindTidRangeScan        NodeKind = "Tid Range Scan"
+	KindSubqueryScan        NodeKind = "Subquery Scan"
+	KindFunctionScan        NodeKind = "Function Scan"
+	KindValuesScan          NodeKind = "Values Scan"
+	KindTableFuncScan       NodeKind = "Table Function Scan"
+	KindCteScan             NodeKind = "CTE Scan"
+	KindNamedTuplestoreScan NodeKind = "Named Tuplestore Scan"
+	KindWorkTableScan       NodeKind = "WorkTable Scan"
+	KindForeignScan         NodeKind = "Foreign Scan"
+	KindCustomScan          NodeKind = "Custom Scan"
 	KindJoin                NodeKind = "Join"
-	KindNestLoop            NodeKind = "NestLoop"
-	KindMergeJoin           NodeKind = "MergeJoin"
-	KindHashJoin            NodeKind = "HashJoin"
-	KindMaterial            NodeKind = "Material"
+	KindNestLoop            NodeKind = "Nested Loop"
+	KindMergeJoin           NodeKind = "Merge Join"
+	KindHashJoin            NodeKind = "Hash Join"
+	KindMaterial            NodeKind = "Materialize"
+	KindMemoize             NodeKind = "Memoize"
 	KindSort                NodeKind = "Sort"
-	KindIncrementalSort     NodeKind = "IncrementalSort"
+	KindIncrementalSort     NodeKind = "Incremental Sort"
 	KindGroup               NodeKind = "Group"
-	KindAgg                 NodeKind = "Agg"
+	KindAgg                 NodeKind = "Aggregate"
 	KindWindowAgg           NodeKind = "WindowAgg"
 	KindUnique              NodeKind = "Unique"
 	KindGather              NodeKind = "Gather"
-	KindGatherMerge         NodeKind = "GatherMerge"
+	KindGatherMerge         NodeKind = "Gather Merge"
 	KindHash                NodeKind = "Hash"
 	KindSetOp               NodeKind = "SetOp"
 	KindLockRows            NodeKind = "LockRows"
@@ -111,6 +117,21 @@ const (
 	StrategyUnknown Strategy = "???"
 )
 
+// JoinType is the type of join for a join node, like Nested Loop or Hash Join.
+type JoinType string
+
+//goland:noinspection GoUnusedConst
+const (
+	JoinInner     JoinType = "Inner"
+	JoinLeft      JoinType = "Left"  // outer join that nulls the inner side
+	JoinRight     JoinType = "Right" // outer join that nulls the outer side
+	JoinFull      JoinType = "Full"  // outer join that nulls both sides
+	JoinSemi      JoinType = "Semi"
+	JoinAnti      JoinType = "Anti"
+	JoinRightSemi JoinType = "Right Semi"
+	JoinRightAnti JoinType = "Right Anti"
+)
+
 // Operation for a ModifyTable node.
 type Operation string
 
@@ -142,6 +163,10 @@ type Plan struct {
 	// Relationship from this node to its parent. Always set for descendant nodes.
 	ParentRelationship ParentRelationship
 
+	// The name of an InitPlan or SubPlan node, like "CTE foo" for the plan of
+	// a CTE named foo, or "InitPlan 1 (returns $0)".
+	SubplanName string
+
 	// How to execute a node. Used for Agg and SetOp nodes.
 	Strategy Strategy
 
@@ -155,6 +180,13 @@ type Plan struct {
 	Nodes []Node
 }
 
+// Relation is the table that a scan node reads.
+type Relation struct {
+	RelationName string
+	Schema       string
+	Alias        string // alias of the table in the query, or the table name
+}
+
 func (p Plan) Output() []string {
 	return p.Outs
 }
@@ -163,6 +195,10 @@ func (p Plan) Children() []Node {
 	return p.Nodes
 }
 
+func (p Plan) Base() Plan {
+	return p
+}
+
 type (
 	// BadNode is returned whenever a plan is not parseable.
 	BadNode struct{ Plan }
@@ -205,39 +241,97 @@ type (
 		SortKey []string
 	}
 
-	RecursiveUnion      struct{ Plan }
-	BitmapAnd           struct{ Plan }
-	BitmapOr            struct{ Plan }
-	Scan                struct{ Plan }
-	SeqScan             struct{ Plan }
-	SampleScan          struct{ Plan }
-	IndexScan           struct{ Plan }
-	IndexOnlyScan       struct{ Plan }
-	BitmapIndexScan     struct{ Plan }
-	BitmapHeapScan      struct{ Plan }
-	TidScan             struct{ Plan }
-	SubqueryScan        struct{ Plan }
-	FunctionScan        struct{ Plan }
-	ValuesScan          struct{ Plan }
-	TableFuncScan       struct{ Plan }
-	CteScan             struct{ Plan }
+	RecursiveUnion struct{ Plan }
+	BitmapAnd      struct{ Plan }
+	BitmapOr       struct{ Plan }
+	Scan           struct{ Plan }
+	SeqScan        struct {
+		Plan
+		Relation
+	}
+	SampleScan struct {
+		Plan
+		Relation
+	}
+	IndexScan struct {
+		Plan
+		Relation
+	}
+	IndexOnlyScan struct {
+		Plan
+		Relation
+	}
+	BitmapIndexScan struct{ Plan }
+	BitmapHeapScan  struct {
+		Plan
+		Relation
+	}
+	TidScan struct {
+		Plan
+		Relation
+	}
+	TidRangeScan struct {
+		Plan
+		Relation
+	}
+	// SubqueryScan reads the rows of a subquery in the FROM clause, the
+	// child node. The output columns are qualified by Alias.
+	SubqueryScan struct {
+		Plan
+		Alias string
+	}
+	FunctionScan  struct{ Plan }
+	ValuesScan    struct{ Plan }
+	TableFuncScan struct{ Plan }
+	// CteScan reads the rows of the CTE named CTEName. The plan of the CTE is
+	// an InitPlan node with the SubplanName "CTE <CTEName>" of an ancestor
+	// node. The output columns are qualified by Alias.
+	CteScan struct {
+		Plan
+		CTEName string
+		Alias   string
+	}
 	NamedTuplestoreScan struct{ Plan }
 	WorkTableScan       struct{ Plan }
-	ForeignScan         struct{ Plan }
-	CustomScan          struct{ Plan }
-	Join                struct{ Plan }
-	NestLoop            struct{ Plan }
-	MergeJoin           struct{ Plan }
-	HashJoin            struct{ Plan }
-	Material            struct{ Plan }
-	Sort                struct {
+	ForeignScan         struct {
+		Plan
+		Relation
+	}
+	CustomScan struct{ Plan }
+	Join       struct {
+		Plan
+		JoinType JoinType
+	}
+	// NestLoop joins the rows of the outer child, the first child, with the
+	// rows of the inner child, the second child.
+	NestLoop struct {
+		Plan
+		JoinType JoinType
+	}
+	MergeJoin struct {
+		Plan
+		JoinType JoinType
+	}
+	HashJoin struct {
+		Plan
+		JoinType JoinType
+	}
+	Material struct{ Plan }
+	Memoize  struct{ Plan }
+	Sort     struct {
 		Plan
 		SortKey []string
 	}
 	IncrementalSort struct{ Plan }
 	Group           struct{ Plan }
-	Agg             struct{ Plan }
-	WindowAgg       struct{ Plan }
+	Agg             struct {
+		Plan
+		// Set if the node computes grouping sets, like GROUP BY ROLLUP (a, b).
+		// The grouping set rows have null for the group keys that aren't in
+		// the set.
+		GroupingSets bool
+	}
+	WindowAgg struct{ Plan }
 	// Unique is a very simple node type that just filters out duplicate tuples
 	// from a stream of sorted tuples from its subplan.
 	// https://sourcegraph.com/github.com/postgres/postgres@8facf1ea00b7a0c08c755a0392212b83e04ae28a/-/blob/src/include/nodes/plannodes.h?subtree=true#L864:16
@@ -267,6 +361,7 @@ func (IndexOnlyScan) Kind() NodeKind       { return KindIndexOnlyScan }
 func (BitmapIndexScan) Kind() NodeKind     { return KindBitmapIndexScan }
 func (BitmapHeapScan) Kind() NodeKind      { return KindBitmapHeapScan }
 func (TidScan) Kind() NodeKind             { return KindTidScan }
+func (TidRangeScan) Kind() NodeKind        { return KindTidRangeScan }
 func (SubqueryScan) Kind() NodeKind        { return KindSubqueryScan }
 func (FunctionScan) Kind() NodeKind        { return KindFunctionScan }
 func (ValuesScan) Kind() NodeKind          { return KindValuesScan }
@@ -281,6 +376,7 @@ func (NestLoop) Kind() NodeKind            { return KindNestLoop }
 func (MergeJoin) Kind() NodeKind           { return KindMergeJoin }
 func (HashJoin) Kind() NodeKind            { return KindHashJoin }
 func (Material) Kind() NodeKind            { return KindMaterial }
+func (Memoize) Kind() NodeKind             { return KindMemoize }
 func (Sort) Kind() NodeKind                { return KindSort }
 func (IncrementalSort) Kind() NodeKind     { return KindIncrementalSort }
 func (Group) Kind() NodeKind               { return KindGroup }
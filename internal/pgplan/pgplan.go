@@ -4,18 +4,38 @@ import (
 	"context"
 	"fmt"
 	"github.com/jackc/pgx/v4"
+	"strings"
 	"time"
 )
 
-// ExplainQuery executes an explain query and parses the plan.
-func ExplainQuery(conn *pgx.Conn, sql string) (Node, error) {
-	explainQuery := `EXPLAIN (VERBOSE, FORMAT JSON) ` + sql
+// ExplainQuery explains the generic plan of sql with nParams params and
+// parses the plan. The generic plan keeps the params, like $1. A custom plan
+// replaces each param with its value, so explaining sql with null args might
+// fold a WHERE clause like "id = $1" to false and remove every scan.
+func ExplainQuery(conn *pgx.Conn, sql string, nParams int) (_ Node, mErr error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	row := conn.QueryRow(ctx, explainQuery)
+	if _, err := conn.Exec(ctx, "PREPARE pggen_explain AS "+sql); err != nil {
+		return BadNode{}, fmt.Errorf("prepare explain query: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(ctx, "DEALLOCATE pggen_explain"); err != nil && mErr == nil {
+			mErr = fmt.Errorf("deallocate explain query: %w", err)
+		}
+	}()
+	explainQuery := "EXPLAIN (VERBOSE, FORMAT JSON) EXECUTE pggen_explain"
+	if nParams > 0 {
+		explainQuery += "(" + strings.TrimSuffix(strings.Repeat("NULL, ", nParams), ", ") + ")"
+	}
 	explain := make([]map[string]map[string]interface{}, 0, 1)
-	if err := row.Scan(&explain); err != nil {
+	err := conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "SET LOCAL plan_cache_mode = force_generic_plan"); err != nil {
+			return fmt.Errorf("force generic plan: %w", err)
+		}
+		return tx.QueryRow(ctx, explainQuery).Scan(&explain)
+	})
+	if err != nil {
 		return BadNode{}, fmt.Errorf("execute explain query: %w", err)
 	}
 
@@ -67,21 +87,24 @@ func ParseNode(rawPlan map[string]interface{}) (Node, error) {
 	case KindScan:
 		return Scan{Plan: plan}, nil
 	case KindSeqScan:
-		return SeqScan{Plan: plan}, nil
+		return SeqScan{Plan: plan, Relation: parseRelation(rawPlan)}, nil
 	case KindSampleScan:
-		return SampleScan{Plan: plan}, nil
+		return SampleScan{Plan: plan, Relation: parseRelation(rawPlan)}, nil
 	case KindIndexScan:
-		return IndexScan{Plan: plan}, nil
+		return IndexScan{Plan: plan, Relation: parseRelation(rawPlan)}, nil
 	case KindIndexOnlyScan:
-		return IndexOnlyScan{Plan: plan}, nil
+		return IndexOnlyScan{Plan: plan, Relation: parseRelation(rawPlan)}, nil
 	case KindBitmapIndexScan:
 		return BitmapIndexScan{Plan: plan}, nil
 	case KindBitmapHeapScan:
-		return BitmapHeapScan{Plan: plan}, nil
+		return BitmapHeapScan{Plan: plan, Relation: parseRelation(rawPlan)}, nil
 	case KindTidScan:
-		return TidScan{Plan: plan}, nil
+		return TidScan{Plan: plan, Relation: parseRelation(rawPlan)}, nil
+	case KindTidRangeScan:
+		return TidRangeScan{Plan: plan, Relation: parseRelation(rawPlan)}, nil
 	case KindSubqueryScan:
-		return SubqueryScan{Plan: plan}, nil
+		alias, _ := parseString(rawPlan, "Alias")
+		return SubqueryScan{Plan: plan, Alias: alias}, nil
 	case KindFunctionScan:
 		return FunctionScan{Plan: plan}, nil
 	case KindValuesScan:
@@ -89,25 +112,29 @@ func ParseNode(rawPlan map[string]interface{}) (Node, error) {
 	case KindTableFuncScan:
 		return TableFuncScan{Plan: plan}, nil
 	case KindCteScan:
-		return CteScan{Plan: plan}, nil
+		cteName, _ := parseString(rawPlan, "CTE Name")
+		alias, _ := parseString(rawPlan, "Alias")
+		return CteScan{Plan: plan, CTEName: cteName, Alias: alias}, nil
 	case KindNamedTuplestoreScan:
 		return NamedTuplestoreScan{Plan: plan}, nil
 	case KindWorkTableScan:
 		return WorkTableScan{Plan: plan}, nil
 	case KindForeignScan:
-		return ForeignScan{Plan: plan}, nil
+		return ForeignScan{Plan: plan, Relation: parseRelation(rawPlan)}, nil
 	case KindCustomScan:
 		return CustomScan{Plan: plan}, nil
 	case KindJoin:
-		return Join{Plan: plan}, nil
+		return Join{Plan: plan, JoinType: parseJoinType(rawPlan)}, nil
 	case KindNestLoop:
-		return NestLoop{Plan: plan}, nil
+		return NestLoop{Plan: plan, JoinType: parseJoinType(rawPlan)}, nil
 	case KindMergeJoin:
-		return MergeJoin{Plan: plan}, nil
+		return MergeJoin{Plan: plan, JoinType: parseJoinType(rawPlan)}, nil
 	case KindHashJoin:
-		return HashJoin{Plan: plan}, nil
+		return HashJoin{Plan: plan, JoinType: parseJoinType(rawPlan)}, nil
 	case KindMaterial:
 		return Material{Plan: plan}, nil
+	case KindMemoize:
+		return Memoize{Plan: plan}, nil
 	case KindSort:
 		sortKey, _ := parseStringSlice(rawPlan, "Sort Key")
 		return Sort{Plan: plan, SortKey: sortKey}, nil
@@ -116,7 +143,8 @@ func ParseNode(rawPlan map[string]interface{}) (Node, error) {
 	case KindGroup:
 		return Group{Plan: plan}, nil
 	case KindAgg:
-		return Agg{Plan: plan}, nil
+		_, groupingSets := rawPlan["Grouping Sets"]
+		return Agg{Plan: plan, GroupingSets: groupingSets}, nil
 	case KindWindowAgg:
 		return WindowAgg{Plan: plan}, nil
 	case KindUnique:
@@ -180,6 +208,7 @@ func parseBasePlan(plan map[string]interface{}) (NodeKind, Plan, error) {
 	parallelAware, _ := parseBool(plan, "Parallel Aware")
 	parallelSafe, _ := parseBool(plan, "Parallel Safe")
 	parentRel, _ := parseString(plan, "Parent Relationship")
+	subplanName, _ := parseString(plan, "Subplan Name")
 	strategy, _ := parseString(plan, "Strategy")
 	customPlanProvider, _ := parseString(plan, "Custom Plan Provider")
 
@@ -202,12 +231,31 @@ func parseBasePlan(plan map[string]interface{}) (NodeKind, Plan, error) {
 		ParallelSafe:       parallelSafe,
 		Strategy:           Strategy(strategy),
 		ParentRelationship: ParentRelationship(parentRel),
+		SubplanName:        subplanName,
 		CustomPlanProvider: customPlanProvider,
 		Outs:               output,
 		Nodes:              nodes,
 	}, nil
 }
 
+// parseRelation parses the table of a scan node.
+func parseRelation(plan map[string]interface{}) Relation {
+	name, _ := parseString(plan, "Relation Name")
+	schema, _ := parseString(plan, "Schema")
+	alias, _ := parseString(plan, "Alias")
+	return Relation{RelationName: name, Schema: schema, Alias: alias}
+}
+
+// parseJoinType parses the join type of a join node. The join type is inner
+// if not set.
+func parseJoinType(plan map[string]interface{}) JoinType {
+	joinType, ok := parseString(plan, "Join Type")
+	if !ok {
+		return JoinInner
+	}
+	return JoinType(joinType)
+}
+
 func parseInt(plan map[string]interface{}, key string) (int, bool) {
 	if c, ok := plan[key]; ok {
 		if n, ok := c.(int); ok {
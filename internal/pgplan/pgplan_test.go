@@ -38,6 +38,99 @@ func TestParseNode(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "Hash Join - join type and scan relations",
+			plan: map[string]interface{}{
+				"Node Type": "Hash Join",
+				"Join Type": "Left",
+				"Output":    []interface{}{"a.id", "b.id"},
+				"Plans": []interface{}{
+					map[string]interface{}{
+						"Node Type":           "Seq Scan",
+						"Parent Relationship": "Outer",
+						"Relation Name":       "author",
+						"Schema":              "public",
+						"Alias":               "a",
+						"Output":              []interface{}{"a.id"},
+					},
+					map[string]interface{}{
+						"Node Type":           "Hash",
+						"Parent Relationship": "Inner",
+						"Output":              []interface{}{"b.id"},
+						"Plans": []interface{}{
+							map[string]interface{}{
+								"Node Type":           "Index Only Scan",
+								"Parent Relationship": "Outer",
+								"Relation Name":       "book",
+								"Schema":              "public",
+								"Alias":               "b",
+								"Output":              []interface{}{"b.id"},
+							},
+						},
+					},
+				},
+			},
+			want: HashJoin{
+				Plan: Plan{
+					Outs: []string{"a.id", "b.id"},
+					Nodes: []Node{
+						SeqScan{
+							Plan:     Plan{Outs: []string{"a.id"}, ParentRelationship: ParentRelationshipOuter},
+							Relation: Relation{RelationName: "author", Schema: "public", Alias: "a"},
+						},
+						Hash{Plan{
+							Outs:               []string{"b.id"},
+							ParentRelationship: ParentRelationshipInner,
+							Nodes: []Node{
+								IndexOnlyScan{
+									Plan:     Plan{Outs: []string{"b.id"}, ParentRelationship: ParentRelationshipOuter},
+									Relation: Relation{RelationName: "book", Schema: "public", Alias: "b"},
+								},
+							},
+						}},
+					},
+				},
+				JoinType: JoinLeft,
+			},
+		},
+		{
+			name: "CTE Scan - CTE name and subplan name",
+			plan: map[string]interface{}{
+				"Node Type": "CTE Scan",
+				"CTE Name":  "foo",
+				"Alias":     "f",
+				"Output":    []interface{}{"f.x"},
+				"Plans": []interface{}{
+					map[string]interface{}{
+						"Node Type":           "Result",
+						"Parent Relationship": "InitPlan",
+						"Subplan Name":        "CTE foo",
+						"Output":              []interface{}{"1"},
+					},
+				},
+			},
+			want: CteScan{
+				Plan: Plan{
+					Outs: []string{"f.x"},
+					Nodes: []Node{
+						Result{Plan{Outs: []string{"1"}, ParentRelationship: ParentRelationshipInitPlan, SubplanName: "CTE foo"}},
+					},
+				},
+				CTEName: "foo",
+				Alias:   "f",
+			},
+		},
+		{
+			name: "Aggregate - grouping sets",
+			plan: map[string]interface{}{
+				"Node Type": "Aggregate",
+				"Strategy":  "Sorted",
+				"Grouping Sets": []interface{}{
+					map[string]interface{}{"Group Keys": []interface{}{"a.id"}},
+				},
+			},
+			want: Agg{Plan: Plan{Strategy: StrategySorted}, GroupingSets: true},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -135,7 +228,7 @@ func TestParseNode_DB(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.sql, func(t *testing.T) {
-			got, err := ExplainQuery(conn, tt.sql)
+			got, err := ExplainQuery(conn, tt.sql, 0)
 			require.NoError(t, err)
 
 			opts := cmp.Options{
@@ -0,0 +1,19 @@
+package texts
+
+import "strings"
+
+// CaretIndent returns the whitespace to put before a caret under the 1-based
+// byte column col of line, the column unit of go/token. Keeps tabs so the
+// caret lines up with the line and writes one space for each other rune, so
+// the caret lines up under multi-byte runes too.
+func CaretIndent(line string, col int) string {
+	sb := &strings.Builder{}
+	for _, r := range line[:max(0, min(col-1, len(line)))] {
+		if r == '\t' {
+			sb.WriteRune('\t')
+		} else {
+			sb.WriteRune(' ')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,26 @@
+package texts
+
+import "testing"
+
+func TestCaretIndent(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		col  int
+		want string
+	}{
+		{"first column", "foo", 1, ""},
+		{"spaces", "foo bar", 5, "    "},
+		{"keeps tabs", "\tfoo\tbar", 6, "\t   \t"},
+		{"multi-byte runes", "é bar", 4, "  "},
+		{"past end of line", "foo", 10, "   "},
+		{"unknown column", "foo", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CaretIndent(tt.line, tt.col); got != tt.want {
+				t.Errorf("CaretIndent(%q, %d) = %q; want %q", tt.line, tt.col, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package pggen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/atomicleads/pggen/internal/pgload"
+	"github.com/jackc/pgx/v4"
+	"math/rand"
+	"os"
+)
+
+// PostgresIsolation is where pggen loads the schema files in the database of
+// a connection string.
+type PostgresIsolation string
+
+const (
+	// IsolationNone loads the schema files directly into the database and
+	// leaves the created objects behind.
+	IsolationNone PostgresIsolation = "none"
+	// IsolationDatabase loads the schema files into a new, uniquely named
+	// database on the same server and drops the database afterwards. Requires
+	// the CREATEDB privilege.
+	IsolationDatabase PostgresIsolation = "database"
+	// IsolationSchema loads the schema files into a new, uniquely named schema
+	// set as the search_path and drops the schema afterwards. Objects that the
+	// schema files create with a schema-qualified name aren't isolated.
+	IsolationSchema PostgresIsolation = "schema"
+)
+
+// isolatedName returns a unique name for a throwaway database or schema.
+func isolatedName() string {
+	return fmt.Sprintf("pggen_%d_%d", os.Getpid(), rand.Int31())
+}
+
+// connectIsolatedDatabase creates a new database on the server of
+// connString, loads the schema files into it, and connects to it. The cleanup
+// func drops the database.
+func connectIsolatedDatabase(ctx context.Context, connString string, schemaFiles []string) (*pgx.Conn, func() error, error) {
+	adminConn, err := pgx.Connect(ctx, connString)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to pggen postgres database: %w", err)
+	}
+	dbName := isolatedName()
+	if _, err := adminConn.Exec(ctx, "CREATE DATABASE "+pgx.Identifier{dbName}.Sanitize()); err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("create isolated database: %w", err), adminConn.Close(ctx))
+	}
+	dropDB := func() error {
+		_, err := adminConn.Exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{dbName}.Sanitize())
+		if err != nil {
+			err = fmt.Errorf("drop isolated database %s: %w", dbName, err)
+		}
+		return errors.Join(err, adminConn.Close(ctx))
+	}
+
+	cfg := adminConn.Config().Copy()
+	cfg.Database = dbName
+	// The new database only has the public schema, so a search_path from
+	// connString might not exist.
+	delete(cfg.RuntimeParams, "search_path")
+	pgConn, err := pgx.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("connect to isolated database: %w", err), dropDB())
+	}
+	// Postgres can't drop a database with open connections, so close the
+	// connection first.
+	cleanup := func() error {
+		return errors.Join(pgConn.Close(ctx), dropDB())
+	}
+	if err := pgload.LoadFiles(ctx, pgConn, schemaFiles); err != nil {
+		return nil, nil, errors.Join(err, cleanup())
+	}
+	return pgConn, cleanup, nil
+}
+
+// connectIsolatedSchema connects to connString, creates a new schema, sets it
+// as the search_path, and loads the schema files into it. The cleanup func
+// drops the schema and everything in it.
+func connectIsolatedSchema(ctx context.Context, connString string, schemaFiles []string) (*pgx.Conn, func() error, error) {
+	pgConn, err := pgx.Connect(ctx, connString)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to pggen postgres database: %w", err)
+	}
+	schema := isolatedName()
+	if _, err := pgConn.Exec(ctx, "CREATE SCHEMA "+pgx.Identifier{schema}.Sanitize()); err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("create isolated schema: %w", err), pgConn.Close(ctx))
+	}
+	cleanup := func() error {
+		_, err := pgConn.Exec(ctx, "DROP SCHEMA IF EXISTS "+pgx.Identifier{schema}.Sanitize()+" CASCADE")
+		if err != nil {
+			err = fmt.Errorf("drop isolated schema %s: %w", schema, err)
+		}
+		return errors.Join(err, pgConn.Close(ctx))
+	}
+	// Set the search_path on the session instead of the connection config so
+	// that copies of the config, like in Watch, connect without the schema.
+	if _, err := pgConn.Exec(ctx, "SELECT set_config('search_path', $1, false)", schema); err != nil {
+		return nil, nil, errors.Join(fmt.Errorf("set search_path to isolated schema: %w", err), cleanup())
+	}
+	if err := pgload.LoadFiles(ctx, pgConn, schemaFiles); err != nil {
+		return nil, nil, errors.Join(err, cleanup())
+	}
+	return pgConn, cleanup, nil
+}
@@ -0,0 +1,56 @@
+package pggen
+
+import (
+	"context"
+	"github.com/atomicleads/pggen/internal/pgtest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestConnectExistingPostgres_Isolation(t *testing.T) {
+	conn, cleanupFunc := pgtest.NewPostgresSchemaString(t, "")
+	defer cleanupFunc()
+	schemaFile := filepath.Join(t.TempDir(), "schema.sql")
+	if err := os.WriteFile(schemaFile, []byte("CREATE TABLE isolated_author (name text);"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, isolation := range []PostgresIsolation{IsolationDatabase, IsolationSchema} {
+		t.Run(string(isolation), func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			// Load the schema twice to check that the second load doesn't fail
+			// with "relation already exists".
+			for i := 0; i < 2; i++ {
+				pgConn, _, cleanup, err := connectExistingPostgres(ctx, conn.Config().ConnString(), isolation, []string{schemaFile})
+				if err != nil {
+					t.Fatalf("connect %d: %s", i, err)
+				}
+				var dbName, schema string
+				err = pgConn.QueryRow(ctx, "SELECT current_database(), current_schema()").Scan(&dbName, &schema)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := cleanup(); err != nil {
+					t.Fatalf("cleanup %d: %s", i, err)
+				}
+
+				// Check that cleanup dropped the isolated database or schema.
+				var exists bool
+				switch isolation {
+				case IsolationDatabase:
+					err = conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)", dbName).Scan(&exists)
+				case IsolationSchema:
+					err = conn.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM pg_namespace WHERE nspname = $1)", schema).Scan(&exists)
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if exists {
+					t.Errorf("want isolated %s dropped after cleanup; database=%s schema=%s", isolation, dbName, schema)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package pggen
+
+import (
+	"errors"
+	"fmt"
+	"github.com/atomicleads/pggen/internal/migrations"
+	"github.com/atomicleads/pggen/internal/pgload"
+	"os"
+)
+
+// MigrationsFormat is the migration tool that created the migrations in
+// GenerateOptions.MigrationsDir.
+type MigrationsFormat string
+
+const (
+	// MigrationsGoose reads goose *.sql migrations with "-- +goose Up" and
+	// "-- +goose Down" annotations.
+	MigrationsGoose MigrationsFormat = "goose"
+	// MigrationsGolangMigrate reads golang-migrate *.up.sql migrations.
+	MigrationsGolangMigrate MigrationsFormat = "golang-migrate"
+	// MigrationsDbmate reads dbmate *.sql migrations with "-- migrate:up" and
+	// "-- migrate:down" sections.
+	MigrationsDbmate MigrationsFormat = "dbmate"
+	// MigrationsSQLMigrate reads sql-migrate *.sql migrations with
+	// "-- +migrate Up" and "-- +migrate Down" annotations.
+	MigrationsSQLMigrate MigrationsFormat = "sql-migrate"
+	// MigrationsAtlas reads Atlas *.sql migrations.
+	MigrationsAtlas MigrationsFormat = "atlas"
+)
+
+// expandSchemaFiles returns the up migrations in the migrations dir, in
+// version order, followed by the schema files. Writes the up migrations to a
+// temp dir that the returned cleanup func removes. The returned origins map
+// the path of each up migration file to the path of its migration.
+func (opts postgresOptions) expandSchemaFiles() (files []string, origins map[string]string, cleanup func() error, mErr error) {
+	nopCleanup := func() error { return nil }
+	if opts.migrationsDir == "" {
+		return opts.schemaFiles, nil, nopCleanup, nil
+	}
+	if opts.migrationsFormat == "" {
+		return nil, nil, nil, fmt.Errorf("migrations format must be set with a migrations dir")
+	}
+	ms, err := migrations.Read(opts.migrationsDir, migrations.Format(opts.migrationsFormat))
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	tmpDir, err := os.MkdirTemp("", "pggen-migrations-")
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("create temp dir for up migrations: %w", err)
+	}
+	cleanup = func() error {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			return fmt.Errorf("remove up migrations temp dir: %w", err)
+		}
+		return nil
+	}
+	files, err = migrations.WriteUp(tmpDir, ms)
+	if err != nil {
+		return nil, nil, nil, errors.Join(err, cleanup())
+	}
+	origins = make(map[string]string, len(ms))
+	for i, m := range ms {
+		origins[files[i]] = m.Path
+	}
+	return append(files, opts.schemaFiles...), origins, cleanup, nil
+}
+
+// restoreMigrationPath replaces a schema loading error in an up migration
+// file with the same error in the migration. The up migration files keep the
+// line numbers of the migrations, so the line and column stay correct.
+func restoreMigrationPath(err error, origins map[string]string) error {
+	stmtErr := (*pgload.StatementError)(nil)
+	if !errors.As(err, &stmtErr) {
+		return err
+	}
+	path, ok := origins[stmtErr.File]
+	if !ok {
+		return err
+	}
+	migrationErr := *stmtErr
+	migrationErr.File = path
+	return fmt.Errorf("load up migration: %w", &migrationErr)
+}
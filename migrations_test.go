@@ -0,0 +1,49 @@
+package pggen
+
+import (
+	"errors"
+	"fmt"
+	"github.com/atomicleads/pggen/internal/pgload"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandSchemaFiles_RestoreMigrationPath(t *testing.T) {
+	migrationsDir := t.TempDir()
+	migration := filepath.Join(migrationsDir, "001_create_author.sql")
+	contents := "-- migrate:up\nCREATE TABLE author (id int);\n-- migrate:down\nDROP TABLE author;\n"
+	if err := os.WriteFile(migration, []byte(contents), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := postgresOptions{
+		schemaFiles:      []string{"schema.sql"},
+		migrationsDir:    migrationsDir,
+		migrationsFormat: MigrationsDbmate,
+	}
+	files, origins, cleanup, err := opts.expandSchemaFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := cleanup(); err != nil {
+			t.Error(err)
+		}
+	}()
+	if !assert.Len(t, files, 2) {
+		return
+	}
+	assert.Equal(t, "schema.sql", files[1])
+
+	err = fmt.Errorf("load schema: %w", &pgload.StatementError{
+		File:          files[0],
+		Line:          2,
+		Statement:     "CREATE TABLE author (id int);",
+		StatementLine: 2,
+		StatementCol:  1,
+		Err:           errors.New("boom"),
+	})
+	err = restoreMigrationPath(err, origins)
+	assert.Equal(t, "load up migration: "+migration+":2: boom\n2 | CREATE TABLE author (id int);", err.Error())
+}
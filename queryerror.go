@@ -0,0 +1,146 @@
+package pggen
+
+import (
+	"errors"
+	"fmt"
+	"github.com/atomicleads/pggen/internal/pginfer"
+	"github.com/atomicleads/pggen/internal/texts"
+	goscan "go/scanner"
+	gotok "go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// QueryErrors is the error returned by Generate and Check when the query files
+// have errors. Contains every error in the query files instead of only the
+// first so that all the errors can be fixed at once. pggen generates no code
+// if any query file has an error.
+type QueryErrors struct {
+	Errors []*QueryError // sorted by file and position
+}
+
+// QueryError is an error in a query file, like a syntax error, a duplicate
+// query name, or a query that Postgres can't prepare.
+type QueryError struct {
+	// The position of the error. Line is 0 if the position is unknown, like
+	// when pggen can't read the query file.
+	Pos   gotok.Position
+	Query string // name of the query with the error, if any
+	Err   error
+	// The line of the query file at Pos to show with a caret under the column,
+	// or empty to only show the position.
+	line string
+}
+
+func (e *QueryErrors) Error() string {
+	sb := &strings.Builder{}
+	if len(e.Errors) == 1 {
+		sb.WriteString("found 1 error in query files:")
+	} else {
+		fmt.Fprintf(sb, "found %d errors in query files:", len(e.Errors))
+	}
+	for _, qErr := range e.Errors {
+		sb.WriteString("\n")
+		sb.WriteString(qErr.Error())
+	}
+	return sb.String()
+}
+
+func (e *QueryErrors) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, qErr := range e.Errors {
+		errs[i] = qErr
+	}
+	return errs
+}
+
+// Error prints the position like the Go compiler so editors can jump to it,
+// followed by the line of the query file with a caret under the column, and
+// the details of the error:
+//
+//	query.sql:3:8: query FindFoo: fetch field descriptions: column "foo" does not exist
+//	3 | SELECT foo FROM author
+//	  |        ^
+//	    ERROR: column "foo" does not exist (SQLSTATE 42703)
+func (e *QueryError) Error() string {
+	first, rest, _ := strings.Cut(e.Err.Error(), "\n")
+	sb := &strings.Builder{}
+	sb.WriteString(e.Pos.String())
+	sb.WriteString(": ")
+	if e.Query != "" {
+		sb.WriteString("query ")
+		sb.WriteString(e.Query)
+		sb.WriteString(": ")
+	}
+	sb.WriteString(first)
+	if e.line != "" {
+		gutter := strconv.Itoa(e.Pos.Line)
+		fmt.Fprintf(sb, "\n%s | %s", gutter, e.line)
+		fmt.Fprintf(sb, "\n%*s | %s^", len(gutter), "", texts.CaretIndent(e.line, e.Pos.Column))
+	}
+	if rest != "" {
+		sb.WriteString("\n")
+		sb.WriteString(rest)
+	}
+	return sb.String()
+}
+
+func (e *QueryError) Unwrap() error { return e.Err }
+
+// queryErrorList collects the errors in a single query file.
+type queryErrorList struct {
+	filename string // the path of the query file to show
+	src      []byte
+	errs     []*QueryError
+}
+
+// addParseErrors adds each error from parsing the query file.
+func (l *queryErrorList) addParseErrors(err error) {
+	scanErrs := goscan.ErrorList(nil)
+	if !errors.As(err, &scanErrs) {
+		l.add(gotok.Position{}, "", err, false)
+		return
+	}
+	for _, scanErr := range scanErrs {
+		l.add(scanErr.Pos, "", errors.New(scanErr.Msg), true)
+	}
+}
+
+// addInferError adds the error from inferring the types of query. Uses the
+// position of the error that Postgres reported, if any, or the start of the
+// query.
+func (l *queryErrorList) addInferError(fset *gotok.FileSet, query string, start gotok.Pos, err error) {
+	prepErr := (*pginfer.PrepareError)(nil)
+	if errors.As(err, &prepErr) && prepErr.Pos.IsValid() {
+		l.add(fset.Position(prepErr.Pos), query, err, true)
+		return
+	}
+	l.add(fset.Position(start), query, err, false)
+}
+
+// add adds an error at pos. If showLine is true, the error shows the line of
+// the query file at pos.
+func (l *queryErrorList) add(pos gotok.Position, query string, err error, showLine bool) {
+	pos.Filename = l.filename
+	qErr := &QueryError{Pos: pos, Query: query, Err: err}
+	if showLine && pos.Line > 0 {
+		lines := strings.Split(string(l.src), "\n")
+		if pos.Line <= len(lines) {
+			qErr.line = strings.TrimRight(lines[pos.Line-1], "\r")
+		}
+	}
+	l.errs = append(l.errs, qErr)
+}
+
+// sort sorts the errors by position. Keeps the order of errors at the same
+// position.
+func (l *queryErrorList) sort() {
+	sort.SliceStable(l.errs, func(i, j int) bool {
+		a, b := l.errs[i].Pos, l.errs[j].Pos
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+}
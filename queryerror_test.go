@@ -0,0 +1,80 @@
+package pggen
+
+import (
+	"errors"
+	"github.com/atomicleads/pggen/internal/texts"
+	"github.com/stretchr/testify/assert"
+	gotok "go/token"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseQueries_ParseErrors(t *testing.T) {
+	queryFile := filepath.Join(t.TempDir(), "query.sql")
+	queries := texts.Dedent(`
+		SELECT 1;
+
+		-- name: Foo :bogus
+		SELECT 2;
+	`)
+	if err := os.WriteFile(queryFile, []byte(queries), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// No valid queries, so parseQueries doesn't use the inferrer.
+	_, err := parseQueries(queryFile, nil)
+	queryErrs := &QueryErrors{}
+	if !errors.As(err, &queryErrs) {
+		t.Fatalf("want *QueryErrors; got %v", err)
+	}
+	if !assert.Len(t, queryErrs.Errors, 2) {
+		return
+	}
+	assert.Equal(t, 1, queryErrs.Errors[0].Pos.Line)
+	assert.Equal(t, 4, queryErrs.Errors[1].Pos.Line)
+	want := "found 2 errors in query files:\n" +
+		queryFile + ":1:1: no comment preceding query\n" +
+		"1 | SELECT 1;\n" +
+		"  | ^\n" +
+		queryFile + `:4:1: no 'name: <name> :<type>' token found in comment before query; comment line: "-- name: Foo :bogus"` + "\n" +
+		"4 | SELECT 2;\n" +
+		"  | ^"
+	assert.Equal(t, want, err.Error())
+}
+
+func TestQueryError_Error(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *QueryError
+		want string
+	}{
+		{
+			name: "no position",
+			err:  &QueryError{Err: errors.New("read query file: boom")},
+			want: "-: read query file: boom",
+		},
+		{
+			name: "query without line",
+			err: func() *QueryError {
+				l := &queryErrorList{filename: "query.sql"}
+				l.add(gotok.Position{Line: 3, Column: 1}, "Foo", errors.New("fetch field descriptions: boom\n    ERROR: boom (SQLSTATE 42703)"), false)
+				return l.errs[0]
+			}(),
+			want: "query.sql:3:1: query Foo: fetch field descriptions: boom\n    ERROR: boom (SQLSTATE 42703)",
+		},
+		{
+			name: "tab indented line",
+			err: func() *QueryError {
+				l := &queryErrorList{filename: "query.sql", src: []byte("-- name: Foo :one\n\tSELECT foo;\n")}
+				l.add(gotok.Position{Line: 2, Column: 9}, "Foo", errors.New("boom"), true)
+				return l.errs[0]
+			}(),
+			want: "query.sql:2:9: query Foo: boom\n2 | \tSELECT foo;\n  | \t       ^",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.err.Error())
+		})
+	}
+}
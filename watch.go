@@ -0,0 +1,443 @@
+package pggen
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/atomicleads/pggen/internal/codegen"
+	"github.com/atomicleads/pggen/internal/codegen/golang"
+	"github.com/atomicleads/pggen/internal/errs"
+	"github.com/atomicleads/pggen/internal/paths"
+	"github.com/atomicleads/pggen/internal/pginfer"
+	"github.com/atomicleads/pggen/internal/pgload"
+	"github.com/jackc/pgx/v4"
+)
+
+// WatchOptions are the options for Watch.
+type WatchOptions struct {
+	// Options for the generated code and the Postgres database. Watch expands
+	// QueryGlobs and SchemaGlobs on every change instead of using QueryFiles
+	// and SchemaFiles.
+	Options GenerateOptions
+	// Globs for the query files to generate code for, like "author/*.sql".
+	QueryGlobs []string
+	// Globs for the schema files to load into Postgres, in order, like
+	// "migrations/*.sql".
+	SchemaGlobs []string
+	// How often to check the files for changes. Defaults to 500ms if zero.
+	PollInterval time.Duration
+	// Called after each regeneration. Defaults to logging the event if nil.
+	OnGenerate func(WatchEvent)
+}
+
+// WatchEvent describes a single regeneration by Watch.
+type WatchEvent struct {
+	// If a schema file changed and Watch loaded the schema into a new database.
+	SchemaReloaded bool
+	// The query files that Watch parsed and inferred types for.
+	QueryFiles []string
+	// The generated files that changed and Watch wrote.
+	Written []string
+	// The generated files that Watch removed because the query file was
+	// removed.
+	Removed []string
+	// The error that stopped the regeneration, if any.
+	Err error
+}
+
+// Watch generates code like Generate and then regenerates the code each time a
+// file that matches QueryGlobs or SchemaGlobs changes, until ctx is done.
+//
+// Watch keeps Postgres running between changes. When only query files change,
+// Watch only infers the types of the changed query files. When a schema file
+// changes, Watch loads the schema into a new database and infers the types of
+// every query file. With a ConnString, Watch creates the new database on the
+// same server, so the user needs the CREATEDB privilege.
+//
+// Watch reports errors from a regeneration with OnGenerate and keeps watching.
+// Returns an error if Watch can't start or connect to Postgres.
+func Watch(ctx context.Context, opts WatchOptions) (mErr error) {
+	// Preconditions.
+	if opts.Options.Language == "" {
+		return fmt.Errorf("generate language must be set; got empty string")
+	}
+	if opts.Options.OutputDir == "" {
+		return fmt.Errorf("output dir must be set")
+	}
+	if len(opts.QueryGlobs) == 0 {
+		return fmt.Errorf("got 0 query globs, at least 1 must be set")
+	}
+	if opts.PollInterval == 0 {
+		opts.PollInterval = 500 * time.Millisecond
+	}
+	if opts.OnGenerate == nil {
+		opts.OnGenerate = logWatchEvent
+	}
+
+	schemaFiles, err := paths.ExpandGlobs(opts.SchemaGlobs)
+	if err != nil {
+		return err
+	}
+	w := &watcher{
+		opts:       opts,
+		queryFiles: make(map[string]codegen.QueryFile),
+		outputs:    make(map[string]struct{}),
+	}
+	w.schemaStamps = w.statSchema(schemaFiles)
+	// Cleanup must run after ctx is done, so don't tie Postgres to ctx.
+	pgCtx := context.WithoutCancel(ctx)
+	if err := w.connect(pgCtx, schemaFiles); err != nil {
+		return err
+	}
+	defer func() {
+		if err := w.close(pgCtx); err != nil {
+			mErr = errors.Join(mErr, err)
+		}
+	}()
+
+	queryFiles, err := paths.ExpandGlobs(opts.QueryGlobs)
+	if err != nil {
+		return err
+	}
+	w.queryStamps = statFiles(queryFiles)
+	opts.OnGenerate(w.regenerate(pgCtx, schemaFiles, false))
+
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		if event, ok := w.poll(pgCtx); ok {
+			opts.OnGenerate(event)
+		}
+	}
+}
+
+func logWatchEvent(event WatchEvent) {
+	if event.Err != nil {
+		slog.Error("regenerate", slog.String("error", event.Err.Error()))
+		return
+	}
+	slog.Info("regenerate",
+		slog.Bool("schema_reloaded", event.SchemaReloaded),
+		slog.Int("query_files", len(event.QueryFiles)),
+		slog.Int("written", len(event.Written)),
+		slog.Int("removed", len(event.Removed)))
+}
+
+// watcher regenerates code for Watch using a long-lived Postgres database.
+type watcher struct {
+	opts WatchOptions
+
+	pgConn      *pgx.Conn // connection to the original database
+	errEnricher func(error) error
+	cleanup     func() error
+	// Connection to the database with the current schema. Either pgConn or a
+	// connection to schemaDB.
+	schemaConn *pgx.Conn
+	schemaDB   string // database created for the current schema, if any
+	reloads    int    // number of databases created for schema changes
+	// If the last schema reload failed, so the next change must reload the
+	// schema even if no schema file changed.
+	schemaStale bool
+	inferrer    *pginfer.Inferrer
+
+	schemaStamps map[string]fileStamp
+	queryStamps  map[string]fileStamp
+	queryFiles   map[string]codegen.QueryFile // parsed query files by path
+	outputs      map[string]struct{}          // generated files from the last regeneration
+	globErr      string                       // error from expanding the globs in the last poll
+}
+
+// fileStamp identifies the version of a file to detect changes.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// statFiles returns the stamp of each file that exists.
+func statFiles(files []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp, len(files))
+	for _, file := range files {
+		stat, err := os.Stat(file)
+		if err != nil {
+			continue // removed since the glob matched
+		}
+		stamps[file] = fileStamp{modTime: stat.ModTime(), size: stat.Size()}
+	}
+	return stamps
+}
+
+// statSchema returns the stamps of the schema files and of the files in the
+// migrations dir, if any.
+func (w *watcher) statSchema(schemaFiles []string) map[string]fileStamp {
+	stamps := statFiles(schemaFiles)
+	if dir := w.opts.Options.MigrationsDir; dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return stamps // reported when loading the migrations
+		}
+		files := make([]string, len(entries))
+		for i, entry := range entries {
+			files[i] = filepath.Join(dir, entry.Name())
+		}
+		for file, stamp := range statFiles(files) {
+			stamps[file] = stamp
+		}
+	}
+	return stamps
+}
+
+// changedFiles returns the files that were added, changed, or removed
+// between the prev and next stamps.
+func changedFiles(prev, next map[string]fileStamp) []string {
+	var changed []string
+	for file, stamp := range next {
+		if prevStamp, ok := prev[file]; !ok || prevStamp != stamp {
+			changed = append(changed, file)
+		}
+	}
+	for file := range prev {
+		if _, ok := next[file]; !ok {
+			changed = append(changed, file)
+		}
+	}
+	return changed
+}
+
+// poll checks the query and schema files for changes and regenerates the
+// code if any file changed. Returns false if nothing changed.
+func (w *watcher) poll(ctx context.Context) (WatchEvent, bool) {
+	schemaFiles, queryFiles, err := w.expandGlobs()
+	if err != nil {
+		// Only report a persistent error, like a missing file, once.
+		if err.Error() == w.globErr {
+			return WatchEvent{}, false
+		}
+		w.globErr = err.Error()
+		return WatchEvent{Err: err}, true
+	}
+	w.globErr = ""
+	schemaStamps := w.statSchema(schemaFiles)
+	queryStamps := statFiles(queryFiles)
+	schemaChanged := len(changedFiles(w.schemaStamps, schemaStamps)) > 0
+	changedQueries := changedFiles(w.queryStamps, queryStamps)
+	if !schemaChanged && len(changedQueries) == 0 {
+		return WatchEvent{}, false
+	}
+	w.schemaStamps = schemaStamps
+	w.queryStamps = queryStamps
+	for _, file := range changedQueries {
+		delete(w.queryFiles, file)
+	}
+	return w.regenerate(ctx, schemaFiles, schemaChanged || w.schemaStale), true
+}
+
+func (w *watcher) expandGlobs() (schemaFiles, queryFiles []string, err error) {
+	schemaFiles, err = paths.ExpandGlobs(w.opts.SchemaGlobs)
+	if err != nil {
+		return nil, nil, err
+	}
+	queryFiles, err = paths.ExpandGlobs(w.opts.QueryGlobs)
+	if err != nil {
+		return nil, nil, err
+	}
+	return schemaFiles, queryFiles, nil
+}
+
+// regenerate writes the generated code for all query files, parsing only the
+// query files that changed since the last regeneration. If reloadSchema is
+// true, loads the schema into a new database first and parses every query
+// file.
+func (w *watcher) regenerate(ctx context.Context, schemaFiles []string, reloadSchema bool) WatchEvent {
+	event := WatchEvent{SchemaReloaded: reloadSchema}
+	if reloadSchema {
+		if err := w.reloadSchema(ctx, schemaFiles); err != nil {
+			w.schemaStale = true
+			event.Err = fmt.Errorf("reload schema: %w", err)
+			return event
+		}
+		w.schemaStale = false
+		w.queryFiles = make(map[string]codegen.QueryFile)
+	}
+
+	queryPaths, err := paths.ExpandGlobs(w.opts.QueryGlobs)
+	if err != nil {
+		event.Err = err
+		return event
+	}
+	if len(queryPaths) == 0 {
+		event.Err = fmt.Errorf("no query files match the query globs")
+		return event
+	}
+	queryFiles := make([]codegen.QueryFile, 0, len(queryPaths))
+	queryErrs := &QueryErrors{}
+	for _, path := range queryPaths {
+		queryFile, ok := w.queryFiles[path]
+		if !ok {
+			queryFile, err = parseQueries(path, w.inferrer)
+			if fileErrs := (*QueryErrors)(nil); errors.As(err, &fileErrs) {
+				queryErrs.Errors = append(queryErrs.Errors, fileErrs.Errors...)
+				continue
+			}
+			if err != nil {
+				event.Err = w.errEnricher(fmt.Errorf("parse template query file %q: %w", path, err))
+				return event
+			}
+			w.queryFiles[path] = queryFile
+			event.QueryFiles = append(event.QueryFiles, path)
+		}
+		queryFiles = append(queryFiles, queryFile)
+	}
+	if len(queryErrs.Errors) > 0 {
+		event.Err = w.errEnricher(queryErrs)
+		return event
+	}
+
+	files, err := renderQueryFiles(w.opts.Options, queryFiles)
+	if err != nil {
+		event.Err = err
+		return event
+	}
+	event.Written, event.Removed, event.Err = w.writeChanged(files)
+	return event
+}
+
+// writeChanged writes the generated files that differ from the files on disk
+// and removes the files generated by the last regeneration that are no longer
+// generated.
+func (w *watcher) writeChanged(files []golang.GeneratedFile) (written, removed []string, err error) {
+	outputs := make(map[string]struct{}, len(files))
+	for _, file := range files {
+		outputs[file.Path] = struct{}{}
+		existing, err := os.ReadFile(file.Path)
+		if err == nil && bytes.Equal(existing, file.Contents) {
+			continue
+		}
+		if err := os.WriteFile(file.Path, file.Contents, 0644); err != nil {
+			return written, removed, fmt.Errorf("write generated query file: %w", err)
+		}
+		written = append(written, file.Path)
+	}
+	for path := range w.outputs {
+		if _, ok := outputs[path]; ok {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return written, removed, fmt.Errorf("remove generated query file: %w", err)
+		}
+		removed = append(removed, path)
+	}
+	w.outputs = outputs
+	return written, removed, nil
+}
+
+// connect connects to Postgres and loads schemaFiles, like Generate.
+func (w *watcher) connect(ctx context.Context, schemaFiles []string) error {
+	pgOpts := w.opts.Options.postgresOptions()
+	pgOpts.schemaFiles = schemaFiles
+	pgConn, errEnricher, cleanup, err := connectPostgres(ctx, pgOpts)
+	if err != nil {
+		return fmt.Errorf("connect postgres: %w", err)
+	}
+	w.pgConn = pgConn
+	w.errEnricher = errEnricher
+	w.cleanup = cleanup
+	w.schemaConn = pgConn
+	w.inferrer = pginfer.NewInferrer(pgConn, pgOpts.notNullFuncs)
+	return nil
+}
+
+// reloadSchema loads schemaFiles into a new database on the same Postgres
+// server and drops the database of the previous schema.
+func (w *watcher) reloadSchema(ctx context.Context, schemaFiles []string) (mErr error) {
+	if w.opts.Options.ConnString == "" && !allSQLFiles(schemaFiles) {
+		// Docker and local Postgres only run init scripts like *.sh when
+		// Postgres starts, so start a new server.
+		if err := w.close(ctx); err != nil {
+			return err
+		}
+		return w.connect(ctx, schemaFiles)
+	}
+
+	pgOpts := w.opts.Options.postgresOptions()
+	pgOpts.schemaFiles = schemaFiles
+	allFiles, origins, cleanupSchema, err := pgOpts.expandSchemaFiles()
+	if err != nil {
+		return err
+	}
+	defer errs.Capture(&mErr, cleanupSchema, "remove up migrations")
+
+	w.reloads++
+	dbName := fmt.Sprintf("pggen_watch_%d_%d", os.Getpid(), w.reloads)
+	if _, err := w.pgConn.Exec(ctx, "CREATE DATABASE "+pgx.Identifier{dbName}.Sanitize()); err != nil {
+		return fmt.Errorf("create database for schema: %w", err)
+	}
+	cfg := w.pgConn.Config().Copy()
+	cfg.Database = dbName
+	conn, err := pgx.ConnectConfig(ctx, cfg)
+	if err != nil {
+		return errors.Join(fmt.Errorf("connect to database for schema: %w", err), w.dropDatabase(ctx, dbName))
+	}
+	if err := pgload.LoadFiles(ctx, conn, allFiles); err != nil {
+		return errors.Join(restoreMigrationPath(err, origins), conn.Close(ctx), w.dropDatabase(ctx, dbName))
+	}
+	if err := w.closeSchemaDB(ctx); err != nil {
+		return err
+	}
+	w.schemaConn = conn
+	w.schemaDB = dbName
+	w.inferrer = pginfer.NewInferrer(conn, pgOpts.notNullFuncs)
+	return nil
+}
+
+// closeSchemaDB closes the connection to the database created for the current
+// schema, if any, and drops the database.
+func (w *watcher) closeSchemaDB(ctx context.Context) error {
+	if w.schemaDB == "" {
+		return nil
+	}
+	if err := w.schemaConn.Close(ctx); err != nil {
+		return fmt.Errorf("close schema database connection: %w", err)
+	}
+	w.schemaConn = w.pgConn
+	dbName := w.schemaDB
+	w.schemaDB = ""
+	return w.dropDatabase(ctx, dbName)
+}
+
+func (w *watcher) dropDatabase(ctx context.Context, dbName string) error {
+	if _, err := w.pgConn.Exec(ctx, "DROP DATABASE IF EXISTS "+pgx.Identifier{dbName}.Sanitize()); err != nil {
+		return fmt.Errorf("drop database %s: %w", dbName, err)
+	}
+	return nil
+}
+
+// close drops the database created for the current schema and closes the
+// Postgres connection.
+func (w *watcher) close(ctx context.Context) error {
+	err := errors.Join(w.closeSchemaDB(ctx), w.pgConn.Close(ctx), w.cleanup())
+	w.cleanup = func() error { return nil } // don't stop Postgres twice
+	if err != nil {
+		return fmt.Errorf("close postgres: %w", err)
+	}
+	return nil
+}
+
+func allSQLFiles(files []string) bool {
+	for _, file := range files {
+		if filepath.Ext(file) != ".sql" {
+			return false
+		}
+	}
+	return true
+}
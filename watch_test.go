@@ -0,0 +1,141 @@
+package pggen
+
+import (
+	"context"
+	"github.com/atomicleads/pggen/internal/codegen/golang"
+	"github.com/atomicleads/pggen/internal/pgtest"
+	"github.com/atomicleads/pggen/internal/texts"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	conn, cleanupFunc := pgtest.NewPostgresSchemaString(t, "")
+	defer cleanupFunc()
+	tmpDir := t.TempDir()
+	schemaFile := filepath.Join(tmpDir, "schema.sql")
+	queryFile := filepath.Join(tmpDir, "query.sql")
+	writeFile := func(path, contents string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// Set the modification time explicitly in case the file system has a
+		// coarse timestamp resolution.
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Now().Add(-time.Hour)
+	writeFile(schemaFile, "CREATE TABLE author (name text);", start)
+	writeFile(queryFile, texts.Dedent(`
+		-- name: FindNames :many
+		SELECT name FROM author;
+	`), start)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan WatchEvent, 8)
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, WatchOptions{
+			Options: GenerateOptions{
+				ConnString: conn.Config().ConnString(),
+				OutputDir:  tmpDir,
+				GoPackage:  "watch_test",
+				Language:   LangGo,
+			},
+			QueryGlobs:   []string{queryFile},
+			SchemaGlobs:  []string{schemaFile},
+			PollInterval: 10 * time.Millisecond,
+			OnGenerate:   func(event WatchEvent) { events <- event },
+		})
+	}()
+	nextEvent := func() WatchEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			if event.Err != nil {
+				t.Fatalf("watch event error: %s", event.Err)
+			}
+			return event
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout waiting for watch event")
+			return WatchEvent{}
+		}
+	}
+	outFile := filepath.Join(tmpDir, "query.sql.go")
+
+	event := nextEvent()
+	assert.Equal(t, []string{queryFile}, event.QueryFiles, "initial query files")
+	assert.Equal(t, []string{outFile}, event.Written, "initial written files")
+
+	writeFile(queryFile, texts.Dedent(`
+		-- name: FindNames :many
+		SELECT name FROM author ORDER BY name;
+	`), start.Add(time.Minute))
+	event = nextEvent()
+	assert.False(t, event.SchemaReloaded, "query change should not reload schema")
+	assert.Equal(t, []string{queryFile}, event.QueryFiles, "query files after query change")
+	assert.Equal(t, []string{outFile}, event.Written, "written files after query change")
+
+	writeFile(schemaFile, "CREATE TABLE author (name text, age int);", start.Add(2*time.Minute))
+	event = nextEvent()
+	assert.True(t, event.SchemaReloaded, "schema change should reload schema")
+	assert.Equal(t, []string{queryFile}, event.QueryFiles, "query files after schema change")
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChangedFiles(t *testing.T) {
+	t0 := time.Now()
+	prev := map[string]fileStamp{
+		"same.sql":    {modTime: t0, size: 1},
+		"touched.sql": {modTime: t0, size: 1},
+		"resized.sql": {modTime: t0, size: 1},
+		"removed.sql": {modTime: t0, size: 1},
+	}
+	next := map[string]fileStamp{
+		"same.sql":    {modTime: t0, size: 1},
+		"touched.sql": {modTime: t0.Add(time.Second), size: 1},
+		"resized.sql": {modTime: t0, size: 2},
+		"added.sql":   {modTime: t0, size: 1},
+	}
+	got := changedFiles(prev, next)
+	sort.Strings(got)
+	assert.Equal(t, []string{"added.sql", "removed.sql", "resized.sql", "touched.sql"}, got)
+}
+
+func TestWatcher_WriteChanged(t *testing.T) {
+	tmpDir := t.TempDir()
+	alpha := filepath.Join(tmpDir, "alpha.sql.go")
+	bravo := filepath.Join(tmpDir, "bravo.sql.go")
+	w := &watcher{outputs: make(map[string]struct{})}
+
+	written, removed, err := w.writeChanged([]golang.GeneratedFile{
+		{Path: alpha, Contents: []byte("package alpha\n")},
+		{Path: bravo, Contents: []byte("package bravo\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{alpha, bravo}, written, "first write")
+	assert.Empty(t, removed, "first write")
+
+	written, removed, err = w.writeChanged([]golang.GeneratedFile{
+		{Path: alpha, Contents: []byte("package alpha\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, written, "unchanged file")
+	assert.Equal(t, []string{bravo}, removed, "file no longer generated")
+	if _, err := os.Stat(bravo); !os.IsNotExist(err) {
+		t.Errorf("want %s removed; got stat error %v", bravo, err)
+	}
+}